@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
+	cgerrors "github.com/chenzhuyu2004/carbon-guard/internal/errors"
+	"github.com/chenzhuyu2004/carbon-guard/pkg"
+)
+
+type BatchResult struct {
+	SchemaVersion      string                   `json:"schema_version"`
+	Solver             string                   `json:"solver"`
+	Optimal            bool                     `json:"optimal"`
+	SlotSeconds        int                      `json:"slot_seconds"`
+	HorizonStartUTC    string                   `json:"horizon_start_utc"`
+	HorizonEndUTC      string                   `json:"horizon_end_utc"`
+	TotalEmissionKg    float64                  `json:"total_emission_kg"`
+	Assignments        []BatchAssignmentOutput  `json:"assignments"`
+	Unscheduled        []BatchUnscheduledOutput `json:"unscheduled"`
+	ZoneMappings       []ZoneMappingOutput      `json:"zone_mappings,omitempty"`
+	ZoneExpansions     []ZoneExpansionOutput    `json:"zone_expansions,omitempty"`
+	DefaultZones       []string                 `json:"default_zones,omitempty"`
+	DefaultZonesSource string                   `json:"default_zones_source,omitempty"`
+	DefaultZonesReason string                   `json:"default_zones_reason,omitempty"`
+}
+
+type BatchAssignmentOutput struct {
+	JobID           string  `json:"job_id"`
+	Zone            string  `json:"zone"`
+	StartUTC        string  `json:"start_utc"`
+	EndUTC          string  `json:"end_utc"`
+	DurationSeconds int     `json:"duration_seconds"`
+	Priority        int     `json:"priority"`
+	EmissionKg      float64 `json:"emission_kg"`
+}
+
+type BatchUnscheduledOutput struct {
+	JobID  string `json:"job_id"`
+	Reason string `json:"reason"`
+}
+
+func batch(args []string) error {
+	defaults, err := resolveSharedDefaults(args)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	addConfigFlag(fs, defaults.ConfigPath)
+	jobsPath := fs.String("jobs", "", "JSON file with jobs (id, duration, earliest_start, deadline, zones, priority) and capacity")
+	zones := fs.String("zones", "", "comma-separated zones for jobs that list none")
+	zoneMode := fs.String("zone-mode", defaults.ZoneMode, "zone resolution mode for jobs that list none: strict|fallback|auto")
+	capacityRaw := fs.String("capacity", "", "concurrent jobs per zone (e.g. DE=2,FR=1); overrides the jobs file, unlisted zones run one job")
+	slotRaw := fs.String("slot", "15m", "start grid and capacity slot (whole minutes)")
+	solver := fs.String("solver", appsvc.BatchSolverHeuristic, "planner: heuristic|exact (exact is limited to small batches)")
+	lookahead := fs.Int("lookahead", 0, "forecast lookahead in hours; 0 derives it from the latest job deadline (24 when a job has none)")
+	timeoutStr := addTimeoutFlag(fs, defaults.Timeout)
+	outputMode := addOutputFlag(fs, defaults.Output)
+	cacheDirRaw, cacheTTLRaw := addCacheFlags(fs, defaults.CacheDir, defaults.CacheTTL)
+	gridMixDir, emissionFactors := addGridMixFlags(fs, defaults.GridMixDir, defaults.EmissionFactors)
+
+	if err := fs.Parse(args); err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	if err := validateOutputMode(*outputMode); err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	if strings.TrimSpace(*jobsPath) == "" {
+		return cgerrors.Newf(cgerrors.InputError, "jobs is required")
+	}
+	if *lookahead < 0 {
+		return cgerrors.Newf(cgerrors.InputError, "lookahead must be >= 0")
+	}
+	slot, err := time.ParseDuration(*slotRaw)
+	if err != nil || slot <= 0 {
+		return cgerrors.Newf(cgerrors.InputError, "slot must be a positive duration")
+	}
+	timeout, err := parseTimeout(*timeoutStr)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	cacheDir, cacheTTL, err := parseCacheConfig(*cacheDirRaw, *cacheTTLRaw)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+
+	data, err := os.ReadFile(*jobsPath)
+	if err != nil {
+		return cgerrors.Newf(cgerrors.InputError, "failed to read jobs file: %v", err)
+	}
+	file, err := appsvc.ParseBatchFile(data)
+	if err != nil {
+		return mapAppError(err)
+	}
+	capacityOverrides, err := parseCapacityList(*capacityRaw)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+
+	hints, err := zoneHints(defaults)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	resolved, err := resolveBatchZones(&file, capacityOverrides, *zones, *zoneMode, defaults.Zones, hints)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+
+	provider, err := buildProvider(*gridMixDir, *emissionFactors, cacheDir, cacheTTL)
+	if err != nil {
+		return err
+	}
+
+	service := appsvc.New(newProviderAdapter(provider))
+	out, err := service.PlanBatch(context.Background(), appsvc.BatchInput{
+		Jobs:            file.Jobs,
+		Capacity:        resolved.capacity,
+		CapacityWindows: file.CapacityWindows,
+		Slot:            slot,
+		Lookahead:       *lookahead,
+		Solver:          *solver,
+		Model:           defaultModelContext(),
+		Timeout:         timeout,
+	})
+	if err != nil {
+		return mapAppError(err)
+	}
+
+	if *outputMode == "json" {
+		payload := BatchResult{
+			SchemaVersion:   pkg.JSONSchemaVersion,
+			Solver:          out.Solver,
+			Optimal:         out.Optimal,
+			SlotSeconds:     out.SlotSeconds,
+			HorizonStartUTC: out.HorizonStartUTC.UTC().Format(time.RFC3339),
+			HorizonEndUTC:   out.HorizonEndUTC.UTC().Format(time.RFC3339),
+			TotalEmissionKg: out.EmissionKg,
+			Assignments:     make([]BatchAssignmentOutput, 0, len(out.Assignments)),
+			Unscheduled:     make([]BatchUnscheduledOutput, 0, len(out.Unscheduled)),
+			ZoneMappings:    zoneMappingOutputs(resolved.selection.Mappings),
+			ZoneExpansions:  zoneExpansionOutputs(resolved.selection.Expansions),
+		}
+		if resolved.defaults != nil {
+			payload.DefaultZones = resolved.defaults.Zones
+			payload.DefaultZonesSource = resolved.defaults.Source
+			payload.DefaultZonesReason = resolved.defaults.Reason
+		}
+		for _, assignment := range out.Assignments {
+			payload.Assignments = append(payload.Assignments, BatchAssignmentOutput{
+				JobID:           assignment.JobID,
+				Zone:            assignment.Zone,
+				StartUTC:        assignment.StartUTC.UTC().Format(time.RFC3339),
+				EndUTC:          assignment.EndUTC.UTC().Format(time.RFC3339),
+				DurationSeconds: assignment.DurationSeconds,
+				Priority:        assignment.Priority,
+				EmissionKg:      assignment.EmissionKg,
+			})
+		}
+		for _, job := range out.Unscheduled {
+			payload.Unscheduled = append(payload.Unscheduled, BatchUnscheduledOutput{JobID: job.JobID, Reason: job.Reason})
+		}
+
+		data, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return cgerrors.Newf(cgerrors.ProviderError, "failed to serialize batch result")
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Batch plan (solver: %s, optimal: %t, slot: %s)\n", out.Solver, out.Optimal, slot)
+	if resolved.defaults != nil {
+		fmt.Printf("Default zones: %s (source: %s, reason: %s)\n", strings.Join(resolved.defaults.Zones, ","), resolved.defaults.Source, resolved.defaults.Reason)
+	}
+	fmt.Printf(
+		"Horizon (UTC): %s - %s\n\n",
+		out.HorizonStartUTC.UTC().Format("2006-01-02 15:04"),
+		out.HorizonEndUTC.UTC().Format("2006-01-02 15:04"),
+	)
+	for _, assignment := range out.Assignments {
+		fmt.Printf(
+			"  %-20s %-10s %s - %s emission %.4f kg\n",
+			assignment.JobID,
+			assignment.Zone,
+			assignment.StartUTC.UTC().Format("2006-01-02 15:04"),
+			assignment.EndUTC.UTC().Format("15:04"),
+			assignment.EmissionKg,
+		)
+	}
+	for _, job := range out.Unscheduled {
+		fmt.Printf("  %-20s unscheduled (%s)\n", job.JobID, job.Reason)
+	}
+	fmt.Printf("\nScheduled: %d of %d jobs\n", len(out.Assignments), len(out.Assignments)+len(out.Unscheduled))
+	fmt.Printf("Total emission: %.4f kg\n", out.EmissionKg)
+	return nil
+}
+
+// parseCapacityList parses "ZONE=N" items separated by commas.
+// parseCapacityList 解析以逗号分隔的 "ZONE=N" 条目。
+func parseCapacityList(raw string) (map[string]int, error) {
+	capacity := make(map[string]int)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		zone, value, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(zone) == "" {
+			return nil, fmt.Errorf("invalid capacity %q (expected ZONE=N)", item)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid capacity %q (expected a non-negative integer)", item)
+		}
+		capacity[strings.TrimSpace(zone)] = n
+	}
+	return capacity, nil
+}
+
+type batchZoneResolution struct {
+	capacity  map[string]int
+	selection zoneSelection
+	// defaults is set when some job listed no zones and fell back to --zones, env, or config.
+	// defaults 在存在未列出区域的作业、并回退到 --zones、环境变量或配置时设置。
+	defaults *resolvedZones
+}
+
+// resolveBatchZones expands job, capacity, and capacity-window zones through aliases, groups,
+// and cloud regions in place; jobs without zones take the resolved default zones. Capacity
+// overrides from --capacity win over the file, and names are applied in sorted order.
+// resolveBatchZones 就地将作业、容量与容量窗口中的区域经别名、分组与云 region 展开；
+// 未列出区域的作业使用解析出的默认区域。--capacity 的覆盖值优先于文件，名称按排序顺序应用。
+func resolveBatchZones(
+	file *appsvc.BatchFile,
+	overrides map[string]int,
+	explicit string,
+	mode string,
+	configZones string,
+	hints autoHints,
+) (batchZoneResolution, error) {
+	var out batchZoneResolution
+	expand := func(items []string) ([]string, error) {
+		selection, ok, err := parseZoneList(strings.Join(items, ","), hints)
+		if err != nil || !ok {
+			return nil, err
+		}
+		out.selection.Mappings = append(out.selection.Mappings, selection.Mappings...)
+		out.selection.Expansions = append(out.selection.Expansions, selection.Expansions...)
+		return selection.Zones, nil
+	}
+
+	for i := range file.Jobs {
+		job := &file.Jobs[i]
+		if len(job.Zones) == 0 {
+			if out.defaults == nil {
+				resolved, err := resolveZones(explicit, mode, configZones, hints)
+				if err != nil {
+					return batchZoneResolution{}, fmt.Errorf("job %s lists no zones: %w", job.ID, err)
+				}
+				out.defaults = &resolved
+			}
+			job.Zones = out.defaults.Zones
+			continue
+		}
+		zones, err := expand(job.Zones)
+		if err != nil {
+			return batchZoneResolution{}, fmt.Errorf("job %s: %w", job.ID, err)
+		}
+		job.Zones = zones
+	}
+
+	out.capacity = make(map[string]int, len(file.Capacity)+len(overrides))
+	for _, source := range []map[string]int{file.Capacity, overrides} {
+		names := make([]string, 0, len(source))
+		for name := range source {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			zones, err := expand([]string{name})
+			if err != nil {
+				return batchZoneResolution{}, fmt.Errorf("capacity: %w", err)
+			}
+			for _, zone := range zones {
+				out.capacity[zone] = source[name]
+			}
+		}
+	}
+	windows := make([]appsvc.CapacityWindowSpec, 0, len(file.CapacityWindows))
+	for _, window := range file.CapacityWindows {
+		zones, err := expand([]string{window.Zone})
+		if err != nil {
+			return batchZoneResolution{}, fmt.Errorf("capacity window: %w", err)
+		}
+		for _, zone := range zones {
+			window.Zone = zone
+			windows = append(windows, window)
+		}
+	}
+	file.CapacityWindows = windows
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
+	cgconfig "github.com/chenzhuyu2004/carbon-guard/internal/config"
+)
+
+func TestResolveBatchZonesExpandsJobsAndCapacity(t *testing.T) {
+	clearZoneHintEnv(t)
+	hints, err := zoneHints(cgconfig.Shared{
+		ZoneAliases: map[string]string{"frankfurt-dc": "DE"},
+		ZoneGroups:  map[string][]string{"pair": {"DE", "FR"}},
+	})
+	if err != nil {
+		t.Fatalf("zoneHints() unexpected error: %v", err)
+	}
+	overrides, err := parseCapacityList("frankfurt-dc=3")
+	if err != nil {
+		t.Fatalf("parseCapacityList() unexpected error: %v", err)
+	}
+	file := appsvc.BatchFile{
+		Jobs: []appsvc.BatchJobSpec{
+			{ID: "a", Zones: []string{"pair"}},
+			{ID: "b"},
+		},
+		Capacity:        map[string]int{"pair": 2},
+		CapacityWindows: []appsvc.CapacityWindowSpec{{Zone: "pair", Capacity: 0}},
+	}
+
+	got, err := resolveBatchZones(&file, overrides, "NL", zoneModeFallback, "", hints)
+	if err != nil {
+		t.Fatalf("resolveBatchZones() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(file.Jobs[0].Zones, []string{"DE", "FR"}) || !reflect.DeepEqual(file.Jobs[1].Zones, []string{"NL"}) {
+		t.Fatalf("unexpected job zones: %#v", file.Jobs)
+	}
+	if !reflect.DeepEqual(got.capacity, map[string]int{"DE": 3, "FR": 2}) {
+		t.Fatalf("unexpected capacity: %v", got.capacity)
+	}
+	if len(file.CapacityWindows) != 2 || file.CapacityWindows[1].Zone != "FR" {
+		t.Fatalf("unexpected capacity windows: %#v", file.CapacityWindows)
+	}
+	if got.defaults == nil || got.defaults.Source != "cli" {
+		t.Fatalf("expected default zones from --zones, got %#v", got.defaults)
+	}
+
+	if _, err := parseCapacityList("DE=-1"); err == nil {
+		t.Fatalf("expected an error for negative capacity")
+	}
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+	"github.com/chenzhuyu2004/carbon-guard/internal/cgroup"
+	cgerrors "github.com/chenzhuyu2004/carbon-guard/internal/errors"
+	"github.com/chenzhuyu2004/carbon-guard/internal/procstat"
+	"github.com/chenzhuyu2004/carbon-guard/internal/rapl"
+	"github.com/chenzhuyu2004/carbon-guard/internal/report"
+)
+
+// forwardedSignals are relayed to the child so wrappers stay transparent in CI.
+// forwardedSignals 会转发给子进程，使包装器在 CI 中保持透明。
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// measuredFlags cannot be combined with exec because exec measures them.
+// measuredFlags 由 exec 实测得到，因此不能与 exec 同时手动指定。
+var measuredFlags = []string{"duration", "load", "segments", "segments-file", "power-file"}
+
+const (
+	cpuSourceProc   = "proc"
+	cpuSourceCgroup = "cgroup"
+
+	energySourceModel = "model"
+	energySourceRAPL  = "rapl"
+)
+
+type execMeasurement struct {
+	Wall         time.Duration
+	CPU          time.Duration
+	Samples      int
+	Sampling     bool
+	ExitCode     int
+	Source       string
+	MemoryPeakGB float64
+}
+
+func execCommand(args []string) error {
+	// Arguments after "--" belong to the command, so --config is only looked up before them.
+	// "--" 之后的参数属于被执行命令，因此只在其之前查找 --config。
+	flagArgs := args
+	if i := slices.Index(args, "--"); i >= 0 {
+		flagArgs = args[:i]
+	}
+	defaults, err := resolveSharedDefaults(flagArgs)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+
+	fs := flag.NewFlagSet("exec", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	model := addRunModelFlags(fs, defaults)
+	budget := addBudgetFlags(fs)
+	sampleInterval := fs.Duration("sample-interval", 500*time.Millisecond, "CPU sampling interval for the process tree")
+	procRoot := fs.String("proc-root", procstat.DefaultRoot, "procfs mount point used for CPU sampling")
+	cpus := fs.Int("cpus", runtime.NumCPU(), "CPU count of the machine the power profile describes")
+	cpuSource := fs.String("cpu-source", cpuSourceProc, "CPU accounting source (proc|cgroup)")
+	cgroupRoot := fs.String("cgroup-root", cgroup.DefaultRoot, "cgroup v2 mount point")
+	cgroupPath := fs.String("cgroup-path", "", "cgroup path relative to cgroup-root (default: own cgroup)")
+	cgroupMemory := fs.Bool("cgroup-memory", false, "use cgroup memory.peak as memory-gb when --memory-gb is not set")
+	energySource := fs.String("energy-source", energySourceModel, "energy source (model|rapl); rapl falls back to model when unavailable")
+	raplRoot := fs.String("rapl-root", rapl.DefaultRoot, "powercap sysfs root used for RAPL counters")
+	reportFile := fs.String("report-file", "", "write the report to a file instead of stdout")
+	asJSON := fs.Bool("json", false, "output JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	command := fs.Args()
+	if len(command) == 0 {
+		return cgerrors.Newf(cgerrors.InputError, "exec requires a command: carbon-guard exec [flags] -- <command> [args]")
+	}
+	if err := rejectMeasuredFlags(fs); err != nil {
+		return err
+	}
+	if *sampleInterval <= 0 {
+		return cgerrors.Newf(cgerrors.InputError, "sample-interval must be > 0")
+	}
+	if *cpus <= 0 {
+		return cgerrors.Newf(cgerrors.InputError, "cpus must be > 0")
+	}
+	if *cpuSource != cpuSourceProc && *cpuSource != cpuSourceCgroup {
+		return cgerrors.Newf(cgerrors.InputError, "cpu-source must be one of: proc, cgroup")
+	}
+	if *cgroupMemory && *cpuSource != cpuSourceCgroup {
+		return cgerrors.Newf(cgerrors.InputError, "cgroup-memory requires --cpu-source cgroup")
+	}
+	if *energySource != energySourceModel && *energySource != energySourceRAPL {
+		return cgerrors.Newf(cgerrors.InputError, "energy-source must be one of: model, rapl")
+	}
+	if err := budget.validate(); err != nil {
+		return err
+	}
+
+	// Resolve model inputs before starting the child so configuration errors fail fast.
+	// 在启动子进程前解析模型输入，使配置错误尽早失败。
+	input, err := model.input()
+	if err != nil {
+		return err
+	}
+	service, err := model.service(input)
+	if err != nil {
+		return err
+	}
+
+	cgroupReader := cgroup.Reader{Root: *cgroupRoot, Path: *cgroupPath}
+	var cgroupStart cgroup.Snapshot
+	if *cpuSource == cpuSourceCgroup {
+		cgroupStart, err = cgroupReader.Snapshot(time.Now())
+		if err != nil {
+			return cgerrors.New(fmt.Errorf("read cgroup accounting: %w", err), cgerrors.InputError)
+		}
+	}
+
+	raplReader := rapl.Reader{Root: *raplRoot}
+	var raplStart rapl.Snapshot
+	useRAPL := *energySource == energySourceRAPL
+	if useRAPL {
+		raplStart, err = raplReader.Snapshot()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: RAPL unavailable, falling back to model: %v\n", err)
+			useRAPL = false
+		}
+	}
+
+	measurement, err := runMeasured(command, *sampleInterval, procstat.Reader{Root: *procRoot})
+	if err != nil {
+		return cgerrors.New(fmt.Errorf("start command: %w", err), cgerrors.InputError)
+	}
+	measurement.Source = cpuSourceProc
+
+	if *cpuSource == cpuSourceCgroup {
+		usage, err := measureCgroup(cgroupReader, cgroupStart)
+		if err != nil {
+			return cgerrors.New(fmt.Errorf("read cgroup accounting: %w", err), cgerrors.InputError)
+		}
+		measurement.Source = cpuSourceCgroup
+		measurement.Wall = usage.Wall
+		measurement.CPU = usage.CPU
+		measurement.MemoryPeakGB = usage.MemoryPeakGB
+		if *cgroupMemory && !flagWasSet(fs, "memory-gb") {
+			input.Resources.MemoryGB = usage.MemoryPeakGB
+		}
+	}
+
+	var raplEnergy rapl.Energy
+	if useRAPL {
+		raplEnergy, err = measureRAPL(raplReader, raplStart)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: RAPL unavailable, falling back to model: %v\n", err)
+		} else {
+			input.Measured = appsvc.MeasuredEnergy{
+				Source:    energySourceRAPL,
+				CPUKWh:    raplEnergy.PackageKWh,
+				MemoryKWh: raplEnergy.DRAMKWh,
+			}
+		}
+	}
+
+	input.Duration = measuredDurationSeconds(measurement.Wall)
+	input.Model.Load = averageLoad(measurement.CPU, measurement.Wall, *cpus)
+	input.Uncertainty.Load = calculator.Range{}
+	result, err := service.Run(context.Background(), input)
+	if err != nil {
+		return mapAppError(err)
+	}
+
+	opts := budget.reportOptions(result)
+	opts.Measurement = &report.Measurement{
+		Command:         strings.Join(command, " "),
+		ExitCode:        measurement.ExitCode,
+		WallSeconds:     measurement.Wall.Seconds(),
+		CPUSeconds:      measurement.CPU.Seconds(),
+		AverageLoad:     input.Model.Load,
+		Samples:         measurement.Samples,
+		SamplingEnabled: measurement.Sampling,
+		CPUSource:       measurement.Source,
+		MemoryPeakGB:    measurement.MemoryPeakGB,
+		EnergySource:    result.EnergySource,
+	}
+	if input.Measured.CPUKWh > 0 {
+		opts.Measurement.RAPLPackageKWh = raplEnergy.PackageKWh
+		opts.Measurement.RAPLDRAMKWh = raplEnergy.DRAMKWh
+	}
+	output := report.BuildFromEmissions(result.DurationSeconds, *asJSON, result.EmissionsKg, opts)
+	if *reportFile != "" {
+		if err := os.WriteFile(*reportFile, []byte(output), 0o644); err != nil {
+			return cgerrors.New(fmt.Errorf("write report file: %w", err), cgerrors.InputError)
+		}
+	} else {
+		fmt.Print(output)
+	}
+
+	if err := budget.gate(result); err != nil {
+		return err
+	}
+	if measurement.ExitCode != 0 {
+		return cgerrors.Newf(measurement.ExitCode, "command exited with status %d", measurement.ExitCode)
+	}
+	return nil
+}
+
+// measureCgroup reads the end snapshot and returns the job's cgroup usage.
+// measureCgroup 读取结束快照并返回作业 cgroup 的使用量。
+func measureCgroup(reader cgroup.Reader, start cgroup.Snapshot) (cgroup.Usage, error) {
+	end, err := reader.Snapshot(time.Now())
+	if err != nil {
+		return cgroup.Usage{}, err
+	}
+	return cgroup.Delta(start, end)
+}
+
+// measureRAPL reads the end counters and returns package/DRAM energy since start.
+// measureRAPL 读取结束计数器并返回自开始以来的 package/DRAM 能耗。
+func measureRAPL(reader rapl.Reader, start rapl.Snapshot) (rapl.Energy, error) {
+	end, err := reader.Snapshot()
+	if err != nil {
+		return rapl.Energy{}, err
+	}
+	energy, err := rapl.Delta(start, end)
+	if err != nil {
+		return rapl.Energy{}, err
+	}
+	if energy.PackageKWh <= 0 {
+		return rapl.Energy{}, fmt.Errorf("%w: package counters did not advance", rapl.ErrUnavailable)
+	}
+	return energy, nil
+}
+
+func flagWasSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func rejectMeasuredFlags(fs *flag.FlagSet) error {
+	var conflict string
+	fs.Visit(func(f *flag.Flag) {
+		for _, name := range measuredFlags {
+			if f.Name == name && conflict == "" {
+				conflict = name
+			}
+		}
+	})
+	if conflict != "" {
+		return cgerrors.Newf(cgerrors.InputError, "exec measures duration and load; --%s is not allowed", conflict)
+	}
+	return nil
+}
+
+// runMeasured runs the command, forwarding signals and sampling process-tree CPU time.
+// runMeasured 运行命令，转发信号并采样进程树 CPU 时间。
+//
+// Sampled CPU time is combined with the child's rusage (which includes reaped descendants),
+// so short commands that finish before the first sample are still measured.
+// 采样值会与子进程 rusage（包含已回收的子孙进程）取较大者，
+// 因此在首次采样前结束的短命令也能被测量。
+func runMeasured(command []string, interval time.Duration, reader procstat.Reader) (execMeasurement, error) {
+	child := exec.Command(command[0], command[1:]...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, len(forwardedSignals))
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	start := time.Now()
+	if err := child.Start(); err != nil {
+		return execMeasurement{}, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- child.Wait()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	measurement := execMeasurement{Sampling: true}
+	var sampled time.Duration
+	for waiting := true; waiting; {
+		select {
+		case sig := <-signals:
+			_ = child.Process.Signal(sig)
+		case <-ticker.C:
+			if !measurement.Sampling {
+				continue
+			}
+			cpu, _, err := reader.TreeCPUTime(child.Process.Pid)
+			if err != nil {
+				if !errors.Is(err, procstat.ErrProcessNotFound) {
+					measurement.Sampling = false
+				}
+				continue
+			}
+			measurement.Samples++
+			if cpu > sampled {
+				sampled = cpu
+			}
+		case <-done:
+			waiting = false
+		}
+	}
+
+	measurement.Wall = time.Since(start)
+	state := child.ProcessState
+	measurement.CPU = state.UserTime() + state.SystemTime()
+	if sampled > measurement.CPU {
+		measurement.CPU = sampled
+	}
+	measurement.ExitCode = exitCodeOf(state)
+	return measurement, nil
+}
+
+// exitCodeOf follows the shell convention of 128+signal for signal-terminated children.
+// exitCodeOf 遵循 shell 约定：被信号终止的子进程返回 128+信号值。
+func exitCodeOf(state *os.ProcessState) int {
+	if code := state.ExitCode(); code >= 0 {
+		return code
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return 1
+}
+
+func measuredDurationSeconds(wall time.Duration) int {
+	seconds := int(math.Ceil(wall.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// averageLoad converts CPU time into machine utilization in [0, 1].
+// averageLoad 将 CPU 时间换算为 [0, 1] 范围内的整机利用率。
+func averageLoad(cpu time.Duration, wall time.Duration, cpus int) float64 {
+	if wall <= 0 || cpus <= 0 {
+		return 0
+	}
+	load := cpu.Seconds() / (wall.Seconds() * float64(cpus))
+	return math.Min(1, math.Max(0, load))
+}
+
+func execOwnArgs(args []string) []string {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i]
+		}
+	}
+	return args
+}
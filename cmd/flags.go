@@ -3,9 +3,14 @@ package cmd
 import (
 	"flag"
 	"fmt"
+	"strings"
 	"time"
 
+	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
+	"github.com/chenzhuyu2004/carbon-guard/internal/catalog"
+	"github.com/chenzhuyu2004/carbon-guard/internal/cloudmeta"
 	cgconfig "github.com/chenzhuyu2004/carbon-guard/internal/config"
+	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
 )
 
 func resolveSharedDefaults(args []string) (cgconfig.Shared, error) {
@@ -31,6 +36,141 @@ func addCacheFlags(fs *flag.FlagSet, defaultDir string, defaultTTL string) (*str
 	return cacheDirRaw, cacheTTLRaw
 }
 
+func addWindowSearchFlags(fs *flag.FlagSet) (*string, *string) {
+	windowSearch := fs.String("window-search", appsvc.WindowSearchContinuous, "window start search: continuous|forecast-starts")
+	startGranularity := fs.String("start-granularity", "", "snap continuous window starts to a UTC grid (e.g. 5m). empty searches at 1s resolution")
+	return windowSearch, startGranularity
+}
+
+func addDeadlineFlags(fs *flag.FlagSet) (*string, *string) {
+	finishBy := fs.String("finish-by", "", "latest window end: RFC3339 time or duration from now (e.g. 8h). derives the lookahead")
+	notBefore := fs.String("not-before", "", "earliest window start: RFC3339 time or duration from now (e.g. 30m)")
+	return finishBy, notBefore
+}
+
+// splitFlags holds the suggest --split flags before parsing.
+// splitFlags 保存解析前的 suggest --split 参数。
+type splitFlags struct {
+	enabled        *bool
+	minChunk       *string
+	maxChunks      *int
+	resumeOverhead *string
+	step           *string
+}
+
+func addSplitFlags(fs *flag.FlagSet) splitFlags {
+	return splitFlags{
+		enabled:        fs.Bool("split", false, "plan a checkpointable job as several lowest-carbon chunks"),
+		minChunk:       fs.String("min-chunk", "", "minimum work per chunk with --split (e.g. 30m). empty means one split step"),
+		maxChunks:      fs.Int("max-chunks", 4, "maximum number of chunks with --split. 0 means no limit"),
+		resumeOverhead: fs.String("resume-overhead", "0s", "extra runtime before every resumed chunk with --split (e.g. 2m)"),
+		step:           fs.String("split-step", scheduling.DefaultSplitStep.String(), "grid for chunk starts and lengths with --split"),
+	}
+}
+
+// riskFlags holds the forecast risk flags before parsing.
+// riskFlags 保存解析前的预测风险参数。
+type riskFlags struct {
+	aversion             *float64
+	forecastError        *float64
+	forecastErrorPerHour *float64
+}
+
+func addRiskFlags(fs *flag.FlagSet) riskFlags {
+	return riskFlags{
+		aversion:             fs.Float64("risk-aversion", 0, "weight of the forecast risk (1-sigma emission error in kg) in the score. 0 reports risk without penalizing it"),
+		forecastError:        fs.Float64("forecast-error", 0.05, "relative CI forecast error at lead time zero"),
+		forecastErrorPerHour: fs.Float64("forecast-error-per-hour", 0.01, "relative CI forecast error added per hour of lead time"),
+	}
+}
+
+func addGridMixFlags(fs *flag.FlagSet, defaultDir string, defaultFactors string) (*string, *string) {
+	gridMixDir := fs.String("grid-mix-dir", defaultDir, "directory of hourly <ZONE>.csv generation mix files (offline provider)")
+	emissionFactors := fs.String("emission-factors", defaultFactors, "path to JSON emission factors overriding embedded IPCC values")
+	return gridMixDir, emissionFactors
+}
+
+// zoneHints builds the auto-resolution hints from shared defaults, loading the cloud region
+// mapping override when configured.
+// zoneHints 基于共享默认值构建自动解析提示，并在配置时加载云 region 映射覆盖文件。
+func zoneHints(defaults cgconfig.Shared) (autoHints, error) {
+	hints := autoHints{
+		ZoneHint:     defaults.ZoneHint,
+		CountryHint:  defaults.CountryHint,
+		TimezoneHint: defaults.TimezoneHint,
+		// Only consulted in auto mode after explicit hints; bounded by cloudmeta.DefaultTimeout.
+		// 仅在 auto 模式且无显式提示时使用；耗时受 cloudmeta.DefaultTimeout 限制。
+		CloudMetadata: cloudmeta.Detector{},
+	}
+	if strings.TrimSpace(defaults.CloudRegionMap) != "" {
+		regions, err := catalog.LoadCloudRegionMap(defaults.CloudRegionMap)
+		if err != nil {
+			return autoHints{}, err
+		}
+		hints.CloudRegions = &regions
+	}
+	if strings.TrimSpace(defaults.ZoneLocations) != "" {
+		locations, err := catalog.LoadZoneLocations(defaults.ZoneLocations)
+		if err != nil {
+			return autoHints{}, err
+		}
+		hints.Locations = &locations
+	}
+	aliases, groups, err := zoneNames(defaults.ZoneAliases, defaults.ZoneGroups)
+	if err != nil {
+		return autoHints{}, err
+	}
+	hints.Aliases, hints.Groups = aliases, groups
+	return hints, nil
+}
+
+// zoneNames lower-cases config alias and group names and rejects names that are empty, contain a
+// comma, or are defined both as an alias and a group. Cycles are detected during expansion.
+// zoneNames 将配置中的别名与分组名转为小写，并拒绝空名称、含逗号的名称或同时定义为别名和分组的名称；
+// 循环引用在展开时检测。
+func zoneNames(rawAliases map[string]string, rawGroups map[string][]string) (map[string]string, map[string][]string, error) {
+	aliases := make(map[string]string, len(rawAliases))
+	for name, target := range rawAliases {
+		key, err := zoneNameKey(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if strings.TrimSpace(target) == "" {
+			return nil, nil, fmt.Errorf("zone alias %q has an empty target", name)
+		}
+		if _, dup := aliases[key]; dup {
+			return nil, nil, fmt.Errorf("zone alias %q is defined more than once", name)
+		}
+		aliases[key] = target
+	}
+	groups := make(map[string][]string, len(rawGroups))
+	for name, members := range rawGroups {
+		key, err := zoneNameKey(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, dup := aliases[key]; dup {
+			return nil, nil, fmt.Errorf("zone name %q is defined as both an alias and a group", name)
+		}
+		if _, dup := groups[key]; dup {
+			return nil, nil, fmt.Errorf("zone group %q is defined more than once", name)
+		}
+		if len(members) == 0 {
+			return nil, nil, fmt.Errorf("zone group %q has no members", name)
+		}
+		groups[key] = members
+	}
+	return aliases, groups, nil
+}
+
+func zoneNameKey(name string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" || strings.Contains(key, ",") {
+		return "", fmt.Errorf("invalid zone alias or group name %q", name)
+	}
+	return key, nil
+}
+
 func validateOutputMode(mode string) error {
 	if mode != "text" && mode != "json" {
 		return fmt.Errorf("output must be text or json")
@@ -38,6 +178,137 @@ func validateOutputMode(mode string) error {
 	return nil
 }
 
+// parseWindowSearch normalizes the window search flags so JSON outputs echo the effective policy.
+// parseWindowSearch 归一化窗口搜索参数，使 JSON 输出回显实际生效的策略。
+func parseWindowSearch(modeRaw string, granularityRaw string) (string, time.Duration, error) {
+	mode := strings.TrimSpace(strings.ToLower(modeRaw))
+	switch mode {
+	case "":
+		mode = appsvc.WindowSearchContinuous
+	case appsvc.WindowSearchContinuous, appsvc.WindowSearchForecastStarts:
+	default:
+		return "", 0, fmt.Errorf("window-search must be continuous or forecast-starts")
+	}
+	if strings.TrimSpace(granularityRaw) == "" {
+		return mode, 0, nil
+	}
+	granularity, err := time.ParseDuration(strings.TrimSpace(granularityRaw))
+	if err != nil || granularity < time.Second || granularity%time.Second != 0 {
+		return "", 0, fmt.Errorf("start-granularity must be a whole number of seconds >= 1s")
+	}
+	if mode == appsvc.WindowSearchForecastStarts {
+		return "", 0, fmt.Errorf("start-granularity requires window-search continuous")
+	}
+	return mode, granularity, nil
+}
+
+// parseSplit converts the split flags into a SuggestSplitInput without zone, duration, or model.
+// parseSplit 将分段参数转换为不含区域、时长与模型的 SuggestSplitInput。
+func parseSplit(flags splitFlags) (appsvc.SuggestSplitInput, error) {
+	step, err := parseSplitDuration("split-step", *flags.step, false)
+	if err != nil {
+		return appsvc.SuggestSplitInput{}, err
+	}
+	minChunk, err := parseSplitDuration("min-chunk", *flags.minChunk, false)
+	if err != nil {
+		return appsvc.SuggestSplitInput{}, err
+	}
+	resumeOverhead, err := parseSplitDuration("resume-overhead", *flags.resumeOverhead, true)
+	if err != nil {
+		return appsvc.SuggestSplitInput{}, err
+	}
+	if *flags.maxChunks < 0 {
+		return appsvc.SuggestSplitInput{}, fmt.Errorf("max-chunks must be >= 0")
+	}
+	return appsvc.SuggestSplitInput{
+		MinChunk:       minChunk,
+		MaxChunks:      *flags.maxChunks,
+		ResumeOverhead: resumeOverhead,
+		Step:           step,
+	}, nil
+}
+
+// parseRisk converts the risk flags into a RiskModel; relative errors are capped at 1.
+// parseRisk 将风险参数转换为 RiskModel；相对误差上限为 1。
+func parseRisk(flags riskFlags) (appsvc.RiskModel, error) {
+	if *flags.aversion < 0 {
+		return appsvc.RiskModel{}, fmt.Errorf("risk-aversion must be >= 0")
+	}
+	if *flags.forecastError < 0 || *flags.forecastError > 1 {
+		return appsvc.RiskModel{}, fmt.Errorf("forecast-error must be in [0, 1]")
+	}
+	if *flags.forecastErrorPerHour < 0 || *flags.forecastErrorPerHour > 1 {
+		return appsvc.RiskModel{}, fmt.Errorf("forecast-error-per-hour must be in [0, 1]")
+	}
+	return appsvc.RiskModel{
+		Aversion:             *flags.aversion,
+		ForecastError:        *flags.forecastError,
+		ForecastErrorPerHour: *flags.forecastErrorPerHour,
+	}, nil
+}
+
+func parseSplitDuration(name string, raw string, allowZero bool) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 || value%time.Second != 0 || (value == 0 && !allowZero) {
+		if allowZero {
+			return 0, fmt.Errorf("%s must be a non-negative whole number of seconds", name)
+		}
+		return 0, fmt.Errorf("%s must be a whole number of seconds >= 1s", name)
+	}
+	return value, nil
+}
+
+// parseWindowBounds parses --finish-by and --not-before; relative durations count from now.
+// parseWindowBounds 解析 --finish-by 与 --not-before；相对时长从 now 起算。
+//
+// --finish-by derives the lookahead, so an explicit --lookahead is rejected alongside it.
+// --finish-by 会推导 lookahead，因此不能同时显式指定 --lookahead。
+func parseWindowBounds(fs *flag.FlagSet, finishByRaw string, notBeforeRaw string, now time.Time) (appsvc.WindowBounds, error) {
+	finishBy, err := parseTimeBound("finish-by", finishByRaw, now)
+	if err != nil {
+		return appsvc.WindowBounds{}, err
+	}
+	notBefore, err := parseTimeBound("not-before", notBeforeRaw, now)
+	if err != nil {
+		return appsvc.WindowBounds{}, err
+	}
+	if !finishBy.IsZero() && flagWasSet(fs, "lookahead") {
+		return appsvc.WindowBounds{}, fmt.Errorf("--lookahead cannot be combined with --finish-by; the lookahead is derived from the deadline")
+	}
+	if !finishBy.IsZero() && !notBefore.IsZero() && !notBefore.Before(finishBy) {
+		return appsvc.WindowBounds{}, fmt.Errorf("not-before must be before finish-by")
+	}
+	return appsvc.WindowBounds{NotBefore: notBefore, FinishBy: finishBy}, nil
+}
+
+func parseTimeBound(name string, raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	offset, err := time.ParseDuration(raw)
+	if err != nil || offset < 0 {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 time or a non-negative duration from now (e.g. 8h)", name)
+	}
+	return now.Add(offset).UTC(), nil
+}
+
+// formatBoundOutput renders an optional bound for JSON output; unset bounds are empty.
+// formatBoundOutput 为 JSON 输出格式化可选边界；未设置时为空。
+func formatBoundOutput(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
 func parseTimeout(timeoutRaw string) (time.Duration, error) {
 	timeout, err := time.ParseDuration(timeoutRaw)
 	if err != nil || timeout <= 0 {
@@ -16,23 +16,49 @@ import (
 type OptimizeZoneOutput struct {
 	Zone         string  `json:"zone"`
 	EmissionKg   float64 `json:"emission_kg"`
+	RiskKg       float64 `json:"risk_kg"`
+	Score        float64 `json:"score"`
 	BestStartUTC string  `json:"best_start_utc"`
 	BestEndUTC   string  `json:"best_end_utc"`
 }
 
+// ZoneMappingOutput is a cloud region identifier resolved to a grid zone.
+// ZoneMappingOutput 为解析到电网区域的云 region 标识。
+type ZoneMappingOutput struct {
+	From string `json:"from"`
+	Zone string `json:"zone"`
+}
+
+// ZoneExpansionOutput is a config alias or group expanded to zones.
+// ZoneExpansionOutput 为展开为区域的配置别名或分组。
+type ZoneExpansionOutput struct {
+	Kind  string   `json:"kind"`
+	Name  string   `json:"name"`
+	Zones []string `json:"zones"`
+}
+
 type OptimizeResult struct {
-	SchemaVersion       string               `json:"schema_version"`
-	DurationSeconds     int                  `json:"duration_seconds"`
-	Zones               []OptimizeZoneOutput `json:"zones"`
-	ZonesSource         string               `json:"zones_source"`
-	ZonesConfidence     string               `json:"zones_confidence"`
-	ZonesReason         string               `json:"zones_reason"`
-	ZonesFallbackUsed   bool                 `json:"zones_fallback_used"`
-	BestZone            string               `json:"best_zone"`
-	BestWindowStartUTC  string               `json:"best_window_start_utc"`
-	BestWindowEndUTC    string               `json:"best_window_end_utc"`
-	EmissionKg          float64              `json:"emission_kg"`
-	ReductionVsWorstPct float64              `json:"reduction_vs_worst_pct"`
+	SchemaVersion           string                `json:"schema_version"`
+	DurationSeconds         int                   `json:"duration_seconds"`
+	Zones                   []OptimizeZoneOutput  `json:"zones"`
+	ZonesSource             string                `json:"zones_source"`
+	ZonesConfidence         string                `json:"zones_confidence"`
+	ZonesReason             string                `json:"zones_reason"`
+	ZonesFallbackUsed       bool                  `json:"zones_fallback_used"`
+	ZoneMappings            []ZoneMappingOutput   `json:"zone_mappings,omitempty"`
+	ZoneExpansions          []ZoneExpansionOutput `json:"zone_expansions,omitempty"`
+	BestZone                string                `json:"best_zone"`
+	BestWindowStartUTC      string                `json:"best_window_start_utc"`
+	BestWindowEndUTC        string                `json:"best_window_end_utc"`
+	EmissionKg              float64               `json:"emission_kg"`
+	RiskKg                  float64               `json:"risk_kg"`
+	Score                   float64               `json:"score"`
+	RiskAversion            float64               `json:"risk_aversion"`
+	ReductionVsWorstPct     float64               `json:"reduction_vs_worst_pct"`
+	WindowSearch            string                `json:"window_search"`
+	StartGranularitySeconds int64                 `json:"start_granularity_seconds"`
+	FinishByUTC             string                `json:"finish_by_utc,omitempty"`
+	NotBeforeUTC            string                `json:"not_before_utc,omitempty"`
 }
 
 func optimize(args []string) error {
@@ -45,14 +71,18 @@ func optimize(args []string) error {
 	fs.SetOutput(os.Stderr)
 
 	addConfigFlag(fs, defaults.ConfigPath)
-	zones := fs.String("zones", "", "comma-separated Electricity Maps zones")
+	zones := fs.String("zones", "", "comma-separated Electricity Maps zones or cloud regions (aws:eu-west-1)")
 	zoneMode := fs.String("zone-mode", defaults.ZoneMode, "zone resolution mode: strict|fallback|auto")
 	duration := fs.Int("duration", 0, "duration in seconds")
 	lookahead := fs.Int("lookahead", 6, "forecast lookahead in hours")
 	waitCost := fs.Float64("wait-cost", 0, "waiting penalty in kgCO2 per hour")
+	windowSearchRaw, startGranularityRaw := addWindowSearchFlags(fs)
+	finishByRaw, notBeforeRaw := addDeadlineFlags(fs)
+	riskRaw := addRiskFlags(fs)
 	timeoutStr := addTimeoutFlag(fs, defaults.Timeout)
 	outputMode := addOutputFlag(fs, defaults.Output)
 	cacheDirRaw, cacheTTLRaw := addCacheFlags(fs, defaults.CacheDir, defaults.CacheTTL)
+	gridMixDir, emissionFactors := addGridMixFlags(fs, defaults.GridMixDir, defaults.EmissionFactors)
 
 	if err := fs.Parse(args); err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
@@ -66,12 +96,20 @@ func optimize(args []string) error {
 	if *lookahead <= 0 {
 		return cgerrors.Newf(cgerrors.InputError, "lookahead must be > 0")
 	}
-	if *duration > *lookahead*3600 {
+	bounds, err := parseWindowBounds(fs, *finishByRaw, *notBeforeRaw, time.Now().UTC())
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	if bounds.FinishBy.IsZero() && *duration > *lookahead*3600 {
 		return cgerrors.Newf(cgerrors.InputError, "duration %ds exceeds forecast coverage %ds", *duration, *lookahead*3600)
 	}
 	if *waitCost < 0 {
 		return cgerrors.Newf(cgerrors.InputError, "wait-cost must be >= 0")
 	}
+	risk, err := parseRisk(riskRaw)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
 	timeout, err := parseTimeout(*timeoutStr)
 	if err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
@@ -80,28 +118,36 @@ func optimize(args []string) error {
 	if err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
 	}
-	resolvedZones, err := resolveZones(*zones, *zoneMode, defaults.Zones, autoHints{
-		ZoneHint:     defaults.ZoneHint,
-		CountryHint:  defaults.CountryHint,
-		TimezoneHint: defaults.TimezoneHint,
-	})
+	windowSearch, startGranularity, err := parseWindowSearch(*windowSearchRaw, *startGranularityRaw)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	hints, err := zoneHints(defaults)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	resolvedZones, err := resolveZones(*zones, *zoneMode, defaults.Zones, hints)
 	if err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
 	}
 
-	apiKey := os.Getenv("ELECTRICITY_MAPS_API_KEY")
-	if apiKey == "" {
-		return cgerrors.Newf(cgerrors.InputError, "missing ELECTRICITY_MAPS_API_KEY")
+	provider, err := buildProvider(*gridMixDir, *emissionFactors, cacheDir, cacheTTL)
+	if err != nil {
+		return err
 	}
 
-	service := appsvc.New(newProviderAdapter(buildLiveProvider(apiKey, cacheDir, cacheTTL)))
+	service := appsvc.New(newProviderAdapter(provider))
 	out, err := service.Optimize(context.Background(), appsvc.OptimizeInput{
-		Zones:     resolvedZones.Zones,
-		Duration:  *duration,
-		Lookahead: *lookahead,
-		WaitCost:  *waitCost,
-		Model:     defaultModelContext(),
-		Timeout:   timeout,
+		Zones:            resolvedZones.Zones,
+		Duration:         *duration,
+		Lookahead:        *lookahead,
+		WaitCost:         *waitCost,
+		Model:            defaultModelContext(),
+		Timeout:          timeout,
+		WindowSearch:     windowSearch,
+		StartGranularity: startGranularity,
+		Bounds:           bounds,
+		Risk:             risk,
 	})
 	if err != nil {
 		return mapAppError(err)
@@ -119,24 +165,35 @@ func optimize(args []string) error {
 			zoneOutputs = append(zoneOutputs, OptimizeZoneOutput{
 				Zone:         result.Zone,
 				EmissionKg:   result.Emission,
+				RiskKg:       result.Risk,
+				Score:        result.Score,
 				BestStartUTC: result.BestStart.UTC().Format(time.RFC3339),
 				BestEndUTC:   result.BestEnd.UTC().Format(time.RFC3339),
 			})
 		}
 
 		payload := OptimizeResult{
-			SchemaVersion:       pkg.JSONSchemaVersion,
-			DurationSeconds:     *duration,
-			Zones:               zoneOutputs,
-			ZonesSource:         resolvedZones.Source,
-			ZonesConfidence:     resolvedZones.Confidence,
-			ZonesReason:         resolvedZones.Reason,
-			ZonesFallbackUsed:   resolvedZones.FallbackUsed,
-			BestZone:            out.Best.Zone,
-			BestWindowStartUTC:  out.Best.BestStart.UTC().Format(time.RFC3339),
-			BestWindowEndUTC:    out.Best.BestEnd.UTC().Format(time.RFC3339),
-			EmissionKg:          out.Best.Emission,
-			ReductionVsWorstPct: out.Reduction,
+			SchemaVersion:           pkg.JSONSchemaVersion,
+			DurationSeconds:         *duration,
+			Zones:                   zoneOutputs,
+			ZonesSource:             resolvedZones.Source,
+			ZonesConfidence:         resolvedZones.Confidence,
+			ZonesReason:             resolvedZones.Reason,
+			ZonesFallbackUsed:       resolvedZones.FallbackUsed,
+			ZoneMappings:            zoneMappingOutputs(resolvedZones.Mappings),
+			ZoneExpansions:          zoneExpansionOutputs(resolvedZones.Expansions),
+			BestZone:                out.Best.Zone,
+			BestWindowStartUTC:      out.Best.BestStart.UTC().Format(time.RFC3339),
+			BestWindowEndUTC:        out.Best.BestEnd.UTC().Format(time.RFC3339),
+			EmissionKg:              out.Best.Emission,
+			RiskKg:                  out.Best.Risk,
+			Score:                   out.Best.Score,
+			RiskAversion:            risk.Aversion,
+			ReductionVsWorstPct:     out.Reduction,
+			WindowSearch:            windowSearch,
+			StartGranularitySeconds: int64(startGranularity / time.Second),
+			FinishByUTC:             formatBoundOutput(bounds.FinishBy),
+			NotBeforeUTC:            formatBoundOutput(bounds.NotBefore),
 		}
 
 		data, err := json.MarshalIndent(payload, "", "  ")
@@ -161,6 +218,29 @@ func optimize(args []string) error {
 	}
 	fmt.Printf("\nBest zone: %s\n", out.Best.Zone)
 	fmt.Printf("Best window (UTC): %s - %s\n", out.Best.BestStart.UTC().Format("15:04"), out.Best.BestEnd.UTC().Format("15:04"))
+	fmt.Printf("Best emission: %.3f kg, forecast risk (1-sigma): %.3f kg, score: %.3f\n", out.Best.Emission, out.Best.Risk, out.Best.Score)
 	fmt.Printf("Reduction vs worst: %.2f %%\n", out.Reduction)
 	return nil
 }
+
+func zoneMappingOutputs(mappings []zoneMapping) []ZoneMappingOutput {
+	if len(mappings) == 0 {
+		return nil
+	}
+	out := make([]ZoneMappingOutput, len(mappings))
+	for i, mapping := range mappings {
+		out[i] = ZoneMappingOutput{From: mapping.From, Zone: mapping.Zone}
+	}
+	return out
+}
+
+func zoneExpansionOutputs(expansions []zoneExpansion) []ZoneExpansionOutput {
+	if len(expansions) == 0 {
+		return nil
+	}
+	out := make([]ZoneExpansionOutput, len(expansions))
+	for i, expansion := range expansions {
+		out[i] = ZoneExpansionOutput{Kind: expansion.Kind, Name: expansion.Name, Zones: expansion.Zones}
+	}
+	return out
+}
@@ -14,19 +14,29 @@ import (
 )
 
 type OptimizeGlobalResult struct {
-	SchemaVersion             string  `json:"schema_version"`
-	DurationSeconds           int     `json:"duration_seconds"`
-	ZonesSource               string  `json:"zones_source"`
-	ZonesConfidence           string  `json:"zones_confidence"`
-	ZonesReason               string  `json:"zones_reason"`
-	ZonesFallbackUsed         bool    `json:"zones_fallback_used"`
-	BestZone                  string  `json:"best_zone"`
-	BestWindowStartUTC        string  `json:"best_window_start_utc"`
-	BestWindowEndUTC          string  `json:"best_window_end_utc"`
-	EmissionKg                float64 `json:"emission_kg"`
-	ReductionVsWorstPct       float64 `json:"reduction_vs_worst_pct"`
-	ResampleFillMode          string  `json:"resample_fill_mode"`
-	ResampleMaxFillAgeSeconds int64   `json:"resample_max_fill_age_seconds"`
+	SchemaVersion             string                `json:"schema_version"`
+	DurationSeconds           int                   `json:"duration_seconds"`
+	ZonesSource               string                `json:"zones_source"`
+	ZonesConfidence           string                `json:"zones_confidence"`
+	ZonesReason               string                `json:"zones_reason"`
+	ZonesFallbackUsed         bool                  `json:"zones_fallback_used"`
+	ZoneMappings              []ZoneMappingOutput   `json:"zone_mappings,omitempty"`
+	ZoneExpansions            []ZoneExpansionOutput `json:"zone_expansions,omitempty"`
+	BestZone                  string                `json:"best_zone"`
+	BestWindowStartUTC        string                `json:"best_window_start_utc"`
+	BestWindowEndUTC          string                `json:"best_window_end_utc"`
+	EmissionKg                float64               `json:"emission_kg"`
+	RiskKg                    float64               `json:"risk_kg"`
+	Score                     float64               `json:"score"`
+	RiskAversion              float64               `json:"risk_aversion"`
+	ReductionVsWorstPct       float64               `json:"reduction_vs_worst_pct"`
+	ResampleFillMode          string                `json:"resample_fill_mode"`
+	ResampleMaxFillAgeSeconds int64                 `json:"resample_max_fill_age_seconds"`
+	ResampleMaxGapSeconds     int64                 `json:"resample_max_gap_seconds"`
+	WindowSearch              string                `json:"window_search"`
+	StartGranularitySeconds   int64                 `json:"start_granularity_seconds"`
+	FinishByUTC               string                `json:"finish_by_utc,omitempty"`
+	NotBeforeUTC              string                `json:"not_before_utc,omitempty"`
 }
 
 func optimizeGlobal(args []string) error {
@@ -39,16 +49,21 @@ func optimizeGlobal(args []string) error {
 	fs.SetOutput(os.Stderr)
 
 	addConfigFlag(fs, defaults.ConfigPath)
-	zones := fs.String("zones", "", "comma-separated Electricity Maps zones")
+	zones := fs.String("zones", "", "comma-separated Electricity Maps zones or cloud regions (aws:eu-west-1)")
 	zoneMode := fs.String("zone-mode", defaults.ZoneMode, "zone resolution mode: strict|fallback|auto")
 	duration := fs.Int("duration", 0, "duration in seconds")
 	lookahead := fs.Int("lookahead", 6, "forecast lookahead in hours")
 	waitCost := fs.Float64("wait-cost", 0, "waiting penalty in kgCO2 per hour")
-	resampleFill := fs.String("resample-fill", "forward", "resample fill mode: forward|strict")
+	windowSearchRaw, startGranularityRaw := addWindowSearchFlags(fs)
+	finishByRaw, notBeforeRaw := addDeadlineFlags(fs)
+	riskRaw := addRiskFlags(fs)
+	resampleFill := fs.String("resample-fill", "forward", "resample fill mode: forward|strict|linear|nearest")
 	resampleMaxFillAgeRaw := fs.String("resample-max-fill-age", "", "max forward-fill age (e.g. 30m). empty uses default")
+	resampleMaxGapRaw := fs.String("resample-max-gap", "", "max source gap bridged by linear|nearest fill (e.g. 90m). empty uses default 2h")
 	timeoutStr := addTimeoutFlag(fs, defaults.Timeout)
 	outputMode := addOutputFlag(fs, defaults.Output)
 	cacheDirRaw, cacheTTLRaw := addCacheFlags(fs, defaults.CacheDir, defaults.CacheTTL)
+	gridMixDir, emissionFactors := addGridMixFlags(fs, defaults.GridMixDir, defaults.EmissionFactors)
 
 	if err := fs.Parse(args); err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
@@ -71,12 +86,20 @@ func optimizeGlobal(args []string) error {
 	if err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
 	}
-	if *duration > *lookahead*3600 {
+	bounds, err := parseWindowBounds(fs, *finishByRaw, *notBeforeRaw, time.Now().UTC())
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	if bounds.FinishBy.IsZero() && *duration > *lookahead*3600 {
 		return cgerrors.Newf(cgerrors.InputError, "duration %ds exceeds forecast coverage %ds", *duration, *lookahead*3600)
 	}
 	if *waitCost < 0 {
 		return cgerrors.Newf(cgerrors.InputError, "wait-cost must be >= 0")
 	}
+	risk, err := parseRisk(riskRaw)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
 	resampleMaxFillAge := time.Duration(0)
 	if *resampleMaxFillAgeRaw != "" {
 		parsed, err := time.ParseDuration(*resampleMaxFillAgeRaw)
@@ -85,22 +108,34 @@ func optimizeGlobal(args []string) error {
 		}
 		resampleMaxFillAge = parsed
 	}
+	resampleMaxGap := time.Duration(0)
+	if *resampleMaxGapRaw != "" {
+		parsed, err := time.ParseDuration(*resampleMaxGapRaw)
+		if err != nil || parsed < 0 {
+			return cgerrors.Newf(cgerrors.InputError, "resample-max-gap must be a non-negative duration")
+		}
+		resampleMaxGap = parsed
+	}
 
-	resolvedZones, err := resolveZones(*zones, *zoneMode, defaults.Zones, autoHints{
-		ZoneHint:     defaults.ZoneHint,
-		CountryHint:  defaults.CountryHint,
-		TimezoneHint: defaults.TimezoneHint,
-	})
+	windowSearch, startGranularity, err := parseWindowSearch(*windowSearchRaw, *startGranularityRaw)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	hints, err := zoneHints(defaults)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	resolvedZones, err := resolveZones(*zones, *zoneMode, defaults.Zones, hints)
 	if err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
 	}
 
-	apiKey := os.Getenv("ELECTRICITY_MAPS_API_KEY")
-	if apiKey == "" {
-		return cgerrors.Newf(cgerrors.InputError, "missing ELECTRICITY_MAPS_API_KEY")
+	provider, err := buildProvider(*gridMixDir, *emissionFactors, cacheDir, cacheTTL)
+	if err != nil {
+		return err
 	}
 
-	service := appsvc.New(newProviderAdapter(buildLiveProvider(apiKey, cacheDir, cacheTTL)))
+	service := appsvc.New(newProviderAdapter(provider))
 	out, err := service.OptimizeGlobal(context.Background(), appsvc.OptimizeGlobalInput{
 		Zones:              resolvedZones.Zones,
 		Duration:           *duration,
@@ -108,8 +143,13 @@ func optimizeGlobal(args []string) error {
 		WaitCost:           *waitCost,
 		ResampleFillMode:   *resampleFill,
 		ResampleMaxFillAge: resampleMaxFillAge,
+		ResampleMaxGap:     resampleMaxGap,
 		Model:              defaultModelContext(),
 		Timeout:            timeout,
+		WindowSearch:       windowSearch,
+		StartGranularity:   startGranularity,
+		Bounds:             bounds,
+		Risk:               risk,
 	})
 	if err != nil {
 		return mapAppError(err)
@@ -123,13 +163,23 @@ func optimizeGlobal(args []string) error {
 			ZonesConfidence:           resolvedZones.Confidence,
 			ZonesReason:               resolvedZones.Reason,
 			ZonesFallbackUsed:         resolvedZones.FallbackUsed,
+			ZoneMappings:              zoneMappingOutputs(resolvedZones.Mappings),
+			ZoneExpansions:            zoneExpansionOutputs(resolvedZones.Expansions),
 			BestZone:                  out.BestZone,
 			BestWindowStartUTC:        out.BestStart.UTC().Format(time.RFC3339),
 			BestWindowEndUTC:          out.BestEnd.UTC().Format(time.RFC3339),
 			EmissionKg:                out.Emission,
+			RiskKg:                    out.Risk,
+			Score:                     out.Score,
+			RiskAversion:              risk.Aversion,
 			ReductionVsWorstPct:       out.Reduction,
 			ResampleFillMode:          out.ResampleFillMode,
 			ResampleMaxFillAgeSeconds: out.ResampleMaxFillAgeSeconds,
+			ResampleMaxGapSeconds:     out.ResampleMaxGapSeconds,
+			WindowSearch:              windowSearch,
+			StartGranularitySeconds:   int64(startGranularity / time.Second),
+			FinishByUTC:               formatBoundOutput(bounds.FinishBy),
+			NotBeforeUTC:              formatBoundOutput(bounds.NotBefore),
 		}
 
 		data, err := json.MarshalIndent(payload, "", "  ")
@@ -152,7 +202,14 @@ func optimizeGlobal(args []string) error {
 	fmt.Printf("Start (UTC): %s\n", out.BestStart.UTC().Format("15:04"))
 	fmt.Printf("Zone: %s\n", out.BestZone)
 	fmt.Printf("Emission: %.3f kg\n", out.Emission)
+	fmt.Printf("Forecast risk (1-sigma): %.3f kg\n", out.Risk)
+	fmt.Printf("Score: %.3f\n", out.Score)
 	fmt.Printf("Improvement vs worst plan: %.2f %%\n", out.Reduction)
-	fmt.Printf("Resample mode: %s (max fill age: %ds)\n", out.ResampleFillMode, out.ResampleMaxFillAgeSeconds)
+	switch out.ResampleFillMode {
+	case "linear", "nearest":
+		fmt.Printf("Resample mode: %s (max gap: %ds)\n", out.ResampleFillMode, out.ResampleMaxGapSeconds)
+	default:
+		fmt.Printf("Resample mode: %s (max fill age: %ds)\n", out.ResampleFillMode, out.ResampleMaxFillAgeSeconds)
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"time"
 
 	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
 	"github.com/chenzhuyu2004/carbon-guard/internal/ci"
@@ -38,3 +39,36 @@ func (p *providerAdapter) GetForecastCI(ctx context.Context, zone string, hours
 	}
 	return out, nil
 }
+
+func (p *providerAdapter) GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) ([]scheduling.ForecastPoint, error) {
+	points, err := ci.GetHistoryCI(ctx, p.inner, zone, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]scheduling.ForecastPoint, len(points))
+	for i, point := range points {
+		out[i] = scheduling.ForecastPoint{
+			Timestamp: point.Timestamp,
+			CI:        point.CI,
+		}
+	}
+	return out, nil
+}
+
+func (p *providerAdapter) GetPriceSeries(ctx context.Context, zone string, start time.Time, end time.Time) ([]appsvc.PricePoint, error) {
+	points, err := ci.GetPriceSeries(ctx, p.inner, zone, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]appsvc.PricePoint, len(points))
+	for i, point := range points {
+		out[i] = appsvc.PricePoint{
+			Timestamp:   point.Timestamp,
+			PricePerKWh: point.PricePerKWh,
+			Currency:    point.Currency,
+		}
+	}
+	return out, nil
+}
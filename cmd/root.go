@@ -23,6 +23,10 @@ func Execute() {
 	switch command {
 	case "run":
 		err = run(args)
+	case "sci":
+		err = sci(args)
+	case "exec":
+		err = execCommand(args)
 	case "suggest":
 		err = suggest(args)
 	case "run-aware":
@@ -31,6 +35,10 @@ func Execute() {
 		err = optimize(args)
 	case "optimize-global":
 		err = optimizeGlobal(args)
+	case "zones":
+		err = zones(args)
+	case "batch":
+		err = batch(args)
 	default:
 		printUsage()
 		os.Exit(1)
@@ -40,17 +48,24 @@ func Execute() {
 }
 
 func printUsage() {
-	fmt.Fprintln(os.Stderr, "usage: carbon-guard <run|suggest|run-aware|optimize|optimize-global> [flags]")
+	fmt.Fprintln(os.Stderr, "usage: carbon-guard <run|sci|exec|suggest|run-aware|optimize|optimize-global|zones|batch> [flags]")
 }
 
 func detectJSONOutput(command string, args []string) bool {
 	switch command {
-	case "run":
+	case "run", "sci":
 		if enabled, ok := parseBoolFlag(args, "json"); ok {
 			return enabled
 		}
 		return false
-	case "optimize", "optimize-global":
+	case "exec":
+		// Only flags before "--" belong to carbon-guard; the rest is the child command.
+		// 仅 "--" 之前的参数属于 carbon-guard，其余为子命令参数。
+		if enabled, ok := parseBoolFlag(execOwnArgs(args), "json"); ok {
+			return enabled
+		}
+		return false
+	case "optimize", "optimize-global", "zones", "batch":
 		if mode, ok := parseStringFlag(args, "output"); ok {
 			return strings.EqualFold(mode, "json")
 		}
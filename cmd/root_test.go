@@ -2,17 +2,26 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"math"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
 	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
 	"github.com/chenzhuyu2004/carbon-guard/internal/ci"
+	cgconfig "github.com/chenzhuyu2004/carbon-guard/internal/config"
+	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
 	cgerrors "github.com/chenzhuyu2004/carbon-guard/internal/errors"
+	"github.com/chenzhuyu2004/carbon-guard/internal/report"
+	"github.com/chenzhuyu2004/carbon-guard/pkg/models"
 )
 
 type fakeCIProvider struct {
@@ -90,7 +99,7 @@ func TestAnalyzeBestWindowDurationExceedsLookahead(t *testing.T) {
 		Runner: "ubuntu",
 		Load:   0.6,
 		PUE:    1.2,
-	}, 0)
+	}, 0, scheduling.WindowSearchOptions{}, appsvc.WindowBounds{}, appsvc.RiskModel{})
 	if err == nil {
 		t.Fatalf("expected error when duration exceeds lookahead")
 	}
@@ -110,7 +119,7 @@ func TestAnalyzeBestWindowCoverageError(t *testing.T) {
 		Runner: "ubuntu",
 		Load:   0.6,
 		PUE:    1.2,
-	}, 0)
+	}, 0, scheduling.WindowSearchOptions{}, appsvc.WindowBounds{}, appsvc.RiskModel{})
 	if err == nil {
 		t.Fatalf("expected coverage error")
 	}
@@ -140,6 +149,34 @@ func TestDetectJSONOutputRun(t *testing.T) {
 	}
 }
 
+func TestDetectJSONOutputSCI(t *testing.T) {
+	if !detectJSONOutput("sci", []string{"--duration", "300", "--functional-units", "10", "--json"}) {
+		t.Fatalf("expected sci output mode to detect json")
+	}
+}
+
+func TestSCIMissingFunctionalUnitsReturnsInputError(t *testing.T) {
+	err := sci([]string{"--duration", "300"})
+	if code := cgerrors.GetCode(err); err == nil || code != cgerrors.InputError {
+		t.Fatalf("error = %v (code %d), expected input error", err, code)
+	}
+}
+
+func TestBuildSCIOutputConvertsToGrams(t *testing.T) {
+	out := buildSCIOutput(appsvc.SCIResult{
+		Run:             appsvc.RunResult{DurationSeconds: 300},
+		FunctionalUnits: 4,
+		FunctionalUnit:  "artifact",
+		SCIKgPerUnit:    0.002,
+	})
+	if out.SchemaVersion == "" || out.Methodology != sciMethodology {
+		t.Fatalf("unexpected contract header: %+v", out)
+	}
+	if out.DurationSeconds != 300 || out.SCIGPerUnit != 2 {
+		t.Fatalf("unexpected sci output: %+v", out)
+	}
+}
+
 func TestDetectJSONOutputOptimize(t *testing.T) {
 	if !detectJSONOutput("optimize", []string{"--zones", "DE,FR", "--duration", "300", "--output=json"}) {
 		t.Fatalf("expected optimize output mode to detect json with equals syntax")
@@ -150,6 +187,12 @@ func TestDetectJSONOutputOptimize(t *testing.T) {
 	if detectJSONOutput("optimize", []string{"--zones", "DE,FR", "--duration", "300", "--output", "text"}) {
 		t.Fatalf("expected optimize output mode to detect text")
 	}
+	if !detectJSONOutput("zones", []string{"resolve", "--explain", "--output=json"}) {
+		t.Fatalf("expected zones output mode to detect json")
+	}
+	if !detectJSONOutput("batch", []string{"--jobs", "jobs.json", "--output", "json"}) {
+		t.Fatalf("expected batch output mode to detect json")
+	}
 }
 
 func TestDetectJSONOutputOptimizeFromEnvDefault(t *testing.T) {
@@ -172,3 +215,566 @@ func TestDetectJSONOutputOptimizeFromConfigFile(t *testing.T) {
 		t.Fatalf("expected optimize-global output mode to detect json from config file")
 	}
 }
+
+func TestRunUnknownInstanceTypeReturnsInputError(t *testing.T) {
+	err := run([]string{
+		"--duration", "300",
+		"--instance-type", "aws:not-a-real-type",
+	})
+	if err == nil {
+		t.Fatalf("expected unknown instance type error")
+	}
+	if code := cgerrors.GetCode(err); code != cgerrors.InputError {
+		t.Fatalf("error code = %d, expected %d", code, cgerrors.InputError)
+	}
+}
+
+func TestResolveInstancePowerUsesCatalog(t *testing.T) {
+	power, err := resolveInstancePower("aws:c6i.4xlarge", "")
+	if err != nil {
+		t.Fatalf("resolveInstancePower() unexpected error: %v", err)
+	}
+	if power.Idle <= 0 || power.Peak <= power.Idle {
+		t.Fatalf("unexpected power profile: %#v", power)
+	}
+
+	empty, err := resolveInstancePower("", "")
+	if err != nil || empty != nil {
+		t.Fatalf("expected empty profile without instance type, got %#v (%v)", empty, err)
+	}
+}
+
+func TestApplyEmbodiedOverrides(t *testing.T) {
+	unchanged := applyEmbodiedOverrides(nil, "ubuntu", -1, -1, -1)
+	if unchanged != nil {
+		t.Fatalf("expected untouched profile without overrides, got %#v", unchanged)
+	}
+
+	got := applyEmbodiedOverrides(&models.PowerProfile{Idle: 10, Peak: 50}, "ubuntu", 800, -1, -1)
+	if got.Embodied.TotalKgCO2e != 800 || got.Embodied.LifetimeYears != defaultEmbodiedLifetimeYears || got.Embodied.Share != defaultEmbodiedShare {
+		t.Fatalf("unexpected embodied override: %#v", got.Embodied)
+	}
+
+	runner := applyEmbodiedOverrides(nil, "ubuntu", -1, -1, 0.25)
+	if runner.Peak != models.RunnerProfiles["ubuntu"].Peak || runner.Embodied.Share != 0.25 {
+		t.Fatalf("expected runner profile with share override, got %#v", runner)
+	}
+}
+
+func TestRunLoadRangeFlagUsesMidpoint(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	model := addRunModelFlags(fs, cgconfig.Shared{})
+	if err := fs.Parse([]string{"--duration", "300", "--load", "0.4..0.8"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	input, err := model.input()
+	if err != nil {
+		t.Fatalf("input() unexpected error: %v", err)
+	}
+	if math.Abs(input.Model.Load-0.6) > 1e-12 {
+		t.Fatalf("Model.Load = %v, expected midpoint 0.6", input.Model.Load)
+	}
+	if input.Uncertainty.Load.Min != 0.4 || input.Uncertainty.Load.Max != 0.8 {
+		t.Fatalf("Uncertainty.Load = %+v, expected [0.4, 0.8]", input.Uncertainty.Load)
+	}
+}
+
+func TestRunBudgetPercentileGatesOnP95(t *testing.T) {
+	args := []string{"--duration", "600", "--load", "0.4..0.8", "--ci-uncertainty", "0.3"}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	model := addRunModelFlags(fs, cgconfig.Shared{})
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	input, err := model.input()
+	if err != nil {
+		t.Fatalf("input() unexpected error: %v", err)
+	}
+	service, err := model.service(input)
+	if err != nil {
+		t.Fatalf("service() unexpected error: %v", err)
+	}
+	result, err := service.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if result.Uncertainty == nil || result.Uncertainty.P95 <= result.Uncertainty.P50 {
+		t.Fatalf("expected a p50 < p95 interval, got %#v", result.Uncertainty)
+	}
+
+	// A budget between the reported p50 and p95 passes the p50 gate and fails the p95 gate.
+	budget := strconv.FormatFloat((result.Uncertainty.P50+result.Uncertainty.P95)/2, 'f', -1, 64)
+	gated := append(slices.Clone(args), "--budget-kg", budget, "--fail-on-budget")
+	if err := run(append(slices.Clone(gated), "--budget-percentile", "p50")); err != nil {
+		t.Fatalf("run() with p50 gate unexpected error: %v", err)
+	}
+	err = run(append(slices.Clone(gated), "--budget-percentile", "p95"))
+	if code := cgerrors.GetCode(err); code != cgerrors.BudgetExceeded {
+		t.Fatalf("error code = %d, expected %d", code, cgerrors.BudgetExceeded)
+	}
+
+	// The JSON budget_exceeded flag follows the same basis as the gate.
+	budgetKg, _ := strconv.ParseFloat(budget, 64)
+	output := report.BuildFromEmissions(result.DurationSeconds, true, result.EmissionsKg, report.BuildOptions{
+		BudgetKg:    budgetKg,
+		Uncertainty: runUncertainty(result, "p95"),
+	})
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(output), &payload); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	if payload["budget_exceeded"] != true {
+		t.Fatalf("budget_exceeded = %#v, expected true under the p95 gate", payload["budget_exceeded"])
+	}
+}
+
+func TestRunPowerFileIntegratesAgainstSegmentsFile(t *testing.T) {
+	dir := t.TempDir()
+	powerFile := filepath.Join(dir, "power.csv")
+	segmentsFile := filepath.Join(dir, "segments.csv")
+	power := "timestamp,watts\n2026-03-01T10:00:00Z,1000\n2026-03-01T11:00:00Z,1000\n"
+	segments := "start,end,ci\n2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,0.5\n"
+	if err := os.WriteFile(powerFile, []byte(power), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if err := os.WriteFile(segmentsFile, []byte(segments), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	// 1 kWh * PUE 1.2 * 0.5 kg/kWh = 0.6 kg.
+	args := []string{"--power-file", powerFile, "--segments-file", segmentsFile, "--fail-on-budget"}
+	if err := run(append(args, "--budget-kg", "0.61")); err != nil {
+		t.Fatalf("run() unexpected error: %v", err)
+	}
+	err := run(append(args, "--budget-kg", "0.59"))
+	if code := cgerrors.GetCode(err); code != cgerrors.BudgetExceeded {
+		t.Fatalf("error code = %d, expected %d", code, cgerrors.BudgetExceeded)
+	}
+}
+
+func TestRunHistoryZoneSkipsProviderWhenSegmentsHaveCI(t *testing.T) {
+	t.Setenv("ELECTRICITY_MAPS_API_KEY", "")
+	path := filepath.Join(t.TempDir(), "segments.csv")
+	segments := "start,duration_seconds,ci\n2026-03-01T10:00:00Z,600,0.4\n"
+	if err := os.WriteFile(path, []byte(segments), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	if err := run([]string{"--segments-file", path, "--history-zone", "DE"}); err != nil {
+		t.Fatalf("run() unexpected error with every segment CI set: %v", err)
+	}
+
+	missing := "start,duration_seconds,ci\n2026-03-01T10:00:00Z,600,\n"
+	if err := os.WriteFile(path, []byte(missing), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	err := run([]string{"--segments-file", path, "--history-zone", "DE"})
+	if code := cgerrors.GetCode(err); code != cgerrors.InputError || !strings.Contains(err.Error(), "ELECTRICITY_MAPS_API_KEY") {
+		t.Fatalf("expected missing API key once history is needed, got %v", err)
+	}
+}
+
+func TestRunInstrumentsFileAddsScope2(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instruments.json")
+	data := `{"instruments":[{"name":"rec","region":"global","coverage_pct":100}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	model := addRunModelFlags(fs, cgconfig.Shared{})
+	budget := addBudgetFlags(fs)
+	if err := fs.Parse([]string{"--duration", "3600", "--instruments-file", path}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	input, err := model.input()
+	if err != nil {
+		t.Fatalf("input() unexpected error: %v", err)
+	}
+	service, err := model.service(input)
+	if err != nil {
+		t.Fatalf("service() unexpected error: %v", err)
+	}
+	result, err := service.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	output := report.BuildFromEmissions(result.DurationSeconds, true, result.EmissionsKg, budget.reportOptions(result))
+	var payload struct {
+		Scope2 struct {
+			LocationBasedKg float64  `json:"location_based_kg"`
+			MarketBasedKg   float64  `json:"market_based_kg"`
+			Instruments     []string `json:"instruments"`
+		} `json:"scope2"`
+	}
+	if err := json.Unmarshal([]byte(output), &payload); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	if payload.Scope2.LocationBasedKg <= 0 || payload.Scope2.MarketBasedKg != 0 || len(payload.Scope2.Instruments) != 1 {
+		t.Fatalf("unexpected scope2 payload: %s", output)
+	}
+}
+
+func TestRunRegionMatchesZoneKeyedInstrumentsAndPrices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instruments.json")
+	data := `{"instruments":[{"name":"de-go","zone":"DE","coverage_pct":100}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	for _, region := range []string{"DE", "aws:eu-central-1"} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		model := addRunModelFlags(fs, cgconfig.Shared{})
+		args := []string{"--duration", "3600", "--region", region, "--instruments-file", path, "--electricity-price", "DE=0.31,*=0.1"}
+		if err := fs.Parse(args); err != nil {
+			t.Fatalf("Parse() unexpected error: %v", err)
+		}
+		input, err := model.input()
+		if err != nil {
+			t.Fatalf("input() unexpected error: %v", err)
+		}
+		service, err := model.service(input)
+		if err != nil {
+			t.Fatalf("service() unexpected error: %v", err)
+		}
+		result, err := service.Run(context.Background(), input)
+		if err != nil {
+			t.Fatalf("Run(%s) unexpected error: %v", region, err)
+		}
+		if result.MarketBased == nil || len(result.MarketBased.Instruments) != 1 || result.MarketBased.EmissionsKg != 0 {
+			t.Fatalf("Run(%s) market based = %+v, expected the DE instrument to apply", region, result.MarketBased)
+		}
+		if want := result.EnergyTotalKWh * 0.31; result.Cost == nil || math.Abs(result.Cost.Amount-want) > 1e-12 {
+			t.Fatalf("Run(%s) cost = %+v, expected DE price %.6f", region, result.Cost, want)
+		}
+	}
+}
+
+func TestParseStaticPricesAcceptsNumberAndZoneList(t *testing.T) {
+	got, err := parseStaticPrices("0.25")
+	if err != nil || !reflect.DeepEqual(got, map[string]float64{"*": 0.25}) {
+		t.Fatalf("parseStaticPrices(number) = %v, %v", got, err)
+	}
+	got, err = parseStaticPrices("DE=0.31, FR=0.19,*=0.2")
+	if err != nil || !reflect.DeepEqual(got, map[string]float64{"DE": 0.31, "FR": 0.19, "*": 0.2}) {
+		t.Fatalf("parseStaticPrices(list) = %v, %v", got, err)
+	}
+	if _, err := parseStaticPrices("DE:0.31"); err == nil {
+		t.Fatalf("expected error for malformed entry")
+	}
+}
+
+func TestExecPreservesChildExitCode(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "report.json")
+	err := execCommand([]string{"--json", "--report-file", report, "--", "sh", "-c", "exit 3"})
+	if code := cgerrors.GetCode(err); code != 3 {
+		t.Fatalf("error code = %d, expected child exit code 3 (err: %v)", code, err)
+	}
+	data, readErr := os.ReadFile(report)
+	if readErr != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", readErr)
+	}
+	if !strings.Contains(string(data), `"exit_code": 3`) {
+		t.Fatalf("expected measurement exit_code in report, got: %s", data)
+	}
+}
+
+func TestExecBudgetGateOverridesExitCode(t *testing.T) {
+	err := execCommand([]string{
+		"--report-file", filepath.Join(t.TempDir(), "report.txt"),
+		"--budget-kg", "0.0000001",
+		"--fail-on-budget",
+		"--", "sh", "-c", "exit 0",
+	})
+	if code := cgerrors.GetCode(err); code != cgerrors.BudgetExceeded {
+		t.Fatalf("error code = %d, expected %d", code, cgerrors.BudgetExceeded)
+	}
+}
+
+func TestExecRejectsMeasuredFlags(t *testing.T) {
+	err := execCommand([]string{"--duration", "60", "--", "true"})
+	if code := cgerrors.GetCode(err); err == nil || code != cgerrors.InputError {
+		t.Fatalf("expected input error, got %v", err)
+	}
+}
+
+func TestDetectJSONOutputExecIgnoresChildArgs(t *testing.T) {
+	if detectJSONOutput("exec", []string{"--", "tool", "--json"}) {
+		t.Fatalf("expected child --json to be ignored")
+	}
+	if !detectJSONOutput("exec", []string{"--json", "--", "tool"}) {
+		t.Fatalf("expected exec --json to be detected")
+	}
+}
+
+func TestAverageLoadClampsToMachine(t *testing.T) {
+	if got := averageLoad(3*time.Second, 2*time.Second, 4); math.Abs(got-0.375) > 1e-12 {
+		t.Fatalf("averageLoad() = %v, expected 0.375", got)
+	}
+	if got := averageLoad(10*time.Second, time.Second, 2); got != 1 {
+		t.Fatalf("averageLoad() = %v, expected clamp to 1", got)
+	}
+}
+
+func TestExecCgroupSourceUsesFakeTree(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "job")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("MkdirAll() unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cpu.stat"), []byte("usage_usec 5000000\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "memory.peak"), []byte("3000000000\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	report := filepath.Join(t.TempDir(), "report.json")
+	err := execCommand([]string{
+		"--json", "--report-file", report,
+		"--cpu-source", "cgroup", "--cgroup-root", root, "--cgroup-path", "job", "--cgroup-memory",
+		"--memory-kwh-per-gb-hour", "1",
+		"--", "sh", "-c", "exit 0",
+	})
+	if err != nil {
+		t.Fatalf("execCommand() unexpected error: %v", err)
+	}
+	data, readErr := os.ReadFile(report)
+	if readErr != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", readErr)
+	}
+	for _, want := range []string{`"cpu_source": "cgroup"`, `"memory_peak_gb": 3`} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected report to contain %q, got: %s", want, data)
+		}
+	}
+	var payload struct {
+		EnergyComponents map[string]float64 `json:"energy_components_kwh"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	if payload.EnergyComponents["memory"] <= 0 {
+		t.Fatalf("expected memory.peak to feed memory energy, got %v", payload.EnergyComponents)
+	}
+}
+
+func TestExecCgroupMemoryRequiresCgroupSource(t *testing.T) {
+	err := execCommand([]string{"--cgroup-memory", "--", "true"})
+	if code := cgerrors.GetCode(err); err == nil || code != cgerrors.InputError {
+		t.Fatalf("expected input error, got %v", err)
+	}
+}
+
+func TestExecRAPLSourceUsesCounterDelta(t *testing.T) {
+	root := t.TempDir()
+	zone := filepath.Join(root, "intel-rapl:0")
+	if err := os.MkdirAll(zone, 0o755); err != nil {
+		t.Fatalf("MkdirAll() unexpected error: %v", err)
+	}
+	for file, content := range map[string]string{"name": "package-0", "energy_uj": "0", "max_energy_range_uj": "262143328850"} {
+		if err := os.WriteFile(filepath.Join(zone, file), []byte(content+"\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() unexpected error: %v", err)
+		}
+	}
+
+	// The child advances the fake counter by 0.01 kWh (3.6e10 uJ).
+	report := filepath.Join(t.TempDir(), "report.json")
+	err := execCommand([]string{
+		"--json", "--report-file", report, "--energy-source", "rapl", "--rapl-root", root,
+		"--", "sh", "-c", "echo 36000000000 > " + filepath.Join(zone, "energy_uj"),
+	})
+	if err != nil {
+		t.Fatalf("execCommand() unexpected error: %v", err)
+	}
+	data, readErr := os.ReadFile(report)
+	if readErr != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", readErr)
+	}
+	var payload struct {
+		EnergyComponents map[string]float64 `json:"energy_components_kwh"`
+		Measurement      map[string]any     `json:"measurement"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	if payload.Measurement["energy_source"] != "rapl" {
+		t.Fatalf("expected energy_source rapl, got %v", payload.Measurement)
+	}
+	if payload.EnergyComponents["cpu"] != 0.01 {
+		t.Fatalf("expected cpu energy 0.01 kWh from RAPL, got %v", payload.EnergyComponents)
+	}
+}
+
+func TestExecRAPLUnavailableFallsBackToModel(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "report.json")
+	err := execCommand([]string{
+		"--json", "--report-file", report, "--energy-source", "rapl", "--rapl-root", t.TempDir(),
+		"--", "sh", "-c", "exit 0",
+	})
+	if err != nil {
+		t.Fatalf("execCommand() unexpected error: %v", err)
+	}
+	data, readErr := os.ReadFile(report)
+	if readErr != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", readErr)
+	}
+	if !strings.Contains(string(data), `"energy_source": "model"`) {
+		t.Fatalf("expected model fallback, got: %s", data)
+	}
+}
+
+func TestRunGridMixUsesEmbeddedEmissionFactors(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	model := addRunModelFlags(fs, cgconfig.Shared{})
+	if err := fs.Parse([]string{"--duration", "3600", "--grid-mix", "gas=40,wind=30,nuclear=30"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	input, err := model.input()
+	if err != nil {
+		t.Fatalf("input() unexpected error: %v", err)
+	}
+	service, err := model.service(input)
+	if err != nil {
+		t.Fatalf("service() unexpected error: %v", err)
+	}
+	result, err := service.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	expected := 0.4*0.49 + 0.3*0.011 + 0.3*0.012
+	if math.Abs(result.EffectiveCIKgPerKWh-expected) > 1e-9 {
+		t.Fatalf("EffectiveCIKgPerKWh = %v, expected %v", result.EffectiveCIKgPerKWh, expected)
+	}
+}
+
+func TestRunLiveCIFromGridMixDirNeedsNoAPIKey(t *testing.T) {
+	t.Setenv("ELECTRICITY_MAPS_API_KEY", "")
+	dir := t.TempDir()
+	ts := time.Now().UTC().Add(-time.Hour).Truncate(time.Hour).Format(time.RFC3339)
+	if err := os.WriteFile(filepath.Join(dir, "DE.csv"), []byte("timestamp,coal,solar\n"+ts+",50,50\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	model := addRunModelFlags(fs, cgconfig.Shared{})
+	if err := fs.Parse([]string{"--duration", "3600", "--live-ci", "DE", "--grid-mix-dir", dir}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	input, err := model.input()
+	if err != nil {
+		t.Fatalf("input() unexpected error: %v", err)
+	}
+	service, err := model.service(input)
+	if err != nil {
+		t.Fatalf("service() unexpected error: %v", err)
+	}
+	result, err := service.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if math.Abs(result.EffectiveCIKgPerKWh-(0.82+0.048)/2) > 1e-9 {
+		t.Fatalf("EffectiveCIKgPerKWh = %v, expected coal/solar mix", result.EffectiveCIKgPerKWh)
+	}
+}
+
+func TestRunGridMixDirFromEnv(t *testing.T) {
+	t.Setenv("ELECTRICITY_MAPS_API_KEY", "")
+	dir := t.TempDir()
+	ts := time.Now().UTC().Add(-time.Hour).Truncate(time.Hour).Format(time.RFC3339)
+	if err := os.WriteFile(filepath.Join(dir, "DE.csv"), []byte("timestamp,coal,solar\n"+ts+",50,50\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	t.Setenv(cgconfig.EnvConfigPath, "")
+	t.Setenv(cgconfig.EnvGridMixDir, dir)
+
+	args := []string{"--duration", "3600", "--live-ci", "DE"}
+	defaults, err := resolveSharedDefaults(args)
+	if err != nil {
+		t.Fatalf("resolveSharedDefaults() unexpected error: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	model := addRunModelFlags(fs, defaults)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	input, err := model.input()
+	if err != nil {
+		t.Fatalf("input() unexpected error: %v", err)
+	}
+	service, err := model.service(input)
+	if err != nil {
+		t.Fatalf("service() unexpected error: %v", err)
+	}
+	result, err := service.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if math.Abs(result.EffectiveCIKgPerKWh-(0.82+0.048)/2) > 1e-9 {
+		t.Fatalf("EffectiveCIKgPerKWh = %v, expected coal/solar mix from %s", result.EffectiveCIKgPerKWh, cgconfig.EnvGridMixDir)
+	}
+}
+
+func TestRunRegionReportsCIDataset(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	model := addRunModelFlags(fs, cgconfig.Shared{})
+	budget := addBudgetFlags(fs)
+	if err := fs.Parse([]string{"--duration", "600", "--region", "fr"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	input, err := model.input()
+	if err != nil {
+		t.Fatalf("input() unexpected error: %v", err)
+	}
+	service, err := model.service(input)
+	if err != nil {
+		t.Fatalf("service() unexpected error: %v", err)
+	}
+	result, err := service.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	output := report.BuildFromEmissions(result.DurationSeconds, true, result.EmissionsKg, budget.reportOptions(result))
+	var payload struct {
+		CIDataset struct {
+			Region  string `json:"region"`
+			Zone    string `json:"zone"`
+			Version string `json:"version"`
+			Year    int    `json:"year"`
+		} `json:"ci_dataset"`
+	}
+	if err := json.Unmarshal([]byte(output), &payload); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	if payload.CIDataset.Region != "FR" || payload.CIDataset.Zone != "FR" || payload.CIDataset.Version == "" || payload.CIDataset.Year == 0 {
+		t.Fatalf("unexpected ci_dataset payload: %s", output)
+	}
+}
+
+func TestParseWindowBoundsAcceptsRFC3339AndRelative(t *testing.T) {
+	now := time.Date(2026, 1, 1, 22, 0, 0, 0, time.UTC)
+	fs := flag.NewFlagSet("suggest", flag.ContinueOnError)
+	fs.Int("lookahead", 6, "")
+	bounds, err := parseWindowBounds(fs, "2026-01-02T08:00:00+01:00", "30m", now)
+	if err != nil {
+		t.Fatalf("parseWindowBounds() unexpected error: %v", err)
+	}
+	if !bounds.FinishBy.Equal(time.Date(2026, 1, 2, 7, 0, 0, 0, time.UTC)) || !bounds.NotBefore.Equal(now.Add(30*time.Minute)) {
+		t.Fatalf("bounds = %+v", bounds)
+	}
+
+	if _, err := parseWindowBounds(fs, "1h", "2h", now); err == nil {
+		t.Fatalf("expected error when not-before is after finish-by")
+	}
+	if _, err := parseWindowBounds(fs, "tomorrow", "", now); err == nil {
+		t.Fatalf("expected error for an unparsable finish-by")
+	}
+	if err := fs.Parse([]string{"--lookahead", "12"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseWindowBounds(fs, "8h", "", now); err == nil {
+		t.Fatalf("expected error when --lookahead is combined with --finish-by")
+	}
+}
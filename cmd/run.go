@@ -12,68 +12,82 @@ import (
 )
 
 func run(args []string) error {
+	defaults, err := resolveSharedDefaults(args)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+
 	fs := flag.NewFlagSet("run", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 
-	duration := fs.Int("duration", 0, "duration in seconds")
-	runner := fs.String("runner", "ubuntu", "runner type (ubuntu/windows/macos)")
-	region := fs.String("region", "global", "region carbon intensity")
-	load := fs.Float64("load", 0.6, "CPU load factor (0-1)")
-	pue := fs.Float64("pue", 1.2, "data center PUE (>=1.0)")
-	segmentsStr := fs.String("segments", "", "dynamic CI segments (duration:ci,...)")
-	liveZone := fs.String("live-ci", "", "fetch live carbon intensity for zone")
-	budgetKg := fs.Float64("budget-kg", 0, "carbon budget in kgCO2 (optional)")
-	baselineKg := fs.Float64("baseline-kg", 0, "baseline emissions in kgCO2 for comparison (optional)")
-	failOnBudget := fs.Bool("fail-on-budget", false, "exit non-zero when emissions exceed budget")
+	model := addRunModelFlags(fs, defaults)
+	budget := addBudgetFlags(fs)
 	asJSON := fs.Bool("json", false, "output JSON")
 
 	if err := fs.Parse(args); err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
 	}
-	if *budgetKg < 0 {
-		return cgerrors.Newf(cgerrors.InputError, "budget-kg must be >= 0")
-	}
-	if *baselineKg < 0 {
-		return cgerrors.Newf(cgerrors.InputError, "baseline-kg must be >= 0")
-	}
-	if *failOnBudget && *budgetKg <= 0 {
-		return cgerrors.Newf(cgerrors.InputError, "fail-on-budget requires budget-kg > 0")
+	if err := budget.validate(); err != nil {
+		return err
 	}
 
-	var provider appsvc.Provider
-	if *liveZone != "" {
-		apiKey := os.Getenv("ELECTRICITY_MAPS_API_KEY")
-		if apiKey == "" {
-			return cgerrors.Newf(cgerrors.InputError, "missing ELECTRICITY_MAPS_API_KEY")
-		}
-		provider = newProviderAdapter(buildLiveProvider(apiKey, "", 0))
+	input, err := model.input()
+	if err != nil {
+		return err
 	}
-	service := appsvc.New(provider)
-	result, err := service.Run(context.Background(), appsvc.RunInput{
-		Duration:    *duration,
-		Region:      *region,
-		SegmentsRaw: *segmentsStr,
-		LiveZone:    *liveZone,
-		Model: appsvc.ModelContext{
-			Runner: *runner,
-			Load:   *load,
-			PUE:    *pue,
-		},
-	})
+	service, err := model.service(input)
+	if err != nil {
+		return err
+	}
+	result, err := service.Run(context.Background(), input)
 	if err != nil {
 		return mapAppError(err)
 	}
 
-	output := report.BuildFromEmissions(result.DurationSeconds, *asJSON, result.EmissionsKg, report.BuildOptions{
-		BudgetKg:            *budgetKg,
-		BaselineKg:          *baselineKg,
-		EnergyTotalKWh:      result.EnergyTotalKWh,
-		EffectiveCIKgPerKWh: result.EffectiveCIKgPerKWh,
-	})
+	output := report.BuildFromEmissions(result.DurationSeconds, *asJSON, result.EmissionsKg, budget.reportOptions(result))
 	fmt.Print(output)
 
-	if *failOnBudget && *budgetKg > 0 && result.EmissionsKg > *budgetKg {
-		return cgerrors.Newf(cgerrors.BudgetExceeded, "carbon budget exceeded: emissions %.4f kgCO2 > budget %.4f kgCO2", result.EmissionsKg, *budgetKg)
+	return budget.gate(result)
+}
+
+func runEnergyComponents(result appsvc.RunResult) []report.EnergyComponent {
+	return []report.EnergyComponent{
+		{Name: "cpu", KWh: result.EnergyCPUKWh},
+		{Name: "memory", KWh: result.EnergyMemoryKWh},
+		{Name: "storage", KWh: result.EnergyStorageKWh},
+		{Name: "network", KWh: result.EnergyNetworkKWh},
+	}
+}
+
+// budgetGateEmissions picks the emissions value compared to the budget.
+// budgetGateEmissions 选择与预算比较的排放值。
+//
+// Without uncertainty every percentile collapses to the point estimate.
+// 未启用不确定性时，所有分位数都退化为点估计。
+func budgetGateEmissions(result appsvc.RunResult, basis string) float64 {
+	if result.Uncertainty == nil {
+		return result.EmissionsKg
+	}
+	switch basis {
+	case "p50":
+		return result.Uncertainty.P50
+	case "p95":
+		return result.Uncertainty.P95
+	default:
+		return result.EmissionsKg
+	}
+}
+
+func runUncertainty(result appsvc.RunResult, basis string) *report.EmissionUncertainty {
+	if result.Uncertainty == nil {
+		return nil
+	}
+	return &report.EmissionUncertainty{
+		P5Kg:        result.Uncertainty.P5,
+		P50Kg:       result.Uncertainty.P50,
+		P95Kg:       result.Uncertainty.P95,
+		Samples:     result.UncertaintySamples,
+		Seed:        result.UncertaintySeed,
+		BudgetBasis: basis,
 	}
-	return nil
 }
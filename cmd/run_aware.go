@@ -21,7 +21,7 @@ func runAware(args []string) error {
 	fs.SetOutput(os.Stderr)
 
 	addConfigFlag(fs, defaults.ConfigPath)
-	zone := fs.String("zone", "", "electricity maps zone")
+	zone := fs.String("zone", "", "electricity maps zone or cloud region (aws:eu-west-1)")
 	zoneMode := fs.String("zone-mode", defaults.ZoneMode, "zone resolution mode: strict|fallback|auto")
 	duration := fs.Int("duration", 0, "duration in seconds")
 	threshold := fs.Float64("threshold", 0.35, "legacy CI threshold in kgCO2/kWh (used when threshold-enter/exit are unset)")
@@ -32,6 +32,7 @@ func runAware(args []string) error {
 	maxDelayForGainRaw := fs.String("max-delay-for-gain", "0s", "no-regret guard: maximum acceptable delay before waiting is skipped")
 	minReductionForWait := fs.Float64("min-reduction-for-wait", 0, "no-regret guard: minimum expected reduction percentage required to justify waiting")
 	cacheDirRaw, cacheTTLRaw := addCacheFlags(fs, defaults.CacheDir, defaults.CacheTTL)
+	gridMixDir, emissionFactors := addGridMixFlags(fs, defaults.GridMixDir, defaults.EmissionFactors)
 
 	if err := fs.Parse(args); err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
@@ -71,11 +72,11 @@ func runAware(args []string) error {
 	if effectiveEnter > effectiveExit {
 		return cgerrors.Newf(cgerrors.InputError, "threshold-enter must be <= threshold-exit")
 	}
-	resolvedZone, err := resolveZone(*zone, *zoneMode, defaults.Zone, autoHints{
-		ZoneHint:     defaults.ZoneHint,
-		CountryHint:  defaults.CountryHint,
-		TimezoneHint: defaults.TimezoneHint,
-	})
+	hints, err := zoneHints(defaults)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	resolvedZone, err := resolveZone(*zone, *zoneMode, defaults.Zone, hints)
 	if err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
 	}
@@ -85,12 +86,12 @@ func runAware(args []string) error {
 		return cgerrors.New(err, cgerrors.InputError)
 	}
 
-	apiKey := os.Getenv("ELECTRICITY_MAPS_API_KEY")
-	if apiKey == "" {
-		return cgerrors.Newf(cgerrors.InputError, "missing ELECTRICITY_MAPS_API_KEY")
+	provider, err := buildProvider(*gridMixDir, *emissionFactors, cacheDir, cacheTTL)
+	if err != nil {
+		return err
 	}
 
-	service := appsvc.New(newProviderAdapter(buildLiveProvider(apiKey, cacheDir, cacheTTL)))
+	service := appsvc.New(newProviderAdapter(provider))
 	out, err := service.RunAware(context.Background(), appsvc.RunAwareInput{
 		Zone:                    resolvedZone.Zone,
 		Duration:                *duration,
@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+	cgconfig "github.com/chenzhuyu2004/carbon-guard/internal/config"
+	cgerrors "github.com/chenzhuyu2004/carbon-guard/internal/errors"
+	"github.com/chenzhuyu2004/carbon-guard/internal/report"
+)
+
+// runModelFlags groups the emission-model flags shared by run-family commands.
+// runModelFlags 汇总 run 类命令共享的排放模型参数。
+type runModelFlags struct {
+	duration            *int
+	runner              *string
+	instanceType        *string
+	instanceCatalogPath *string
+	region              *string
+	load                *rangeValue
+	pue                 *rangeValue
+	embodiedKg          *float64
+	lifetimeYears       *float64
+	machineShare        *float64
+	memoryGB            *float64
+	storageGB           *float64
+	networkGB           *float64
+	memoryCoeff         *float64
+	storageCoeff        *float64
+	networkCoeff        *float64
+	segments            *string
+	segmentsFile        *string
+	historyZone         *string
+	liveZone            *string
+	powerFile           *string
+	powerCISource       *string
+	powerZone           *string
+	gridMix             *string
+	gridMixDir          *string
+	emissionFactors     *string
+	instrumentsFile     *string
+	electricityPrice    *string
+	priceFile           *string
+	priceZone           *string
+	currency            *string
+	wue                 *float64
+	ciUncertainty       *float64
+	powerUncertainty    *float64
+	samples             *int
+	seed                *int64
+}
+
+// rangeValue is a flag accepting either a point value ("0.6") or a range ("0.4..0.8").
+// rangeValue 为既接受单值（"0.6"）也接受区间（"0.4..0.8"）的参数类型。
+type rangeValue struct {
+	r calculator.Range
+}
+
+func newRangeValue(fs *flag.FlagSet, name string, value float64, usage string) *rangeValue {
+	v := &rangeValue{r: calculator.PointRange(value)}
+	fs.Var(v, name, usage)
+	return v
+}
+
+func (v *rangeValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.r.String()
+}
+
+func (v *rangeValue) Set(raw string) error {
+	parsed, err := calculator.ParseRange(raw)
+	if err != nil {
+		return err
+	}
+	v.r = parsed
+	return nil
+}
+
+// addRunModelFlags registers the run-family flags; --config, --grid-mix-dir, and --emission-factors
+// default to the resolved config file and env values.
+// addRunModelFlags 注册 run 类命令参数；--config、--grid-mix-dir 与 --emission-factors 默认取自
+// 解析后的配置文件与环境变量。
+func addRunModelFlags(fs *flag.FlagSet, defaults cgconfig.Shared) *runModelFlags {
+	addConfigFlag(fs, defaults.ConfigPath)
+	gridMixDir, emissionFactors := addGridMixFlags(fs, defaults.GridMixDir, defaults.EmissionFactors)
+	load := newRangeValue(fs, "load", 0.6, "CPU load factor (0-1), or a range min..max")
+	pue := newRangeValue(fs, "pue", 1.2, "data center PUE (>=1.0), or a range min..max")
+	return &runModelFlags{
+		duration:            fs.Int("duration", 0, "duration in seconds"),
+		runner:              fs.String("runner", "ubuntu", "runner type (ubuntu/windows/macos)"),
+		instanceType:        fs.String("instance-type", "", "cloud instance type for power profile (e.g. aws:c6i.4xlarge)"),
+		instanceCatalogPath: fs.String("instance-catalog", "", "path to JSON instance catalog overriding embedded entries"),
+		region:              fs.String("region", "global", "static CI region: global|china|us|eu, zone code (DE), or cloud region (aws:eu-west-1)"),
+		load:                load,
+		pue:                 pue,
+		embodiedKg:          fs.Float64("embodied-kg", -1, "hardware embodied emissions in kgCO2e (<0 = none; embodied emissions are opt-in)"),
+		lifetimeYears:       fs.Float64("lifetime-years", -1, "expected hardware lifetime in years (<0 uses the instance catalog entry, else 4)"),
+		machineShare:        fs.Float64("machine-share", -1, "share of the machine used by the job, (0,1] (<0 uses the instance catalog entry, else 1)"),
+		memoryGB:            fs.Float64("memory-gb", 0, "average resident memory in GB (optional)"),
+		storageGB:           fs.Float64("storage-gb", 0, "storage written in GB (optional)"),
+		networkGB:           fs.Float64("network-gb", 0, "network transfer in GB (optional)"),
+		memoryCoeff:         fs.Float64("memory-kwh-per-gb-hour", calculator.DefaultMemoryKWhPerGBHour, "memory energy coefficient in kWh per GB-hour"),
+		storageCoeff:        fs.Float64("storage-kwh-per-gb", calculator.DefaultStorageKWhPerGB, "storage energy coefficient in kWh per GB written"),
+		networkCoeff:        fs.Float64("network-kwh-per-gb", calculator.DefaultNetworkKWhPerGB, "network energy coefficient in kWh per GB transferred"),
+		segments:            fs.String("segments", "", "dynamic CI segments (duration:ci[:load[:runner]],...)"),
+		segmentsFile:        fs.String("segments-file", "", "JSON or CSV segments file with optional timestamps"),
+		historyZone:         fs.String("history-zone", "", "zone used to fill missing segment CI from provider history"),
+		liveZone:            fs.String("live-ci", "", "fetch live carbon intensity for zone"),
+		powerFile:           fs.String("power-file", "", "timestamp,watts CSV of measured IT power"),
+		powerCISource:       fs.String("power-ci-source", "", "CI series for --power-file (segments|history|forecast)"),
+		powerZone:           fs.String("power-zone", "", "zone used for history/forecast CI with --power-file"),
+		gridMix:             fs.String("grid-mix", "", "generation shares for a CI estimate instead of --region (gas=40,wind=30,nuclear=30)"),
+		gridMixDir:          gridMixDir,
+		emissionFactors:     emissionFactors,
+		instrumentsFile:     fs.String("instruments-file", "", "contractual instruments JSON for market-based scope 2"),
+		electricityPrice:    fs.String("electricity-price", "", "static price per kWh, or per zone/region (DE=0.31,FR=0.19,*=0.25)"),
+		priceFile:           fs.String("price-file", "", "timestamp,price CSV of electricity prices per kWh"),
+		priceZone:           fs.String("price-zone", "", "fetch electricity prices for zone from the provider"),
+		currency:            fs.String("currency", "USD", "currency of --electricity-price and --price-file"),
+		wue:                 fs.Float64("wue", 0, "water usage effectiveness in liters per kWh of IT energy (optional)"),
+		ciUncertainty:       fs.Float64("ci-uncertainty", 0, "relative carbon intensity error, e.g. 0.1 for +/-10%"),
+		powerUncertainty:    fs.Float64("power-uncertainty", 0, "relative runner power error, e.g. 0.15 for +/-15%"),
+		samples:             fs.Int("samples", 1000, "Monte Carlo samples when any input is uncertain"),
+		seed:                fs.Int64("seed", 42, "Monte Carlo random seed (deterministic output)"),
+	}
+}
+
+// service builds the app service for in, wiring a provider only when a zone flag needs one.
+// --history-zone only needs it when a segment's CI is filled from history. --grid-mix-dir
+// selects the offline grid-mix provider instead of Electricity Maps.
+// service 为 in 构建 app 服务；仅在区域参数需要时接入 provider。--history-zone 仅在有分段需要
+// 历史 CI 补齐时才需要 provider。--grid-mix-dir 选用离线发电结构 provider 替代 Electricity Maps。
+func (f *runModelFlags) service(in appsvc.RunInput) (*appsvc.App, error) {
+	needsHistory := *f.historyZone != "" && slices.ContainsFunc(in.Segments, appsvc.SegmentSpec.NeedsHistoryCI)
+	var provider appsvc.Provider
+	if *f.liveZone != "" || needsHistory || *f.powerZone != "" || *f.priceZone != "" {
+		live, err := buildProvider(*f.gridMixDir, *f.emissionFactors, "", 0)
+		if err != nil {
+			return nil, err
+		}
+		provider = newProviderAdapter(live)
+	}
+	return appsvc.New(provider), nil
+}
+
+// input converts parsed flags into a run use-case input.
+// input 将解析后的参数转换为 run 用例输入。
+func (f *runModelFlags) input() (appsvc.RunInput, error) {
+	power, err := resolveInstancePower(*f.instanceType, *f.instanceCatalogPath)
+	if err != nil {
+		return appsvc.RunInput{}, cgerrors.New(err, cgerrors.InputError)
+	}
+	power = applyEmbodiedOverrides(power, *f.runner, *f.embodiedKg, *f.lifetimeYears, *f.machineShare)
+
+	var segmentSpecs []appsvc.SegmentSpec
+	if path := strings.TrimSpace(*f.segmentsFile); path != "" {
+		if *f.segments != "" {
+			return appsvc.RunInput{}, cgerrors.Newf(cgerrors.InputError, "segments and segments-file are mutually exclusive")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return appsvc.RunInput{}, cgerrors.New(fmt.Errorf("read segments file: %w", err), cgerrors.InputError)
+		}
+		segmentSpecs, err = appsvc.ParseSegmentsFile(path, data)
+		if err != nil {
+			return appsvc.RunInput{}, mapAppError(err)
+		}
+	}
+
+	var powerSamples []appsvc.PowerSample
+	if path := strings.TrimSpace(*f.powerFile); path != "" {
+		if *f.segments != "" || *f.liveZone != "" {
+			return appsvc.RunInput{}, cgerrors.Newf(cgerrors.InputError, "power-file cannot be combined with segments or live-ci")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return appsvc.RunInput{}, cgerrors.New(fmt.Errorf("read power file: %w", err), cgerrors.InputError)
+		}
+		powerSamples, err = appsvc.ParsePowerFile(data)
+		if err != nil {
+			return appsvc.RunInput{}, mapAppError(err)
+		}
+	} else if *f.powerCISource != "" || *f.powerZone != "" {
+		return appsvc.RunInput{}, cgerrors.Newf(cgerrors.InputError, "power-ci-source and power-zone require power-file")
+	}
+
+	var instruments []calculator.ContractualInstrument
+	if path := strings.TrimSpace(*f.instrumentsFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return appsvc.RunInput{}, cgerrors.New(fmt.Errorf("read instruments file: %w", err), cgerrors.InputError)
+		}
+		instruments, err = appsvc.ParseInstrumentsFile(data)
+		if err != nil {
+			return appsvc.RunInput{}, mapAppError(err)
+		}
+	}
+
+	gridMix, err := f.gridMixInput()
+	if err != nil {
+		return appsvc.RunInput{}, err
+	}
+
+	price, err := f.price()
+	if err != nil {
+		return appsvc.RunInput{}, err
+	}
+
+	return appsvc.RunInput{
+		Duration:    *f.duration,
+		Region:      *f.region,
+		SegmentsRaw: *f.segments,
+		Segments:    segmentSpecs,
+		HistoryZone: *f.historyZone,
+		LiveZone:    *f.liveZone,
+		GridMix:     gridMix,
+		Instruments: instruments,
+		Price:       price,
+		WUE:         *f.wue,
+		Power: appsvc.PowerInput{
+			Samples:  powerSamples,
+			CISource: *f.powerCISource,
+			Zone:     *f.powerZone,
+		},
+		Model: appsvc.ModelContext{
+			Runner: *f.runner,
+			Load:   f.load.r.Mid(),
+			PUE:    f.pue.r.Mid(),
+			Power:  power,
+			Coefficients: &calculator.ResourceCoefficients{
+				MemoryKWhPerGBHour: *f.memoryCoeff,
+				StorageKWhPerGB:    *f.storageCoeff,
+				NetworkKWhPerGB:    *f.networkCoeff,
+			},
+		},
+		Resources: calculator.ResourceUsage{
+			MemoryGB:         *f.memoryGB,
+			StorageWrittenGB: *f.storageGB,
+			NetworkGB:        *f.networkGB,
+		},
+		Uncertainty: appsvc.UncertaintyInput{
+			Load:          f.load.r,
+			PUE:           f.pue.r,
+			CIRelative:    *f.ciUncertainty,
+			PowerRelative: *f.powerUncertainty,
+			Samples:       *f.samples,
+			Seed:          *f.seed,
+		},
+	}, nil
+}
+
+// gridMixInput parses --grid-mix against the embedded or overridden emission factor table.
+// gridMixInput 基于内嵌或覆盖后的排放因子表解析 --grid-mix。
+func (f *runModelFlags) gridMixInput() (appsvc.GridMixInput, error) {
+	raw := strings.TrimSpace(*f.gridMix)
+	if raw == "" {
+		return appsvc.GridMixInput{}, nil
+	}
+	shares, err := calculator.ParseGridMix(raw)
+	if err != nil {
+		return appsvc.GridMixInput{}, cgerrors.New(err, cgerrors.InputError)
+	}
+	factors, err := loadEmissionFactors(*f.emissionFactors)
+	if err != nil {
+		return appsvc.GridMixInput{}, err
+	}
+	return appsvc.GridMixInput{Shares: shares, Factors: factors}, nil
+}
+
+// price converts the price flags into a price input; at most one source may be set.
+// price 将电价参数转换为电价输入；最多只能设置一种来源。
+func (f *runModelFlags) price() (appsvc.PriceInput, error) {
+	price := appsvc.PriceInput{Zone: strings.TrimSpace(*f.priceZone), Currency: strings.TrimSpace(*f.currency)}
+	if raw := strings.TrimSpace(*f.electricityPrice); raw != "" {
+		static, err := parseStaticPrices(raw)
+		if err != nil {
+			return appsvc.PriceInput{}, cgerrors.New(err, cgerrors.InputError)
+		}
+		price.Static = static
+	}
+	if path := strings.TrimSpace(*f.priceFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return appsvc.PriceInput{}, cgerrors.New(fmt.Errorf("read price file: %w", err), cgerrors.InputError)
+		}
+		price.Series, err = appsvc.ParsePriceFile(data)
+		if err != nil {
+			return appsvc.PriceInput{}, mapAppError(err)
+		}
+	}
+	return price, nil
+}
+
+// parseStaticPrices parses "0.25" or "DE=0.31,FR=0.19,*=0.25"; a bare number applies to any zone.
+// parseStaticPrices 解析 "0.25" 或 "DE=0.31,FR=0.19,*=0.25"；单个数值适用于所有区域。
+func parseStaticPrices(raw string) (map[string]float64, error) {
+	if value, err := strconv.ParseFloat(raw, 64); err == nil {
+		return map[string]float64{"*": value}, nil
+	}
+	prices := make(map[string]float64)
+	for _, item := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid electricity-price entry %q: expected zone=price", item)
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid electricity-price for %s: %q", key, value)
+		}
+		prices[key] = parsed
+	}
+	return prices, nil
+}
+
+// budgetFlags groups budget/baseline gating flags shared by run and exec.
+// budgetFlags 汇总 run 与 exec 共享的预算/基线判定参数。
+type budgetFlags struct {
+	budgetKg         *float64
+	baselineKg       *float64
+	failOnBudget     *bool
+	budgetPercentile *string
+}
+
+func addBudgetFlags(fs *flag.FlagSet) *budgetFlags {
+	return &budgetFlags{
+		budgetKg:         fs.Float64("budget-kg", 0, "carbon budget in kgCO2 (optional)"),
+		baselineKg:       fs.Float64("baseline-kg", 0, "baseline emissions in kgCO2 for comparison (optional)"),
+		failOnBudget:     fs.Bool("fail-on-budget", false, "exit non-zero when emissions exceed budget"),
+		budgetPercentile: fs.String("budget-percentile", "point", "emissions value compared to the budget (point|p50|p95)"),
+	}
+}
+
+func (b *budgetFlags) validate() error {
+	if *b.budgetKg < 0 {
+		return cgerrors.Newf(cgerrors.InputError, "budget-kg must be >= 0")
+	}
+	if *b.baselineKg < 0 {
+		return cgerrors.Newf(cgerrors.InputError, "baseline-kg must be >= 0")
+	}
+	if *b.failOnBudget && *b.budgetKg <= 0 {
+		return cgerrors.Newf(cgerrors.InputError, "fail-on-budget requires budget-kg > 0")
+	}
+	switch *b.budgetPercentile {
+	case "point", "p50", "p95":
+		return nil
+	default:
+		return cgerrors.Newf(cgerrors.InputError, "budget-percentile must be one of: point, p50, p95")
+	}
+}
+
+// gate returns a BudgetExceeded error when the selected emissions value exceeds the budget.
+// gate 在所选排放值超出预算时返回 BudgetExceeded 错误。
+func (b *budgetFlags) gate(result appsvc.RunResult) error {
+	gated := budgetGateEmissions(result, *b.budgetPercentile)
+	if *b.failOnBudget && *b.budgetKg > 0 && gated > *b.budgetKg {
+		return cgerrors.Newf(cgerrors.BudgetExceeded, "carbon budget exceeded: emissions (%s) %.4f kgCO2 > budget %.4f kgCO2", *b.budgetPercentile, gated, *b.budgetKg)
+	}
+	return nil
+}
+
+// reportOptions maps a run result and budget flags onto report options.
+// reportOptions 将运行结果与预算参数映射为报告选项。
+func (b *budgetFlags) reportOptions(result appsvc.RunResult) report.BuildOptions {
+	return report.BuildOptions{
+		BudgetKg:            *b.budgetKg,
+		BaselineKg:          *b.baselineKg,
+		EnergyTotalKWh:      result.EnergyTotalKWh,
+		EffectiveCIKgPerKWh: result.EffectiveCIKgPerKWh,
+		EnergyITKWh:         result.EnergyITKWh,
+		EnergyComponents:    runEnergyComponents(result),
+		EmbodiedKg:          result.EmbodiedEmissionsKg,
+		Uncertainty:         runUncertainty(result, *b.budgetPercentile),
+		EnergySource:        measuredEnergySource(result),
+		CIDataset:           runCIDataset(result),
+		Scope2:              runScope2(result),
+		Cost:                runCost(result),
+		WaterLiters:         result.WaterLiters,
+	}
+}
+
+func runCIDataset(result appsvc.RunResult) *report.CIDataset {
+	if result.RegionCI == nil {
+		return nil
+	}
+	return &report.CIDataset{
+		Region:  result.RegionCI.Region,
+		Zone:    result.RegionCI.Zone,
+		Version: result.RegionCI.DatasetVersion,
+		Year:    result.RegionCI.DatasetYear,
+	}
+}
+
+func runCost(result appsvc.RunResult) *report.Cost {
+	if result.Cost == nil {
+		return nil
+	}
+	return &report.Cost{
+		Amount:   result.Cost.Amount,
+		Currency: result.Cost.Currency,
+		Source:   result.Cost.Source,
+	}
+}
+
+func runScope2(result appsvc.RunResult) *report.Scope2 {
+	if result.MarketBased == nil {
+		return nil
+	}
+	return &report.Scope2{
+		LocationBasedKg: result.EmissionsKg,
+		MarketBasedKg:   result.MarketBased.EmissionsKg,
+		CoveragePct:     result.MarketBased.CoveragePct,
+		Instruments:     result.MarketBased.Instruments,
+	}
+}
+
+// measuredEnergySource returns the energy source for reports, or "" for modelled energy.
+// measuredEnergySource 返回报告中的能耗来源；建模能耗返回 ""。
+func measuredEnergySource(result appsvc.RunResult) string {
+	if result.EnergySource == "model" {
+		return ""
+	}
+	return result.EnergySource
+}
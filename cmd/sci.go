@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
+	cgerrors "github.com/chenzhuyu2004/carbon-guard/internal/errors"
+	"github.com/chenzhuyu2004/carbon-guard/pkg"
+)
+
+const sciMethodology = "gsf-sci-1.0"
+
+// SCIOutput is the stable JSON contract for the sci command.
+// SCIOutput 为 sci 命令的稳定 JSON 契约。
+type SCIOutput struct {
+	SchemaVersion   string  `json:"schema_version"`
+	Methodology     string  `json:"methodology"`
+	DurationSeconds int     `json:"duration_seconds"`
+	EnergyKWh       float64 `json:"energy_kwh"`
+	CarbonIntensity float64 `json:"carbon_intensity_kg_per_kwh"`
+	OperationalKg   float64 `json:"operational_emissions_kg"`
+	EmbodiedKg      float64 `json:"embodied_emissions_kg"`
+	TotalKg         float64 `json:"total_emissions_kg"`
+	FunctionalUnit  string  `json:"functional_unit"`
+	FunctionalUnits float64 `json:"functional_units"`
+	SCIKgPerUnit    float64 `json:"sci_kg_per_unit"`
+	SCIGPerUnit     float64 `json:"sci_g_per_unit"`
+	// MarketBasedKg is informational only: SCI excludes market-based instruments, so the score
+	// always uses location-based operational emissions.
+	// MarketBasedKg 仅供参考：SCI 不计入市场法合同工具，评分始终使用位置法运行期排放。
+	MarketBasedKg *float64 `json:"market_based_emissions_kg,omitempty"`
+	// CIDataset identifies the offline dataset entry behind a static region I.
+	// CIDataset 标识静态 region 的 I 所用离线数据集条目。
+	CIDataset *SCICIDataset `json:"ci_dataset,omitempty"`
+}
+
+// SCICIDataset is the yearly average CI entry used for I.
+// SCICIDataset 为 I 所用的年均 CI 数据条目。
+type SCICIDataset struct {
+	Region  string `json:"region"`
+	Zone    string `json:"zone,omitempty"`
+	Version string `json:"version"`
+	Year    int    `json:"year"`
+}
+
+func sci(args []string) error {
+	defaults, err := resolveSharedDefaults(args)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+
+	fs := flag.NewFlagSet("sci", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	model := addRunModelFlags(fs, defaults)
+	functionalUnits := fs.Float64("functional-units", 0, "functional unit count R (e.g. tests executed), > 0")
+	functionalUnit := fs.String("functional-unit", "unit", "functional unit name (e.g. test, artifact, request)")
+	asJSON := fs.Bool("json", false, "output JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	if *functionalUnits <= 0 {
+		return cgerrors.Newf(cgerrors.InputError, "functional-units must be > 0")
+	}
+
+	input, err := model.input()
+	if err != nil {
+		return err
+	}
+	service, err := model.service(input)
+	if err != nil {
+		return err
+	}
+	result, err := service.SCI(context.Background(), appsvc.SCIInput{
+		Run:             input,
+		FunctionalUnits: *functionalUnits,
+		FunctionalUnit:  *functionalUnit,
+	})
+	if err != nil {
+		return mapAppError(err)
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(buildSCIOutput(result), "", "  ")
+		if err != nil {
+			return cgerrors.Newf(cgerrors.ProviderError, "failed to serialize sci result")
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println("Software Carbon Intensity (SCI = ((E * I) + M) / R)")
+	fmt.Println("-----------------------------------------------")
+	fmt.Printf("E  Energy: %.6f kWh\n", result.EnergyKWh)
+	fmt.Printf("I  Carbon Intensity: %.4f kgCO2/kWh\n", result.CarbonIntensity)
+	if region := result.Run.RegionCI; region != nil {
+		fmt.Printf("   Dataset: %s %s (%d annual average)\n", region.DatasetVersion, region.Region, region.DatasetYear)
+	}
+	fmt.Printf("M  Embodied Emissions: %.6f kgCO2e\n", result.EmbodiedKg)
+	fmt.Printf("R  Functional Units: %g %s\n", result.FunctionalUnits, result.FunctionalUnit)
+	fmt.Printf("Total Emissions (E*I + M): %.6f kgCO2e\n", result.TotalKg)
+	fmt.Printf("SCI: %.4f gCO2e per %s\n", result.SCIKgPerUnit*1000, result.FunctionalUnit)
+	if market := result.Run.MarketBased; market != nil {
+		fmt.Printf("Market-based Scope 2 (not part of SCI): %.6f kgCO2e\n", market.EmissionsKg)
+	}
+	return nil
+}
+
+func buildSCIOutput(result appsvc.SCIResult) SCIOutput {
+	var marketBased *float64
+	if market := result.Run.MarketBased; market != nil {
+		marketBased = &market.EmissionsKg
+	}
+	var dataset *SCICIDataset
+	if region := result.Run.RegionCI; region != nil {
+		dataset = &SCICIDataset{
+			Region:  region.Region,
+			Zone:    region.Zone,
+			Version: region.DatasetVersion,
+			Year:    region.DatasetYear,
+		}
+	}
+	return SCIOutput{
+		SchemaVersion:   pkg.JSONSchemaVersion,
+		Methodology:     sciMethodology,
+		DurationSeconds: result.Run.DurationSeconds,
+		EnergyKWh:       result.EnergyKWh,
+		CarbonIntensity: result.CarbonIntensity,
+		OperationalKg:   result.OperationalKg,
+		EmbodiedKg:      result.EmbodiedKg,
+		TotalKg:         result.TotalKg,
+		FunctionalUnit:  result.FunctionalUnit,
+		FunctionalUnits: result.FunctionalUnits,
+		SCIKgPerUnit:    result.SCIKgPerUnit,
+		SCIGPerUnit:     result.SCIKgPerUnit * 1000,
+		MarketBasedKg:   marketBased,
+		CIDataset:       dataset,
+	}
+}
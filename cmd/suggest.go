@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
 	cgerrors "github.com/chenzhuyu2004/carbon-guard/internal/errors"
@@ -20,13 +21,18 @@ func suggest(args []string) error {
 	fs.SetOutput(os.Stderr)
 
 	addConfigFlag(fs, defaults.ConfigPath)
-	zone := fs.String("zone", "", "electricity maps zone")
+	zone := fs.String("zone", "", "electricity maps zone or cloud region (aws:eu-west-1)")
 	zoneMode := fs.String("zone-mode", defaults.ZoneMode, "zone resolution mode: strict|fallback|auto")
 	duration := fs.Int("duration", 0, "duration in seconds")
 	threshold := fs.Float64("threshold", 0.35, "current CI threshold in kgCO2/kWh")
 	lookahead := fs.Int("lookahead", 6, "forecast lookahead in hours")
 	waitCost := fs.Float64("wait-cost", 0, "waiting penalty in kgCO2 per hour")
+	windowSearchRaw, startGranularityRaw := addWindowSearchFlags(fs)
+	split := addSplitFlags(fs)
+	riskRaw := addRiskFlags(fs)
+	finishByRaw, notBeforeRaw := addDeadlineFlags(fs)
 	cacheDirRaw, cacheTTLRaw := addCacheFlags(fs, defaults.CacheDir, defaults.CacheTTL)
+	gridMixDir, emissionFactors := addGridMixFlags(fs, defaults.GridMixDir, defaults.EmissionFactors)
 
 	if err := fs.Parse(args); err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
@@ -44,11 +50,33 @@ func suggest(args []string) error {
 	if *waitCost < 0 {
 		return cgerrors.Newf(cgerrors.InputError, "wait-cost must be >= 0")
 	}
-	resolvedZone, err := resolveZone(*zone, *zoneMode, defaults.Zone, autoHints{
-		ZoneHint:     defaults.ZoneHint,
-		CountryHint:  defaults.CountryHint,
-		TimezoneHint: defaults.TimezoneHint,
-	})
+	windowSearch, startGranularity, err := parseWindowSearch(*windowSearchRaw, *startGranularityRaw)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	bounds, err := parseWindowBounds(fs, *finishByRaw, *notBeforeRaw, time.Now().UTC())
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	risk, err := parseRisk(riskRaw)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	var splitInput appsvc.SuggestSplitInput
+	if *split.enabled {
+		if *waitCost > 0 || risk.Aversion > 0 || windowSearch != appsvc.WindowSearchContinuous || startGranularity > 0 {
+			return cgerrors.Newf(cgerrors.InputError, "--split cannot be combined with --wait-cost, --risk-aversion, --window-search forecast-starts, or --start-granularity")
+		}
+		splitInput, err = parseSplit(split)
+		if err != nil {
+			return cgerrors.New(err, cgerrors.InputError)
+		}
+	}
+	hints, err := zoneHints(defaults)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	resolvedZone, err := resolveZone(*zone, *zoneMode, defaults.Zone, hints)
 	if err != nil {
 		return cgerrors.New(err, cgerrors.InputError)
 	}
@@ -57,26 +85,43 @@ func suggest(args []string) error {
 		return cgerrors.New(err, cgerrors.InputError)
 	}
 
-	apiKey := os.Getenv("ELECTRICITY_MAPS_API_KEY")
-	if apiKey == "" {
-		return cgerrors.Newf(cgerrors.InputError, "missing ELECTRICITY_MAPS_API_KEY")
+	provider, err := buildProvider(*gridMixDir, *emissionFactors, cacheDir, cacheTTL)
+	if err != nil {
+		return err
 	}
 
-	service := appsvc.New(newProviderAdapter(buildLiveProvider(apiKey, cacheDir, cacheTTL)))
+	service := appsvc.New(newProviderAdapter(provider))
+	if *split.enabled {
+		splitInput.Zone = resolvedZone.Zone
+		splitInput.Duration = *duration
+		splitInput.Lookahead = *lookahead
+		splitInput.Model = defaultModelContext()
+		splitInput.Bounds = bounds
+		plan, err := service.SuggestSplit(context.Background(), splitInput)
+		if err != nil {
+			return mapAppError(err)
+		}
+		printSplitPlan(resolvedZone, plan)
+		return nil
+	}
 	out, err := service.Suggest(context.Background(), appsvc.SuggestInput{
-		Zone:      resolvedZone.Zone,
-		Duration:  *duration,
-		Threshold: *threshold,
-		Lookahead: *lookahead,
-		WaitCost:  *waitCost,
-		Model:     defaultModelContext(),
+		Zone:             resolvedZone.Zone,
+		Duration:         *duration,
+		Threshold:        *threshold,
+		Lookahead:        *lookahead,
+		WaitCost:         *waitCost,
+		Model:            defaultModelContext(),
+		WindowSearch:     windowSearch,
+		StartGranularity: startGranularity,
+		Bounds:           bounds,
+		Risk:             risk,
 	})
 	if err != nil {
 		return mapAppError(err)
 	}
 
 	fmt.Printf(
-		"Resolved Zone: %s (source: %s, confidence: %s, reason: %s, fallback_used: %t)\nCurrent CI: %.4f kg/kWh\nBest execution window (UTC): %s - %s\nExpected emission: %.4f kg\nEmission reduction vs now: %.2f %%\n",
+		"Resolved Zone: %s (source: %s, confidence: %s, reason: %s, fallback_used: %t)\nCurrent CI: %.4f kg/kWh\nBest execution window (UTC): %s - %s\nExpected emission: %.4f kg\nForecast risk (1-sigma): %.4f kg\nScore: %.4f\nEmission reduction vs now: %.2f %%\n",
 		resolvedZone.Zone,
 		resolvedZone.Source,
 		resolvedZone.Confidence,
@@ -86,7 +131,41 @@ func suggest(args []string) error {
 		out.BestWindowStartUTC.UTC().Format("15:04"),
 		out.BestWindowEndUTC.UTC().Format("15:04"),
 		out.ExpectedEmissionKg,
+		out.RiskKg,
+		out.Score,
 		out.EmissionReductionVsNow,
 	)
 	return nil
 }
+
+func printSplitPlan(zone resolvedZone, plan appsvc.SuggestSplitOutput) {
+	fmt.Printf(
+		"Resolved Zone: %s (source: %s, confidence: %s, reason: %s, fallback_used: %t)\nSplit plan (UTC, %d chunks):\n",
+		zone.Zone,
+		zone.Source,
+		zone.Confidence,
+		zone.Reason,
+		zone.FallbackUsed,
+		len(plan.Chunks),
+	)
+	for i, chunk := range plan.Chunks {
+		fmt.Printf(
+			"  %d. %s - %s work %ds overhead %ds emission %.4f kg\n",
+			i+1,
+			chunk.StartUTC.UTC().Format("2006-01-02 15:04"),
+			chunk.EndUTC.UTC().Format("15:04"),
+			chunk.WorkSeconds,
+			chunk.OverheadSeconds,
+			chunk.EmissionKg,
+		)
+	}
+	fmt.Printf(
+		"Expected emission: %.4f kg\nBest contiguous window (UTC): %s - %s (%.4f kg)\nSaving vs contiguous: %.4f kg (%.2f %%)\n",
+		plan.EmissionKg,
+		plan.ContiguousStartUTC.UTC().Format("15:04"),
+		plan.ContiguousEndUTC.UTC().Format("15:04"),
+		plan.ContiguousEmissionKg,
+		plan.SavingKg,
+		plan.SavingPct,
+	)
+}
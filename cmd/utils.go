@@ -10,8 +10,11 @@ import (
 	"time"
 
 	appsvc "github.com/chenzhuyu2004/carbon-guard/internal/app"
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+	"github.com/chenzhuyu2004/carbon-guard/internal/catalog"
 	"github.com/chenzhuyu2004/carbon-guard/internal/ci"
 	cgerrors "github.com/chenzhuyu2004/carbon-guard/internal/errors"
+	"github.com/chenzhuyu2004/carbon-guard/pkg/models"
 )
 
 const (
@@ -93,6 +96,37 @@ func buildLiveProvider(apiKey string, cacheDir string, cacheTTL time.Duration) c
 	})
 }
 
+// buildProvider returns the offline grid-mix provider when gridMixDir is set, and the live
+// Electricity Maps pipeline otherwise.
+// buildProvider 在设置 gridMixDir 时返回离线发电结构 provider，否则返回 Electricity Maps 实时管线。
+func buildProvider(gridMixDir string, emissionFactorsPath string, cacheDir string, cacheTTL time.Duration) (ci.Provider, error) {
+	if dir := strings.TrimSpace(gridMixDir); dir != "" {
+		expanded, err := expandHomeDir(dir)
+		if err != nil {
+			return nil, cgerrors.New(err, cgerrors.InputError)
+		}
+		factors, err := loadEmissionFactors(emissionFactorsPath)
+		if err != nil {
+			return nil, err
+		}
+		return &ci.GridMixFileProvider{Dir: expanded, Factors: factors}, nil
+	}
+
+	apiKey := os.Getenv("ELECTRICITY_MAPS_API_KEY")
+	if apiKey == "" {
+		return nil, cgerrors.Newf(cgerrors.InputError, "missing ELECTRICITY_MAPS_API_KEY")
+	}
+	return buildLiveProvider(apiKey, cacheDir, cacheTTL), nil
+}
+
+func loadEmissionFactors(path string) (map[string]float64, error) {
+	factors, err := catalog.LoadEmissionFactors(path)
+	if err != nil {
+		return nil, cgerrors.New(err, cgerrors.InputError)
+	}
+	return factors.KgPerKWh(), nil
+}
+
 func parseCacheConfig(cacheDirRaw string, cacheTTLRaw string) (string, time.Duration, error) {
 	cacheTTL, err := time.ParseDuration(cacheTTLRaw)
 	if err != nil || cacheTTL < 0 {
@@ -131,3 +165,67 @@ func expandHomeDir(path string) (string, error) {
 	}
 	return path, nil
 }
+
+// resolveInstancePower derives a power profile from an instance type; empty input returns nil,
+// which keeps runner profiles.
+// resolveInstancePower 根据实例类型推导功率画像；输入为空时返回 nil，沿用 runner 画像。
+func resolveInstancePower(instanceType string, catalogPath string) (*models.PowerProfile, error) {
+	if strings.TrimSpace(instanceType) == "" {
+		if strings.TrimSpace(catalogPath) != "" {
+			return nil, fmt.Errorf("instance-catalog requires instance-type")
+		}
+		return nil, nil
+	}
+
+	path, err := expandHomeDir(catalogPath)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := catalog.LoadInstanceCatalog(path)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := instances.Lookup(instanceType)
+	if err != nil {
+		return nil, err
+	}
+	profile := instance.PowerProfile()
+	return &profile, nil
+}
+
+const (
+	defaultEmbodiedLifetimeYears = 4
+	defaultEmbodiedShare         = 1
+)
+
+// applyEmbodiedOverrides applies non-negative embodied flags on top of the resolved profile.
+// applyEmbodiedOverrides 在已解析的画像上应用非负的 embodied 参数。
+//
+// A nil power profile means the runner profile, so it is materialized before overriding.
+// nil 功率画像表示沿用 runner 画像，因此覆盖前先展开为具体值。
+func applyEmbodiedOverrides(profile *models.PowerProfile, runner string, totalKg float64, lifetimeYears float64, share float64) *models.PowerProfile {
+	if totalKg < 0 && lifetimeYears < 0 && share < 0 {
+		return profile
+	}
+	power := calculator.RunnerProfile(runner)
+	if profile != nil {
+		power = *profile
+	}
+
+	embodied := power.Embodied
+	if totalKg >= 0 {
+		embodied.TotalKgCO2e = totalKg
+	}
+	if lifetimeYears >= 0 {
+		embodied.LifetimeYears = lifetimeYears
+	} else if embodied.LifetimeYears == 0 {
+		embodied.LifetimeYears = defaultEmbodiedLifetimeYears
+	}
+	if share >= 0 {
+		embodied.Share = share
+	} else if embodied.Share == 0 {
+		embodied.Share = defaultEmbodiedShare
+	}
+	power.Embodied = embodied
+	return &power
+}
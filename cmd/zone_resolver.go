@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/catalog"
+	"github.com/chenzhuyu2004/carbon-guard/internal/cloudmeta"
 )
 
 const (
@@ -22,12 +28,18 @@ const (
 
 var zonePattern = regexp.MustCompile(`^[A-Z]{2}(?:-[A-Z0-9]+)*$`)
 
+var loadDefaultCloudRegions = sync.OnceValues(catalog.DefaultCloudRegionMap)
+
+var loadDefaultZoneLocations = sync.OnceValues(catalog.DefaultZoneLocations)
+
 type resolvedZone struct {
 	Zone         string
 	Source       string
 	Confidence   string
 	Reason       string
 	FallbackUsed bool
+	Mappings     []zoneMapping
+	Expansions   []zoneExpansion
 }
 
 type resolvedZones struct {
@@ -36,70 +48,154 @@ type resolvedZones struct {
 	Confidence   string
 	Reason       string
 	FallbackUsed bool
+	Mappings     []zoneMapping
+	Expansions   []zoneExpansion
+}
+
+// zoneMapping records a cloud region identifier that was translated to a grid zone.
+// zoneMapping 记录一次由云 region 标识到电网区域的转换。
+type zoneMapping struct {
+	From string
+	Zone string
+}
+
+// Kinds reported in zoneExpansion.Kind.
+// zoneExpansion.Kind 中报告的展开类型。
+const (
+	zoneExpansionAlias = "alias"
+	zoneExpansionGroup = "group"
+)
+
+// zoneExpansion records a config alias or group expanded to zones.
+// zoneExpansion 记录一次配置别名或分组到区域的展开。
+type zoneExpansion struct {
+	Kind  string
+	Name  string
+	Zones []string
 }
 
 type autoHints struct {
 	ZoneHint     string
 	CountryHint  string
 	TimezoneHint string
+	// CloudRegions maps "provider:region" identifiers; nil uses the embedded mapping.
+	// CloudRegions 用于映射 "provider:region" 标识；为 nil 时使用内嵌映射。
+	CloudRegions *catalog.CloudRegionMap
+	// CloudMetadata detects the instance's cloud region in auto mode; nil skips detection.
+	// CloudMetadata 在 auto 模式下探测实例所在云 region；为 nil 时跳过探测。
+	CloudMetadata cloudMetadataDetector
+	// Locations maps countries and timezones to zones; nil uses the embedded dataset.
+	// Locations 将国家与时区映射到区域；为 nil 时使用内嵌数据集。
+	Locations *catalog.ZoneLocations
+	// Aliases and Groups are config-defined names keyed in lower case; they apply to every zone source.
+	// Aliases 与 Groups 为配置中定义的名称（键为小写），作用于所有区域来源。
+	Aliases map[string]string
+	Groups  map[string][]string
+}
+
+type cloudMetadataDetector interface {
+	Detect(ctx context.Context) (cloudmeta.Region, error)
+}
+
+func (h autoHints) cloudRegions() (catalog.CloudRegionMap, error) {
+	if h.CloudRegions != nil {
+		return *h.CloudRegions, nil
+	}
+	return loadDefaultCloudRegions()
+}
+
+func (h autoHints) zoneLocations() (catalog.ZoneLocations, error) {
+	if h.Locations != nil {
+		return *h.Locations, nil
+	}
+	return loadDefaultZoneLocations()
 }
 
 func resolveZone(explicit string, mode string, configZone string, hints autoHints) (resolvedZone, error) {
+	return resolveZoneTraced(explicit, mode, configZone, hints, nil)
+}
+
+// resolveZoneTraced is resolveZone that records every source it consults in trace; a nil trace
+// records nothing.
+// resolveZoneTraced 与 resolveZone 相同，并将查询的每个来源记录到 trace；trace 为 nil 时不记录。
+func resolveZoneTraced(explicit string, mode string, configZone string, hints autoHints, trace *zoneTracer) (resolvedZone, error) {
 	mode, err := normalizeZoneMode(mode)
 	if err != nil {
 		return resolvedZone{}, err
 	}
+	trace.begin(mode, "--zone", explicit, envZoneDefault, "config zone", configZone, hints)
+	defer trace.finish()
 
-	if zone, ok, err := parseSingleZone(explicit); err != nil {
+	if selection, ok, err := parseSingleZone(explicit, hints); err != nil {
+		trace.invalid("cli", err)
 		return resolvedZone{}, err
 	} else if ok {
-		return resolvedZone{
-			Zone:         zone,
+		resolved := resolvedZone{
+			Zone:         selection.Zones[0],
 			Source:       "cli",
 			Confidence:   "high",
-			Reason:       "provided by --zone",
+			Reason:       selection.describe("provided by --zone"),
 			FallbackUsed: false,
-		}, nil
+			Mappings:     selection.Mappings,
+			Expansions:   selection.Expansions,
+		}
+		trace.used("cli", selection.Zones, resolved.Confidence, resolved.Reason)
+		return resolved, nil
 	}
+	trace.empty("cli")
 
 	if mode == zoneModeStrict {
 		return resolvedZone{}, fmt.Errorf("zone is required in strict mode (set --zone)")
 	}
 
 	if raw := strings.TrimSpace(os.Getenv(envZoneDefault)); raw != "" {
-		zone, ok, err := parseSingleZone(raw)
+		selection, ok, err := parseSingleZone(raw, hints)
 		if err != nil {
-			return resolvedZone{}, fmt.Errorf("invalid %s: %w", envZoneDefault, err)
+			err = fmt.Errorf("invalid %s: %w", envZoneDefault, err)
+			trace.invalid("env", err)
+			return resolvedZone{}, err
 		}
 		if ok {
-			return resolvedZone{
-				Zone:         zone,
+			resolved := resolvedZone{
+				Zone:         selection.Zones[0],
 				Source:       "env",
 				Confidence:   "medium",
-				Reason:       "from " + envZoneDefault,
+				Reason:       selection.describe("from " + envZoneDefault),
 				FallbackUsed: true,
-			}, nil
+				Mappings:     selection.Mappings,
+				Expansions:   selection.Expansions,
+			}
+			trace.used("env", selection.Zones, resolved.Confidence, resolved.Reason)
+			return resolved, nil
 		}
+		trace.empty("env")
 	}
 
 	if raw := strings.TrimSpace(configZone); raw != "" {
-		zone, ok, err := parseSingleZone(raw)
+		selection, ok, err := parseSingleZone(raw, hints)
 		if err != nil {
-			return resolvedZone{}, fmt.Errorf("invalid config zone: %w", err)
+			err = fmt.Errorf("invalid config zone: %w", err)
+			trace.invalid("config", err)
+			return resolvedZone{}, err
 		}
 		if ok {
-			return resolvedZone{
-				Zone:         zone,
+			resolved := resolvedZone{
+				Zone:         selection.Zones[0],
 				Source:       "config",
 				Confidence:   "medium",
-				Reason:       "from config zone",
+				Reason:       selection.describe("from config zone"),
 				FallbackUsed: true,
-			}, nil
+				Mappings:     selection.Mappings,
+				Expansions:   selection.Expansions,
+			}
+			trace.used("config", selection.Zones, resolved.Confidence, resolved.Reason)
+			return resolved, nil
 		}
+		trace.empty("config")
 	}
 
 	if mode == zoneModeAuto {
-		if auto, ok, err := resolveAutoZone(hints); err != nil {
+		if auto, ok, err := resolveAutoZone(hints, trace); err != nil {
 			return resolvedZone{}, err
 		} else if ok {
 			return auto, nil
@@ -113,61 +209,90 @@ func resolveZone(explicit string, mode string, configZone string, hints autoHint
 }
 
 func resolveZones(explicit string, mode string, configZones string, hints autoHints) (resolvedZones, error) {
+	return resolveZonesTraced(explicit, mode, configZones, hints, nil)
+}
+
+// resolveZonesTraced is resolveZones that records every source it consults in trace; a nil trace
+// records nothing.
+// resolveZonesTraced 与 resolveZones 相同，并将查询的每个来源记录到 trace；trace 为 nil 时不记录。
+func resolveZonesTraced(explicit string, mode string, configZones string, hints autoHints, trace *zoneTracer) (resolvedZones, error) {
 	mode, err := normalizeZoneMode(mode)
 	if err != nil {
 		return resolvedZones{}, err
 	}
+	trace.begin(mode, "--zones", explicit, envZonesDefault, "config zones", configZones, hints)
+	defer trace.finish()
 
-	if zones, ok, err := parseZoneList(explicit); err != nil {
+	if selection, ok, err := parseZoneList(explicit, hints); err != nil {
+		trace.invalid("cli", err)
 		return resolvedZones{}, err
 	} else if ok {
-		return resolvedZones{
-			Zones:        zones,
+		resolved := resolvedZones{
+			Zones:        selection.Zones,
 			Source:       "cli",
 			Confidence:   "high",
-			Reason:       "provided by --zones",
+			Reason:       selection.describe("provided by --zones"),
 			FallbackUsed: false,
-		}, nil
+			Mappings:     selection.Mappings,
+			Expansions:   selection.Expansions,
+		}
+		trace.used("cli", resolved.Zones, resolved.Confidence, resolved.Reason)
+		return resolved, nil
 	}
+	trace.empty("cli")
 
 	if mode == zoneModeStrict {
 		return resolvedZones{}, fmt.Errorf("zones are required in strict mode (set --zones)")
 	}
 
 	if raw := strings.TrimSpace(os.Getenv(envZonesDefault)); raw != "" {
-		zones, ok, err := parseZoneList(raw)
+		selection, ok, err := parseZoneList(raw, hints)
 		if err != nil {
-			return resolvedZones{}, fmt.Errorf("invalid %s: %w", envZonesDefault, err)
+			err = fmt.Errorf("invalid %s: %w", envZonesDefault, err)
+			trace.invalid("env", err)
+			return resolvedZones{}, err
 		}
 		if ok {
-			return resolvedZones{
-				Zones:        zones,
+			resolved := resolvedZones{
+				Zones:        selection.Zones,
 				Source:       "env",
 				Confidence:   "medium",
-				Reason:       "from " + envZonesDefault,
+				Reason:       selection.describe("from " + envZonesDefault),
 				FallbackUsed: true,
-			}, nil
+				Mappings:     selection.Mappings,
+				Expansions:   selection.Expansions,
+			}
+			trace.used("env", resolved.Zones, resolved.Confidence, resolved.Reason)
+			return resolved, nil
 		}
+		trace.empty("env")
 	}
 
 	if raw := strings.TrimSpace(configZones); raw != "" {
-		zones, ok, err := parseZoneList(raw)
+		selection, ok, err := parseZoneList(raw, hints)
 		if err != nil {
-			return resolvedZones{}, fmt.Errorf("invalid config zones: %w", err)
+			err = fmt.Errorf("invalid config zones: %w", err)
+			trace.invalid("config", err)
+			return resolvedZones{}, err
 		}
 		if ok {
-			return resolvedZones{
-				Zones:        zones,
+			resolved := resolvedZones{
+				Zones:        selection.Zones,
 				Source:       "config",
 				Confidence:   "medium",
-				Reason:       "from config zones",
+				Reason:       selection.describe("from config zones"),
 				FallbackUsed: true,
-			}, nil
+				Mappings:     selection.Mappings,
+				Expansions:   selection.Expansions,
+			}
+			trace.used("config", resolved.Zones, resolved.Confidence, resolved.Reason)
+			return resolved, nil
 		}
+		trace.empty("config")
 	}
 
 	if mode == zoneModeAuto {
-		if auto, ok, err := resolveAutoZone(hints); err != nil {
+		if auto, ok, err := resolveAutoZone(hints, trace); err != nil {
 			return resolvedZones{}, err
 		} else if ok {
 			return resolvedZones{
@@ -176,6 +301,8 @@ func resolveZones(explicit string, mode string, configZones string, hints autoHi
 				Confidence:   auto.Confidence,
 				Reason:       auto.Reason,
 				FallbackUsed: true,
+				Mappings:     auto.Mappings,
+				Expansions:   auto.Expansions,
 			}, nil
 		}
 	}
@@ -199,45 +326,149 @@ func normalizeZoneMode(mode string) (string, error) {
 	}
 }
 
-func parseSingleZone(raw string) (string, bool, error) {
-	zone := normalizeZoneAlias(raw)
-	if zone == "" {
-		return "", false, nil
+// zoneSelection is the outcome of parsing one zone source, with the expansions that produced it.
+// zoneSelection 为解析单个区域来源的结果，并记录产生该结果的展开过程。
+type zoneSelection struct {
+	Zones      []string
+	Mappings   []zoneMapping
+	Expansions []zoneExpansion
+}
+
+func parseSingleZone(raw string, hints autoHints) (zoneSelection, bool, error) {
+	var selection zoneSelection
+	zones, err := selection.expand(raw, hints, nil)
+	if err != nil || len(zones) == 0 {
+		return zoneSelection{}, false, err
 	}
-	if !zonePattern.MatchString(zone) {
-		return "", false, fmt.Errorf("invalid zone format %q", zone)
+	if len(zones) > 1 {
+		return zoneSelection{}, false, fmt.Errorf("%q expands to %d zones (%s); a single zone is required", strings.TrimSpace(raw), len(zones), strings.Join(zones, ", "))
 	}
-	return zone, true, nil
+	selection.Zones = zones
+	return selection, true, nil
 }
 
-func parseZoneList(raw string) ([]string, bool, error) {
+func parseZoneList(raw string, hints autoHints) (zoneSelection, bool, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
-		return nil, false, nil
+		return zoneSelection{}, false, nil
 	}
 
-	items := strings.Split(trimmed, ",")
-	zones := make([]string, 0, len(items))
-	seen := make(map[string]struct{}, len(items))
-	for _, item := range items {
-		zone := normalizeZoneAlias(item)
-		if zone == "" {
-			continue
+	var selection zoneSelection
+	var zones []string
+	for _, item := range strings.Split(trimmed, ",") {
+		expanded, err := selection.expand(item, hints, nil)
+		if err != nil {
+			return zoneSelection{}, false, err
 		}
-		if !zonePattern.MatchString(zone) {
-			return nil, false, fmt.Errorf("invalid zone format %q", zone)
+		zones = append(zones, expanded...)
+	}
+
+	zones = dedupeZones(zones)
+	if len(zones) == 0 {
+		return zoneSelection{}, false, nil
+	}
+	selection.Zones = zones
+	return selection, true, nil
+}
+
+// expand resolves one item: a config alias or group (recursively, rejecting cycles), a cloud
+// region ("aws:eu-west-1"), or a grid zone ("DE").
+// expand 解析单个条目：配置中的别名或分组（递归展开并拒绝循环）、云 region（"aws:eu-west-1"）
+// 或电网区域（"DE"）。
+func (s *zoneSelection) expand(raw string, hints autoHints, path []string) ([]string, error) {
+	name := strings.ToLower(strings.TrimSpace(raw))
+	if name == "" {
+		return nil, nil
+	}
+
+	target, isAlias := hints.Aliases[name]
+	members, isGroup := hints.Groups[name]
+	if isAlias || isGroup {
+		if slices.Contains(path, name) {
+			return nil, fmt.Errorf("zone alias cycle: %s -> %s", strings.Join(path, " -> "), name)
+		}
+		path = append(slices.Clip(path), name)
+
+		kind := zoneExpansionAlias
+		var zones []string
+		if isAlias {
+			expanded, err := s.expand(target, hints, path)
+			if err != nil {
+				return nil, err
+			}
+			zones = expanded
+		} else {
+			kind = zoneExpansionGroup
+			for _, member := range members {
+				expanded, err := s.expand(member, hints, path)
+				if err != nil {
+					return nil, err
+				}
+				zones = append(zones, expanded...)
+			}
 		}
+		zones = dedupeZones(zones)
+		if len(zones) == 0 {
+			return nil, fmt.Errorf("zone %s %q expands to no zones", kind, name)
+		}
+		s.Expansions = append(s.Expansions, zoneExpansion{Kind: kind, Name: name, Zones: zones})
+		return zones, nil
+	}
+
+	if catalog.IsCloudRegionID(raw) {
+		regions, err := hints.cloudRegions()
+		if err != nil {
+			return nil, err
+		}
+		region, err := regions.Lookup(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !zonePattern.MatchString(region.Zone) {
+			return nil, fmt.Errorf("cloud region %s maps to invalid zone %q", region.ID, region.Zone)
+		}
+		s.Mappings = append(s.Mappings, zoneMapping{From: region.ID, Zone: region.Zone})
+		return []string{region.Zone}, nil
+	}
+
+	zone := normalizeZoneAlias(raw)
+	if !zonePattern.MatchString(zone) {
+		return nil, fmt.Errorf("invalid zone format %q", zone)
+	}
+	return []string{zone}, nil
+}
+
+// describe appends alias, group, and cloud region expansions to a resolution reason.
+// describe 将别名、分组与云 region 的展开过程附加到解析原因中。
+func (s zoneSelection) describe(reason string) string {
+	var notes []string
+	for _, expansion := range s.Expansions {
+		notes = append(notes, expansion.Kind+" "+expansion.Name+" -> "+strings.Join(expansion.Zones, ", "))
+	}
+	if len(s.Mappings) > 0 {
+		parts := make([]string, len(s.Mappings))
+		for i, mapping := range s.Mappings {
+			parts[i] = mapping.From + " -> " + mapping.Zone
+		}
+		notes = append(notes, "cloud region "+strings.Join(parts, ", "))
+	}
+	if len(notes) == 0 {
+		return reason
+	}
+	return reason + " (" + strings.Join(notes, "; ") + ")"
+}
+
+func dedupeZones(zones []string) []string {
+	out := make([]string, 0, len(zones))
+	seen := make(map[string]struct{}, len(zones))
+	for _, zone := range zones {
 		if _, ok := seen[zone]; ok {
 			continue
 		}
 		seen[zone] = struct{}{}
-		zones = append(zones, zone)
+		out = append(out, zone)
 	}
-
-	if len(zones) == 0 {
-		return nil, false, nil
-	}
-	return zones, true, nil
+	return out
 }
 
 func normalizeZoneAlias(value string) string {
@@ -259,79 +490,233 @@ func firstNonEmpty(values ...string) string {
 	return ""
 }
 
-func resolveAutoZone(hints autoHints) (resolvedZone, bool, error) {
-	if zoneRaw := firstNonEmpty(strings.TrimSpace(hints.ZoneHint), strings.TrimSpace(os.Getenv(envZoneHint))); zoneRaw != "" {
-		zone, ok, err := parseSingleZone(zoneRaw)
-		if err != nil {
-			return resolvedZone{}, false, fmt.Errorf("invalid zone hint: %w", err)
-		}
-		if ok {
-			return resolvedZone{
-				Zone:         zone,
-				Source:       "auto:zone-hint",
-				Confidence:   "high",
-				Reason:       "from zone hint",
-				FallbackUsed: true,
-			}, true, nil
+// resolveAutoZone consults the auto-mode sources in order; hint errors abort resolution, while
+// cloud metadata failures fall through to the locale and TZ heuristics.
+// resolveAutoZone 按顺序查询 auto 模式来源；提示错误会中止解析，云元数据失败则继续使用 locale 与 TZ 启发式。
+func resolveAutoZone(hints autoHints, trace *zoneTracer) (resolvedZone, bool, error) {
+	if zoneRaw := zoneHintValue(hints); zoneRaw != "" {
+		auto, ok, err := zoneFromZoneHint(zoneRaw, hints)
+		if err != nil || ok {
+			trace.resolved("auto:zone-hint", auto, err)
+			return auto, ok, err
 		}
+		trace.empty("auto:zone-hint")
 	}
 
-	if countryRaw := firstNonEmpty(strings.TrimSpace(hints.CountryHint), strings.TrimSpace(os.Getenv(envCountryHint))); countryRaw != "" {
-		country := strings.ToUpper(strings.TrimSpace(countryRaw))
-		zone, ok := zoneFromCountry(country)
-		if !ok {
-			return resolvedZone{}, false, fmt.Errorf("country hint %q has no curated default zone; set %s instead", countryRaw, envZoneHint)
-		}
-		return resolvedZone{
-			Zone:         zone,
-			Source:       "auto:country-hint",
-			Confidence:   "medium",
-			Reason:       "from country hint",
-			FallbackUsed: true,
-		}, true, nil
-	}
-
-	if tzRaw := firstNonEmpty(strings.TrimSpace(hints.TimezoneHint), strings.TrimSpace(os.Getenv(envTimezoneHint))); tzRaw != "" {
-		if zone, ok := zoneFromTimezone(tzRaw); ok {
-			return resolvedZone{
-				Zone:         zone,
-				Source:       "auto:timezone-hint",
-				Confidence:   "medium",
-				Reason:       "from timezone hint",
-				FallbackUsed: true,
-			}, true, nil
+	locations, err := hints.zoneLocations()
+	if err != nil {
+		return resolvedZone{}, false, err
+	}
+	tzRaw := timezoneHintValue(hints)
+
+	if countryRaw := countryHintValue(hints); countryRaw != "" {
+		auto, err := zoneFromCountryHint(countryRaw, tzRaw, locations)
+		trace.resolved("auto:country-hint", auto, err)
+		return auto, err == nil, err
+	}
+
+	if tzRaw != "" {
+		auto, err := zoneFromTimezoneHint(tzRaw, locations)
+		trace.resolved("auto:timezone-hint", auto, err)
+		return auto, err == nil, err
+	}
+
+	if hints.CloudMetadata == nil {
+		trace.skipped("auto:cloud-metadata", "detection disabled")
+	} else {
+		auto, regionID, err := resolveCloudMetadataZone(hints)
+		trace.probed(regionID)
+		if err == nil {
+			trace.resolved("auto:cloud-metadata", auto, nil)
+			return auto, true, nil
 		}
-		return resolvedZone{}, false, fmt.Errorf("invalid timezone hint %q", tzRaw)
+		trace.noMatch("auto:cloud-metadata", err.Error())
 	}
 
-	if country, source, reason, ok := detectCountryHint(); ok {
-		if zone, ok := zoneFromCountry(country); ok {
-			return resolvedZone{
-				Zone:         zone,
-				Source:       source,
-				Confidence:   "low",
-				Reason:       reason,
-				FallbackUsed: true,
-			}, true, nil
+	guess, source, reason, ok := detectLocaleZone(locations)
+	if source != "auto:locale" {
+		trace.noMatch("auto:locale", "no ISO-3166 country in the zone location dataset")
+	}
+	if !ok {
+		trace.noMatch("auto:tz", "timezone not in the zone location dataset")
+		return resolvedZone{}, false, nil
+	}
+	auto, err := zoneFromGuess(guess, source, catalog.ConfidenceLow, reason)
+	trace.resolved(source, auto, err)
+	return auto, err == nil, err
+}
+
+func zoneHintValue(hints autoHints) string {
+	return firstNonEmpty(strings.TrimSpace(hints.ZoneHint), strings.TrimSpace(os.Getenv(envZoneHint)))
+}
+
+func countryHintValue(hints autoHints) string {
+	return firstNonEmpty(strings.TrimSpace(hints.CountryHint), strings.TrimSpace(os.Getenv(envCountryHint)))
+}
+
+func timezoneHintValue(hints autoHints) string {
+	return firstNonEmpty(strings.TrimSpace(hints.TimezoneHint), strings.TrimSpace(os.Getenv(envTimezoneHint)))
+}
+
+func zoneFromZoneHint(raw string, hints autoHints) (resolvedZone, bool, error) {
+	selection, ok, err := parseSingleZone(raw, hints)
+	if err != nil {
+		return resolvedZone{}, false, fmt.Errorf("invalid zone hint: %w", err)
+	}
+	if !ok {
+		return resolvedZone{}, false, nil
+	}
+	return resolvedZone{
+		Zone:         selection.Zones[0],
+		Source:       "auto:zone-hint",
+		Confidence:   "high",
+		Reason:       selection.describe("from zone hint"),
+		FallbackUsed: true,
+		Mappings:     selection.Mappings,
+		Expansions:   selection.Expansions,
+	}, true, nil
+}
+
+func zoneFromCountryHint(raw string, tzRaw string, locations catalog.ZoneLocations) (resolvedZone, error) {
+	guess, ok := locations.Country(normalizeZoneAlias(raw))
+	if !ok {
+		return resolvedZone{}, fmt.Errorf("country hint %q is not an ISO-3166 country code; set %s instead", raw, envZoneHint)
+	}
+	reason := "from country hint"
+	if narrowed, ok := narrowByTimezone(guess, locations, tzRaw); ok {
+		guess, reason = narrowed, "from country hint, narrowed by timezone hint"
+	}
+	return zoneFromGuess(guess, "auto:country-hint", catalog.ConfidenceMedium, reason)
+}
+
+func zoneFromTimezoneHint(raw string, locations catalog.ZoneLocations) (resolvedZone, error) {
+	guess, ok := locations.Timezone(raw)
+	if !ok {
+		return resolvedZone{}, fmt.Errorf("invalid timezone hint %q", raw)
+	}
+	return zoneFromGuess(guess, "auto:timezone-hint", catalog.ConfidenceMedium, "from timezone hint")
+}
+
+// resolveCloudMetadataZone maps the region reported by the instance metadata service to a grid
+// zone, returning the detected region ID when there is one. Detection is best effort: callers
+// treat any error as "fall through to the locale/timezone heuristics".
+// resolveCloudMetadataZone 将实例元数据服务报告的 region 映射为电网区域，并在探测到时返回 region ID。
+// 探测为尽力而为：调用方将任何错误视为继续使用 locale/时区启发式。
+func resolveCloudMetadataZone(hints autoHints) (resolvedZone, string, error) {
+	if hints.CloudMetadata == nil {
+		return resolvedZone{}, "", fmt.Errorf("cloud metadata detection disabled")
+	}
+	detected, err := hints.CloudMetadata.Detect(context.Background())
+	if err != nil {
+		return resolvedZone{}, "", err
+	}
+	regions, err := hints.cloudRegions()
+	if err != nil {
+		return resolvedZone{}, detected.ID(), err
+	}
+	region, err := regions.Lookup(detected.ID())
+	if err != nil {
+		return resolvedZone{}, detected.ID(), err
+	}
+	if !zonePattern.MatchString(region.Zone) {
+		return resolvedZone{}, region.ID, fmt.Errorf("cloud region %s maps to invalid zone %q", region.ID, region.Zone)
+	}
+	selection := zoneSelection{
+		Zones:    []string{region.Zone},
+		Mappings: []zoneMapping{{From: region.ID, Zone: region.Zone}},
+	}
+	return resolvedZone{
+		Zone:         region.Zone,
+		Source:       "auto:cloud-metadata",
+		Confidence:   "high",
+		Reason:       selection.describe("detected from instance metadata"),
+		FallbackUsed: true,
+		Mappings:     selection.Mappings,
+	}, region.ID, nil
+}
+
+// detectLocaleZone infers a zone from the locale country, narrowed to a sub-zone by TZ when both
+// agree on the country, and falls back to TZ alone.
+// detectLocaleZone 根据 locale 国家推断区域；若 TZ 属于同一国家则据其细化到子区域，否则仅使用 TZ。
+func detectLocaleZone(locations catalog.ZoneLocations) (catalog.ZoneGuess, string, string, bool) {
+	tz := os.Getenv(envTimezoneSystem)
+	if key, guess, ok := localeZoneGuess(locations); ok {
+		if narrowed, ok := narrowByTimezone(guess, locations, tz); ok {
+			return narrowed, "auto:locale", "inferred from " + key + ", narrowed by " + envTimezoneSystem, true
 		}
+		return guess, "auto:locale", "inferred from " + key, true
+	}
+	if guess, ok := locations.Timezone(tz); ok {
+		return guess, "auto:tz", "inferred from " + envTimezoneSystem, true
 	}
-	return resolvedZone{}, false, nil
+	return catalog.ZoneGuess{}, "", "", false
 }
 
-func detectCountryHint() (string, string, string, bool) {
-	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
-		if country, ok := countryFromLocale(os.Getenv(key)); ok {
-			return country, "auto:locale", "inferred from " + key, true
+var localeEnvKeys = []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+
+// localeZoneGuess returns the first locale variable whose country is in the dataset.
+// localeZoneGuess 返回首个国家收录于数据集中的 locale 变量。
+func localeZoneGuess(locations catalog.ZoneLocations) (string, catalog.ZoneGuess, bool) {
+	for _, key := range localeEnvKeys {
+		country, ok := countryFromLocale(os.Getenv(key))
+		if !ok {
+			continue
+		}
+		if guess, ok := locations.Country(country); ok {
+			return key, guess, true
 		}
 	}
-	if zone, ok := zoneFromTimezone(os.Getenv(envTimezoneSystem)); ok {
-		country := strings.SplitN(zone, "-", 2)[0]
-		return country, "auto:tz", "inferred from " + envTimezoneSystem, true
+	return "", catalog.ZoneGuess{}, false
+}
+
+// narrowByTimezone returns the timezone's guess when it lies in the same country and is more
+// specific than the country-level guess.
+// narrowByTimezone 当时区属于同一国家且比国家级结果更精确时返回时区结果。
+func narrowByTimezone(guess catalog.ZoneGuess, locations catalog.ZoneLocations, tz string) (catalog.ZoneGuess, bool) {
+	if strings.TrimSpace(tz) == "" {
+		return catalog.ZoneGuess{}, false
+	}
+	narrowed, ok := locations.Timezone(tz)
+	if !ok || narrowed.Country != guess.Country || confidenceRank(narrowed.Confidence) <= confidenceRank(guess.Confidence) {
+		return catalog.ZoneGuess{}, false
 	}
-	if country, ok := countryFromTZ(os.Getenv(envTimezoneSystem)); ok {
-		return country, "auto:tz", "inferred from " + envTimezoneSystem, true
+	return narrowed, true
+}
+
+// zoneFromGuess caps the dataset confidence at the source's confidence and explains ambiguous
+// multi-zone guesses in the reason.
+// zoneFromGuess 以来源置信度为上限截断数据集置信度，并在原因中说明多子区域的不确定性。
+func zoneFromGuess(guess catalog.ZoneGuess, source string, maxConfidence string, reason string) (resolvedZone, error) {
+	if !zonePattern.MatchString(guess.Zone) {
+		return resolvedZone{}, fmt.Errorf("zone location for %s maps to invalid zone %q", guess.Country, guess.Zone)
+	}
+	confidence := guess.Confidence
+	if confidenceRank(maxConfidence) < confidenceRank(confidence) {
+		confidence = maxConfidence
+	}
+	if guess.Confidence != catalog.ConfidenceHigh {
+		reason = fmt.Sprintf("%s (most likely zone in %s; dataset confidence %s)", reason, guess.Country, guess.Confidence)
+	}
+	return resolvedZone{
+		Zone:         guess.Zone,
+		Source:       source,
+		Confidence:   confidence,
+		Reason:       reason,
+		FallbackUsed: true,
+	}, nil
+}
+
+func confidenceRank(confidence string) int {
+	switch confidence {
+	case catalog.ConfidenceHigh:
+		return 3
+	case catalog.ConfidenceMedium:
+		return 2
+	case catalog.ConfidenceLow:
+		return 1
+	default:
+		return 0
 	}
-	return "", "", "", false
 }
 
 func countryFromLocale(locale string) (string, bool) {
@@ -362,15 +747,6 @@ func countryFromLocale(locale string) (string, bool) {
 	return "", false
 }
 
-func countryFromTZ(tz string) (string, bool) {
-	normalized := strings.TrimSpace(tz)
-	if normalized == "" {
-		return "", false
-	}
-	country, ok := tzCountryHints[normalized]
-	return country, ok
-}
-
 func isAlpha2(value string) bool {
 	if len(value) != 2 {
 		return false
@@ -382,67 +758,3 @@ func isAlpha2(value string) bool {
 	}
 	return true
 }
-
-func zoneFromCountry(country string) (string, bool) {
-	zone, ok := curatedCountryZoneMap[strings.ToUpper(strings.TrimSpace(country))]
-	return zone, ok
-}
-
-func zoneFromTimezone(tz string) (string, bool) {
-	zone, ok := curatedTimezoneZoneMap[strings.TrimSpace(tz)]
-	return zone, ok
-}
-
-var curatedCountryZoneMap = map[string]string{
-	"AT": "AT",
-	"BE": "BE",
-	"CH": "CH",
-	"CN": "CN",
-	"DE": "DE",
-	"DK": "DK",
-	"ES": "ES",
-	"FI": "FI",
-	"FR": "FR",
-	"GB": "GB",
-	"IE": "IE",
-	"IN": "IN",
-	"IT": "IT",
-	"JP": "JP",
-	"KR": "KR",
-	"NL": "NL",
-	"NO": "NO",
-	"PL": "PL",
-	"SE": "SE",
-	"SG": "SG",
-	"UK": "GB",
-}
-
-var curatedTimezoneZoneMap = map[string]string{
-	"Europe/Berlin":    "DE",
-	"Europe/Paris":     "FR",
-	"Europe/Warsaw":    "PL",
-	"Europe/London":    "GB",
-	"Europe/Madrid":    "ES",
-	"Europe/Rome":      "IT",
-	"Europe/Amsterdam": "NL",
-	"Europe/Brussels":  "BE",
-	"Europe/Stockholm": "SE",
-	"Europe/Oslo":      "NO",
-	"Europe/Zurich":    "CH",
-	"America/New_York": "US-NY",
-	"America/Toronto":  "CA-ON",
-	"Australia/Sydney": "AU-NSW",
-}
-
-var tzCountryHints = map[string]string{
-	"America/New_York": "US",
-	"America/Toronto":  "CA",
-	"Australia/Sydney": "AU",
-	"Asia/Shanghai":    "CN",
-	"Asia/Chongqing":   "CN",
-	"Asia/Beijing":     "CN",
-	"Asia/Singapore":   "SG",
-	"Asia/Tokyo":       "JP",
-	"Asia/Seoul":       "KR",
-	"Asia/Kolkata":     "IN",
-}
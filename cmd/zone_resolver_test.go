@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/cloudmeta"
+	cgconfig "github.com/chenzhuyu2004/carbon-guard/internal/config"
 )
 
 func clearZoneHintEnv(t *testing.T) {
@@ -140,13 +147,90 @@ func TestResolveZoneAutoFallback(t *testing.T) {
 		}
 	})
 
-	t.Run("unsupported locale country requires explicit hint", func(t *testing.T) {
+	t.Run("multi-zone locale country resolves with low confidence", func(t *testing.T) {
 		clearZoneHintEnv(t)
 		t.Setenv("LANG", "en_US.UTF-8")
 
+		got, err := resolveZone("", zoneModeAuto, "", autoHints{})
+		if err != nil {
+			t.Fatalf("resolveZone() unexpected error: %v", err)
+		}
+		if got.Zone != "US-MIDA-PJM" || got.Source != "auto:locale" || got.Confidence != "low" {
+			t.Fatalf("unexpected resolution: %#v", got)
+		}
+	})
+
+	t.Run("locale narrowed to sub-zone by TZ", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv("LANG", "en_US.UTF-8")
+		t.Setenv(envTimezoneSystem, "America/Phoenix")
+
+		got, err := resolveZone("", zoneModeAuto, "", autoHints{})
+		if err != nil {
+			t.Fatalf("resolveZone() unexpected error: %v", err)
+		}
+		if got.Zone != "US-SW-AZPS" || got.Source != "auto:locale" || got.Reason != "inferred from LANG, narrowed by TZ" {
+			t.Fatalf("unexpected resolution: %#v", got)
+		}
+	})
+
+	t.Run("unknown locale country requires explicit hint", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv("LANG", "en_ZZ.UTF-8")
+
 		_, err := resolveZone("", zoneModeAuto, "", autoHints{})
 		if err == nil {
-			t.Fatalf("expected error for unsupported locale-only inference")
+			t.Fatalf("expected error for unknown locale-only inference")
+		}
+	})
+}
+
+func TestResolveZoneAutoLocationDataset(t *testing.T) {
+	cases := []struct {
+		name       string
+		hints      autoHints
+		zone       string
+		source     string
+		confidence string
+	}{
+		{name: "multi-zone country hint", hints: autoHints{CountryHint: "US"}, zone: "US-MIDA-PJM", source: "auto:country-hint", confidence: "low"},
+		{name: "country hint narrowed by timezone hint", hints: autoHints{CountryHint: "au", TimezoneHint: "Australia/Perth"}, zone: "AU-WA", source: "auto:country-hint", confidence: "medium"},
+		{name: "timezone hint in other country is ignored", hints: autoHints{CountryHint: "JP", TimezoneHint: "Europe/Paris"}, zone: "JP-TK", source: "auto:country-hint", confidence: "low"},
+		{name: "uk alias", hints: autoHints{CountryHint: "uk"}, zone: "GB", source: "auto:country-hint", confidence: "medium"},
+		{name: "brazil timezone", hints: autoHints{TimezoneHint: "America/Manaus"}, zone: "BR-N", source: "auto:timezone-hint", confidence: "medium"},
+		{name: "india timezone", hints: autoHints{TimezoneHint: "Asia/Kolkata"}, zone: "IN-WE", source: "auto:timezone-hint", confidence: "low"},
+		{name: "backward link", hints: autoHints{TimezoneHint: "US/Eastern"}, zone: "US-NY-NYIS", source: "auto:timezone-hint", confidence: "medium"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearZoneHintEnv(t)
+			got, err := resolveZone("", zoneModeAuto, "", tc.hints)
+			if err != nil {
+				t.Fatalf("resolveZone() unexpected error: %v", err)
+			}
+			if got.Zone != tc.zone || got.Source != tc.source || got.Confidence != tc.confidence {
+				t.Fatalf("unexpected resolution: %#v", got)
+			}
+		})
+	}
+
+	t.Run("config override", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		path := filepath.Join(t.TempDir(), "locations.json")
+		content := `{"countries":{"US":{"zone":"US-CAL-CISO","confidence":"high"}}}`
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		hints, err := zoneHints(cgconfig.Shared{CountryHint: "US", ZoneLocations: path})
+		if err != nil {
+			t.Fatalf("zoneHints() unexpected error: %v", err)
+		}
+		got, err := resolveZone("", zoneModeAuto, "", hints)
+		if err != nil {
+			t.Fatalf("resolveZone() unexpected error: %v", err)
+		}
+		if got.Zone != "US-CAL-CISO" || got.Confidence != "medium" {
+			t.Fatalf("unexpected resolution: %#v", got)
 		}
 	})
 }
@@ -175,9 +259,9 @@ func TestResolveZoneValidation(t *testing.T) {
 		t.Fatalf("expected invalid country hint error")
 	}
 
-	_, err = resolveZone("", zoneModeAuto, "", autoHints{CountryHint: "US"})
+	_, err = resolveZone("", zoneModeAuto, "", autoHints{CountryHint: "ZZ"})
 	if err == nil {
-		t.Fatalf("expected unsupported country hint error")
+		t.Fatalf("expected unknown country hint error")
 	}
 
 	_, err = resolveZone("", zoneModeAuto, "", autoHints{TimezoneHint: "Mars/Base"})
@@ -281,3 +365,350 @@ func TestResolveZonesValidation(t *testing.T) {
 		t.Fatalf("expected invalid env zone list error")
 	}
 }
+
+func TestResolveZoneMapsCloudRegion(t *testing.T) {
+	t.Run("cli cloud region", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		got, err := resolveZone("aws:eu-west-1", zoneModeFallback, "", autoHints{})
+		if err != nil {
+			t.Fatalf("resolveZone() unexpected error: %v", err)
+		}
+		want := []zoneMapping{{From: "aws:eu-west-1", Zone: "IE"}}
+		if got.Zone != "IE" || got.Source != "cli" || !reflect.DeepEqual(got.Mappings, want) {
+			t.Fatalf("unexpected resolution: %#v", got)
+		}
+		if got.Reason != "provided by --zone (cloud region aws:eu-west-1 -> IE)" {
+			t.Fatalf("unexpected reason: %q", got.Reason)
+		}
+	})
+
+	t.Run("env zones mix cloud regions and zones", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv(envZonesDefault, "gcp:europe-west4,NL,DE")
+		got, err := resolveZones("", zoneModeFallback, "", autoHints{})
+		if err != nil {
+			t.Fatalf("resolveZones() unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got.Zones, []string{"NL", "DE"}) || got.Source != "env" {
+			t.Fatalf("unexpected resolution: %#v", got)
+		}
+		if !reflect.DeepEqual(got.Mappings, []zoneMapping{{From: "gcp:europe-west4", Zone: "NL"}}) {
+			t.Fatalf("unexpected mappings: %#v", got.Mappings)
+		}
+	})
+
+	t.Run("config override map", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		path := filepath.Join(t.TempDir(), "cloud.json")
+		if err := os.WriteFile(path, []byte(`{"cloud_regions":{"aws":{"eu-west-1":"GB"}}}`), 0o644); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		hints, err := zoneHints(cgconfig.Shared{CloudRegionMap: path})
+		if err != nil {
+			t.Fatalf("zoneHints() unexpected error: %v", err)
+		}
+		got, err := resolveZone("", zoneModeFallback, "aws:eu-west-1", hints)
+		if err != nil {
+			t.Fatalf("resolveZone() unexpected error: %v", err)
+		}
+		if got.Zone != "GB" || got.Source != "config" {
+			t.Fatalf("unexpected resolution: %#v", got)
+		}
+	})
+
+	t.Run("unknown cloud region", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		if _, err := resolveZone("aws:mars-north-1", zoneModeFallback, "", autoHints{}); err == nil {
+			t.Fatalf("expected unknown cloud region error")
+		}
+		if _, err := resolveZones("DE,azure:nowhere", zoneModeFallback, "", autoHints{}); err == nil {
+			t.Fatalf("expected unknown cloud region error")
+		}
+	})
+}
+
+type fakeCloudMetadata struct {
+	region cloudmeta.Region
+	err    error
+	calls  int
+}
+
+func (f *fakeCloudMetadata) Detect(context.Context) (cloudmeta.Region, error) {
+	f.calls++
+	return f.region, f.err
+}
+
+func TestResolveZoneAutoCloudMetadata(t *testing.T) {
+	t.Run("metadata beats locale heuristic", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv("LANG", "en_US.UTF-8")
+		detector := &fakeCloudMetadata{region: cloudmeta.Region{Provider: "gcp", Region: "europe-west4"}}
+		got, err := resolveZone("", zoneModeAuto, "", autoHints{CloudMetadata: detector})
+		if err != nil {
+			t.Fatalf("resolveZone() unexpected error: %v", err)
+		}
+		if got.Zone != "NL" || got.Source != "auto:cloud-metadata" || got.Confidence != "high" || !got.FallbackUsed {
+			t.Fatalf("unexpected resolution: %#v", got)
+		}
+		if !reflect.DeepEqual(got.Mappings, []zoneMapping{{From: "gcp:europe-west4", Zone: "NL"}}) {
+			t.Fatalf("unexpected mappings: %#v", got.Mappings)
+		}
+	})
+
+	t.Run("explicit hints skip detection", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		detector := &fakeCloudMetadata{region: cloudmeta.Region{Provider: "aws", Region: "eu-west-1"}}
+		got, err := resolveZone("", zoneModeAuto, "", autoHints{TimezoneHint: "Europe/Paris", CloudMetadata: detector})
+		if err != nil {
+			t.Fatalf("resolveZone() unexpected error: %v", err)
+		}
+		if got.Zone != "FR" || detector.calls != 0 {
+			t.Fatalf("unexpected resolution %#v (detector calls %d)", got, detector.calls)
+		}
+	})
+
+	t.Run("unavailable or unmapped falls back to locale", func(t *testing.T) {
+		for _, detector := range []*fakeCloudMetadata{
+			{err: cloudmeta.ErrUnavailable},
+			{region: cloudmeta.Region{Provider: "aws", Region: "mars-north-1"}},
+		} {
+			clearZoneHintEnv(t)
+			t.Setenv("LANG", "de_DE.UTF-8")
+			got, err := resolveZone("", zoneModeAuto, "", autoHints{CloudMetadata: detector})
+			if err != nil {
+				t.Fatalf("resolveZone() unexpected error: %v", err)
+			}
+			if got.Zone != "DE" || got.Source != "auto:locale" {
+				t.Fatalf("unexpected resolution: %#v", got)
+			}
+		}
+	})
+
+	t.Run("zones use metadata too", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		detector := &fakeCloudMetadata{region: cloudmeta.Region{Provider: "azure", Region: "westeurope"}}
+		got, err := resolveZones("", zoneModeAuto, "", autoHints{CloudMetadata: detector})
+		if err != nil {
+			t.Fatalf("resolveZones() unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got.Zones, []string{"NL"}) || got.Source != "auto:cloud-metadata" || len(got.Mappings) != 1 {
+			t.Fatalf("unexpected resolution: %#v", got)
+		}
+	})
+}
+
+func TestResolveZonesExpandsAliasesAndGroups(t *testing.T) {
+	hints, err := zoneHints(cgconfig.Shared{
+		ZoneAliases: map[string]string{"Frankfurt-DC": "DE", "dublin": "aws:eu-west-1", "home": "frankfurt-dc"},
+		ZoneGroups: map[string][]string{
+			"eu-primary": {"frankfurt-dc", "FR", "NL"},
+			"eu-all":     {"eu-primary", "dublin", "de"},
+			"pair":       {"DE", "FR"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("zoneHints() unexpected error: %v", err)
+	}
+
+	t.Run("single zone alias chain", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		got, err := resolveZone("HOME", zoneModeFallback, "", hints)
+		if err != nil {
+			t.Fatalf("resolveZone() unexpected error: %v", err)
+		}
+		want := []zoneExpansion{
+			{Kind: "alias", Name: "frankfurt-dc", Zones: []string{"DE"}},
+			{Kind: "alias", Name: "home", Zones: []string{"DE"}},
+		}
+		if got.Zone != "DE" || !reflect.DeepEqual(got.Expansions, want) {
+			t.Fatalf("unexpected resolution: %#v", got)
+		}
+		if got.Reason != "provided by --zone (alias frankfurt-dc -> DE; alias home -> DE)" {
+			t.Fatalf("unexpected reason: %q", got.Reason)
+		}
+	})
+
+	t.Run("nested groups with cloud region", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv(envZonesDefault, "eu-all,PL")
+		got, err := resolveZones("", zoneModeFallback, "", hints)
+		if err != nil {
+			t.Fatalf("resolveZones() unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got.Zones, []string{"DE", "FR", "NL", "IE", "PL"}) || got.Source != "env" {
+			t.Fatalf("unexpected resolution: %#v", got)
+		}
+		last := got.Expansions[len(got.Expansions)-1]
+		if last.Kind != "group" || last.Name != "eu-all" || !reflect.DeepEqual(last.Zones, []string{"DE", "FR", "NL", "IE"}) {
+			t.Fatalf("unexpected expansions: %#v", got.Expansions)
+		}
+		if !reflect.DeepEqual(got.Mappings, []zoneMapping{{From: "aws:eu-west-1", Zone: "IE"}}) {
+			t.Fatalf("unexpected mappings: %#v", got.Mappings)
+		}
+	})
+
+	t.Run("group in single zone command", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		if _, err := resolveZone("", zoneModeFallback, "pair", hints); err == nil {
+			t.Fatalf("expected multi-zone group error for single zone")
+		}
+	})
+}
+
+func TestZoneAliasValidation(t *testing.T) {
+	cycle, err := zoneHints(cgconfig.Shared{
+		ZoneAliases: map[string]string{"a": "b"},
+		ZoneGroups:  map[string][]string{"b": {"DE", "c"}, "c": {"a"}},
+	})
+	if err != nil {
+		t.Fatalf("zoneHints() unexpected error: %v", err)
+	}
+	clearZoneHintEnv(t)
+	if _, err := resolveZones("a", zoneModeFallback, "", cycle); err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+
+	invalid := []cgconfig.Shared{
+		{ZoneAliases: map[string]string{"x": "DE"}, ZoneGroups: map[string][]string{"X": {"FR"}}},
+		{ZoneAliases: map[string]string{"a,b": "DE"}},
+		{ZoneAliases: map[string]string{"x": " "}},
+		{ZoneGroups: map[string][]string{"empty": {}}},
+	}
+	for _, defaults := range invalid {
+		if _, err := zoneHints(defaults); err == nil {
+			t.Fatalf("zoneHints(%+v) expected error", defaults)
+		}
+	}
+}
+
+func zoneTraceStatuses(steps []zoneTraceStep) map[string]string {
+	out := make(map[string]string, len(steps))
+	for _, step := range steps {
+		out[step.Source] = step.Status
+	}
+	return out
+}
+
+// traceZoneResolution runs resolveZoneTraced (or resolveZonesTraced when multi is set) and returns
+// the recorded trace.
+func traceZoneResolution(explicit string, mode string, configValue string, multi bool, hints autoHints) []zoneTraceStep {
+	trace := &zoneTracer{}
+	if multi {
+		_, _ = resolveZonesTraced(explicit, mode, configValue, hints, trace)
+	} else {
+		_, _ = resolveZoneTraced(explicit, mode, configValue, hints, trace)
+	}
+	return trace.steps
+}
+
+func TestZoneResolutionTrace(t *testing.T) {
+	order := []string{"cli", "env", "config", "auto:zone-hint", "auto:country-hint", "auto:timezone-hint", "auto:cloud-metadata", "auto:locale", "auto:tz"}
+
+	t.Run("env wins and later sources are skipped", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv(envZoneDefault, "fr")
+		t.Setenv("LANG", "de_DE.UTF-8")
+		detector := &fakeCloudMetadata{region: cloudmeta.Region{Provider: "aws", Region: "eu-west-1"}}
+		steps := traceZoneResolution("", zoneModeAuto, "DE", false, autoHints{CountryHint: "US", CloudMetadata: detector})
+		sources := make([]string, len(steps))
+		for i, step := range steps {
+			sources[i] = step.Source
+		}
+		if !reflect.DeepEqual(sources, order) {
+			t.Fatalf("sources = %v, expected %v", sources, order)
+		}
+		want := map[string]string{
+			"cli":                 zoneStepUnset,
+			"env":                 zoneStepUsed,
+			"config":              zoneStepSkipped,
+			"auto:zone-hint":      zoneStepUnset,
+			"auto:country-hint":   zoneStepSkipped,
+			"auto:timezone-hint":  zoneStepUnset,
+			"auto:cloud-metadata": zoneStepSkipped,
+			"auto:locale":         zoneStepSkipped,
+			"auto:tz":             zoneStepUnset,
+		}
+		if got := zoneTraceStatuses(steps); !reflect.DeepEqual(got, want) {
+			t.Fatalf("statuses = %v, expected %v", got, want)
+		}
+		if !reflect.DeepEqual(steps[1].Zones, []string{"FR"}) || steps[1].Raw != "fr" || steps[2].Detail != "superseded by env" {
+			t.Fatalf("unexpected steps: %#v", steps[1:3])
+		}
+		if detector.calls != 0 {
+			t.Fatalf("metadata probed %d times after env won", detector.calls)
+		}
+	})
+
+	t.Run("metadata failure falls through to locale narrowed by TZ", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv("LANG", "en_US.UTF-8")
+		t.Setenv(envTimezoneSystem, "America/Phoenix")
+		detector := &fakeCloudMetadata{err: cloudmeta.ErrUnavailable}
+		steps := traceZoneResolution("", zoneModeAuto, "", false, autoHints{CloudMetadata: detector})
+		got := zoneTraceStatuses(steps)
+		if got["auto:cloud-metadata"] != zoneStepNoMatch || got["auto:locale"] != zoneStepUsed || got["auto:tz"] != zoneStepSkipped {
+			t.Fatalf("unexpected statuses: %v", got)
+		}
+		resolved, err := resolveZone("", zoneModeAuto, "", autoHints{CloudMetadata: detector})
+		if err != nil {
+			t.Fatalf("resolveZone() unexpected error: %v", err)
+		}
+		locale := steps[7]
+		if locale.Raw != "en_US.UTF-8" || !reflect.DeepEqual(locale.Zones, []string{resolved.Zone}) || locale.Detail != resolved.Reason {
+			t.Fatalf("locale step %#v does not match resolution %#v", locale, resolved)
+		}
+	})
+
+	t.Run("trace is recorded by the resolution itself", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv("LANG", "de_DE.UTF-8")
+		detector := &fakeCloudMetadata{region: cloudmeta.Region{Provider: "aws", Region: "eu-west-1"}}
+		trace := &zoneTracer{}
+		resolved, err := resolveZonesTraced("", zoneModeAuto, "", autoHints{CloudMetadata: detector}, trace)
+		if err != nil {
+			t.Fatalf("resolveZonesTraced() unexpected error: %v", err)
+		}
+		if detector.calls != 1 {
+			t.Fatalf("metadata probed %d times, expected 1", detector.calls)
+		}
+		if len(trace.steps) != len(order) {
+			t.Fatalf("trace has %d steps, expected %d", len(trace.steps), len(order))
+		}
+		metadata := trace.steps[6]
+		if metadata.Status != zoneStepUsed || metadata.Raw != "aws:eu-west-1" || !reflect.DeepEqual(metadata.Zones, resolved.Zones) || metadata.Detail != resolved.Reason {
+			t.Fatalf("metadata step %#v does not match resolution %#v", metadata, resolved)
+		}
+		if trace.steps[7].Status != zoneStepSkipped || trace.steps[7].Detail != "superseded by auto:cloud-metadata" {
+			t.Fatalf("unexpected locale step: %#v", trace.steps[7])
+		}
+	})
+
+	t.Run("invalid hint stops resolution", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv(envTimezoneHint, "Europe/Berlin")
+		steps := traceZoneResolution("", zoneModeAuto, "", true, autoHints{CountryHint: "ZZ"})
+		got := zoneTraceStatuses(steps)
+		if got["auto:country-hint"] != zoneStepInvalid || got["auto:timezone-hint"] != zoneStepSkipped {
+			t.Fatalf("unexpected statuses: %v", got)
+		}
+		if steps[5].Detail != "superseded by auto:country-hint" {
+			t.Fatalf("unexpected timezone hint detail: %q", steps[5].Detail)
+		}
+	})
+
+	t.Run("strict and fallback modes skip later sources", func(t *testing.T) {
+		clearZoneHintEnv(t)
+		t.Setenv(envZonesDefault, "DE,FR")
+		t.Setenv(envTimezoneSystem, "Europe/Paris")
+		steps := traceZoneResolution("", zoneModeStrict, "", true, autoHints{})
+		if steps[1].Input != envZonesDefault || steps[1].Status != zoneStepSkipped || steps[1].Detail != "not consulted in strict mode" {
+			t.Fatalf("unexpected env step: %#v", steps[1])
+		}
+
+		t.Setenv(envZonesDefault, "")
+		steps = traceZoneResolution("", zoneModeFallback, "", true, autoHints{})
+		if steps[8].Status != zoneStepSkipped || steps[8].Detail != "only consulted in auto mode" {
+			t.Fatalf("unexpected tz step: %#v", steps[8])
+		}
+	})
+}
@@ -0,0 +1,386 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	cgerrors "github.com/chenzhuyu2004/carbon-guard/internal/errors"
+	"github.com/chenzhuyu2004/carbon-guard/pkg"
+)
+
+// Statuses reported in zoneTraceStep.Status.
+// zoneTraceStep.Status 中报告的状态。
+const (
+	zoneStepUsed    = "used"
+	zoneStepSkipped = "skipped"
+	zoneStepUnset   = "unset"
+	zoneStepInvalid = "invalid"
+	zoneStepNoMatch = "no-match"
+)
+
+// ZoneResolveStepOutput is one zone source evaluated by `zones resolve --explain`.
+// ZoneResolveStepOutput 为 `zones resolve --explain` 评估的单个区域来源。
+type ZoneResolveStepOutput struct {
+	Source     string   `json:"source"`
+	Input      string   `json:"input"`
+	Raw        string   `json:"raw,omitempty"`
+	Status     string   `json:"status"`
+	Zones      []string `json:"zones,omitempty"`
+	Confidence string   `json:"confidence,omitempty"`
+	Detail     string   `json:"detail,omitempty"`
+}
+
+type ZonesResolveResult struct {
+	SchemaVersion  string                  `json:"schema_version"`
+	ZoneMode       string                  `json:"zone_mode"`
+	Multi          bool                    `json:"multi"`
+	Zones          []string                `json:"zones,omitempty"`
+	Source         string                  `json:"source,omitempty"`
+	Confidence     string                  `json:"confidence,omitempty"`
+	Reason         string                  `json:"reason,omitempty"`
+	FallbackUsed   bool                    `json:"fallback_used"`
+	Error          string                  `json:"error,omitempty"`
+	ZoneMappings   []ZoneMappingOutput     `json:"zone_mappings,omitempty"`
+	ZoneExpansions []ZoneExpansionOutput   `json:"zone_expansions,omitempty"`
+	Steps          []ZoneResolveStepOutput `json:"steps,omitempty"`
+}
+
+func zones(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return cgerrors.Newf(cgerrors.InputError, "usage: carbon-guard zones resolve [flags]")
+	}
+	switch args[0] {
+	case "resolve":
+		return zonesResolve(args[1:])
+	default:
+		return cgerrors.Newf(cgerrors.InputError, "unknown zones subcommand %q (expected resolve)", args[0])
+	}
+}
+
+func zonesResolve(args []string) error {
+	defaults, err := resolveSharedDefaults(args)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+
+	fs := flag.NewFlagSet("zones resolve", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	addConfigFlag(fs, defaults.ConfigPath)
+	zone := fs.String("zone", "", "electricity maps zone or cloud region (aws:eu-west-1), as passed to suggest/run-aware")
+	zoneList := fs.String("zones", "", "comma-separated zones or cloud regions, as passed to optimize/optimize-global (implies --multi)")
+	multi := fs.Bool("multi", false, "resolve a zone list (optimize/optimize-global) instead of a single zone")
+	zoneMode := fs.String("zone-mode", defaults.ZoneMode, "zone resolution mode: strict|fallback|auto")
+	explain := fs.Bool("explain", false, "show every zone source in resolution order and why it was used or skipped")
+	outputMode := addOutputFlag(fs, defaults.Output)
+
+	if err := fs.Parse(args); err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	if fs.NArg() > 0 {
+		return cgerrors.Newf(cgerrors.InputError, "unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if err := validateOutputMode(*outputMode); err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	if strings.TrimSpace(*zone) != "" && strings.TrimSpace(*zoneList) != "" {
+		return cgerrors.Newf(cgerrors.InputError, "--zone and --zones are mutually exclusive")
+	}
+	mode, err := normalizeZoneMode(*zoneMode)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	hints, err := zoneHints(defaults)
+	if err != nil {
+		return cgerrors.New(err, cgerrors.InputError)
+	}
+	payload := ZonesResolveResult{
+		SchemaVersion: pkg.JSONSchemaVersion,
+		ZoneMode:      mode,
+		Multi:         *multi || strings.TrimSpace(*zoneList) != "",
+	}
+	// The config values are taken before env overrides so the trace reports env and config apart.
+	// 配置取值为环境变量覆盖前的值，使说明能分别报告环境变量与配置来源。
+	var trace *zoneTracer
+	if *explain {
+		trace = &zoneTracer{}
+	}
+	var resolveErr error
+	if payload.Multi {
+		resolved, err := resolveZonesTraced(*zoneList, mode, defaults.FileZones, hints, trace)
+		resolveErr = err
+		payload.Zones = resolved.Zones
+		payload.Source, payload.Confidence, payload.Reason = resolved.Source, resolved.Confidence, resolved.Reason
+		payload.FallbackUsed = resolved.FallbackUsed
+		payload.ZoneMappings = zoneMappingOutputs(resolved.Mappings)
+		payload.ZoneExpansions = zoneExpansionOutputs(resolved.Expansions)
+	} else {
+		resolved, err := resolveZoneTraced(*zone, mode, defaults.FileZone, hints, trace)
+		resolveErr = err
+		if err == nil {
+			payload.Zones = []string{resolved.Zone}
+		}
+		payload.Source, payload.Confidence, payload.Reason = resolved.Source, resolved.Confidence, resolved.Reason
+		payload.FallbackUsed = resolved.FallbackUsed
+		payload.ZoneMappings = zoneMappingOutputs(resolved.Mappings)
+		payload.ZoneExpansions = zoneExpansionOutputs(resolved.Expansions)
+	}
+	if resolveErr != nil {
+		payload.Error = resolveErr.Error()
+	}
+
+	if trace != nil {
+		payload.Steps = zoneResolveStepOutputs(trace.steps)
+	}
+
+	if *outputMode == "json" {
+		data, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return cgerrors.Newf(cgerrors.ProviderError, "failed to serialize zones resolve result")
+		}
+		fmt.Println(string(data))
+	} else {
+		printZonesResolveText(payload)
+	}
+
+	if resolveErr != nil {
+		return cgerrors.New(resolveErr, cgerrors.InputError)
+	}
+	return nil
+}
+
+func printZonesResolveText(result ZonesResolveResult) {
+	if result.Error != "" {
+		fmt.Printf("Resolution failed: %s\n", result.Error)
+	} else {
+		label := "Resolved Zone"
+		if result.Multi {
+			label = "Resolved Zones"
+		}
+		fmt.Printf(
+			"%s: %s (source: %s, confidence: %s, reason: %s, fallback_used: %t)\n",
+			label,
+			strings.Join(result.Zones, ", "),
+			result.Source,
+			result.Confidence,
+			result.Reason,
+			result.FallbackUsed,
+		)
+	}
+	if len(result.Steps) == 0 {
+		return
+	}
+
+	fmt.Printf("\nResolution order (zone-mode=%s):\n", result.ZoneMode)
+	for i, step := range result.Steps {
+		raw := "-"
+		if step.Raw != "" {
+			raw = fmt.Sprintf("%q", step.Raw)
+		}
+		fmt.Printf("%2d. %-20s %-8s %s = %s\n", i+1, step.Source, step.Status, step.Input, raw)
+		if len(step.Zones) > 0 {
+			fmt.Printf("    -> %s (confidence: %s)\n", strings.Join(step.Zones, ", "), step.Confidence)
+		}
+		if step.Detail != "" {
+			fmt.Printf("    %s\n", step.Detail)
+		}
+	}
+}
+
+func zoneResolveStepOutputs(steps []zoneTraceStep) []ZoneResolveStepOutput {
+	out := make([]ZoneResolveStepOutput, len(steps))
+	for i, step := range steps {
+		out[i] = ZoneResolveStepOutput(step)
+	}
+	return out
+}
+
+// zoneTraceStep is the outcome of one source in resolveZone/resolveZones order.
+// zoneTraceStep 为按 resolveZone/resolveZones 顺序评估单个来源的结果。
+type zoneTraceStep struct {
+	Source     string
+	Input      string
+	Raw        string
+	Status     string
+	Zones      []string
+	Confidence string
+	Detail     string
+}
+
+// zoneTraceSources lists every zone source in the order resolveZone/resolveZones consult them.
+// zoneTraceSources 按 resolveZone/resolveZones 的查询顺序列出所有区域来源。
+var zoneTraceSources = []string{"cli", "env", "config", "auto:zone-hint", "auto:country-hint", "auto:timezone-hint", "auto:cloud-metadata", "auto:locale", "auto:tz"}
+
+// zoneNarrowedSources maps a source to the one it only narrows once that one is used.
+// zoneNarrowedSources 将来源映射到其仅用于细化的来源（后者被采用时）。
+var zoneNarrowedSources = map[string]string{
+	"auto:timezone-hint": "auto:country-hint",
+	"auto:tz":            "auto:locale",
+}
+
+// zoneTracer records the sources resolveZoneTraced/resolveZonesTraced consult. Sources the
+// resolver never reaches are filled in with the reason they were passed over; stoppedBy is the
+// source that decided the outcome, either by being used or by failing resolution. All methods are
+// no-ops on a nil tracer.
+// zoneTracer 记录 resolveZoneTraced/resolveZonesTraced 查询的来源。解析未到达的来源会补记其被跳过的原因；
+// stoppedBy 为决定结果的来源（被采用或导致解析失败）。nil tracer 上的所有方法均不执行任何操作。
+type zoneTracer struct {
+	mode      string
+	inputs    map[string]zoneTraceStep
+	steps     []zoneTraceStep
+	stoppedBy string
+}
+
+// begin captures the input name and raw value of every source before resolution starts.
+// begin 在解析开始前记录每个来源的输入名称与原始取值。
+func (t *zoneTracer) begin(mode string, flagName string, explicit string, envName string, configLabel string, configValue string, hints autoHints) {
+	if t == nil {
+		return
+	}
+	localeInput, localeRaw := "LC_ALL/LC_MESSAGES/LANG", ""
+	for _, key := range localeEnvKeys {
+		if value := os.Getenv(key); strings.TrimSpace(value) != "" {
+			localeInput, localeRaw = key, value
+			break
+		}
+	}
+	if locations, err := hints.zoneLocations(); err == nil {
+		if key, _, ok := localeZoneGuess(locations); ok {
+			localeInput, localeRaw = key, os.Getenv(key)
+		}
+	}
+
+	t.mode, t.steps, t.stoppedBy = mode, nil, ""
+	t.inputs = map[string]zoneTraceStep{
+		"cli":                 {Input: flagName, Raw: explicit},
+		"env":                 {Input: envName, Raw: os.Getenv(envName)},
+		"config":              {Input: configLabel, Raw: configValue},
+		"auto:zone-hint":      {Input: "zone_hint/" + envZoneHint, Raw: zoneHintValue(hints)},
+		"auto:country-hint":   {Input: "country_hint/" + envCountryHint, Raw: countryHintValue(hints)},
+		"auto:timezone-hint":  {Input: "timezone_hint/" + envTimezoneHint, Raw: timezoneHintValue(hints)},
+		"auto:cloud-metadata": {Input: "instance metadata"},
+		"auto:locale":         {Input: localeInput, Raw: localeRaw},
+		"auto:tz":             {Input: envTimezoneSystem, Raw: os.Getenv(envTimezoneSystem)},
+	}
+}
+
+// finish reports the sources resolution never reached.
+// finish 报告解析未到达的来源。
+func (t *zoneTracer) finish() {
+	if t == nil {
+		return
+	}
+	t.passOver(len(zoneTraceSources))
+}
+
+// passOver records the sources before zoneTraceSources[until] that were not consulted.
+// passOver 记录 zoneTraceSources[until] 之前未被查询的来源。
+func (t *zoneTracer) passOver(until int) {
+	for _, source := range zoneTraceSources[len(t.steps):until] {
+		step := t.input(source)
+		set := step.Raw != "" || source == "auto:cloud-metadata"
+		switch {
+		case t.stoppedBy != "" && set:
+			step.Status, step.Detail = zoneStepSkipped, "superseded by "+t.stoppedBy
+			if zoneNarrowedSources[source] == t.stoppedBy && t.steps[len(t.steps)-1].Status == zoneStepUsed {
+				step.Detail += " (only used to narrow it)"
+			}
+		case !set:
+			step.Status = zoneStepUnset
+		case t.mode == zoneModeStrict && source != "cli":
+			step.Status, step.Detail = zoneStepSkipped, "not consulted in strict mode"
+		case strings.HasPrefix(source, "auto:") && t.mode != zoneModeAuto:
+			step.Status, step.Detail = zoneStepSkipped, "only consulted in auto mode"
+		default:
+			step.Status, step.Detail = zoneStepSkipped, "not consulted"
+		}
+		t.steps = append(t.steps, step)
+	}
+}
+
+func (t *zoneTracer) input(source string) zoneTraceStep {
+	step := t.inputs[source]
+	step.Source, step.Raw = source, strings.TrimSpace(step.Raw)
+	return step
+}
+
+// add records a consulted source after the sources passed over on the way to it.
+// add 在补记途经的未查询来源后记录一个已查询来源。
+func (t *zoneTracer) add(source string, status string, detail string) *zoneTraceStep {
+	t.passOver(slices.Index(zoneTraceSources, source))
+	step := t.input(source)
+	step.Status, step.Detail = status, detail
+	t.steps = append(t.steps, step)
+	return &t.steps[len(t.steps)-1]
+}
+
+func (t *zoneTracer) used(source string, zones []string, confidence string, detail string) {
+	if t == nil {
+		return
+	}
+	step := t.add(source, zoneStepUsed, detail)
+	step.Zones, step.Confidence = zones, confidence
+	t.stoppedBy = source
+}
+
+// resolved records an auto-mode outcome; err aborts resolution.
+// resolved 记录 auto 模式的结果；err 会中止解析。
+func (t *zoneTracer) resolved(source string, auto resolvedZone, err error) {
+	if err != nil {
+		t.invalid(source, err)
+		return
+	}
+	t.used(source, []string{auto.Zone}, auto.Confidence, auto.Reason)
+}
+
+func (t *zoneTracer) invalid(source string, err error) {
+	if t == nil {
+		return
+	}
+	t.add(source, zoneStepInvalid, err.Error())
+	t.stoppedBy = source
+}
+
+// empty records a source whose value held no zones; unset sources need no call.
+// empty 记录取值中不含区域的来源；未设置的来源无需调用。
+func (t *zoneTracer) empty(source string) {
+	if t == nil || t.input(source).Raw == "" {
+		return
+	}
+	t.add(source, zoneStepUnset, "no zones in value")
+}
+
+// noMatch records a heuristic that found no zone and let resolution fall through.
+// noMatch 记录未找到区域并继续解析的启发式来源。
+func (t *zoneTracer) noMatch(source string, detail string) {
+	if t == nil {
+		return
+	}
+	if source != "auto:cloud-metadata" && t.input(source).Raw == "" {
+		t.add(source, zoneStepUnset, "")
+		return
+	}
+	t.add(source, zoneStepNoMatch, detail)
+}
+
+func (t *zoneTracer) skipped(source string, detail string) {
+	if t == nil {
+		return
+	}
+	t.add(source, zoneStepSkipped, detail)
+}
+
+// probed records the region ID reported by the instance metadata service.
+// probed 记录实例元数据服务报告的 region ID。
+func (t *zoneTracer) probed(regionID string) {
+	if t == nil {
+		return
+	}
+	step := t.inputs["auto:cloud-metadata"]
+	step.Raw = regionID
+	t.inputs["auto:cloud-metadata"] = step
+}
@@ -7,3 +7,13 @@ type App struct {
 func New(provider Provider) *App {
 	return &App{provider: provider}
 }
+
+// historyProvider returns the provider's history capability when it has one.
+// historyProvider 在 provider 支持时返回其历史数据能力。
+func (a *App) historyProvider() (HistoryProvider, bool) {
+	if a == nil || a.provider == nil {
+		return nil, false
+	}
+	history, ok := a.provider.(HistoryProvider)
+	return history, ok
+}
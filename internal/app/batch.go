@@ -0,0 +1,259 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBatchSlot      = 15 * time.Minute
+	defaultBatchLookahead = 24
+)
+
+// BatchJobSpec is one job loaded from a batch jobs file.
+// BatchJobSpec 表示从批量作业文件读取的一个作业。
+//
+// Zero EarliestStart and Deadline leave the job bounded by the plan horizon only.
+// EarliestStart 与 Deadline 为零值时，作业仅受计划范围约束。
+type BatchJobSpec struct {
+	ID            string
+	Duration      int
+	EarliestStart time.Time
+	Deadline      time.Time
+	Zones         []string
+	// Priority ranks jobs; higher values are kept first when capacity runs out.
+	// Priority 为作业优先级；容量不足时优先保留数值更高的作业。
+	Priority int
+}
+
+// CapacityWindowSpec overrides Zone's capacity for slots starting in [Start, End).
+// CapacityWindowSpec 覆盖 Zone 在起点位于 [Start, End) 内的时间槽的容量。
+type CapacityWindowSpec struct {
+	Zone     string
+	Start    time.Time
+	End      time.Time
+	Capacity int
+}
+
+// BatchFile is a decoded batch jobs file.
+// BatchFile 为解析后的批量作业文件。
+type BatchFile struct {
+	Jobs            []BatchJobSpec
+	Capacity        map[string]int
+	CapacityWindows []CapacityWindowSpec
+}
+
+type batchFileJob struct {
+	ID              string   `json:"id"`
+	DurationSeconds int      `json:"duration_seconds"`
+	Duration        string   `json:"duration"`
+	EarliestStart   string   `json:"earliest_start"`
+	Deadline        string   `json:"deadline"`
+	Zones           []string `json:"zones"`
+	Priority        int      `json:"priority"`
+}
+
+type batchFileWindow struct {
+	Zone     string `json:"zone"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Capacity int    `json:"capacity"`
+}
+
+type batchFileRecord struct {
+	Jobs            []batchFileJob    `json:"jobs"`
+	Capacity        map[string]int    `json:"capacity"`
+	CapacityWindows []batchFileWindow `json:"capacity_windows"`
+}
+
+// ParseBatchFile decodes a JSON batch file: an array of jobs or {"jobs", "capacity", "capacity_windows"}.
+// ParseBatchFile 解析 JSON 批量文件：作业数组或 {"jobs", "capacity", "capacity_windows"}。
+//
+// Jobs take duration_seconds or a Go duration string, RFC3339 earliest_start and deadline,
+// zones, and priority. Jobs without zones are left for the caller to fill.
+// 作业字段为 duration_seconds 或 Go 时长字符串、RFC3339 格式的 earliest_start 与 deadline、
+// zones 以及 priority。未设置 zones 的作业由调用方补全。
+func ParseBatchFile(data []byte) (BatchFile, error) {
+	var record batchFileRecord
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &record.Jobs); err != nil {
+			return BatchFile{}, fmt.Errorf("%w: invalid batch json: %v", ErrInput, err)
+		}
+	} else if err := json.Unmarshal(trimmed, &record); err != nil {
+		return BatchFile{}, fmt.Errorf("%w: invalid batch json: %v", ErrInput, err)
+	}
+	if len(record.Jobs) == 0 {
+		return BatchFile{}, fmt.Errorf("%w: batch file has no jobs", ErrInput)
+	}
+
+	file := BatchFile{
+		Jobs:            make([]BatchJobSpec, 0, len(record.Jobs)),
+		Capacity:        record.Capacity,
+		CapacityWindows: make([]CapacityWindowSpec, 0, len(record.CapacityWindows)),
+	}
+	for i, job := range record.Jobs {
+		spec, err := job.spec()
+		if err != nil {
+			return BatchFile{}, fmt.Errorf("%w (job %d)", err, i+1)
+		}
+		file.Jobs = append(file.Jobs, spec)
+	}
+	for i, window := range record.CapacityWindows {
+		spec, err := window.spec()
+		if err != nil {
+			return BatchFile{}, fmt.Errorf("%w (capacity window %d)", err, i+1)
+		}
+		file.CapacityWindows = append(file.CapacityWindows, spec)
+	}
+	return file, nil
+}
+
+func (r batchFileJob) spec() (BatchJobSpec, error) {
+	spec := BatchJobSpec{
+		ID:       strings.TrimSpace(r.ID),
+		Duration: r.DurationSeconds,
+		Priority: r.Priority,
+	}
+	if raw := strings.TrimSpace(r.Duration); raw != "" {
+		if r.DurationSeconds != 0 {
+			return BatchJobSpec{}, fmt.Errorf("%w: set either duration or duration_seconds", ErrInput)
+		}
+		duration, err := time.ParseDuration(raw)
+		if err != nil || duration%time.Second != 0 {
+			return BatchJobSpec{}, fmt.Errorf("%w: invalid duration: %s", ErrInput, raw)
+		}
+		spec.Duration = int(duration / time.Second)
+	}
+	var err error
+	if spec.EarliestStart, err = parseBatchTime("earliest_start", r.EarliestStart); err != nil {
+		return BatchJobSpec{}, err
+	}
+	if spec.Deadline, err = parseBatchTime("deadline", r.Deadline); err != nil {
+		return BatchJobSpec{}, err
+	}
+	for _, zone := range r.Zones {
+		if zone = strings.TrimSpace(zone); zone != "" {
+			spec.Zones = append(spec.Zones, zone)
+		}
+	}
+	return spec, nil
+}
+
+func (r batchFileWindow) spec() (CapacityWindowSpec, error) {
+	start, err := parseBatchTime("start", r.Start)
+	if err != nil {
+		return CapacityWindowSpec{}, err
+	}
+	end, err := parseBatchTime("end", r.End)
+	if err != nil {
+		return CapacityWindowSpec{}, err
+	}
+	return CapacityWindowSpec{Zone: strings.TrimSpace(r.Zone), Start: start, End: end, Capacity: r.Capacity}, nil
+}
+
+func parseBatchTime(field string, raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	ts, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid %s: %s", ErrInput, field, raw)
+	}
+	return ts.UTC(), nil
+}
+
+// batchZones validates jobs and returns their distinct zones in first-seen order.
+// batchZones 校验作业并按首次出现顺序返回其涉及的区域。
+func batchZones(jobs []BatchJobSpec) ([]string, error) {
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("%w: jobs is required", ErrInput)
+	}
+	if len(jobs) > maxBatchJobs {
+		return nil, fmt.Errorf("%w: jobs count must be <= %d", ErrInput, maxBatchJobs)
+	}
+	ids := make(map[string]bool, len(jobs))
+	seen := make(map[string]bool)
+	var zones []string
+	for _, job := range jobs {
+		if job.ID == "" {
+			return nil, fmt.Errorf("%w: job id is required", ErrInput)
+		}
+		if ids[job.ID] {
+			return nil, fmt.Errorf("%w: duplicate job id: %s", ErrInput, job.ID)
+		}
+		ids[job.ID] = true
+		if err := validateDurationSeconds(job.Duration); err != nil {
+			return nil, fmt.Errorf("%w (job %s)", err, job.ID)
+		}
+		if len(job.Zones) == 0 {
+			return nil, fmt.Errorf("%w: job %s has no zones", ErrInput, job.ID)
+		}
+		if !job.EarliestStart.IsZero() && !job.Deadline.IsZero() && !job.EarliestStart.Before(job.Deadline) {
+			return nil, fmt.Errorf("%w: job %s earliest_start must be before deadline", ErrInput, job.ID)
+		}
+		for _, zone := range job.Zones {
+			if !seen[zone] {
+				seen[zone] = true
+				zones = append(zones, zone)
+			}
+		}
+	}
+	if err := validateZones(zones); err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+func validateBatchCapacity(capacity map[string]int, windows []CapacityWindowSpec) error {
+	for zone, value := range capacity {
+		if value < 0 {
+			return fmt.Errorf("%w: capacity for zone %s must be >= 0", ErrInput, zone)
+		}
+	}
+	for _, window := range windows {
+		if window.Zone == "" {
+			return fmt.Errorf("%w: capacity window zone is required", ErrInput)
+		}
+		if window.Capacity < 0 {
+			return fmt.Errorf("%w: capacity window for zone %s must be >= 0", ErrInput, window.Zone)
+		}
+		if window.Start.IsZero() || window.End.IsZero() || !window.Start.Before(window.End) {
+			return fmt.Errorf("%w: capacity window for zone %s needs start before end", ErrInput, window.Zone)
+		}
+	}
+	return nil
+}
+
+func validateBatchSolver(solver string, slot time.Duration) error {
+	solver = strings.TrimSpace(strings.ToLower(solver))
+	if solver != "" && solver != BatchSolverHeuristic && solver != BatchSolverExact {
+		return fmt.Errorf("%w: solver must be one of heuristic|exact", ErrInput)
+	}
+	if slot < 0 || slot%time.Minute != 0 {
+		return fmt.Errorf("%w: slot must be a non-negative whole number of minutes", ErrInput)
+	}
+	if slot > 24*time.Hour {
+		return fmt.Errorf("%w: slot must be <= 24h", ErrInput)
+	}
+	return nil
+}
+
+// batchLookahead derives the forecast hours from the latest deadline when every job has one.
+// batchLookahead 在所有作业均设置截止时间时，据最晚截止时间推导 forecast 小时数。
+func batchLookahead(jobs []BatchJobSpec, now time.Time) int {
+	var latest time.Time
+	for _, job := range jobs {
+		if job.Deadline.IsZero() {
+			return defaultBatchLookahead
+		}
+		if job.Deadline.After(latest) {
+			latest = job.Deadline
+		}
+	}
+	return LookaheadForDeadline(latest, now)
+}
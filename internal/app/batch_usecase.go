@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
+)
+
+// PlanBatch assigns a batch of jobs to zones and start times, minimizing total emission.
+// PlanBatch 为一批作业分配区域与起点，使总排放最小。
+//
+// Starts are aligned to a UTC slot grid from the first slot after now, and each zone runs at
+// most its capacity of jobs in any slot. Higher-priority jobs are kept first when capacity runs
+// out; jobs that cannot be placed are reported with a reason instead of failing the batch.
+// Forecasts are fetched concurrently; a zone without forecast points only leaves its jobs out.
+// 起点对齐到从当前时刻之后首个时间槽开始的 UTC 网格，每个区域在任一时间槽内运行的作业数不超过
+// 其容量。容量不足时优先保留高优先级作业；无法排入的作业附带原因返回，而不会使整批失败。
+// forecast 并发拉取；无 forecast 点的区域只会导致其作业未排入。
+func (a *App) PlanBatch(ctx context.Context, in BatchInput) (BatchOutput, error) {
+	if a == nil || a.provider == nil {
+		return BatchOutput{}, fmt.Errorf("%w: provider is not configured", ErrProvider)
+	}
+	zones, err := batchZones(in.Jobs)
+	if err != nil {
+		return BatchOutput{}, err
+	}
+	if err := validateBatchCapacity(in.Capacity, in.CapacityWindows); err != nil {
+		return BatchOutput{}, err
+	}
+	if err := validateBatchSolver(in.Solver, in.Slot); err != nil {
+		return BatchOutput{}, err
+	}
+	if in.Timeout <= 0 {
+		return BatchOutput{}, fmt.Errorf("%w: timeout must be > 0", ErrInput)
+	}
+	model, err := normalizeModel(in.Model)
+	if err != nil {
+		return BatchOutput{}, err
+	}
+	solver := strings.TrimSpace(strings.ToLower(in.Solver))
+	if solver == "" {
+		solver = BatchSolverHeuristic
+	}
+	slot := in.Slot
+	if slot == 0 {
+		slot = defaultBatchSlot
+	}
+
+	now := time.Now().UTC()
+	lookahead := in.Lookahead
+	if lookahead == 0 {
+		lookahead = batchLookahead(in.Jobs, now)
+	}
+	if err := validateLookaheadHours(lookahead); err != nil {
+		return BatchOutput{}, err
+	}
+	start := now.Truncate(slot)
+	if start.Before(now) {
+		start = start.Add(slot)
+	}
+	end := now.Add(time.Duration(lookahead) * time.Hour)
+
+	ctx, cancel := context.WithTimeout(ctx, in.Timeout)
+	defer cancel()
+
+	evaluators := make(map[string]scheduling.EmissionEvaluator, len(zones))
+	errCh := make(chan error, len(zones))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, zone := range zones {
+		zone := zone
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, evaluator, err := a.loadForecastEvaluator(ctx, zone, lookahead, now, end)
+			if errors.Is(err, ErrNoValidWindow) {
+				return
+			}
+			if err != nil {
+				errCh <- fmt.Errorf("zone %s failed: %w", zone, err)
+				cancel()
+				return
+			}
+			mu.Lock()
+			evaluators[zone] = evaluator
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return BatchOutput{}, fmt.Errorf("%w: operation timed out", ErrTimeout)
+	}
+	// loadForecastEvaluator already classifies errors; the first one is the cause of cancel.
+	// loadForecastEvaluator 已对错误分类；第一个错误即触发取消的原因。
+	for err := range errCh {
+		if errors.Is(err, ErrTimeout) {
+			return BatchOutput{}, fmt.Errorf("%w: operation timed out", ErrTimeout)
+		}
+		return BatchOutput{}, err
+	}
+
+	jobs := make([]scheduling.BatchJob, 0, len(in.Jobs))
+	priorities := make(map[string]int, len(in.Jobs))
+	for _, job := range in.Jobs {
+		jobs = append(jobs, scheduling.BatchJob{
+			ID:            job.ID,
+			Duration:      job.Duration,
+			EarliestStart: job.EarliestStart,
+			Deadline:      job.Deadline,
+			Zones:         job.Zones,
+			Priority:      job.Priority,
+		})
+		priorities[job.ID] = job.Priority
+	}
+	capacity := make(map[string]scheduling.ZoneCapacity, len(zones))
+	for zone, value := range in.Capacity {
+		capacity[zone] = scheduling.ZoneCapacity{Default: value}
+	}
+	for _, window := range in.CapacityWindows {
+		zoneCapacity, ok := capacity[window.Zone]
+		if !ok {
+			zoneCapacity.Default = 1
+		}
+		zoneCapacity.Windows = append(zoneCapacity.Windows, scheduling.CapacityWindow{
+			Start:    window.Start,
+			End:      window.End,
+			Capacity: window.Capacity,
+		})
+		capacity[window.Zone] = zoneCapacity
+	}
+
+	plan, err := scheduling.PlanBatch(jobs, evaluators, capacity, scheduling.BatchOptions{
+		Start:  start,
+		End:    end,
+		Slot:   slot,
+		Exact:  solver == BatchSolverExact,
+		Runner: model.Runner,
+		Load:   model.Load,
+		PUE:    model.PUE,
+	})
+	if err != nil {
+		return BatchOutput{}, fmt.Errorf("%w: %v", ErrInput, err)
+	}
+	if len(plan.Assignments) == 0 {
+		return BatchOutput{}, fmt.Errorf("%w: no job fits its zones, window and capacity between %s and %s", ErrNoValidWindow, formatBound(start), formatBound(end))
+	}
+
+	out := BatchOutput{
+		Solver:          solver,
+		Optimal:         plan.Optimal,
+		SlotSeconds:     int(slot / time.Second),
+		HorizonStartUTC: start,
+		HorizonEndUTC:   end,
+		Assignments:     make([]BatchAssignment, 0, len(plan.Assignments)),
+		Unscheduled:     make([]BatchUnscheduled, 0, len(plan.Unscheduled)),
+		EmissionKg:      plan.Emission,
+	}
+	for _, assignment := range plan.Assignments {
+		out.Assignments = append(out.Assignments, BatchAssignment{
+			JobID:           assignment.JobID,
+			Zone:            assignment.Zone,
+			StartUTC:        assignment.Start.UTC(),
+			EndUTC:          assignment.End.UTC(),
+			DurationSeconds: int(assignment.End.Sub(assignment.Start) / time.Second),
+			Priority:        priorities[assignment.JobID],
+			EmissionKg:      assignment.Emission,
+		})
+	}
+	for _, job := range plan.Unscheduled {
+		out.Unscheduled = append(out.Unscheduled, BatchUnscheduled{JobID: job.JobID, Reason: job.Reason})
+	}
+	return out, nil
+}
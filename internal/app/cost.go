@@ -0,0 +1,313 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
+)
+
+// Price sources reported in CostEstimate.Source.
+// CostEstimate.Source 中报告的电价来源。
+const (
+	PriceSourceStatic   = "static"
+	PriceSourceFile     = "file"
+	PriceSourceProvider = "provider"
+)
+
+const staticPriceWildcard = "*"
+
+// PricePoint is an electricity price per kWh that holds until the next point.
+// PricePoint 为每 kWh 电价，保持到下一个数据点。
+type PricePoint struct {
+	Timestamp   time.Time
+	PricePerKWh float64
+	Currency    string
+}
+
+// PriceInput selects electricity prices: a static price per zone/region, a price series, or
+// provider prices for Zone. Static keys match the run's CI zone, then its region, then "*".
+// PriceInput 选择电价：按区域/region 的静态电价、电价时间序列，或 Zone 的 provider 电价。
+// 静态电价的键依次匹配运行的 CI 区域、region，最后是 "*"。
+type PriceInput struct {
+	Static   map[string]float64
+	Series   []PricePoint
+	Zone     string
+	Currency string
+}
+
+// CostEstimate is the electricity cost of a run (total energy after PUE times price).
+// CostEstimate 为一次运行的电费（PUE 后总能耗乘以电价）。
+type CostEstimate struct {
+	Amount   float64
+	Currency string
+	Source   string
+}
+
+func (p PriceInput) enabled() bool {
+	return len(p.Static) > 0 || len(p.Series) > 0 || strings.TrimSpace(p.Zone) != ""
+}
+
+func validatePriceAndWater(price PriceInput, wue float64) error {
+	if !isFinite(wue) || wue < 0 {
+		return fmt.Errorf("%w: wue must be >= 0", ErrInput)
+	}
+	sources := 0
+	if len(price.Static) > 0 {
+		sources++
+	}
+	if len(price.Series) > 0 {
+		sources++
+	}
+	if strings.TrimSpace(price.Zone) != "" {
+		sources++
+	}
+	if sources > 1 {
+		return fmt.Errorf("%w: static prices, price series, and price zone are mutually exclusive", ErrInput)
+	}
+	for key, value := range price.Static {
+		if !isFinite(value) || value < 0 {
+			return fmt.Errorf("%w: price for %s must be >= 0", ErrInput, key)
+		}
+	}
+	for _, point := range price.Series {
+		if !isFinite(point.PricePerKWh) || point.PricePerKWh < 0 {
+			return fmt.Errorf("%w: price series values must be >= 0", ErrInput)
+		}
+	}
+	return nil
+}
+
+// ParsePriceFile parses a "timestamp,price" CSV (price per kWh) with a header row.
+// ParsePriceFile 解析带表头的 "timestamp,price" CSV（每 kWh 电价）。
+func ParsePriceFile(data []byte) ([]PricePoint, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid price csv header: %v", ErrInput, err)
+	}
+	tsCol, priceCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "timestamp":
+			tsCol = i
+		case "price":
+			priceCol = i
+		}
+	}
+	if tsCol < 0 || priceCol < 0 {
+		return nil, fmt.Errorf("%w: price csv requires timestamp and price columns", ErrInput)
+	}
+
+	var points []PricePoint
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid price csv: %v", ErrInput, err)
+		}
+		if tsCol >= len(row) || priceCol >= len(row) {
+			return nil, fmt.Errorf("%w: missing price csv field on line %d", ErrInput, line)
+		}
+		ts, err := time.Parse(time.RFC3339, strings.TrimSpace(row[tsCol]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid timestamp on line %d: %s", ErrInput, line, row[tsCol])
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(row[priceCol]), 64)
+		if err != nil || !isFinite(price) || price < 0 {
+			return nil, fmt.Errorf("%w: invalid price on line %d: %s", ErrInput, line, row[priceCol])
+		}
+		points = append(points, PricePoint{Timestamp: ts.UTC(), PricePerKWh: price})
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%w: price csv has no rows", ErrInput)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points, nil
+}
+
+// estimateCost prices each segment's total energy at the time-weighted mean price over its span.
+// estimateCost 以各分段时间跨度内的时间加权平均电价为其总能耗计价。
+//
+// Segments without timestamps are laid out back to back from the run start, so an untimestamped
+// run ends now. Energy from power telemetry is priced at the power-weighted mean price instead.
+// 没有时间戳的分段从运行起点开始首尾相接排列，因此无时间戳的运行结束于当前时刻。
+// 来自功率遥测的能耗改用功率加权平均电价计价。
+func (a *App) estimateCost(ctx context.Context, in RunInput, regionCI *RegionCI, spans []segmentSpan) (*CostEstimate, error) {
+	price := in.Price
+	if !price.enabled() {
+		return nil, nil
+	}
+
+	if len(price.Static) > 0 {
+		zone := runZone(in, regionCI)
+		value, ok := staticPrice(price.Static, zone, in.Region)
+		if !ok {
+			return nil, fmt.Errorf("%w: no static price for zone %q or region %q", ErrInput, zone, in.Region)
+		}
+		total := 0.0
+		for _, span := range spans {
+			total += span.EnergyKWh * value
+		}
+		return &CostEstimate{Amount: total, Currency: price.Currency, Source: PriceSourceStatic}, nil
+	}
+
+	series, source := price.Series, PriceSourceFile
+	currency := price.Currency
+	if len(series) == 0 {
+		fetched, err := a.fetchPrices(ctx, price.Zone, spans)
+		if err != nil {
+			return nil, err
+		}
+		series, source = fetched, PriceSourceProvider
+		if len(series) > 0 && series[0].Currency != "" {
+			currency = series[0].Currency
+		}
+	}
+
+	evaluator, ok := priceEvaluator(series)
+	if !ok {
+		return nil, fmt.Errorf("%w: price series is empty", ErrInput)
+	}
+	telemetryPrice := 0.0
+	if len(in.Power.Samples) > 0 {
+		integral, err := integratePower(in.Power.Samples, closeStepSeries(priceForecastPoints(series)))
+		if errors.Is(err, errSeriesCoverage) {
+			return nil, fmt.Errorf("%w: price series does not cover power telemetry", ErrInput)
+		}
+		if err != nil {
+			return nil, err
+		}
+		telemetryPrice = integral.Mean
+	}
+	total := 0.0
+	for _, span := range spans {
+		mean, ok := evaluator.MeanCIAt(span.Start, span.Duration)
+		if !ok {
+			return nil, fmt.Errorf("%w: price series does not cover %s", ErrInput, span.Start.Format(time.RFC3339))
+		}
+		total += (span.EnergyKWh-span.TelemetryKWh)*mean + span.TelemetryKWh*telemetryPrice
+	}
+	return &CostEstimate{Amount: total, Currency: currency, Source: source}, nil
+}
+
+func (a *App) fetchPrices(ctx context.Context, zone string, spans []segmentSpan) ([]PricePoint, error) {
+	if a == nil || a.provider == nil {
+		return nil, fmt.Errorf("%w: price provider is not configured", ErrProvider)
+	}
+	prices, ok := a.provider.(PriceProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w: provider does not support electricity prices", ErrProvider)
+	}
+	start := spans[0].Start
+	end := spans[len(spans)-1].Start.Add(time.Duration(spans[len(spans)-1].Duration) * time.Second)
+	// Include the slot that contains start so the first span is covered.
+	// 向前多取一个时段，确保首个区间被覆盖。
+	points, err := prices.GetPriceSeries(ctx, zone, start.Add(-time.Hour), end)
+	if err != nil {
+		return nil, wrapProviderError(err)
+	}
+	return points, nil
+}
+
+// priceEvaluator reuses the scheduling prefix integral for price means; the last point is held
+// for the preceding cadence (one hour when the series has a single point).
+// priceEvaluator 复用调度模块的前缀积分计算电价均值；最后一个点沿用前一间隔（单点时为一小时）。
+func priceEvaluator(series []PricePoint) (scheduling.EmissionEvaluator, bool) {
+	if len(series) == 0 {
+		return scheduling.EmissionEvaluator{}, false
+	}
+	points := closeStepSeries(priceForecastPoints(series))
+	return scheduling.BuildEmissionEvaluator(points, points[len(points)-1].Timestamp)
+}
+
+// priceForecastPoints carries prices in ForecastPoint.CI so scheduling integrals apply to them.
+// priceForecastPoints 将电价放入 ForecastPoint.CI，以便复用调度模块的积分。
+func priceForecastPoints(series []PricePoint) []scheduling.ForecastPoint {
+	points := make([]scheduling.ForecastPoint, len(series))
+	for i, point := range series {
+		points[i] = scheduling.ForecastPoint{Timestamp: point.Timestamp.UTC(), CI: point.PricePerKWh}
+	}
+	return scheduling.NormalizeForecastUTC(points)
+}
+
+func staticPrice(prices map[string]float64, zone string, region string) (float64, bool) {
+	for _, want := range []string{zone, region, staticPriceWildcard} {
+		want = strings.TrimSpace(want)
+		if want == "" {
+			continue
+		}
+		for key, value := range prices {
+			if strings.EqualFold(strings.TrimSpace(key), want) {
+				return value, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// segmentSpan is one segment placed on the wall clock with its total energy after PUE.
+// segmentSpan 为放置到时间轴上的一个分段及其 PUE 后总能耗。
+type segmentSpan struct {
+	Start     time.Time
+	Duration  int
+	EnergyKWh float64
+	// TelemetryKWh is the part of EnergyKWh measured by power telemetry.
+	// TelemetryKWh 为 EnergyKWh 中由功率遥测测得的部分。
+	TelemetryKWh float64
+}
+
+// segmentSpans splits the run's total energy by segment, mirroring computeSegments: CPU energy
+// follows each segment's power, while measured energy and resource energy are split by duration.
+// segmentSpans 按分段拆分运行总能耗，与 computeSegments 一致：CPU 能耗按各分段功率计算，
+// 实测能耗与资源能耗按时长分摊。
+func segmentSpans(in RunInput, duration int, segments []calculator.Segment, computation runComputation) []segmentSpan {
+	model := in.Model
+	profile := model.powerProfile()
+	nonCPU := computation.Components.Total() - computation.Components.CPUKWh
+
+	starts := make([]time.Time, len(segments))
+	next := runStart(in, duration)
+	for i := range segments {
+		if i < len(in.Segments) && !in.Segments[i].Start.IsZero() && len(in.Power.Samples) == 0 {
+			next = in.Segments[i].Start.UTC()
+		}
+		starts[i] = next
+		next = next.Add(time.Duration(segments[i].Duration) * time.Second)
+	}
+
+	spans := make([]segmentSpan, len(segments))
+	for i, segment := range segments {
+		share := 0.0
+		if duration > 0 {
+			share = float64(segment.Duration) / float64(duration)
+		}
+		cpu := segment.EnergyKWh(profile, model.Load)
+		if in.Measured.enabled() {
+			cpu = computation.Components.CPUKWh * share
+		}
+		spans[i] = segmentSpan{
+			Start:     starts[i],
+			Duration:  segment.Duration,
+			EnergyKWh: (cpu + nonCPU*share) * model.PUE,
+		}
+		if len(in.Power.Samples) > 0 {
+			spans[i].TelemetryKWh = cpu * model.PUE
+		}
+	}
+	return spans
+}
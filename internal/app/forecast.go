@@ -1,6 +1,10 @@
 package app
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
@@ -13,29 +17,158 @@ func resolveEvalStart(evalStart time.Time) time.Time {
 	return evalStart.UTC()
 }
 
-func clipForecastToWindow(
+// clipForecastToRange keeps forecast points with timestamps in [from, to] from sorted points.
+// clipForecastToRange 从已排序的点中保留时间戳位于 [from, to] 内的 forecast 点。
+//
+// The slot in progress at from is kept: the last point before from is moved to from, so the
+// range starts at from rather than at the next slot boundary.
+// 保留 from 时刻正在进行的时段：from 之前的最后一个点被移到 from，使范围从 from 开始，
+// 而不是从下一个时段边界开始。
+func clipForecastToRange(
 	points []scheduling.ForecastPoint,
-	evalStart time.Time,
-	lookahead int,
+	from time.Time,
+	to time.Time,
 ) []scheduling.ForecastPoint {
-	if len(points) == 0 || lookahead <= 0 {
+	if len(points) == 0 || !to.After(from) {
 		return nil
 	}
+	from, to = from.UTC(), to.UTC()
 
-	start := resolveEvalStart(evalStart)
-	limit := start.Add(time.Duration(lookahead) * time.Hour).UTC()
 	clipped := make([]scheduling.ForecastPoint, 0, len(points))
-
-	for _, point := range points {
+	var inProgress *scheduling.ForecastPoint
+	for i, point := range points {
 		ts := point.Timestamp.UTC()
-		if ts.Before(start) || ts.After(limit) {
+		if ts.Before(from) {
+			inProgress = &points[i]
 			continue
 		}
+		if ts.After(to) {
+			continue
+		}
+		if inProgress != nil && ts.After(from) {
+			clipped = append(clipped, scheduling.ForecastPoint{Timestamp: from, CI: inProgress.CI})
+		}
+		inProgress = nil
 		clipped = append(clipped, scheduling.ForecastPoint{
 			Timestamp: ts,
 			CI:        point.CI,
 		})
 	}
+	if inProgress != nil {
+		clipped = append(clipped, scheduling.ForecastPoint{Timestamp: from, CI: inProgress.CI})
+	}
 
 	return clipped
 }
+
+// LookaheadForDeadline returns the whole forecast hours from now needed to reach finishBy.
+// LookaheadForDeadline 返回从 now 到 finishBy 所需的整小时 forecast 范围。
+func LookaheadForDeadline(finishBy time.Time, now time.Time) int {
+	hours := int(math.Ceil(finishBy.Sub(now).Hours()))
+	if hours < 1 {
+		return 1
+	}
+	return hours
+}
+
+// resolveLookahead derives the lookahead from bounds.FinishBy when set, overriding lookahead.
+// A deadline that leaves less than duration seconds after now or NotBefore is ErrNoValidWindow.
+// resolveLookahead 在设置 bounds.FinishBy 时据其推导 lookahead，并覆盖传入值。
+// 若截止时间在 now 或 NotBefore 之后剩余不足 duration 秒，返回 ErrNoValidWindow。
+func resolveLookahead(lookahead int, duration int, bounds WindowBounds, now time.Time) (int, error) {
+	if !bounds.NotBefore.IsZero() && !bounds.FinishBy.IsZero() && !bounds.NotBefore.Before(bounds.FinishBy) {
+		return 0, fmt.Errorf("%w: not-before %s must be before finish-by %s", ErrInput, formatBound(bounds.NotBefore), formatBound(bounds.FinishBy))
+	}
+	if bounds.FinishBy.IsZero() {
+		return lookahead, nil
+	}
+	if !bounds.FinishBy.After(now) {
+		return 0, fmt.Errorf("%w: deadline cannot be met: finish-by %s has already passed", ErrNoValidWindow, formatBound(bounds.FinishBy))
+	}
+	from := now.UTC()
+	if bounds.NotBefore.After(from) {
+		from = bounds.NotBefore.UTC()
+	}
+	if err := checkRangeFits(duration, from, bounds.FinishBy.UTC(), bounds); err != nil {
+		return 0, err
+	}
+	derived := LookaheadForDeadline(bounds.FinishBy, now)
+	if derived > maxLookaheadHours {
+		return 0, fmt.Errorf("%w: finish-by must be within %d hours", ErrInput, maxLookaheadHours)
+	}
+	return derived, nil
+}
+
+// searchRange returns the earliest start and latest end of candidate windows.
+// searchRange 返回候选窗口的最早起点与最晚终点。
+func searchRange(evalStart time.Time, lookahead int, bounds WindowBounds) (time.Time, time.Time) {
+	from := evalStart.UTC()
+	if bounds.NotBefore.After(from) {
+		from = bounds.NotBefore.UTC()
+	}
+	to := evalStart.Add(time.Duration(lookahead) * time.Hour).UTC()
+	if !bounds.FinishBy.IsZero() && bounds.FinishBy.Before(to) {
+		to = bounds.FinishBy.UTC()
+	}
+	return from, to
+}
+
+// checkRangeFits explains why a job of duration seconds cannot fit between the bounds.
+// checkRangeFits 说明 duration 秒的作业为何无法放入边界之间。
+func checkRangeFits(duration int, from time.Time, to time.Time, bounds WindowBounds) error {
+	if bounds.IsZero() || to.Sub(from) >= time.Duration(duration)*time.Second {
+		return nil
+	}
+	return fmt.Errorf("%w: deadline cannot be met: %ds of work does not fit between %s and %s", ErrNoValidWindow, duration, formatBound(from), formatBound(to))
+}
+
+// coverageError reports a forecast that covers less than duration seconds in [from, to].
+// coverageError 报告 [from, to] 内 forecast 覆盖不足 duration 秒的情况。
+func coverageError(duration int, coverage int, from time.Time, to time.Time, bounds WindowBounds) error {
+	if bounds.IsZero() {
+		return fmt.Errorf("%w: forecast does not cover full duration: need %ds but only %ds available", ErrNoValidWindow, duration, coverage)
+	}
+	return fmt.Errorf("%w: deadline cannot be met: forecast covers only %ds between %s and %s but the job needs %ds", ErrNoValidWindow, coverage, formatBound(from), formatBound(to), duration)
+}
+
+func formatBound(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// loadForecastEvaluator fetches, normalizes, and clips one zone's forecast to [from, to]
+// and builds its emission evaluator.
+// loadForecastEvaluator 获取单区域 forecast，归一化并裁剪到 [from, to]，再构建排放评估器。
+func (a *App) loadForecastEvaluator(
+	ctx context.Context,
+	zone string,
+	lookahead int,
+	from time.Time,
+	to time.Time,
+) ([]scheduling.ForecastPoint, scheduling.EmissionEvaluator, error) {
+	forecast, err := a.provider.GetForecastCI(ctx, zone, lookahead)
+	if err != nil {
+		return nil, scheduling.EmissionEvaluator{}, wrapProviderError(err)
+	}
+	forecast = scheduling.NormalizeForecastUTC(forecast)
+	// Clip in app layer so provider remains a pure transport adapter.
+	// 在 app 层裁剪时间窗口，保持 provider 仅负责传输与解析。
+	forecast = clipForecastToRange(forecast, from, to)
+	if len(forecast) == 0 {
+		return nil, scheduling.EmissionEvaluator{}, fmt.Errorf("%w: no forecast points found for zone %s", ErrNoValidWindow, zone)
+	}
+
+	evaluator, ok := scheduling.BuildEmissionEvaluator(forecast, to)
+	if !ok {
+		return nil, scheduling.EmissionEvaluator{}, fmt.Errorf("%w: no forecast points found for zone %s", ErrNoValidWindow, zone)
+	}
+	return forecast, evaluator, nil
+}
+
+// resolveWindowSearch maps app window-search input onto domain search options.
+// resolveWindowSearch 将 app 层窗口搜索输入映射为 domain 搜索选项。
+func resolveWindowSearch(mode string, granularity time.Duration) scheduling.WindowSearchOptions {
+	if strings.TrimSpace(strings.ToLower(mode)) == WindowSearchForecastStarts {
+		return scheduling.WindowSearchOptions{ForecastStartsOnly: true}
+	}
+	return scheduling.WindowSearchOptions{Granularity: granularity}
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+)
+
+// GridMixInput estimates a static CI from generation shares when no CI feed is available.
+// GridMixInput 在没有 CI 数据源时，根据发电结构份额估算静态 CI。
+//
+// Factors are lifecycle emission factors in kgCO2eq/kWh keyed by lower-case fuel name.
+// Factors 为以小写燃料名为键的全生命周期排放因子，单位 kgCO2eq/kWh。
+type GridMixInput struct {
+	Shares  map[string]float64
+	Factors map[string]float64
+}
+
+func (g GridMixInput) enabled() bool {
+	return len(g.Shares) > 0
+}
+
+func validateGridMix(in RunInput) error {
+	if !in.GridMix.enabled() {
+		return nil
+	}
+	if in.SegmentsRaw != "" || len(in.Segments) > 0 || in.LiveZone != "" || len(in.Power.Samples) > 0 {
+		return fmt.Errorf("%w: grid mix cannot be combined with segments, live ci, or power telemetry", ErrInput)
+	}
+	return nil
+}
+
+// gridMixIntensity returns the static CI of the run's grid mix.
+// gridMixIntensity 返回运行发电结构对应的静态 CI。
+func gridMixIntensity(mix GridMixInput) (float64, error) {
+	ci, err := calculator.GridMixIntensity(mix.Shares, mix.Factors)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInput, err)
+	}
+	return ci, nil
+}
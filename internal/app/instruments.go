@@ -0,0 +1,167 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+)
+
+type instrumentRecord struct {
+	Name                   string  `json:"name"`
+	Zone                   string  `json:"zone"`
+	Region                 string  `json:"region"`
+	CoveragePct            float64 `json:"coverage_pct"`
+	EmissionFactorKgPerKWh float64 `json:"emission_factor_kg_per_kwh"`
+	ResidualMixKgPerKWh    float64 `json:"residual_mix_kg_per_kwh"`
+	ValidFrom              string  `json:"valid_from"`
+	ValidTo                string  `json:"valid_to"`
+}
+
+type instrumentsFile struct {
+	Instruments []instrumentRecord `json:"instruments"`
+}
+
+// ParseInstrumentsFile parses a contractual instruments JSON file.
+// ParseInstrumentsFile 解析合同工具 JSON 文件。
+//
+// valid_from/valid_to accept RFC3339 or YYYY-MM-DD; a date-only valid_to includes that whole day.
+// valid_from/valid_to 接受 RFC3339 或 YYYY-MM-DD；仅日期的 valid_to 包含当天全天。
+func ParseInstrumentsFile(data []byte) ([]calculator.ContractualInstrument, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var file instrumentsFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: invalid instruments json: %v", ErrInput, err)
+	}
+
+	instruments := make([]calculator.ContractualInstrument, 0, len(file.Instruments))
+	for i, record := range file.Instruments {
+		instrument, err := record.instrument(i)
+		if err != nil {
+			return nil, err
+		}
+		instruments = append(instruments, instrument)
+	}
+	return instruments, nil
+}
+
+func (r instrumentRecord) instrument(idx int) (calculator.ContractualInstrument, error) {
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		name = fmt.Sprintf("instrument-%d", idx+1)
+	}
+	if strings.TrimSpace(r.Zone) == "" && strings.TrimSpace(r.Region) == "" {
+		return calculator.ContractualInstrument{}, fmt.Errorf("%w: instrument %q requires zone or region", ErrInput, name)
+	}
+	if !isFinite(r.CoveragePct) || r.CoveragePct < 0 || r.CoveragePct > 100 {
+		return calculator.ContractualInstrument{}, fmt.Errorf("%w: instrument %q coverage_pct must be in [0,100]", ErrInput, name)
+	}
+	if !isFinite(r.EmissionFactorKgPerKWh) || r.EmissionFactorKgPerKWh < 0 ||
+		!isFinite(r.ResidualMixKgPerKWh) || r.ResidualMixKgPerKWh < 0 {
+		return calculator.ContractualInstrument{}, fmt.Errorf("%w: instrument %q emission factors must be >= 0", ErrInput, name)
+	}
+
+	instrument := calculator.ContractualInstrument{
+		Name:                   name,
+		Zone:                   strings.TrimSpace(r.Zone),
+		Region:                 strings.TrimSpace(r.Region),
+		CoveragePct:            r.CoveragePct,
+		EmissionFactorKgPerKWh: r.EmissionFactorKgPerKWh,
+		ResidualMixKgPerKWh:    r.ResidualMixKgPerKWh,
+	}
+	var err error
+	if instrument.ValidFrom, err = parseValidityBound(r.ValidFrom, false); err != nil {
+		return calculator.ContractualInstrument{}, fmt.Errorf("%w: instrument %q invalid valid_from: %s", ErrInput, name, r.ValidFrom)
+	}
+	if instrument.ValidTo, err = parseValidityBound(r.ValidTo, true); err != nil {
+		return calculator.ContractualInstrument{}, fmt.Errorf("%w: instrument %q invalid valid_to: %s", ErrInput, name, r.ValidTo)
+	}
+	if !instrument.ValidFrom.IsZero() && !instrument.ValidTo.IsZero() && !instrument.ValidTo.After(instrument.ValidFrom) {
+		return calculator.ContractualInstrument{}, fmt.Errorf("%w: instrument %q valid_to must be after valid_from", ErrInput, name)
+	}
+	return instrument, nil
+}
+
+func parseValidityBound(raw string, end bool) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	day, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day.UTC(), nil
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// marketBased applies contractual instruments to the location-based result of a run.
+// marketBased 将合同工具应用到一次运行的基于位置结果。
+//
+// The run is matched by its CI zone (live, history, or power zone, else the zone its region
+// resolves to) or its static region, at the first timestamp of the run (segments or power
+// telemetry). A run without timestamps is taken to have just finished, so it starts its duration
+// before now.
+// 运行按其 CI 区域（实时、历史或功率区域，否则为 region 解析出的区域）或静态 region 匹配；匹配时刻取运行的首个时间戳
+// （分段或功率遥测）。没有时间戳的运行视为刚刚结束，即起点为当前时间减去其时长。
+func marketBased(in RunInput, result RunResult) *calculator.MarketBasedResult {
+	if len(in.Instruments) == 0 {
+		return nil
+	}
+	market := calculator.EstimateMarketBased(
+		result.EnergyTotalKWh,
+		result.EmissionsKg,
+		in.Instruments,
+		runZone(in, result.RegionCI),
+		in.Region,
+		runStart(in, result.DurationSeconds),
+	)
+	return &market
+}
+
+// runZone returns the run's CI zone, falling back to the zone the static region resolved to
+// (e.g. DE for aws:eu-central-1).
+// runZone 返回运行的 CI 区域；否则回退到静态 region 解析出的区域（如 aws:eu-central-1 对应 DE）。
+func runZone(in RunInput, region *RegionCI) string {
+	for _, zone := range []string{in.LiveZone, in.HistoryZone, in.Power.Zone} {
+		if strings.TrimSpace(zone) != "" {
+			return zone
+		}
+	}
+	if region != nil {
+		return region.Zone
+	}
+	return ""
+}
+
+// runStart returns the first timestamp of the run, or now minus duration for untimestamped runs.
+// runStart 返回运行的首个时间戳；无时间戳时返回当前时间减去 duration。
+func runStart(in RunInput, duration int) time.Time {
+	if len(in.Power.Samples) > 0 {
+		return in.Power.Samples[0].Timestamp.UTC()
+	}
+	var start time.Time
+	for _, spec := range in.Segments {
+		if !spec.Start.IsZero() && (start.IsZero() || spec.Start.Before(start)) {
+			start = spec.Start
+		}
+	}
+	if !start.IsZero() {
+		return start.UTC()
+	}
+	return time.Now().UTC().Add(-time.Duration(duration) * time.Second)
+}
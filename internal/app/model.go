@@ -1,6 +1,11 @@
 package app
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+	"github.com/chenzhuyu2004/carbon-guard/pkg/models"
+)
 
 const (
 	defaultModelRunner = "ubuntu"
@@ -25,5 +30,127 @@ func normalizeModel(model ModelContext) (ModelContext, error) {
 	if model.PUE < 1.0 {
 		return ModelContext{}, fmt.Errorf("%w: pue must be >= 1.0", ErrInput)
 	}
+	if err := validatePowerProfile(model.Power); err != nil {
+		return ModelContext{}, err
+	}
+	if err := validateCoefficients(model.Coefficients); err != nil {
+		return ModelContext{}, err
+	}
 	return model, nil
 }
+
+// validateCoefficients rejects negative coefficients; zero is valid and disables a component.
+// validateCoefficients 拒绝负数系数；0 为合法值，表示关闭对应组件。
+func validateCoefficients(in *calculator.ResourceCoefficients) error {
+	if in == nil {
+		return nil
+	}
+	if in.MemoryKWhPerGBHour < 0 || in.StorageKWhPerGB < 0 || in.NetworkKWhPerGB < 0 {
+		return fmt.Errorf("%w: resource energy coefficients must be >= 0", ErrInput)
+	}
+	return nil
+}
+
+// validatePowerProfile rejects partial overrides: a set profile replaces the runner profile as a
+// whole, so a missing peak would silently model a machine that draws no power.
+// validatePowerProfile 拒绝不完整的覆盖：设置的画像整体替换 runner 画像，
+// 缺少 peak 会悄然建模为不耗电的机器。
+func validatePowerProfile(power *models.PowerProfile) error {
+	if power == nil {
+		return nil
+	}
+	if power.Peak <= 0 {
+		return fmt.Errorf("%w: power profile requires peak watts > 0", ErrInput)
+	}
+	if power.Idle < 0 || power.Peak < power.Idle {
+		return fmt.Errorf("%w: power profile requires 0 <= idle <= peak", ErrInput)
+	}
+	return validateEmbodied(power.Embodied)
+}
+
+func validateEmbodied(embodied models.EmbodiedProfile) error {
+	if embodied.TotalKgCO2e < 0 {
+		return fmt.Errorf("%w: embodied-kg must be >= 0", ErrInput)
+	}
+	// A set share also scales measured host energy, so it is checked without embodied-kg too.
+	// 设置的占用比例也会缩放实测的整机能耗，因此即使未设置 embodied-kg 也要校验。
+	if embodied.Share < 0 || embodied.Share > 1 {
+		return fmt.Errorf("%w: machine-share must be in (0, 1]", ErrInput)
+	}
+	if embodied.TotalKgCO2e == 0 {
+		return nil
+	}
+	if embodied.LifetimeYears <= 0 {
+		return fmt.Errorf("%w: lifetime-years must be > 0 when embodied-kg is set", ErrInput)
+	}
+	if embodied.Share == 0 {
+		return fmt.Errorf("%w: machine-share must be in (0, 1]", ErrInput)
+	}
+	return nil
+}
+
+func validateResourceUsage(usage calculator.ResourceUsage) error {
+	if usage.MemoryGB < 0 {
+		return fmt.Errorf("%w: memory-gb must be >= 0", ErrInput)
+	}
+	if usage.StorageWrittenGB < 0 {
+		return fmt.Errorf("%w: storage-gb must be >= 0", ErrInput)
+	}
+	if usage.NetworkGB < 0 {
+		return fmt.Errorf("%w: network-gb must be >= 0", ErrInput)
+	}
+	return nil
+}
+
+// powerProfile returns the explicit power override, or the runner profile when unset.
+// powerProfile 返回显式功率画像；未设置时使用 runner 画像。
+func (m ModelContext) powerProfile() models.PowerProfile {
+	if m.Power != nil {
+		return *m.Power
+	}
+	return calculator.RunnerProfile(m.Runner)
+}
+
+// machineShare returns the share of the host reserved by the job; unset means the whole host.
+// machineShare 返回作业占用的整机比例；未设置时为整机。
+func (m ModelContext) machineShare() float64 {
+	if share := m.powerProfile().Embodied.Share; share > 0 {
+		return share
+	}
+	return 1
+}
+
+// resourceCoefficients returns the explicit coefficients, or the built-in defaults when unset.
+// resourceCoefficients 返回显式系数；未设置时使用内置默认值。
+func (m ModelContext) resourceCoefficients() calculator.ResourceCoefficients {
+	if m.Coefficients != nil {
+		return *m.Coefficients
+	}
+	return calculator.DefaultResourceCoefficients()
+}
+
+const energySourceModel = "model"
+
+func (m MeasuredEnergy) enabled() bool {
+	return m.CPUKWh > 0
+}
+
+func (m MeasuredEnergy) source() string {
+	if !m.enabled() {
+		return energySourceModel
+	}
+	if m.Source == "" {
+		return "measured"
+	}
+	return m.Source
+}
+
+func validateMeasuredEnergy(m MeasuredEnergy) error {
+	if m.CPUKWh < 0 || m.MemoryKWh < 0 {
+		return fmt.Errorf("%w: measured energy must be >= 0", ErrInput)
+	}
+	if m.MemoryKWh > 0 && m.CPUKWh == 0 {
+		return fmt.Errorf("%w: measured memory energy requires measured cpu energy", ErrInput)
+	}
+	return nil
+}
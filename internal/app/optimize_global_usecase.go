@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,11 +17,11 @@ import (
 //
 // Objective:
 //
-//	score = emission_kg + wait_cost * wait_hours
+//	score = emission_kg + wait_cost * wait_hours + risk_aversion * risk_kg
 //
 // 目标函数：
 //
-//	score = emission_kg + wait_cost * wait_hours
+//	score = emission_kg + wait_cost * wait_hours + risk_aversion * risk_kg
 //
 // Forecast fetching is concurrent, but all scoring uses one UTC anchor (requestStart)
 // to avoid cross-zone drift.
@@ -36,16 +37,27 @@ func (a *App) OptimizeGlobal(ctx context.Context, in OptimizeGlobalInput) (Optim
 	if err := validateDurationSeconds(in.Duration); err != nil {
 		return OptimizeGlobalOutput{}, err
 	}
-	if err := validateLookaheadHours(in.Lookahead); err != nil {
+	requestStart := time.Now().UTC()
+	lookahead, err := resolveLookahead(in.Lookahead, in.Duration, in.Bounds, requestStart)
+	if err != nil {
+		return OptimizeGlobalOutput{}, err
+	}
+	if err := validateLookaheadHours(lookahead); err != nil {
 		return OptimizeGlobalOutput{}, err
 	}
-	if err := validateDurationWithinLookahead(in.Duration, in.Lookahead); err != nil {
+	if err := validateDurationWithinLookahead(in.Duration, lookahead); err != nil {
 		return OptimizeGlobalOutput{}, err
 	}
 	if err := validateWaitCost(in.WaitCost); err != nil {
 		return OptimizeGlobalOutput{}, err
 	}
-	if err := validateResampleConfig(in.ResampleFillMode, in.ResampleMaxFillAge); err != nil {
+	if err := validateResampleConfig(in.ResampleFillMode, in.ResampleMaxFillAge, in.ResampleMaxGap); err != nil {
+		return OptimizeGlobalOutput{}, err
+	}
+	if err := validateWindowSearch(in.WindowSearch, in.StartGranularity); err != nil {
+		return OptimizeGlobalOutput{}, err
+	}
+	if err := validateRisk(in.Risk); err != nil {
 		return OptimizeGlobalOutput{}, err
 	}
 	if in.Timeout <= 0 {
@@ -56,14 +68,19 @@ func (a *App) OptimizeGlobal(ctx context.Context, in OptimizeGlobalInput) (Optim
 		return OptimizeGlobalOutput{}, err
 	}
 
-	requestStart := time.Now().UTC()
-	windowEnd := requestStart.Add(time.Duration(in.Lookahead) * time.Hour).UTC()
+	// from/to narrow the lookahead to the NotBefore/FinishBy bounds when set.
+	// 设置 NotBefore/FinishBy 时，from/to 将 lookahead 收窄到边界内。
+	from, windowEnd := searchRange(requestStart, lookahead, in.Bounds)
+	if err := checkRangeFits(in.Duration, from, windowEnd, in.Bounds); err != nil {
+		return OptimizeGlobalOutput{}, err
+	}
 
 	ctx, cancel := context.WithTimeout(ctx, in.Timeout)
 	defer cancel()
 
 	zoneForecasts := make(map[string][]scheduling.ForecastPoint, len(in.Zones))
-	errCh := make(chan error, len(in.Zones))
+	zoneErrors := make(map[string]error, len(in.Zones))
+	cancelled := make(map[string]bool, len(in.Zones))
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
@@ -73,18 +90,23 @@ func (a *App) OptimizeGlobal(ctx context.Context, in OptimizeGlobalInput) (Optim
 		go func() {
 			defer wg.Done()
 
-			forecast, err := a.provider.GetForecastCI(ctx, zone, in.Lookahead)
+			forecast, err := a.provider.GetForecastCI(ctx, zone, lookahead)
 			if err != nil {
-				errCh <- fmt.Errorf("zone %s failed: %w", zone, wrapProviderError(err))
+				mu.Lock()
+				zoneErrors[zone] = fmt.Errorf("zone %s failed: %w", zone, wrapProviderError(err))
+				cancelled[zone] = errors.Is(err, context.Canceled)
+				mu.Unlock()
 				cancel()
 				return
 			}
 			normalized := scheduling.NormalizeForecastUTC(forecast)
 			// Apply lookahead clipping in app layer for deterministic orchestration.
 			// 在 app 层执行 lookahead 裁剪，保证编排层语义可控且一致。
-			normalized = clipForecastToWindow(normalized, requestStart, in.Lookahead)
+			normalized = clipForecastToRange(normalized, from, windowEnd)
 			if len(normalized) == 0 {
-				errCh <- fmt.Errorf("%w: zone %s failed: no forecast points in lookahead window", ErrNoValidWindow, zone)
+				mu.Lock()
+				zoneErrors[zone] = fmt.Errorf("%w: zone %s failed: no forecast points in lookahead window", ErrNoValidWindow, zone)
+				mu.Unlock()
 				cancel()
 				return
 			}
@@ -95,22 +117,23 @@ func (a *App) OptimizeGlobal(ctx context.Context, in OptimizeGlobalInput) (Optim
 	}
 
 	wg.Wait()
-	close(errCh)
 
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 		return OptimizeGlobalOutput{}, fmt.Errorf("%w: operation timed out", ErrTimeout)
 	}
 
-	for err := range errCh {
-		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) || (errors.Is(err, context.Canceled) && errors.Is(ctx.Err(), context.DeadlineExceeded)) {
-				return OptimizeGlobalOutput{}, fmt.Errorf("%w: operation timed out", ErrTimeout)
-			}
-			if errors.Is(err, ErrNoValidWindow) {
-				return OptimizeGlobalOutput{}, err
-			}
-			return OptimizeGlobalOutput{}, fmt.Errorf("%w: %v", ErrProvider, err)
+	// Report the first failure in zone input order, preferring zones that were not cancelled
+	// because another one failed, so the error does not depend on which fetch finished first.
+	// 按区域输入顺序报告第一个失败，并优先报告未因其他区域失败而被取消的区域，
+	// 使错误与拉取完成顺序无关。
+	if err := firstZoneError(in.Zones, zoneErrors, cancelled); err != nil {
+		if errors.Is(err, ErrTimeout) {
+			return OptimizeGlobalOutput{}, fmt.Errorf("%w: operation timed out", ErrTimeout)
+		}
+		if errors.Is(err, ErrNoValidWindow) {
+			return OptimizeGlobalOutput{}, err
 		}
+		return OptimizeGlobalOutput{}, fmt.Errorf("%w: %v", ErrProvider, err)
 	}
 
 	// Infer axis cadence from data, then apply explicit resample policy.
@@ -127,17 +150,22 @@ func (a *App) OptimizeGlobal(ctx context.Context, in OptimizeGlobalInput) (Optim
 		return OptimizeGlobalOutput{}, fmt.Errorf("%w: no common timestamps across zones", ErrNoValidWindow)
 	}
 
+	search := resolveWindowSearch(in.WindowSearch, in.StartGranularity)
 	evaluators := make(map[string]scheduling.EmissionEvaluator, len(in.Zones))
+	risks := make(map[string]windowRisk, len(in.Zones))
 	for _, zone := range in.Zones {
 		evaluator, ok := scheduling.BuildEmissionEvaluator(alignedForecasts[zone], windowEnd)
 		if !ok {
 			continue
 		}
 		evaluators[zone] = evaluator
+		risks[zone] = newWindowRisk(in.Risk, alignedForecasts[zone], requestStart, windowEnd, in.Duration, model)
 	}
+	candidates := globalCandidates(in.Zones, timeAxis, evaluators, in.Duration, search, requestStart)
 
 	bestFound := false
 	bestEmission := 0.0
+	bestRisk := 0.0
 	bestScore := 0.0
 	bestZone := ""
 	bestStart := time.Time{}
@@ -145,39 +173,43 @@ func (a *App) OptimizeGlobal(ctx context.Context, in OptimizeGlobalInput) (Optim
 	worstScore := 0.0
 	worstFound := false
 
-	for _, start := range timeAxis {
-		for _, zone := range in.Zones {
-			evaluator, ok := evaluators[zone]
-			if !ok {
-				continue
-			}
+	for _, candidate := range candidates {
+		start, zone := candidate.start, candidate.zone
+		// The resampled axis may begin before NotBefore.
+		// 重采样时间轴可能早于 NotBefore。
+		if start.Before(from) {
+			continue
+		}
+		emission, ok := evaluators[zone].EstimateAt(start, in.Duration, model.Runner, model.Load, model.PUE)
+		if !ok {
+			continue
+		}
+		// Negative wait is clamped for safety; only future delay is penalized.
+		// 对负等待时间进行钳制；仅惩罚未来等待。
+		waitHours := maxFloat(start.Sub(requestStart).Hours(), 0)
+		risk := risks[zone].at(start)
+		score := emission + in.WaitCost*waitHours + in.Risk.Aversion*risk
 
-			emission, ok := evaluator.EstimateAt(start.UTC(), in.Duration, model.Runner, model.Load, model.PUE)
-			if !ok {
-				continue
-			}
-			// Negative wait is clamped for safety; only future delay is penalized.
-			// 对负等待时间进行钳制；仅惩罚未来等待。
-			waitHours := maxFloat(start.UTC().Sub(requestStart).Hours(), 0)
-			score := emission + in.WaitCost*waitHours
-
-			if !bestFound || score < bestScore || (score == bestScore && emission < bestEmission) {
-				bestFound = true
-				bestEmission = emission
-				bestScore = score
-				bestZone = zone
-				bestStart = start
-			}
+		if !bestFound || score < bestScore || (score == bestScore && emission < bestEmission) {
+			bestFound = true
+			bestEmission = emission
+			bestRisk = risk
+			bestScore = score
+			bestZone = zone
+			bestStart = start
+		}
 
-			if !worstFound || score > worstScore || (score == worstScore && emission > worstEmission) {
-				worstFound = true
-				worstEmission = emission
-				worstScore = score
-			}
+		if !worstFound || score > worstScore || (score == worstScore && emission > worstEmission) {
+			worstFound = true
+			worstEmission = emission
+			worstScore = score
 		}
 	}
 
 	if !bestFound {
+		if !in.Bounds.IsZero() {
+			return OptimizeGlobalOutput{}, fmt.Errorf("%w: deadline cannot be met: no zone has a full %ds window between %s and %s", ErrNoValidWindow, in.Duration, formatBound(from), formatBound(windowEnd))
+		}
 		return OptimizeGlobalOutput{}, fmt.Errorf("%w: no valid full window found across zones and timestamps", ErrNoValidWindow)
 	}
 
@@ -191,12 +223,71 @@ func (a *App) OptimizeGlobal(ctx context.Context, in OptimizeGlobalInput) (Optim
 		BestStart:                 bestStart.UTC(),
 		BestEnd:                   bestStart.Add(time.Duration(in.Duration) * time.Second).UTC(),
 		Emission:                  bestEmission,
+		Risk:                      bestRisk,
+		Score:                     bestScore,
 		Reduction:                 reduction,
 		ResampleFillMode:          string(resampleOptions.FillMode),
 		ResampleMaxFillAgeSeconds: int64(resampleOptions.MaxFillAge.Round(time.Second).Seconds()),
+		ResampleMaxGapSeconds:     int64(resampleOptions.MaxGap.Round(time.Second).Seconds()),
 	}, nil
 }
 
+func firstZoneError(zones []string, zoneErrors map[string]error, cancelled map[string]bool) error {
+	var fallback error
+	for _, zone := range zones {
+		err := zoneErrors[zone]
+		if err == nil {
+			continue
+		}
+		if !cancelled[zone] {
+			return err
+		}
+		if fallback == nil {
+			fallback = err
+		}
+	}
+	return fallback
+}
+
+type globalCandidate struct {
+	start time.Time
+	zone  string
+}
+
+// globalCandidates lists (start, zone) pairs ordered by start, then by zone input order, so ties
+// resolve to the earliest start as in the legacy time-axis scan.
+// globalCandidates 按起点、再按区域输入顺序列出 (起点, 区域) 组合，使平局时与旧的时间轴扫描一样取最早起点。
+//
+// Legacy search uses the shared time axis; continuous search uses each zone's own breakpoints.
+// 旧搜索方式使用公共时间轴；连续搜索使用各区域自身的分段边界。
+func globalCandidates(
+	zones []string,
+	timeAxis []time.Time,
+	evaluators map[string]scheduling.EmissionEvaluator,
+	duration int,
+	search scheduling.WindowSearchOptions,
+	requestStart time.Time,
+) []globalCandidate {
+	var candidates []globalCandidate
+	for _, zone := range zones {
+		evaluator, ok := evaluators[zone]
+		if !ok {
+			continue
+		}
+		starts := timeAxis
+		if !search.ForecastStartsOnly {
+			starts = evaluator.CandidateStarts(duration, search.Granularity, requestStart)
+		}
+		for _, start := range starts {
+			candidates = append(candidates, globalCandidate{start: start.UTC(), zone: zone})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].start.Before(candidates[j].start)
+	})
+	return candidates
+}
+
 // resolveResampleOptions normalizes CLI/app input into domain resample policy.
 // resolveResampleOptions 将 CLI/app 输入归一化为 domain 重采样策略。
 func resolveResampleOptions(in OptimizeGlobalInput, step time.Duration) scheduling.ResampleOptions {
@@ -210,6 +301,12 @@ func resolveResampleOptions(in OptimizeGlobalInput, step time.Duration) scheduli
 	case string(scheduling.FillModeStrict):
 		options.FillMode = scheduling.FillModeStrict
 		options.MaxFillAge = 0
+	case string(scheduling.FillModeLinear), string(scheduling.FillModeNearest):
+		options.FillMode = scheduling.FillMode(mode)
+		options.MaxGap = in.ResampleMaxGap
+		if options.MaxGap <= 0 {
+			options.MaxGap = scheduling.DefaultResampleMaxGap
+		}
 	default:
 		options.FillMode = scheduling.FillModeForward
 		if in.ResampleMaxFillAge > 0 {
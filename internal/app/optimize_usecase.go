@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -19,15 +20,26 @@ func (a *App) Optimize(ctx context.Context, in OptimizeInput) (OptimizeOutput, e
 	if err := validateDurationSeconds(in.Duration); err != nil {
 		return OptimizeOutput{}, err
 	}
-	if err := validateLookaheadHours(in.Lookahead); err != nil {
+	evalStart := time.Now().UTC()
+	lookahead, err := resolveLookahead(in.Lookahead, in.Duration, in.Bounds, evalStart)
+	if err != nil {
 		return OptimizeOutput{}, err
 	}
-	if err := validateDurationWithinLookahead(in.Duration, in.Lookahead); err != nil {
+	if err := validateLookaheadHours(lookahead); err != nil {
+		return OptimizeOutput{}, err
+	}
+	if err := validateDurationWithinLookahead(in.Duration, lookahead); err != nil {
 		return OptimizeOutput{}, err
 	}
 	if err := validateWaitCost(in.WaitCost); err != nil {
 		return OptimizeOutput{}, err
 	}
+	if err := validateWindowSearch(in.WindowSearch, in.StartGranularity); err != nil {
+		return OptimizeOutput{}, err
+	}
+	if err := validateRisk(in.Risk); err != nil {
+		return OptimizeOutput{}, err
+	}
 	if in.Timeout <= 0 {
 		return OptimizeOutput{}, fmt.Errorf("%w: timeout must be > 0", ErrInput)
 	}
@@ -35,7 +47,7 @@ func (a *App) Optimize(ctx context.Context, in OptimizeInput) (OptimizeOutput, e
 	if err != nil {
 		return OptimizeOutput{}, err
 	}
-	evalStart := time.Now().UTC()
+	search := resolveWindowSearch(in.WindowSearch, in.StartGranularity)
 
 	ctx, cancel := context.WithTimeout(ctx, in.Timeout)
 	defer cancel()
@@ -55,7 +67,7 @@ func (a *App) Optimize(ctx context.Context, in OptimizeInput) (OptimizeOutput, e
 		go func() {
 			defer wg.Done()
 
-			analysis, err := a.AnalyzeBestWindow(ctx, zone, in.Duration, in.Lookahead, evalStart, model, in.WaitCost)
+			analysis, err := a.AnalyzeBestWindow(ctx, zone, in.Duration, lookahead, evalStart, model, in.WaitCost, search, in.Bounds, in.Risk)
 			if err != nil {
 				outcomeCh <- zoneOutcome{zone: zone, err: err}
 				return
@@ -66,6 +78,7 @@ func (a *App) Optimize(ctx context.Context, in OptimizeInput) (OptimizeOutput, e
 				result: ZoneResult{
 					Zone:      zone,
 					Emission:  analysis.BestEmission,
+					Risk:      analysis.BestRisk,
 					Score:     analysis.BestScore,
 					BestStart: analysis.BestStart.UTC(),
 					BestEnd:   analysis.BestEnd.UTC(),
@@ -108,6 +121,8 @@ func (a *App) Optimize(ctx context.Context, in OptimizeInput) (OptimizeOutput, e
 			return OptimizeOutput{}, fmt.Errorf("%w: operation timed out", ErrTimeout)
 		case hadProviderError:
 			return OptimizeOutput{}, fmt.Errorf("%w: all zones failed due provider/api errors", ErrProvider)
+		case hadNoValidWindow && !in.Bounds.IsZero():
+			return OptimizeOutput{}, fmt.Errorf("%w: deadline cannot be met in any zone: %s", ErrNoValidWindow, strings.Join(FormatZoneFailures(failures), "; "))
 		case hadNoValidWindow || len(failures) > 0:
 			return OptimizeOutput{}, fmt.Errorf("%w: no valid window found", ErrNoValidWindow)
 		default:
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"time"
 
 	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
 )
@@ -10,3 +11,15 @@ type Provider interface {
 	GetCurrentCI(ctx context.Context, zone string) (float64, error)
 	GetForecastCI(ctx context.Context, zone string, hours int) ([]scheduling.ForecastPoint, error)
 }
+
+// HistoryProvider is an optional provider capability returning past CI points in [start, end].
+// HistoryProvider 为可选的 provider 能力，返回 [start, end] 内的历史 CI 点。
+type HistoryProvider interface {
+	GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) ([]scheduling.ForecastPoint, error)
+}
+
+// PriceProvider is an optional provider capability returning electricity prices in [start, end].
+// PriceProvider 为可选的 provider 能力，返回 [start, end] 内的电价。
+type PriceProvider interface {
+	GetPriceSeries(ctx context.Context, zone string, start time.Time, end time.Time) ([]PricePoint, error)
+}
@@ -0,0 +1,308 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
+)
+
+// Power telemetry CI sources.
+// 功率遥测对应的 CI 来源。
+const (
+	PowerCISourceSegments = "segments"
+	PowerCISourceHistory  = "history"
+	PowerCISourceForecast = "forecast"
+)
+
+const (
+	energySourcePowerFile = "power-file"
+	wattSecondsPerKWh     = 3600.0 * 1000.0
+	defaultCISlotSeconds  = 3600
+)
+
+// PowerSample is one measured IT power reading, held until the next sample.
+// PowerSample 为一次 IT 功率读数，保持到下一个采样点。
+type PowerSample struct {
+	Timestamp time.Time
+	Watts     float64
+}
+
+// PowerInput is a power time series integrated against a time-aligned CI series.
+// PowerInput 为与对齐后的 CI 时间序列共同积分的功率时间序列。
+//
+// CISource selects the CI series: timestamped segments, provider history, or forecast for Zone.
+// CISource 选择 CI 序列：带时间戳的分段、provider 历史数据或 Zone 的预测数据。
+type PowerInput struct {
+	Samples  []PowerSample
+	CISource string
+	Zone     string
+}
+
+// ParsePowerFile parses a "timestamp,watts" CSV with a header row into sorted samples.
+// ParsePowerFile 将带表头的 "timestamp,watts" CSV 解析为按时间排序的采样点。
+//
+// The last sample marks the end of the series; at least two samples are required.
+// 最后一个采样点标记序列结束；至少需要两个采样点。
+func ParsePowerFile(data []byte) ([]PowerSample, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid power csv header: %v", ErrInput, err)
+	}
+	tsCol, wattsCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "timestamp":
+			tsCol = i
+		case "watts":
+			wattsCol = i
+		}
+	}
+	if tsCol < 0 || wattsCol < 0 {
+		return nil, fmt.Errorf("%w: power csv requires timestamp and watts columns", ErrInput)
+	}
+
+	var samples []PowerSample
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid power csv: %v", ErrInput, err)
+		}
+		if tsCol >= len(row) || wattsCol >= len(row) {
+			return nil, fmt.Errorf("%w: missing power csv field on line %d", ErrInput, line)
+		}
+		ts, err := time.Parse(time.RFC3339, strings.TrimSpace(row[tsCol]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid timestamp on line %d: %s", ErrInput, line, row[tsCol])
+		}
+		watts, err := strconv.ParseFloat(strings.TrimSpace(row[wattsCol]), 64)
+		if err != nil || math.IsNaN(watts) || math.IsInf(watts, 0) || watts < 0 {
+			return nil, fmt.Errorf("%w: invalid watts on line %d: %s", ErrInput, line, row[wattsCol])
+		}
+		samples = append(samples, PowerSample{Timestamp: ts.UTC(), Watts: watts})
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+	if err := validatePowerSamples(samples); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func validatePowerSamples(samples []PowerSample) error {
+	if len(samples) < 2 {
+		return fmt.Errorf("%w: power telemetry requires at least two samples", ErrInput)
+	}
+	for i := 1; i < len(samples); i++ {
+		if !samples[i].Timestamp.After(samples[i-1].Timestamp) {
+			return fmt.Errorf("%w: duplicate power sample timestamp %s", ErrInput, samples[i].Timestamp.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// resolvePowerSeries integrates power telemetry against a CI series over the telemetry span.
+// resolvePowerSeries 在遥测跨度上将功率遥测与 CI 序列共同积分。
+//
+// The result is one segment whose CI is the energy-weighted mean (see integratePower), plus the
+// measured energy.
+// 结果为一个 CI 取能耗加权平均的分段（见 integratePower），以及实测能耗。
+func (a *App) resolvePowerSeries(ctx context.Context, in RunInput) (int, []calculator.Segment, MeasuredEnergy, error) {
+	samples := in.Power.Samples
+	if err := validatePowerSamples(samples); err != nil {
+		return 0, nil, MeasuredEnergy{}, err
+	}
+	if in.SegmentsRaw != "" || in.LiveZone != "" || in.Measured.enabled() {
+		return 0, nil, MeasuredEnergy{}, fmt.Errorf("%w: power telemetry cannot be combined with inline segments, live ci, or measured energy", ErrInput)
+	}
+
+	ciPoints, err := a.powerCISeries(ctx, in)
+	if err != nil {
+		return 0, nil, MeasuredEnergy{}, err
+	}
+	integral, err := integratePower(samples, ciPoints)
+	if errors.Is(err, errSeriesCoverage) {
+		first, last := samples[0].Timestamp.UTC(), samples[len(samples)-1].Timestamp.UTC()
+		return 0, nil, MeasuredEnergy{}, fmt.Errorf("%w: ci series does not cover power telemetry %s..%s", ErrProvider, first.Format(time.RFC3339), last.Format(time.RFC3339))
+	}
+	if err != nil {
+		return 0, nil, MeasuredEnergy{}, err
+	}
+
+	segments := []calculator.Segment{{Duration: integral.Duration, CI: integral.Mean}}
+	measured := MeasuredEnergy{Source: energySourcePowerFile, CPUKWh: integral.EnergyWattSeconds / wattSecondsPerKWh}
+	return integral.Duration, segments, measured, nil
+}
+
+// errSeriesCoverage marks a step series that does not cover the power telemetry.
+// errSeriesCoverage 表示阶梯序列未覆盖功率遥测。
+var errSeriesCoverage = errors.New("series does not cover power telemetry")
+
+// powerIntegral is power telemetry integrated against a step series over the telemetry span.
+// powerIntegral 为功率遥测与阶梯序列在遥测跨度上的积分结果。
+type powerIntegral struct {
+	Duration          int
+	EnergyWattSeconds float64
+	// Mean is the power-weighted mean of the series.
+	// Mean 为序列的功率加权平均值。
+	Mean float64
+}
+
+// integratePower integrates P and P*value over [first sample, last sample] against a closed step series.
+// integratePower 在 [首个采样点, 最后采样点] 上对封闭的阶梯序列积分 P 与 P*value。
+//
+// Both series are piecewise constant, so the integral is summed exactly over their merged
+// breakpoints; no grid is imposed and partial slots at either end are kept.
+// 两个序列均为分段常数，因此在合并后的断点上精确求和；不引入网格，两端不完整的时段均保留。
+func integratePower(samples []PowerSample, values []scheduling.ForecastPoint) (powerIntegral, error) {
+	first, last := samples[0].Timestamp.UTC(), samples[len(samples)-1].Timestamp.UTC()
+	if len(values) < 2 || values[0].Timestamp.After(first) || values[len(values)-1].Timestamp.Before(last) {
+		return powerIntegral{}, errSeriesCoverage
+	}
+
+	var energyWattSeconds, weightedWattSeconds float64
+	v := 0
+	for i := 0; i+1 < len(samples); i++ {
+		from, to := samples[i].Timestamp.UTC(), samples[i+1].Timestamp.UTC()
+		for from.Before(to) {
+			for v+1 < len(values) && !values[v+1].Timestamp.After(from) {
+				v++
+			}
+			until := to
+			if v+1 < len(values) && values[v+1].Timestamp.Before(until) {
+				until = values[v+1].Timestamp
+			}
+			seconds := until.Sub(from).Seconds()
+			energyWattSeconds += samples[i].Watts * seconds
+			weightedWattSeconds += samples[i].Watts * values[v].CI * seconds
+			from = until
+		}
+	}
+	if energyWattSeconds <= 0 {
+		return powerIntegral{}, fmt.Errorf("%w: power telemetry has no energy", ErrInput)
+	}
+	return powerIntegral{
+		Duration:          int(last.Sub(first).Seconds()),
+		EnergyWattSeconds: energyWattSeconds,
+		Mean:              weightedWattSeconds / energyWattSeconds,
+	}, nil
+}
+
+// powerCISeries returns the CI series covering the power telemetry, closed by an end point.
+// powerCISeries 返回覆盖功率遥测的 CI 序列，并以终点封闭。
+func (a *App) powerCISeries(ctx context.Context, in RunInput) ([]scheduling.ForecastPoint, error) {
+	samples := in.Power.Samples
+	first, last := samples[0].Timestamp.UTC(), samples[len(samples)-1].Timestamp.UTC()
+
+	source := strings.TrimSpace(in.Power.CISource)
+	if source == "" {
+		source = PowerCISourceHistory
+		if len(in.Segments) > 0 {
+			source = PowerCISourceSegments
+		}
+	}
+
+	var points []scheduling.ForecastPoint
+	switch source {
+	case PowerCISourceSegments:
+		if len(in.Segments) == 0 {
+			return nil, fmt.Errorf("%w: segments ci source requires a segments file", ErrInput)
+		}
+		for _, spec := range in.Segments {
+			if spec.Start.IsZero() {
+				return nil, fmt.Errorf("%w: segments used with power telemetry must have start timestamps", ErrInput)
+			}
+		}
+		segments, err := a.resolveSegmentSpecs(ctx, in.Segments, in.HistoryZone)
+		if err != nil {
+			return nil, err
+		}
+		specs := make([]SegmentSpec, len(in.Segments))
+		copy(specs, in.Segments)
+		for i := range specs {
+			specs[i].Segment = segments[i]
+		}
+		sort.Slice(specs, func(i, j int) bool { return specs[i].Start.Before(specs[j].Start) })
+		for _, spec := range specs {
+			points = append(points, scheduling.ForecastPoint{Timestamp: spec.Start.UTC(), CI: spec.Segment.CI})
+		}
+		lastSpec := specs[len(specs)-1]
+		end := lastSpec.Start.Add(time.Duration(lastSpec.Segment.Duration) * time.Second)
+		return append(points, scheduling.ForecastPoint{Timestamp: end.UTC(), CI: lastSpec.Segment.CI}), nil
+	case PowerCISourceHistory:
+		if strings.TrimSpace(in.Power.Zone) == "" {
+			return nil, fmt.Errorf("%w: zone is required for history ci", ErrInput)
+		}
+		history, ok := a.historyProvider()
+		if !ok {
+			return nil, fmt.Errorf("%w: ci history provider is not configured", ErrProvider)
+		}
+		fetched, err := history.GetHistoryCI(ctx, in.Power.Zone, first.Add(-time.Hour), last)
+		if err != nil {
+			return nil, wrapProviderError(err)
+		}
+		points = fetched
+	case PowerCISourceForecast:
+		if strings.TrimSpace(in.Power.Zone) == "" {
+			return nil, fmt.Errorf("%w: zone is required for forecast ci", ErrInput)
+		}
+		if a == nil || a.provider == nil {
+			return nil, fmt.Errorf("%w: forecast ci provider is not configured", ErrProvider)
+		}
+		// A forecast only covers the future, so size it from the telemetry's future extent.
+		// forecast 只覆盖未来，因此按遥测在未来的跨度确定 lookahead。
+		ahead := last.Sub(time.Now().UTC())
+		if ahead <= 0 {
+			return nil, fmt.Errorf("%w: forecast ci requires telemetry ending after now (last sample %s)", ErrInput, last.Format(time.RFC3339))
+		}
+		hours := int(math.Ceil(ahead.Hours()))
+		if err := validateLookaheadHours(hours); err != nil {
+			return nil, err
+		}
+		fetched, err := a.provider.GetForecastCI(ctx, in.Power.Zone, hours)
+		if err != nil {
+			return nil, wrapProviderError(err)
+		}
+		points = fetched
+	default:
+		return nil, fmt.Errorf("%w: unsupported power ci source %q", ErrInput, source)
+	}
+
+	points = scheduling.NormalizeForecastUTC(points)
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%w: no ci data for zone %s", ErrProvider, in.Power.Zone)
+	}
+	return closeStepSeries(points), nil
+}
+
+// closeStepSeries appends an end point that holds the last value for the preceding cadence (one
+// hour for a single point). Points describe the slot that follows them.
+// closeStepSeries 追加一个终点，使最后一个值保持前一间隔（单点时为一小时）；数据点描述其后的时段。
+func closeStepSeries(points []scheduling.ForecastPoint) []scheduling.ForecastPoint {
+	if len(points) == 0 {
+		return points
+	}
+	slot := time.Duration(defaultCISlotSeconds) * time.Second
+	if n := len(points); n > 1 {
+		slot = points[n-1].Timestamp.Sub(points[n-2].Timestamp)
+	}
+	lastPoint := points[len(points)-1]
+	return append(points, scheduling.ForecastPoint{Timestamp: lastPoint.Timestamp.Add(slot), CI: lastPoint.CI})
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/catalog"
+)
+
+const defaultRegion = "global"
+
+// RegionCI is the yearly average CI resolved for the static region, with its dataset provenance.
+// RegionCI 为静态 region 解析得到的年均 CI 及其数据集来源。
+type RegionCI struct {
+	Region         string
+	Kind           string
+	Zone           string
+	KgPerKWh       float64
+	DatasetVersion string
+	DatasetYear    int
+}
+
+var loadRegionDataset = sync.OnceValues(catalog.DefaultRegionDataset)
+
+// usesRegionCI reports whether the run falls back to the static region CI.
+// usesRegionCI 判断运行是否回退到静态 region CI。
+func usesRegionCI(in RunInput) bool {
+	return in.SegmentsRaw == "" && len(in.Segments) == 0 && in.LiveZone == "" &&
+		!in.GridMix.enabled() && len(in.Power.Samples) == 0
+}
+
+// resolveRegionCI looks region up in the embedded dataset; an empty region means "global".
+// resolveRegionCI 在内嵌数据集中查找 region；为空时视为 "global"。
+func resolveRegionCI(region string) (*RegionCI, error) {
+	if strings.TrimSpace(region) == "" {
+		region = defaultRegion
+	}
+	dataset, err := loadRegionDataset()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := dataset.Lookup(region)
+	if errors.Is(err, catalog.ErrUnknownRegion) {
+		return nil, fmt.Errorf("%w: %v (dataset %s)", ErrInput, err, dataset.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &RegionCI{
+		Region:         entry.ID,
+		Kind:           entry.Kind,
+		Zone:           entry.Zone,
+		KgPerKWh:       entry.KgPerKWh,
+		DatasetVersion: dataset.Version,
+		DatasetYear:    dataset.Year,
+	}, nil
+}
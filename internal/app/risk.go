@@ -0,0 +1,37 @@
+package app
+
+import (
+	"time"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
+)
+
+// windowRisk prices one zone's forecast error band for windows of a fixed duration.
+// windowRisk 针对固定时长的窗口，对单个区域的预测误差带计价。
+type windowRisk struct {
+	evaluator scheduling.EmissionEvaluator
+	ok        bool
+	duration  int
+	model     ModelContext
+}
+
+// newWindowRisk builds the error band of forecast with lead times measured from anchor.
+// newWindowRisk 以 anchor 为提前量起点构建 forecast 的误差带。
+func newWindowRisk(risk RiskModel, forecast []scheduling.ForecastPoint, anchor time.Time, end time.Time, duration int, model ModelContext) windowRisk {
+	uncertainty := scheduling.HorizonUncertainty{Base: risk.ForecastError, PerHour: risk.ForecastErrorPerHour}
+	if uncertainty.IsZero() {
+		return windowRisk{}
+	}
+	evaluator, ok := scheduling.BuildEmissionEvaluator(uncertainty.ErrorBand(forecast, anchor), end)
+	return windowRisk{evaluator: evaluator, ok: ok, duration: duration, model: model}
+}
+
+// at returns the 1-sigma emission error in kg of the window starting at start.
+// at 返回起点为 start 的窗口的 1-sigma 排放误差（kg）。
+func (w windowRisk) at(start time.Time) float64 {
+	if !w.ok {
+		return 0
+	}
+	risk, _ := w.evaluator.EstimateAt(start, w.duration, w.model.Runner, w.model.Load, w.model.PUE)
+	return risk
+}
@@ -53,7 +53,9 @@ func (a *App) RunAware(ctx context.Context, in RunAwareInput) (RunAwareOutput, e
 	startTime := time.Now().UTC()
 	deadline := startTime.Add(in.MaxWait)
 
-	analysis, err := a.AnalyzeBestWindow(ctx, in.Zone, in.Duration, in.Lookahead, startTime, model, 0)
+	// run-aware keeps forecast-timestamp starts; continuous search covers suggest and optimize*.
+	// run-aware 保持在 forecast 时间戳处起跑；连续搜索仅用于 suggest 与 optimize*。
+	analysis, err := a.AnalyzeBestWindow(ctx, in.Zone, in.Duration, in.Lookahead, startTime, model, 0, scheduling.WindowSearchOptions{ForecastStartsOnly: true}, WindowBounds{}, RiskModel{})
 	if err != nil {
 		return RunAwareOutput{}, err
 	}
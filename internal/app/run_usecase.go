@@ -3,11 +3,8 @@ package app
 import (
 	"context"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
-	"github.com/chenzhuyu2004/carbon-guard/pkg/models"
 )
 
 type runComputation struct {
@@ -15,11 +12,17 @@ type runComputation struct {
 	EmissionsKg     float64
 	EnergyITKWh     float64
 	EnergyTotalKWh  float64
+	Components      calculator.ComponentEnergy
+	EmbodiedKg      float64
 }
 
 func (a *App) Run(ctx context.Context, in RunInput) (RunResult, error) {
-	if err := validateDurationSeconds(in.Duration); err != nil {
-		return RunResult{}, err
+	// Segment inputs carry their own durations, validated after parsing.
+	// 分段输入自带时长，解析后再校验。
+	if in.SegmentsRaw == "" && len(in.Segments) == 0 && len(in.Power.Samples) == 0 {
+		if err := validateDurationSeconds(in.Duration); err != nil {
+			return RunResult{}, err
+		}
 	}
 
 	model, err := normalizeModel(in.Model)
@@ -27,80 +30,176 @@ func (a *App) Run(ctx context.Context, in RunInput) (RunResult, error) {
 		return RunResult{}, err
 	}
 	in.Model = model
+	if err := validateResourceUsage(in.Resources); err != nil {
+		return RunResult{}, err
+	}
+
+	if err := validateMeasuredEnergy(in.Measured); err != nil {
+		return RunResult{}, err
+	}
+	if err := validatePriceAndWater(in.Price, in.WUE); err != nil {
+		return RunResult{}, err
+	}
+	if err := validateGridMix(in); err != nil {
+		return RunResult{}, err
+	}
+	uncertainty, err := normalizeUncertainty(in.Uncertainty, in.Model)
+	if err != nil {
+		return RunResult{}, err
+	}
+
+	var regionCI *RegionCI
+	if usesRegionCI(in) {
+		if regionCI, err = resolveRegionCI(in.Region); err != nil {
+			return RunResult{}, err
+		}
+	}
 
-	computation, err := a.calculateEmissions(ctx, in)
+	var (
+		duration int
+		segments []calculator.Segment
+	)
+	if len(in.Power.Samples) > 0 {
+		duration, segments, in.Measured, err = a.resolvePowerSeries(ctx, in)
+	} else {
+		duration, segments, err = a.resolveSegments(ctx, in, regionCI)
+	}
 	if err != nil {
 		return RunResult{}, err
 	}
+	computation := computeSegments(duration, segments, in.Model, in.Resources, in.Measured)
 	effectiveCI := 0.0
 	if computation.EnergyTotalKWh > 0 {
 		effectiveCI = computation.EmissionsKg / computation.EnergyTotalKWh
 	}
 
-	return RunResult{
+	result := RunResult{
 		DurationSeconds:     computation.DurationSeconds,
 		EmissionsKg:         computation.EmissionsKg,
 		EnergyITKWh:         computation.EnergyITKWh,
 		EnergyTotalKWh:      computation.EnergyTotalKWh,
 		EffectiveCIKgPerKWh: effectiveCI,
-	}, nil
+		EnergyCPUKWh:        computation.Components.CPUKWh,
+		EnergyMemoryKWh:     computation.Components.MemoryKWh,
+		EnergyStorageKWh:    computation.Components.StorageKWh,
+		EnergyNetworkKWh:    computation.Components.NetworkKWh,
+		EmbodiedEmissionsKg: computation.EmbodiedKg,
+		TotalEmissionsKg:    computation.EmissionsKg + computation.EmbodiedKg,
+		EnergySource:        in.Measured.source(),
+		RegionCI:            regionCI,
+	}
+	result.MarketBased = marketBased(in, result)
+	result.WaterLiters = result.EnergyITKWh * in.WUE
+	spans := segmentSpans(in, duration, segments, computation)
+	if result.Cost, err = a.estimateCost(ctx, in, regionCI, spans); err != nil {
+		return RunResult{}, err
+	}
+	if uncertainty.enabled() {
+		percentiles := simulateEmissions(duration, segments, in.Model, in.Resources, in.Measured, uncertainty)
+		result.Uncertainty = &percentiles
+		result.UncertaintySamples = uncertainty.Samples
+		result.UncertaintySeed = uncertainty.Seed
+	}
+	return result, nil
 }
 
-func (a *App) calculateEmissions(ctx context.Context, in RunInput) (runComputation, error) {
-	if in.SegmentsRaw != "" {
-		segments, err := parseSegments(in.SegmentsRaw)
+// resolveSegments returns the CI timeline from explicit segments, live CI, the grid mix, or the
+// static region.
+// resolveSegments 从显式分段、实时 CI、发电结构或静态区域得到 CI 时间线。
+func (a *App) resolveSegments(ctx context.Context, in RunInput, regionCI *RegionCI) (int, []calculator.Segment, error) {
+	if len(in.Segments) > 0 || in.SegmentsRaw != "" {
+		var (
+			segments []calculator.Segment
+			err      error
+		)
+		if len(in.Segments) > 0 {
+			segments, err = a.resolveSegmentSpecs(ctx, in.Segments, in.HistoryZone)
+		} else {
+			segments, err = parseSegments(in.SegmentsRaw)
+		}
 		if err != nil {
-			return runComputation{}, err
+			return 0, nil, err
 		}
 		duration := sumSegmentDurations(segments)
 		if err := validateDurationSeconds(duration); err != nil {
-			return runComputation{}, err
+			return 0, nil, err
 		}
-		energyIT, energyTotal := estimateEnergyKWh(duration, in.Model.Runner, in.Model.Load, in.Model.PUE)
-		return runComputation{
-			DurationSeconds: duration,
-			EmissionsKg:     calculator.EstimateEmissionsWithSegments(segments, in.Model.Runner, in.Model.Load, in.Model.PUE),
-			EnergyITKWh:     energyIT,
-			EnergyTotalKWh:  energyTotal,
-		}, nil
+		return duration, segments, nil
 	}
 
 	if in.LiveZone != "" {
 		if a == nil || a.provider == nil {
-			return runComputation{}, fmt.Errorf("%w: live ci provider is not configured", ErrProvider)
+			return 0, nil, fmt.Errorf("%w: live ci provider is not configured", ErrProvider)
 		}
 		ciValue, err := a.provider.GetCurrentCI(ctx, in.LiveZone)
 		if err != nil {
-			return runComputation{}, wrapProviderError(err)
+			return 0, nil, wrapProviderError(err)
 		}
-		segments := []calculator.Segment{{Duration: in.Duration, CI: ciValue}}
-		energyIT, energyTotal := estimateEnergyKWh(in.Duration, in.Model.Runner, in.Model.Load, in.Model.PUE)
-		return runComputation{
-			DurationSeconds: in.Duration,
-			EmissionsKg:     calculator.EstimateEmissionsWithSegments(segments, in.Model.Runner, in.Model.Load, in.Model.PUE),
-			EnergyITKWh:     energyIT,
-			EnergyTotalKWh:  energyTotal,
-		}, nil
+		return in.Duration, []calculator.Segment{{Duration: in.Duration, CI: ciValue}}, nil
 	}
 
-	energyIT, energyTotal := estimateEnergyKWh(in.Duration, in.Model.Runner, in.Model.Load, in.Model.PUE)
-	return runComputation{
-		DurationSeconds: in.Duration,
-		EmissionsKg:     calculator.EstimateEmissionsAdvanced(in.Duration, in.Model.Runner, in.Region, in.Model.Load, in.Model.PUE),
-		EnergyITKWh:     energyIT,
-		EnergyTotalKWh:  energyTotal,
-	}, nil
+	if in.GridMix.enabled() {
+		ciValue, err := gridMixIntensity(in.GridMix)
+		if err != nil {
+			return 0, nil, err
+		}
+		return in.Duration, []calculator.Segment{{Duration: in.Duration, CI: ciValue}}, nil
+	}
+
+	return in.Duration, []calculator.Segment{{Duration: in.Duration, CI: regionCI.KgPerKWh}}, nil
 }
 
-func estimateEnergyKWh(duration int, runner string, load float64, pue float64) (float64, float64) {
-	profile, ok := models.RunnerProfiles[runner]
-	if !ok {
-		profile = models.RunnerProfiles["ubuntu"]
+// computeSegments combines CPU segment emissions with optional resource components.
+// computeSegments 将 CPU 分段排放与可选资源组件合并计算。
+//
+// Resource components carry no time profile, so they use the duration-weighted mean CI.
+// 资源组件没有时间分布，因此使用按时长加权的平均 CI。
+func computeSegments(
+	duration int,
+	segments []calculator.Segment,
+	model ModelContext,
+	resources calculator.ResourceUsage,
+	measured MeasuredEnergy,
+) runComputation {
+	profile := model.powerProfile()
+	cpuIT := calculator.SegmentsEnergyKWh(segments, profile, model.Load)
+	memory, storage, network := calculator.EstimateResourceEnergyKWh(duration, resources, model.resourceCoefficients())
+
+	var emissions float64
+	if measured.enabled() {
+		// Counters carry no per-segment split, so measured energy uses the mean CI. They also
+		// measure the whole host, so only the job's machine share is attributed to it.
+		// 计数器没有分段拆分，因此实测能耗使用平均 CI；计数器测量的是整机，
+		// 因此只将作业占用的整机比例计入。
+		share := model.machineShare()
+		cpuIT = measured.CPUKWh * share
+		if measured.MemoryKWh > 0 {
+			memory = measured.MemoryKWh * share
+		}
+		emissions = (cpuIT + memory + storage + network) * model.PUE * calculator.WeightedMeanCI(segments)
+	} else {
+		emissions = calculator.EstimateEmissionsWithProfile(segments, profile, model.Load, model.PUE)
+		if resourceIT := memory + storage + network; resourceIT > 0 {
+			emissions += resourceIT * model.PUE * calculator.WeightedMeanCI(segments)
+		}
+	}
+
+	components := calculator.ComponentEnergy{
+		CPUKWh:     cpuIT,
+		MemoryKWh:  memory,
+		StorageKWh: storage,
+		NetworkKWh: network,
 	}
 
-	power := profile.Idle + (profile.Peak-profile.Idle)*load
-	energyIT := float64(duration) * power / 1000.0 / 3600.0
-	return energyIT, energyIT * pue
+	energyIT := components.Total()
+	return runComputation{
+		DurationSeconds: duration,
+		EmissionsKg:     emissions,
+		EnergyITKWh:     energyIT,
+		EnergyTotalKWh:  energyIT * model.PUE,
+		Components:      components,
+		EmbodiedKg:      calculator.EstimateEmbodiedKg(duration, profile.Embodied),
+	}
 }
 
 func sumSegmentDurations(segments []calculator.Segment) int {
@@ -110,33 +209,3 @@ func sumSegmentDurations(segments []calculator.Segment) int {
 	}
 	return total
 }
-
-func parseSegments(raw string) ([]calculator.Segment, error) {
-	items := strings.Split(raw, ",")
-	segments := make([]calculator.Segment, 0, len(items))
-
-	for _, item := range items {
-		item = strings.TrimSpace(item)
-		parts := strings.Split(item, ":")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("%w: invalid segment format: %s", ErrInput, item)
-		}
-
-		duration, err := strconv.Atoi(strings.TrimSpace(parts[0]))
-		if err != nil || duration <= 0 {
-			return nil, fmt.Errorf("%w: invalid segment duration: %s", ErrInput, parts[0])
-		}
-
-		ci, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
-		if err != nil || ci <= 0 {
-			return nil, fmt.Errorf("%w: invalid segment ci: %s", ErrInput, parts[1])
-		}
-
-		segments = append(segments, calculator.Segment{
-			Duration: duration,
-			CI:       ci,
-		})
-	}
-
-	return segments, nil
-}
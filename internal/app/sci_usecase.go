@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const defaultFunctionalUnit = "unit"
+
+// SCI computes the Software Carbon Intensity score on top of a run estimate.
+// SCI 在 run 估算结果之上计算软件碳强度（SCI）评分。
+//
+// E is facility energy (IT energy * PUE), I the effective CI, M amortized embodied emissions
+// and R the functional unit count, so E*I equals the run's operational emissions.
+// E 为设施能耗（IT 能耗 * PUE），I 为有效碳强度，M 为摊销隐含排放，R 为功能单元数量，
+// 因此 E*I 等于 run 的运行期排放。
+func (a *App) SCI(ctx context.Context, in SCIInput) (SCIResult, error) {
+	if in.FunctionalUnits <= 0 || math.IsNaN(in.FunctionalUnits) || math.IsInf(in.FunctionalUnits, 0) {
+		return SCIResult{}, fmt.Errorf("%w: functional units must be a finite number > 0", ErrInput)
+	}
+	unit := strings.TrimSpace(in.FunctionalUnit)
+	if unit == "" {
+		unit = defaultFunctionalUnit
+	}
+
+	run, err := a.Run(ctx, in.Run)
+	if err != nil {
+		return SCIResult{}, err
+	}
+
+	operational := run.EnergyTotalKWh * run.EffectiveCIKgPerKWh
+	total := operational + run.EmbodiedEmissionsKg
+	return SCIResult{
+		Run:             run,
+		EnergyKWh:       run.EnergyTotalKWh,
+		CarbonIntensity: run.EffectiveCIKgPerKWh,
+		OperationalKg:   operational,
+		EmbodiedKg:      run.EmbodiedEmissionsKg,
+		TotalKg:         total,
+		FunctionalUnits: in.FunctionalUnits,
+		FunctionalUnit:  unit,
+		SCIKgPerUnit:    total / in.FunctionalUnits,
+	}, nil
+}
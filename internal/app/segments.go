@@ -0,0 +1,374 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
+	"github.com/chenzhuyu2004/carbon-guard/pkg/models"
+)
+
+// SegmentSpec is one run segment loaded from a segments file.
+// SegmentSpec 表示从分段文件读取的一个运行分段。
+//
+// A zero CI with a non-zero Start is filled from provider history.
+// CI 为零且 Start 非零时，会使用 provider 历史数据填充。
+type SegmentSpec struct {
+	Start   time.Time
+	Segment calculator.Segment
+}
+
+type segmentFileRecord struct {
+	Start           string   `json:"start"`
+	End             string   `json:"end"`
+	DurationSeconds int      `json:"duration_seconds"`
+	CI              float64  `json:"ci"`
+	Load            *float64 `json:"load"`
+	Runner          string   `json:"runner"`
+}
+
+type segmentFile struct {
+	Segments []segmentFileRecord `json:"segments"`
+}
+
+// parseSegments parses "duration:ci[:load[:runner]]" items separated by commas.
+// parseSegments 解析以逗号分隔的 "duration:ci[:load[:runner]]" 分段。
+func parseSegments(raw string) ([]calculator.Segment, error) {
+	items := strings.Split(raw, ",")
+	segments := make([]calculator.Segment, 0, len(items))
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 || len(parts) > 4 {
+			return nil, fmt.Errorf("%w: invalid segment format: %s", ErrInput, item)
+		}
+
+		duration, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("%w: invalid segment duration: %s", ErrInput, parts[0])
+		}
+
+		ci, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || ci <= 0 {
+			return nil, fmt.Errorf("%w: invalid segment ci: %s", ErrInput, parts[1])
+		}
+
+		segment := calculator.Segment{
+			Duration: duration,
+			CI:       ci,
+		}
+		if len(parts) >= 3 {
+			load, err := parseSegmentLoad(parts[2])
+			if err != nil {
+				return nil, err
+			}
+			segment.Load = load
+			segment.HasLoad = true
+		}
+		if len(parts) == 4 {
+			runner, err := parseSegmentRunner(parts[3])
+			if err != nil {
+				return nil, err
+			}
+			segment.Runner = runner
+		}
+		segments = append(segments, segment)
+	}
+
+	return segments, nil
+}
+
+func parseSegmentLoad(raw string) (float64, error) {
+	load, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || load < 0 || load > 1 {
+		return 0, fmt.Errorf("%w: invalid segment load: %s", ErrInput, raw)
+	}
+	return load, nil
+}
+
+func parseSegmentRunner(raw string) (string, error) {
+	runner := strings.ToLower(strings.TrimSpace(raw))
+	if _, ok := models.RunnerProfiles[runner]; !ok {
+		return "", fmt.Errorf("%w: unknown segment runner: %s", ErrInput, raw)
+	}
+	return runner, nil
+}
+
+// NeedsHistoryCI reports whether the segment's CI is filled from provider history.
+// NeedsHistoryCI 报告该分段的 CI 是否需要由 provider 历史数据补齐。
+func (s SegmentSpec) NeedsHistoryCI() bool {
+	return s.Segment.CI == 0
+}
+
+// ParseSegmentsFile decodes a JSON or CSV segments file; the format follows the file extension.
+// ParseSegmentsFile 解析 JSON 或 CSV 分段文件；格式由文件扩展名决定。
+//
+// JSON accepts either an array of records or {"segments": [...]}. CSV requires a header with
+// any of: start, end, duration_seconds, ci, load, runner. Timestamps are RFC3339.
+// JSON 可为记录数组或 {"segments": [...]}。CSV 需要表头，列可取 start、end、
+// duration_seconds、ci、load、runner。时间戳为 RFC3339。
+func ParseSegmentsFile(name string, data []byte) ([]SegmentSpec, error) {
+	var (
+		records []segmentFileRecord
+		err     error
+	)
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		records, err = decodeSegmentsJSON(data)
+	case ".csv":
+		records, err = decodeSegmentsCSV(data)
+	default:
+		return nil, fmt.Errorf("%w: segments file must be .json or .csv: %s", ErrInput, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: segments file has no segments", ErrInput)
+	}
+
+	specs := make([]SegmentSpec, 0, len(records))
+	for i, record := range records {
+		spec, err := record.spec()
+		if err != nil {
+			return nil, fmt.Errorf("%w (segment %d)", err, i+1)
+		}
+		specs = append(specs, spec)
+	}
+	return orderSegmentSpecs(specs)
+}
+
+// orderSegmentSpecs sorts fully timestamped segments by start and rejects overlaps, which would
+// count the shared time twice. With mixed rows, an untimestamped segment follows the previous one,
+// so the file order is kept and each start must not fall before the previous segment ends.
+// orderSegmentSpecs 将全部带时间戳的分段按起点排序并拒绝重叠（重叠时间会被重复计算）。
+// 混合时无时间戳的分段紧随前一分段，因此保持文件顺序，且每个起点不得早于前一分段的结束时间。
+func orderSegmentSpecs(specs []SegmentSpec) ([]SegmentSpec, error) {
+	timestamped := true
+	for _, spec := range specs {
+		if spec.Start.IsZero() {
+			timestamped = false
+			break
+		}
+	}
+	if timestamped {
+		sort.SliceStable(specs, func(i, j int) bool {
+			return specs[i].Start.Before(specs[j].Start)
+		})
+	}
+
+	// end stays zero until the first timestamped segment anchors the timeline.
+	// 在首个带时间戳的分段确定时间轴之前，end 保持为零值。
+	var end time.Time
+	for _, spec := range specs {
+		start := spec.Start
+		switch {
+		case start.IsZero():
+			start = end
+		case !end.IsZero() && start.Before(end):
+			return nil, fmt.Errorf("%w: segment starting %s overlaps the previous segment ending %s", ErrInput, start.Format(time.RFC3339), end.Format(time.RFC3339))
+		}
+		if !start.IsZero() {
+			end = start.Add(time.Duration(spec.Segment.Duration) * time.Second)
+		}
+	}
+	return specs, nil
+}
+
+func decodeSegmentsJSON(data []byte) ([]segmentFileRecord, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var records []segmentFileRecord
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, fmt.Errorf("%w: invalid segments json: %v", ErrInput, err)
+		}
+		return records, nil
+	}
+	var file segmentFile
+	if err := json.Unmarshal(trimmed, &file); err != nil {
+		return nil, fmt.Errorf("%w: invalid segments json: %v", ErrInput, err)
+	}
+	return file.Segments, nil
+}
+
+func decodeSegmentsCSV(data []byte) ([]segmentFileRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid segments csv header: %v", ErrInput, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	field := func(row []string, name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	var records []segmentFileRecord
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid segments csv: %v", ErrInput, err)
+		}
+
+		record := segmentFileRecord{
+			Start:  field(row, "start"),
+			End:    field(row, "end"),
+			Runner: field(row, "runner"),
+		}
+		if raw := field(row, "duration_seconds"); raw != "" {
+			record.DurationSeconds, err = strconv.Atoi(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid duration_seconds on line %d: %s", ErrInput, line, raw)
+			}
+		}
+		if raw := field(row, "ci"); raw != "" {
+			record.CI, err = strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid ci on line %d: %s", ErrInput, line, raw)
+			}
+		}
+		if raw := field(row, "load"); raw != "" {
+			load, err := parseSegmentLoad(raw)
+			if err != nil {
+				return nil, err
+			}
+			record.Load = &load
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (r segmentFileRecord) spec() (SegmentSpec, error) {
+	var spec SegmentSpec
+	if r.Start != "" {
+		start, err := time.Parse(time.RFC3339, r.Start)
+		if err != nil {
+			return SegmentSpec{}, fmt.Errorf("%w: invalid segment start: %s", ErrInput, r.Start)
+		}
+		spec.Start = start.UTC()
+	}
+
+	duration := r.DurationSeconds
+	if r.End != "" {
+		if spec.Start.IsZero() {
+			return SegmentSpec{}, fmt.Errorf("%w: segment end requires start", ErrInput)
+		}
+		end, err := time.Parse(time.RFC3339, r.End)
+		if err != nil {
+			return SegmentSpec{}, fmt.Errorf("%w: invalid segment end: %s", ErrInput, r.End)
+		}
+		fromEnd := int(end.UTC().Sub(spec.Start).Seconds())
+		if duration != 0 && duration != fromEnd {
+			return SegmentSpec{}, fmt.Errorf("%w: segment duration_seconds %d does not match start/end (%ds)", ErrInput, duration, fromEnd)
+		}
+		duration = fromEnd
+	}
+	if duration <= 0 {
+		return SegmentSpec{}, fmt.Errorf("%w: segment duration must be > 0", ErrInput)
+	}
+
+	if r.CI < 0 || (r.CI == 0 && spec.Start.IsZero()) {
+		return SegmentSpec{}, fmt.Errorf("%w: segment ci must be > 0 unless start is set for history fill", ErrInput)
+	}
+
+	spec.Segment = calculator.Segment{Duration: duration, CI: r.CI}
+	if r.Load != nil {
+		if *r.Load < 0 || *r.Load > 1 {
+			return SegmentSpec{}, fmt.Errorf("%w: invalid segment load: %v", ErrInput, *r.Load)
+		}
+		spec.Segment.Load = *r.Load
+		spec.Segment.HasLoad = true
+	}
+	if r.Runner != "" {
+		runner, err := parseSegmentRunner(r.Runner)
+		if err != nil {
+			return SegmentSpec{}, err
+		}
+		spec.Segment.Runner = runner
+	}
+	return spec, nil
+}
+
+// resolveSegmentSpecs returns calculator segments, filling missing CI from provider history.
+// resolveSegmentSpecs 返回计算用分段，并使用 provider 历史数据补齐缺失的 CI。
+//
+// History is fetched once for the span of all segments that need it; each segment receives the
+// time-weighted mean CI over [start, start+duration) using the same integral as scheduling.
+// 历史数据按所有待补分段的时间跨度只请求一次；每个分段取 [start, start+duration)
+// 内的时间加权平均 CI，积分方式与调度模块一致。
+func (a *App) resolveSegmentSpecs(ctx context.Context, specs []SegmentSpec, zone string) ([]calculator.Segment, error) {
+	segments := make([]calculator.Segment, len(specs))
+	var spanStart, spanEnd time.Time
+	missing := false
+	for i, spec := range specs {
+		segments[i] = spec.Segment
+		if !spec.NeedsHistoryCI() {
+			continue
+		}
+		end := spec.Start.Add(time.Duration(spec.Segment.Duration) * time.Second)
+		if !missing || spec.Start.Before(spanStart) {
+			spanStart = spec.Start
+		}
+		if !missing || end.After(spanEnd) {
+			spanEnd = end
+		}
+		missing = true
+	}
+	if !missing {
+		return segments, nil
+	}
+
+	if strings.TrimSpace(zone) == "" {
+		return nil, fmt.Errorf("%w: history zone is required to fill segment ci", ErrInput)
+	}
+	history, ok := a.historyProvider()
+	if !ok {
+		return nil, fmt.Errorf("%w: ci history provider is not configured", ErrProvider)
+	}
+	// Include the slot that contains spanStart so the first segment is covered.
+	// 向前多取一个时段，确保首个分段被覆盖。
+	points, err := history.GetHistoryCI(ctx, zone, spanStart.Add(-time.Hour), spanEnd)
+	if err != nil {
+		return nil, wrapProviderError(err)
+	}
+	points = scheduling.NormalizeForecastUTC(points)
+	evaluator, ok := scheduling.BuildEmissionEvaluator(points, spanEnd)
+	if !ok {
+		return nil, fmt.Errorf("%w: no ci history for zone %s", ErrProvider, zone)
+	}
+
+	for i, spec := range specs {
+		if !spec.NeedsHistoryCI() {
+			continue
+		}
+		ci, ok := evaluator.MeanCIAt(spec.Start, spec.Segment.Duration)
+		if !ok {
+			return nil, fmt.Errorf("%w: ci history does not cover segment starting %s", ErrProvider, spec.Start.Format(time.RFC3339))
+		}
+		segments[i].CI = ci
+	}
+	return segments, nil
+}
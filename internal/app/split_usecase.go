@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
+)
+
+// SuggestSplit plans a checkpointable job as the lowest-carbon set of chunks in the lookahead.
+// SuggestSplit 在 lookahead 内为可断点续跑的作业规划碳排放最低的片段组合。
+//
+// The plan is compared with the best contiguous window of the same duration (continuous search).
+// When the contiguous window is no worse, for example because resume overhead outweighs the
+// gain, it is returned as a one-chunk plan, so the saving is never negative.
+// 计划与同等时长的最优连续窗口（连续搜索）比较；若连续窗口不差于分段计划（例如恢复开销
+// 抵消了收益），则以单片段计划返回，因此节省量不会为负。
+func (a *App) SuggestSplit(ctx context.Context, in SuggestSplitInput) (SuggestSplitOutput, error) {
+	if a == nil || a.provider == nil {
+		return SuggestSplitOutput{}, fmt.Errorf("%w: provider is not configured", ErrProvider)
+	}
+	if in.Zone == "" {
+		return SuggestSplitOutput{}, fmt.Errorf("%w: zone is required", ErrInput)
+	}
+	if err := validateDurationSeconds(in.Duration); err != nil {
+		return SuggestSplitOutput{}, err
+	}
+	evalStart := time.Now().UTC()
+	lookahead, err := resolveLookahead(in.Lookahead, in.Duration, in.Bounds, evalStart)
+	if err != nil {
+		return SuggestSplitOutput{}, err
+	}
+	if err := validateLookaheadHours(lookahead); err != nil {
+		return SuggestSplitOutput{}, err
+	}
+	if err := validateDurationWithinLookahead(in.Duration, lookahead); err != nil {
+		return SuggestSplitOutput{}, err
+	}
+	if err := validateSplit(in.MinChunk, in.MaxChunks, in.ResumeOverhead, in.Step); err != nil {
+		return SuggestSplitOutput{}, err
+	}
+	model, err := normalizeModel(in.Model)
+	if err != nil {
+		return SuggestSplitOutput{}, err
+	}
+
+	from, to := searchRange(evalStart, lookahead, in.Bounds)
+	if err := checkRangeFits(in.Duration, from, to, in.Bounds); err != nil {
+		return SuggestSplitOutput{}, err
+	}
+	forecast, evaluator, err := a.loadForecastEvaluator(ctx, in.Zone, lookahead, from, to)
+	if err != nil {
+		return SuggestSplitOutput{}, err
+	}
+	if coverage := evaluator.CoverageSeconds(); coverage < in.Duration {
+		return SuggestSplitOutput{}, coverageError(in.Duration, coverage, from, to, in.Bounds)
+	}
+
+	_, contiguous, ok := scheduling.FindBestWindow(forecast, evaluator, in.Duration, model.Runner, model.Load, model.PUE, scheduling.WindowSearchOptions{})
+	if !ok {
+		return SuggestSplitOutput{}, fmt.Errorf("%w: forecast does not cover full duration: need %ds within lookahead %dh", ErrNoValidWindow, in.Duration, lookahead)
+	}
+
+	plan, ok, err := scheduling.FindBestSplitPlan(evaluator, in.Duration, model.Runner, model.Load, model.PUE, scheduling.SplitOptions{
+		MinChunk:       int(in.MinChunk / time.Second),
+		MaxChunks:      in.MaxChunks,
+		ResumeOverhead: int(in.ResumeOverhead / time.Second),
+		Step:           in.Step,
+	})
+	if errors.Is(err, scheduling.ErrSplitSearchTooLarge) {
+		return SuggestSplitOutput{}, fmt.Errorf("%w: %v", ErrInput, err)
+	}
+	if err != nil {
+		return SuggestSplitOutput{}, err
+	}
+	if !ok || plan.Emission >= contiguous.Emission {
+		plan = scheduling.SplitPlanFromWindow(contiguous)
+	}
+
+	out := SuggestSplitOutput{
+		Chunks:               make([]SplitChunk, 0, len(plan.Chunks)),
+		EmissionKg:           plan.Emission,
+		ContiguousStartUTC:   contiguous.Start.UTC(),
+		ContiguousEndUTC:     contiguous.End.UTC(),
+		ContiguousEmissionKg: contiguous.Emission,
+		SavingKg:             contiguous.Emission - plan.Emission,
+	}
+	if contiguous.Emission > 0 {
+		out.SavingPct = out.SavingKg / contiguous.Emission * 100
+	}
+	for _, chunk := range plan.Chunks {
+		out.Chunks = append(out.Chunks, SplitChunk{
+			StartUTC:        chunk.Start.UTC(),
+			EndUTC:          chunk.End.UTC(),
+			WorkSeconds:     chunk.WorkSeconds,
+			OverheadSeconds: chunk.OverheadSeconds,
+			EmissionKg:      chunk.Emission,
+		})
+	}
+	return out, nil
+}
@@ -23,6 +23,14 @@ import (
 // The function keeps backward compatibility when waitCost == 0
 // (pure emission minimization).
 // 当 waitCost == 0 时保持向后兼容（退化为纯排放最小化）。
+//
+// search selects candidate starts: every breakpoint-derived offset by default, or only forecast
+// timestamps with ForecastStartsOnly.
+// search 选择候选起点：默认为由分段边界推导的全部偏移，ForecastStartsOnly 时仅取 forecast 时间戳。
+//
+// bounds keeps windows within [NotBefore, FinishBy]; lookahead must already reach FinishBy.
+// The wait penalty is still measured from evalStart.
+// bounds 将窗口限制在 [NotBefore, FinishBy] 内；lookahead 需已覆盖 FinishBy。等待惩罚仍从 evalStart 起算。
 func (a *App) AnalyzeBestWindow(
 	ctx context.Context,
 	zone string,
@@ -31,6 +39,9 @@ func (a *App) AnalyzeBestWindow(
 	evalStart time.Time,
 	model ModelContext,
 	waitCost float64,
+	search scheduling.WindowSearchOptions,
+	bounds WindowBounds,
+	risk RiskModel,
 ) (SuggestionAnalysis, error) {
 	if a == nil || a.provider == nil {
 		return SuggestionAnalysis{}, fmt.Errorf("%w: provider is not configured", ErrProvider)
@@ -55,67 +66,74 @@ func (a *App) AnalyzeBestWindow(
 	if err := validateWaitCost(waitCost); err != nil {
 		return SuggestionAnalysis{}, err
 	}
+	if err := validateRisk(risk); err != nil {
+		return SuggestionAnalysis{}, err
+	}
 
 	// Use one explicit UTC anchor to keep multi-zone/multi-call comparisons stable.
 	// 使用统一 UTC 锚点，保证多区域/多次调用的可比性与稳定性。
 	evalStart = resolveEvalStart(evalStart)
-	forecast, err := a.provider.GetForecastCI(ctx, zone, lookahead)
-	if err != nil {
-		return SuggestionAnalysis{}, wrapProviderError(err)
-	}
-	windowEnd := evalStart.Add(time.Duration(lookahead) * time.Hour).UTC()
-	forecast = scheduling.NormalizeForecastUTC(forecast)
-	// Clip in app layer so provider remains a pure transport adapter.
-	// 在 app 层裁剪时间窗口，保持 provider 仅负责传输与解析。
-	forecast = clipForecastToWindow(forecast, evalStart, lookahead)
-	if len(forecast) == 0 {
-		return SuggestionAnalysis{}, fmt.Errorf("%w: no forecast points found for zone %s", ErrNoValidWindow, zone)
+	from, to := searchRange(evalStart, lookahead, bounds)
+	if err := checkRangeFits(duration, from, to, bounds); err != nil {
+		return SuggestionAnalysis{}, err
 	}
-
-	evaluator, ok := scheduling.BuildEmissionEvaluator(forecast, windowEnd)
-	if !ok {
-		return SuggestionAnalysis{}, fmt.Errorf("%w: no forecast points found for zone %s", ErrNoValidWindow, zone)
+	forecast, evaluator, err := a.loadForecastEvaluator(ctx, zone, lookahead, from, to)
+	if err != nil {
+		return SuggestionAnalysis{}, err
 	}
 
 	maxCoverage := evaluator.CoverageSeconds()
 	if maxCoverage < duration {
-		return SuggestionAnalysis{}, fmt.Errorf("%w: forecast does not cover full duration: need %ds but only %ds available", ErrNoValidWindow, duration, maxCoverage)
+		return SuggestionAnalysis{}, coverageError(duration, maxCoverage, from, to, bounds)
 	}
 
-	currentWindow, bestWindow, ok := scheduling.FindBestWindowAtForecastStarts(
+	currentWindow, bestWindow, ok := scheduling.FindBestWindow(
 		forecast,
 		evaluator,
 		duration,
 		model.Runner,
 		model.Load,
 		model.PUE,
+		search,
 	)
 	if !ok {
 		return SuggestionAnalysis{}, fmt.Errorf("%w: forecast does not cover full duration: need %ds within lookahead %dh", ErrNoValidWindow, duration, lookahead)
 	}
 
+	// The error band shares the forecast breakpoints, so the same starts stay exact.
+	// 误差带与 forecast 共享分段边界，因此相同的候选起点仍然精确。
+	riskAt := newWindowRisk(risk, forecast, evalStart, to, duration, model)
+	// Wait penalty only applies to future windows; negative wait is clamped to zero.
+	// 等待惩罚仅对未来窗口生效；负等待时间会被钳制为 0。
+	score := func(start time.Time, emission float64, windowRisk float64) float64 {
+		return emission + waitCost*maxFloat(start.Sub(evalStart).Hours(), 0) + risk.Aversion*windowRisk
+	}
+
 	currentEmission := currentWindow.Emission
 	currentStart := currentWindow.Start.UTC()
 	currentEnd := currentWindow.End.UTC()
-	// Wait penalty only applies to future windows; negative wait is clamped to zero.
-	// 等待惩罚仅对未来窗口生效；负等待时间会被钳制为 0。
-	currentScore := currentEmission + waitCost*maxFloat(currentStart.Sub(evalStart).Hours(), 0)
+	currentRisk := riskAt.at(currentStart)
+	currentScore := score(currentStart, currentEmission, currentRisk)
 	bestStart := bestWindow.Start.UTC()
 	bestEnd := bestWindow.End.UTC()
 	bestEmission := bestWindow.Emission
-	bestScore := bestEmission + waitCost*maxFloat(bestStart.Sub(evalStart).Hours(), 0)
+	bestRisk := riskAt.at(bestStart)
+	bestScore := score(bestStart, bestEmission, bestRisk)
 
-	for _, point := range forecast {
-		start := point.Timestamp.UTC()
+	// evalStart is passed as an anchor because the wait penalty is kinked there.
+	// 等待惩罚在 evalStart 处转折，因此将其作为 anchor 传入。
+	for _, start := range evaluator.SearchStarts(forecast, duration, search, evalStart) {
 		emission, ok := evaluator.EstimateAt(start, duration, model.Runner, model.Load, model.PUE)
 		if !ok {
 			break
 		}
 
-		score := emission + waitCost*maxFloat(start.Sub(evalStart).Hours(), 0)
-		if score < bestScore || (score == bestScore && emission < bestEmission) {
-			bestScore = score
+		windowRisk := riskAt.at(start)
+		candidate := score(start, emission, windowRisk)
+		if candidate < bestScore || (candidate == bestScore && emission < bestEmission) {
+			bestScore = candidate
 			bestEmission = emission
+			bestRisk = windowRisk
 			bestStart = start.UTC()
 			bestEnd = start.Add(time.Duration(duration) * time.Second).UTC()
 		}
@@ -130,10 +148,12 @@ func (a *App) AnalyzeBestWindow(
 		CurrentEmission: currentEmission,
 		CurrentStart:    currentStart,
 		CurrentEnd:      currentEnd,
+		CurrentRisk:     currentRisk,
 		CurrentScore:    currentScore,
 		BestStart:       bestStart,
 		BestEnd:         bestEnd,
 		BestEmission:    bestEmission,
+		BestRisk:        bestRisk,
 		BestScore:       bestScore,
 		Reduction:       reduction,
 	}, nil
@@ -157,22 +177,32 @@ func (a *App) Suggest(ctx context.Context, in SuggestInput) (SuggestOutput, erro
 	if in.Threshold <= 0 {
 		return SuggestOutput{}, fmt.Errorf("%w: threshold must be > 0", ErrInput)
 	}
-	if err := validateLookaheadHours(in.Lookahead); err != nil {
+	evalStart := time.Now().UTC()
+	lookahead, err := resolveLookahead(in.Lookahead, in.Duration, in.Bounds, evalStart)
+	if err != nil {
+		return SuggestOutput{}, err
+	}
+	if err := validateLookaheadHours(lookahead); err != nil {
 		return SuggestOutput{}, err
 	}
-	if err := validateDurationWithinLookahead(in.Duration, in.Lookahead); err != nil {
+	if err := validateDurationWithinLookahead(in.Duration, lookahead); err != nil {
 		return SuggestOutput{}, err
 	}
 	if err := validateWaitCost(in.WaitCost); err != nil {
 		return SuggestOutput{}, err
 	}
+	if err := validateWindowSearch(in.WindowSearch, in.StartGranularity); err != nil {
+		return SuggestOutput{}, err
+	}
+	if err := validateRisk(in.Risk); err != nil {
+		return SuggestOutput{}, err
+	}
 	model, err := normalizeModel(in.Model)
 	if err != nil {
 		return SuggestOutput{}, err
 	}
-	evalStart := time.Now().UTC()
 
-	analysis, err := a.AnalyzeBestWindow(ctx, in.Zone, in.Duration, in.Lookahead, evalStart, model, in.WaitCost)
+	analysis, err := a.AnalyzeBestWindow(ctx, in.Zone, in.Duration, lookahead, evalStart, model, in.WaitCost, resolveWindowSearch(in.WindowSearch, in.StartGranularity), in.Bounds, in.Risk)
 	if err != nil {
 		return SuggestOutput{}, err
 	}
@@ -190,18 +220,31 @@ func (a *App) Suggest(ctx context.Context, in SuggestInput) (SuggestOutput, erro
 	bestStart := analysis.BestStart
 	bestEnd := analysis.BestEnd
 	bestEmission := analysis.BestEmission
+	bestRisk := analysis.BestRisk
+	bestScore := analysis.BestScore
 	reduction := 0.0
 	if currentEmissionNow > 0 {
 		reduction = (currentEmissionNow - bestEmission) / currentEmissionNow * 100
 	}
 
-	// "Run now" has zero waiting cost, so score == current emission.
-	// “立即执行”不产生等待成本，因此 score 等于当前排放。
-	nowScore := currentEmissionNow
-	if currentCI <= in.Threshold && nowScore <= analysis.BestScore*1.05 {
+	// "Run now" has zero waiting cost, so score == current emission plus its risk penalty.
+	// Its risk keeps the relative error of the first forecast window.
+	// “立即执行”不产生等待成本，因此 score 等于当前排放加上风险惩罚；
+	// 其风险沿用首个 forecast 窗口的相对误差。
+	// Running now is only an option when it is not held back by NotBefore.
+	// 仅当未受 NotBefore 限制时，“立即执行”才是可选项。
+	nowRisk := 0.0
+	if analysis.CurrentEmission > 0 {
+		nowRisk = currentEmissionNow * analysis.CurrentRisk / analysis.CurrentEmission
+	}
+	nowScore := currentEmissionNow + in.Risk.Aversion*nowRisk
+	canRunNow := !in.Bounds.NotBefore.After(evalStart)
+	if canRunNow && currentCI <= in.Threshold && nowScore <= analysis.BestScore*1.05 {
 		bestStart = analysis.CurrentStart
 		bestEnd = analysis.CurrentEnd
 		bestEmission = currentEmissionNow
+		bestRisk = nowRisk
+		bestScore = nowScore
 		reduction = 0
 	}
 
@@ -211,6 +254,8 @@ func (a *App) Suggest(ctx context.Context, in SuggestInput) (SuggestOutput, erro
 		BestWindowEndUTC:       bestEnd.UTC(),
 		ExpectedEmissionKg:     bestEmission,
 		EmissionReductionVsNow: reduction,
+		RiskKg:                 bestRisk,
+		Score:                  bestScore,
 	}, nil
 }
 
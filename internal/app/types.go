@@ -1,27 +1,195 @@
 package app
 
-import "time"
+import (
+	"time"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+	"github.com/chenzhuyu2004/carbon-guard/pkg/models"
+)
 
 type ModelContext struct {
 	Runner string
 	Load   float64
 	PUE    float64
+	// Power overrides the runner power profile (e.g. derived from an instance type); nil uses the
+	// runner profile. A set profile is used as given, so it must carry its own idle and peak watts.
+	// Power 覆盖 runner 功率画像（例如由实例类型推导）；nil 时使用 runner 画像。
+	// 设置后按原值使用，因此必须自带 idle 与 peak 功率。
+	Power *models.PowerProfile
+	// Coefficients converts non-CPU resource usage into energy; nil uses built-in defaults.
+	// A set value is used as given, so a zero coefficient disables its component.
+	// Coefficients 将非 CPU 资源使用量换算为能耗；nil 时使用内置默认值。
+	// 设置后按原值使用，因此系数为 0 会关闭对应组件。
+	Coefficients *calculator.ResourceCoefficients
+}
+
+// Window search modes accepted by SuggestInput, OptimizeInput and OptimizeGlobalInput.
+// SuggestInput、OptimizeInput 与 OptimizeGlobalInput 接受的窗口搜索模式。
+const (
+	// WindowSearchContinuous searches every start offset through the forecast breakpoints (default).
+	// WindowSearchContinuous 借助 forecast 分段边界搜索所有起点偏移（默认）。
+	WindowSearchContinuous = "continuous"
+	// WindowSearchForecastStarts only starts at forecast timestamps, as before continuous search.
+	// WindowSearchForecastStarts 仅在 forecast 时间戳处起跑，即连续搜索之前的行为。
+	WindowSearchForecastStarts = "forecast-starts"
+)
+
+// WindowBounds restricts candidate windows to start at or after NotBefore and end by FinishBy.
+// Zero fields are unbounded; a set FinishBy derives the forecast lookahead.
+// WindowBounds 限制候选窗口不早于 NotBefore 开始、不晚于 FinishBy 结束。
+// 零值字段表示不限制；设置 FinishBy 时据其推导 forecast lookahead。
+type WindowBounds struct {
+	NotBefore time.Time
+	FinishBy  time.Time
+}
+
+// IsZero reports whether neither bound is set.
+// IsZero 判断两个边界是否均未设置。
+func (b WindowBounds) IsZero() bool {
+	return b.NotBefore.IsZero() && b.FinishBy.IsZero()
+}
+
+// RiskModel prices forecast uncertainty into window scores:
+//
+//	score = emission_kg + wait_cost * wait_hours + Aversion * risk_kg
+//
+// risk_kg is the window's 1-sigma emission error for a relative CI forecast error of
+// ForecastError + ForecastErrorPerHour * lead_hours. The zero value reports no risk and
+// leaves scores unchanged; a zero Aversion reports risk without penalizing it.
+// RiskModel 将预测不确定性计入窗口评分：
+//
+//	score = emission_kg + wait_cost * wait_hours + Aversion * risk_kg
+//
+// risk_kg 为相对 CI 预测误差取 ForecastError + ForecastErrorPerHour * lead_hours 时窗口的
+// 1-sigma 排放误差。零值表示不计风险且评分不变；Aversion 为 0 时只报告风险而不惩罚。
+type RiskModel struct {
+	Aversion             float64
+	ForecastError        float64
+	ForecastErrorPerHour float64
 }
 
 type RunInput struct {
 	Duration    int
 	Region      string
 	SegmentsRaw string
+	// Segments come from a segments file and take precedence over SegmentsRaw.
+	// Segments 来自分段文件，优先于 SegmentsRaw。
+	Segments []SegmentSpec
+	// HistoryZone is the zone used to fill missing segment CI from provider history.
+	// HistoryZone 为使用 provider 历史数据补齐分段 CI 时所用区域。
+	HistoryZone string
 	LiveZone    string
 	Model       ModelContext
+	// Resources adds optional memory/storage/network energy on top of CPU energy.
+	// Resources 在 CPU 能耗之外叠加可选的内存/存储/网络能耗。
+	Resources calculator.ResourceUsage
+	// Power integrates a measured power time series against a time-aligned CI series.
+	// Power 将实测功率时间序列与对齐后的 CI 序列共同积分。
+	Power PowerInput
+	// GridMix replaces the static region CI with one derived from generation shares.
+	// GridMix 以发电结构份额推导的 CI 替代静态区域 CI。
+	GridMix GridMixInput
+	// Instruments are contractual instruments (RECs, GOs, PPAs) for market-based scope 2.
+	// Instruments 为用于市场法范围二的合同工具（REC、GO、PPA）。
+	Instruments []calculator.ContractualInstrument
+	// Price optionally prices total energy; WUE (L/kWh of IT energy) optionally adds water usage.
+	// Price 可选地为总能耗计价；WUE（每 kWh IT 能耗的升数）可选地计算用水量。
+	Price PriceInput
+	WUE   float64
+	// Measured replaces modelled CPU/memory energy with hardware counter readings when set.
+	// Measured 设置时以硬件计数器读数替代 CPU/内存的建模能耗。
+	Measured MeasuredEnergy
+	// Uncertainty optionally propagates input ranges into emission percentiles.
+	// Uncertainty 可选地将输入区间传播为排放分位数。
+	Uncertainty UncertaintyInput
+}
+
+// MeasuredEnergy is IT energy measured by hardware counters (for example RAPL), before PUE.
+// MeasuredEnergy 为硬件计数器（如 RAPL）实测的 IT 能耗，未乘 PUE。
+//
+// CPUKWh > 0 enables measurement; MemoryKWh > 0 additionally replaces modelled memory energy.
+// CPUKWh > 0 时启用实测；MemoryKWh > 0 时同时替代建模的内存能耗。
+type MeasuredEnergy struct {
+	Source    string
+	CPUKWh    float64
+	MemoryKWh float64
+}
+
+// UncertaintyInput describes input ranges for seeded Monte Carlo propagation.
+// UncertaintyInput 描述用于带种子蒙特卡洛传播的输入区间。
+//
+// Zero-valued Load/PUE ranges fall back to the model point value; relative errors are
+// symmetric fractions (0.1 means +/-10%). Samples == 0 uses the default sample count.
+// Load/PUE 区间为零值时回退到模型点值；相对误差为对称比例（0.1 表示 +/-10%）。
+// Samples == 0 时使用默认样本数。
+type UncertaintyInput struct {
+	Load          calculator.Range
+	PUE           calculator.Range
+	CIRelative    float64
+	PowerRelative float64
+	Samples       int
+	Seed          int64
 }
 
 type RunResult struct {
-	DurationSeconds     int
+	DurationSeconds int
+	// EmissionsKg is operational (scope 2) emissions, kept for contract compatibility.
+	// EmissionsKg 为运行期（范围二）排放，保持与既有契约兼容。
 	EmissionsKg         float64
 	EnergyITKWh         float64
 	EnergyTotalKWh      float64
 	EffectiveCIKgPerKWh float64
+	// Energy*KWh split EnergyITKWh by component (before PUE).
+	// Energy*KWh 为 EnergyITKWh 的组件拆分（未乘 PUE）。
+	EnergyCPUKWh     float64
+	EnergyMemoryKWh  float64
+	EnergyStorageKWh float64
+	EnergyNetworkKWh float64
+	// Embodied is amortized hardware (scope 3) emissions; Total = EmissionsKg + Embodied.
+	// Embodied 为摊销的硬件（范围三）排放；Total = EmissionsKg + Embodied。
+	EmbodiedEmissionsKg float64
+	TotalEmissionsKg    float64
+	// MarketBased is market-based scope 2 emissions; nil when no instruments were given.
+	// EmissionsKg remains the location-based figure.
+	// MarketBased 为市场法范围二排放；未提供合同工具时为 nil。EmissionsKg 仍为位置法结果。
+	MarketBased *calculator.MarketBasedResult
+	// Cost is nil when no price was configured; WaterLiters is 0 when WUE is not set.
+	// 未配置电价时 Cost 为 nil；未设置 WUE 时 WaterLiters 为 0。
+	Cost        *CostEstimate
+	WaterLiters float64
+	// RegionCI is the dataset entry used for the static region CI; nil when CI came from elsewhere.
+	// RegionCI 为静态 region CI 所用的数据集条目；CI 来自其他来源时为 nil。
+	RegionCI *RegionCI
+	// EnergySource is "model" or the measurement source that replaced modelled energy.
+	// EnergySource 为 "model" 或替代建模能耗的实测来源。
+	EnergySource string
+	// Emissions percentiles of operational emissions; set only when uncertainty was requested.
+	// 运行期排放分位数；仅在请求不确定性分析时填充。
+	Uncertainty        *calculator.Percentiles
+	UncertaintySamples int
+	UncertaintySeed    int64
+}
+
+// SCIInput scores one run against a functional unit count (R in the SCI formula).
+// SCIInput 以功能单元数量（SCI 公式中的 R）对一次运行进行评分。
+type SCIInput struct {
+	Run             RunInput
+	FunctionalUnits float64
+	FunctionalUnit  string
+}
+
+// SCIResult is the Green Software Foundation SCI breakdown: ((E*I)+M)/R.
+// SCIResult 为绿色软件基金会 SCI 拆解：((E*I)+M)/R。
+type SCIResult struct {
+	Run             RunResult
+	EnergyKWh       float64
+	CarbonIntensity float64
+	OperationalKg   float64
+	EmbodiedKg      float64
+	TotalKg         float64
+	FunctionalUnits float64
+	FunctionalUnit  string
+	SCIKgPerUnit    float64
 }
 
 type SuggestInput struct {
@@ -31,6 +199,16 @@ type SuggestInput struct {
 	Lookahead int
 	WaitCost  float64
 	Model     ModelContext
+	// WindowSearch is continuous (default) or forecast-starts.
+	// WindowSearch 为 continuous（默认）或 forecast-starts。
+	WindowSearch string
+	// StartGranularity snaps continuous starts to a UTC grid; 0 searches at 1s resolution.
+	// StartGranularity 将连续起点对齐到 UTC 网格；0 表示以 1 秒精度搜索。
+	StartGranularity time.Duration
+	// Bounds restricts candidate windows; a set FinishBy overrides Lookahead.
+	// Bounds 限制候选窗口；设置 FinishBy 时覆盖 Lookahead。
+	Bounds WindowBounds
+	Risk   RiskModel
 }
 
 type SuggestOutput struct {
@@ -39,6 +217,118 @@ type SuggestOutput struct {
 	BestWindowEndUTC       time.Time
 	ExpectedEmissionKg     float64
 	EmissionReductionVsNow float64
+	// RiskKg and Score follow RiskModel for the recommended window.
+	// RiskKg 与 Score 为推荐窗口按 RiskModel 计算的结果。
+	RiskKg float64
+	Score  float64
+}
+
+// SuggestSplitInput schedules a checkpointable job as several chunks within the lookahead.
+// SuggestSplitInput 在 lookahead 内将可断点续跑的作业拆分为多个片段进行调度。
+type SuggestSplitInput struct {
+	Zone      string
+	Duration  int
+	Lookahead int
+	Model     ModelContext
+	// MinChunk is the minimum work per chunk; 0 means one Step.
+	// MinChunk 为每个片段的最少工作时长；0 表示一个 Step。
+	MinChunk time.Duration
+	// MaxChunks caps the number of chunks; 0 means no cap beyond one chunk per Step of work.
+	// MaxChunks 限制片段数量；0 表示仅受每个 Step 一个片段的上限约束。
+	MaxChunks int
+	// ResumeOverhead is added before every chunk after the first and emits like work.
+	// ResumeOverhead 加在第一个片段之后的每个片段之前，按工作负载计排放。
+	ResumeOverhead time.Duration
+	// Step is the chunk start and length grid; 0 uses scheduling.DefaultSplitStep.
+	// Step 为片段起点与时长网格；0 表示使用 scheduling.DefaultSplitStep。
+	Step time.Duration
+	// Bounds restricts candidate windows; a set FinishBy overrides Lookahead.
+	// Bounds 限制候选窗口；设置 FinishBy 时覆盖 Lookahead。
+	Bounds WindowBounds
+}
+
+// SplitChunk is one chunk of a split plan; [Start, End) includes OverheadSeconds.
+// SplitChunk 为分段计划中的一个片段；[Start, End) 包含 OverheadSeconds。
+type SplitChunk struct {
+	StartUTC        time.Time
+	EndUTC          time.Time
+	WorkSeconds     int
+	OverheadSeconds int
+	EmissionKg      float64
+}
+
+type SuggestSplitOutput struct {
+	Chunks     []SplitChunk
+	EmissionKg float64
+	// Contiguous* is the best single window of the same duration, the baseline for Saving*.
+	// Contiguous* 为同等时长的最优单一窗口，作为 Saving* 的基准。
+	ContiguousStartUTC   time.Time
+	ContiguousEndUTC     time.Time
+	ContiguousEmissionKg float64
+	SavingKg             float64
+	SavingPct            float64
+}
+
+// Batch solvers accepted by BatchInput.
+// BatchInput 接受的批量求解器。
+const (
+	// BatchSolverHeuristic is the deterministic greedy-and-improve planner (default).
+	// BatchSolverHeuristic 为确定性的贪心加改进规划器（默认）。
+	BatchSolverHeuristic = "heuristic"
+	// BatchSolverExact proves the optimum by branch and bound; limited to small batches.
+	// BatchSolverExact 通过分支定界证明最优；仅适用于小批量。
+	BatchSolverExact = "exact"
+)
+
+// BatchInput plans several jobs across zones and start times under per-zone capacity.
+// BatchInput 在区域容量约束下为多个作业规划区域与起点。
+type BatchInput struct {
+	Jobs []BatchJobSpec
+	// Capacity is the number of jobs a zone runs at once; unlisted zones run one at a time.
+	// Capacity 为区域可同时运行的作业数；未列出的区域一次只运行一个作业。
+	Capacity        map[string]int
+	CapacityWindows []CapacityWindowSpec
+	// Slot is the start grid and capacity granularity; 0 uses 15m.
+	// Slot 为起点网格与容量粒度；0 表示 15m。
+	Slot time.Duration
+	// Lookahead 0 derives the horizon from the latest job deadline, or 24 hours when a job has none.
+	// Lookahead 为 0 时由最晚作业截止时间推导范围；若有作业未设截止时间则为 24 小时。
+	Lookahead int
+	Solver    string
+	Model     ModelContext
+	Timeout   time.Duration
+}
+
+// BatchAssignment places one job in a zone; [StartUTC, EndUTC) is the job's run.
+// BatchAssignment 将一个作业安排到某区域；[StartUTC, EndUTC) 为作业运行区间。
+type BatchAssignment struct {
+	JobID           string
+	Zone            string
+	StartUTC        time.Time
+	EndUTC          time.Time
+	DurationSeconds int
+	Priority        int
+	EmissionKg      float64
+}
+
+// BatchUnscheduled is a job left out of the plan, with Reason "no-window" or "capacity".
+// BatchUnscheduled 为未排入计划的作业，Reason 为 "no-window" 或 "capacity"。
+type BatchUnscheduled struct {
+	JobID  string
+	Reason string
+}
+
+type BatchOutput struct {
+	Solver string
+	// Optimal is true when the exact solver proved the plan optimal.
+	// Optimal 为 true 表示精确求解器已证明该计划最优。
+	Optimal         bool
+	SlotSeconds     int
+	HorizonStartUTC time.Time
+	HorizonEndUTC   time.Time
+	Assignments     []BatchAssignment
+	Unscheduled     []BatchUnscheduled
+	EmissionKg      float64
 }
 
 type SuggestionAnalysis struct {
@@ -47,12 +337,14 @@ type SuggestionAnalysis struct {
 	CurrentEmission float64
 	CurrentStart    time.Time
 	CurrentEnd      time.Time
+	CurrentRisk     float64
 	CurrentScore    float64
 	// Best* describes the minimal-score window under current objective.
 	// Best* 描述当前目标函数下评分最小的窗口。
 	BestStart    time.Time
 	BestEnd      time.Time
 	BestEmission float64
+	BestRisk     float64
 	BestScore    float64
 	Reduction    float64
 }
@@ -83,18 +375,25 @@ type RunAwareOutput struct {
 type ZoneResult struct {
 	Zone      string
 	Emission  float64
+	Risk      float64
 	Score     float64
 	BestStart time.Time
 	BestEnd   time.Time
 }
 
 type OptimizeInput struct {
-	Zones     []string
-	Duration  int
-	Lookahead int
-	WaitCost  float64
-	Model     ModelContext
-	Timeout   time.Duration
+	Zones            []string
+	Duration         int
+	Lookahead        int
+	WaitCost         float64
+	Model            ModelContext
+	Timeout          time.Duration
+	WindowSearch     string
+	StartGranularity time.Duration
+	// Bounds restricts candidate windows; a set FinishBy overrides Lookahead.
+	// Bounds 限制候选窗口；设置 FinishBy 时覆盖 Lookahead。
+	Bounds WindowBounds
+	Risk   RiskModel
 }
 
 type OptimizeOutput struct {
@@ -106,8 +405,8 @@ type OptimizeOutput struct {
 }
 
 type OptimizeGlobalInput struct {
-	// ResampleFillMode controls cross-zone alignment: forward|strict.
-	// ResampleFillMode 控制跨区域对齐策略：forward|strict。
+	// ResampleFillMode controls cross-zone alignment: forward|strict|linear|nearest.
+	// ResampleFillMode 控制跨区域对齐策略：forward|strict|linear|nearest。
 	Zones            []string
 	Duration         int
 	Lookahead        int
@@ -116,8 +415,17 @@ type OptimizeGlobalInput struct {
 	// ResampleMaxFillAge is only effective in forward mode.
 	// ResampleMaxFillAge 仅在 forward 模式下生效。
 	ResampleMaxFillAge time.Duration
-	Model              ModelContext
-	Timeout            time.Duration
+	// ResampleMaxGap is only effective in linear and nearest modes.
+	// ResampleMaxGap 仅在 linear 与 nearest 模式下生效。
+	ResampleMaxGap   time.Duration
+	Model            ModelContext
+	Timeout          time.Duration
+	WindowSearch     string
+	StartGranularity time.Duration
+	// Bounds restricts candidate windows; a set FinishBy overrides Lookahead.
+	// Bounds 限制候选窗口；设置 FinishBy 时覆盖 Lookahead。
+	Bounds WindowBounds
+	Risk   RiskModel
 }
 
 type OptimizeGlobalOutput struct {
@@ -125,9 +433,12 @@ type OptimizeGlobalOutput struct {
 	BestStart time.Time
 	BestEnd   time.Time
 	Emission  float64
+	Risk      float64
+	Score     float64
 	Reduction float64
 	// Resample* echoes effective policy so outputs are audit-friendly.
 	// Resample* 回显实际生效策略，便于审计与复现。
 	ResampleFillMode          string
 	ResampleMaxFillAgeSeconds int64
+	ResampleMaxGapSeconds     int64
 }
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+)
+
+const (
+	defaultUncertaintySamples = 1000
+	maxUncertaintySamples     = 100000
+)
+
+// enabled reports whether any input carries spread worth propagating.
+// enabled 判断是否存在需要传播的输入离散度。
+func (u UncertaintyInput) enabled() bool {
+	return !u.Load.IsPoint() || !u.PUE.IsPoint() || u.CIRelative > 0 || u.PowerRelative > 0
+}
+
+func normalizeUncertainty(u UncertaintyInput, model ModelContext) (UncertaintyInput, error) {
+	if u.Load == (calculator.Range{}) {
+		u.Load = calculator.PointRange(model.Load)
+	}
+	if u.PUE == (calculator.Range{}) {
+		u.PUE = calculator.PointRange(model.PUE)
+	}
+	if u.Load.Min > u.Load.Max || u.Load.Min < 0 || u.Load.Max > 1 {
+		return UncertaintyInput{}, fmt.Errorf("%w: load range must be within [0, 1]", ErrInput)
+	}
+	if u.PUE.Min > u.PUE.Max || u.PUE.Min < 1.0 {
+		return UncertaintyInput{}, fmt.Errorf("%w: pue range must be >= 1.0", ErrInput)
+	}
+	if u.CIRelative < 0 || u.CIRelative >= 1 {
+		return UncertaintyInput{}, fmt.Errorf("%w: ci-uncertainty must be in [0, 1)", ErrInput)
+	}
+	if u.PowerRelative < 0 || u.PowerRelative >= 1 {
+		return UncertaintyInput{}, fmt.Errorf("%w: power-uncertainty must be in [0, 1)", ErrInput)
+	}
+	if u.Samples < 0 || u.Samples > maxUncertaintySamples {
+		return UncertaintyInput{}, fmt.Errorf("%w: samples must be between 0 and %d", ErrInput, maxUncertaintySamples)
+	}
+	if u.Samples == 0 {
+		u.Samples = defaultUncertaintySamples
+	}
+	return u, nil
+}
+
+// simulateEmissions propagates input ranges through the segment model with seeded Monte Carlo.
+// simulateEmissions 使用带种子的蒙特卡洛将输入区间传播到分段模型。
+//
+// Every sample draws load, PUE, power scale and CI scale in a fixed order, so results depend
+// only on the seed and inputs. Load and PUE are uniform over their ranges; power and CI are
+// scaled by a uniform factor in [1-rel, 1+rel]. Segment load and runner overrides are kept, and
+// the power factor applies to overriding runner profiles as well.
+// 每个样本按固定顺序抽取 load、PUE、功率系数与 CI 系数，因此结果只取决于种子与输入。
+// load 与 PUE 在区间内均匀分布；功率与 CI 乘以 [1-rel, 1+rel] 内的均匀因子。分段的 load 与
+// runner 覆盖保持不变，功率因子同样作用于覆盖的 runner 画像。
+func simulateEmissions(
+	duration int,
+	segments []calculator.Segment,
+	model ModelContext,
+	resources calculator.ResourceUsage,
+	measured MeasuredEnergy,
+	u UncertaintyInput,
+) calculator.Percentiles {
+	rng := rand.New(rand.NewSource(u.Seed))
+	powerRange := calculator.RelativeRange(1, u.PowerRelative)
+	ciRange := calculator.RelativeRange(1, u.CIRelative)
+
+	scaled := make([]calculator.Segment, len(segments))
+	samples := make([]float64, u.Samples)
+	for i := range samples {
+		sampleModel := model
+		sampleModel.Load = u.Load.Sample(rng)
+		sampleModel.PUE = u.PUE.Sample(rng)
+		powerScale := powerRange.Sample(rng)
+		ciScale := ciRange.Sample(rng)
+
+		for j, segment := range segments {
+			segment.CI *= ciScale
+			if segment.PowerScale != 0 {
+				segment.PowerScale *= powerScale
+			} else {
+				segment.PowerScale = powerScale
+			}
+			scaled[j] = segment
+		}
+		samples[i] = computeSegments(duration, scaled, sampleModel, resources, measured).EmissionsKg
+	}
+	return calculator.ComputePercentiles(samples)
+}
@@ -8,7 +8,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
 	"github.com/chenzhuyu2004/carbon-guard/internal/domain/scheduling"
+	"github.com/chenzhuyu2004/carbon-guard/pkg/models"
 )
 
 type fakeProvider struct {
@@ -16,6 +18,7 @@ type fakeProvider struct {
 	currentErr     error
 	forecastByZone map[string][]scheduling.ForecastPoint
 	forecastErr    error
+	forecastHours  int
 }
 
 func (f *fakeProvider) GetCurrentCI(_ context.Context, zone string) (float64, error) {
@@ -31,7 +34,8 @@ func (f *fakeProvider) GetCurrentCI(_ context.Context, zone string) (float64, er
 	return 0.4, nil
 }
 
-func (f *fakeProvider) GetForecastCI(_ context.Context, zone string, _ int) ([]scheduling.ForecastPoint, error) {
+func (f *fakeProvider) GetForecastCI(_ context.Context, zone string, hours int) ([]scheduling.ForecastPoint, error) {
+	f.forecastHours = hours
 	if f.forecastErr != nil {
 		return nil, f.forecastErr
 	}
@@ -62,7 +66,7 @@ func TestAnalyzeBestWindowNoForecastReturnsErrNoValidWindow(t *testing.T) {
 		Runner: "ubuntu",
 		Load:   0.6,
 		PUE:    1.2,
-	}, 0)
+	}, 0, scheduling.WindowSearchOptions{}, WindowBounds{}, RiskModel{})
 	if !errors.Is(err, ErrNoValidWindow) {
 		t.Fatalf("expected ErrNoValidWindow, got %v", err)
 	}
@@ -86,7 +90,7 @@ func TestAnalyzeBestWindowWaitCostChangesBestWindow(t *testing.T) {
 		PUE:    1.2,
 	}
 
-	withoutWaitCost, err := a.AnalyzeBestWindow(context.Background(), "DE", 3600, 4, now, model, 0)
+	withoutWaitCost, err := a.AnalyzeBestWindow(context.Background(), "DE", 3600, 4, now, model, 0, scheduling.WindowSearchOptions{ForecastStartsOnly: true}, WindowBounds{}, RiskModel{})
 	if err != nil {
 		t.Fatalf("AnalyzeBestWindow() unexpected error without wait-cost: %v", err)
 	}
@@ -94,7 +98,7 @@ func TestAnalyzeBestWindowWaitCostChangesBestWindow(t *testing.T) {
 		t.Fatalf("best start without wait-cost = %s, expected %s", withoutWaitCost.BestStart, now.Add(time.Hour))
 	}
 
-	withWaitCost, err := a.AnalyzeBestWindow(context.Background(), "DE", 3600, 4, now, model, 0.2)
+	withWaitCost, err := a.AnalyzeBestWindow(context.Background(), "DE", 3600, 4, now, model, 0.2, scheduling.WindowSearchOptions{ForecastStartsOnly: true}, WindowBounds{}, RiskModel{})
 	if err != nil {
 		t.Fatalf("AnalyzeBestWindow() unexpected error with wait-cost: %v", err)
 	}
@@ -103,6 +107,54 @@ func TestAnalyzeBestWindowWaitCostChangesBestWindow(t *testing.T) {
 	}
 }
 
+func TestAnalyzeBestWindowRiskAversionPrefersNearerWindow(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second).Add(10 * time.Minute)
+	forecast := make([]scheduling.ForecastPoint, 0, 7)
+	for hour := 0; hour <= 6; hour++ {
+		ci := 0.3
+		if hour == 5 {
+			ci = 0.25
+		}
+		forecast = append(forecast, scheduling.ForecastPoint{Timestamp: now.Add(time.Duration(hour) * time.Hour), CI: ci})
+	}
+	a := New(&fakeProvider{forecastByZone: map[string][]scheduling.ForecastPoint{"DE": forecast}})
+	model := ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2}
+	search := scheduling.WindowSearchOptions{ForecastStartsOnly: true}
+
+	// Relative error is 5% now and 30% five hours ahead.
+	reportOnly := RiskModel{ForecastError: 0.05, ForecastErrorPerHour: 0.05}
+	analysis, err := a.AnalyzeBestWindow(context.Background(), "DE", 3600, 7, now, model, 0, search, WindowBounds{}, reportOnly)
+	if err != nil {
+		t.Fatalf("AnalyzeBestWindow() unexpected error without aversion: %v", err)
+	}
+	if !analysis.BestStart.Equal(now.Add(5 * time.Hour)) {
+		t.Fatalf("best start without aversion = %s, expected %s", analysis.BestStart, now.Add(5*time.Hour))
+	}
+	if math.Abs(analysis.BestRisk-analysis.BestEmission*0.3) > 1e-9 {
+		t.Fatalf("best risk = %f, expected %f", analysis.BestRisk, analysis.BestEmission*0.3)
+	}
+	if analysis.BestScore != analysis.BestEmission {
+		t.Fatalf("best score = %f, expected emission %f when aversion is zero", analysis.BestScore, analysis.BestEmission)
+	}
+
+	averse := reportOnly
+	averse.Aversion = 1
+	analysis, err = a.AnalyzeBestWindow(context.Background(), "DE", 3600, 7, now, model, 0, search, WindowBounds{}, averse)
+	if err != nil {
+		t.Fatalf("AnalyzeBestWindow() unexpected error with aversion: %v", err)
+	}
+	if !analysis.BestStart.Equal(now) {
+		t.Fatalf("best start with aversion = %s, expected %s", analysis.BestStart, now)
+	}
+	if math.Abs(analysis.BestScore-(analysis.BestEmission+analysis.BestRisk)) > 1e-9 {
+		t.Fatalf("best score = %f, expected emission + risk = %f", analysis.BestScore, analysis.BestEmission+analysis.BestRisk)
+	}
+
+	if _, err := a.AnalyzeBestWindow(context.Background(), "DE", 3600, 7, now, model, 0, search, WindowBounds{}, RiskModel{Aversion: -1}); !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for negative aversion, got %v", err)
+	}
+}
+
 func TestAnalyzeBestWindowClipsForecastByEvalStart(t *testing.T) {
 	evalStart := time.Now().UTC().Truncate(time.Second).Add(2 * time.Minute)
 	a := New(&fakeProvider{
@@ -119,7 +171,7 @@ func TestAnalyzeBestWindowClipsForecastByEvalStart(t *testing.T) {
 		Runner: "ubuntu",
 		Load:   0.6,
 		PUE:    1.2,
-	}, 0)
+	}, 0, scheduling.WindowSearchOptions{}, WindowBounds{}, RiskModel{})
 	if err != nil {
 		t.Fatalf("AnalyzeBestWindow() unexpected error: %v", err)
 	}
@@ -128,6 +180,43 @@ func TestAnalyzeBestWindowClipsForecastByEvalStart(t *testing.T) {
 	}
 }
 
+func TestAnalyzeBestWindowKeepsSlotInProgress(t *testing.T) {
+	hour := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	a := New(&fakeProvider{
+		forecastByZone: map[string][]scheduling.ForecastPoint{
+			"DE": {
+				{Timestamp: hour, CI: 0.2},
+				{Timestamp: hour.Add(time.Hour), CI: 0.4},
+				{Timestamp: hour.Add(2 * time.Hour), CI: 0.6},
+				{Timestamp: hour.Add(3 * time.Hour), CI: 0.6},
+			},
+		},
+	})
+	model := ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2}
+
+	// Mid-slot now: the 0.2 slot in progress counts from now, so 4490s fits before the deadline.
+	now := hour.Add(25*time.Minute + 10*time.Second)
+	finishBy := hour.Add(2 * time.Hour)
+	analysis, err := a.AnalyzeBestWindow(context.Background(), "DE", 4490, 2, now, model, 0, scheduling.WindowSearchOptions{}, WindowBounds{FinishBy: finishBy}, RiskModel{})
+	if err != nil {
+		t.Fatalf("AnalyzeBestWindow() mid-slot now unexpected error: %v", err)
+	}
+	if !analysis.CurrentStart.Equal(now) || !analysis.BestStart.Equal(now) {
+		t.Fatalf("current = %s, best = %s, expected both at now %s", analysis.CurrentStart, analysis.BestStart, now)
+	}
+
+	// Mid-slot not-before: the window must start exactly at not-before to fit 9000s.
+	notBefore := hour.Add(30 * time.Minute)
+	bounds := WindowBounds{NotBefore: notBefore, FinishBy: hour.Add(3 * time.Hour)}
+	analysis, err = a.AnalyzeBestWindow(context.Background(), "DE", 9000, 3, hour, model, 0, scheduling.WindowSearchOptions{}, bounds, RiskModel{})
+	if err != nil {
+		t.Fatalf("AnalyzeBestWindow() mid-slot not-before unexpected error: %v", err)
+	}
+	if !analysis.BestStart.Equal(notBefore) || !analysis.BestEnd.Equal(bounds.FinishBy) {
+		t.Fatalf("best = %s..%s, expected %s..%s", analysis.BestStart, analysis.BestEnd, notBefore, bounds.FinishBy)
+	}
+}
+
 func TestOptimizeUsesScoreWhenWaitCostProvided(t *testing.T) {
 	now := time.Now().UTC().Truncate(time.Second).Add(10 * time.Minute)
 	a := New(&fakeProvider{
@@ -318,6 +407,166 @@ func TestOptimizeGlobalInvalidResampleModeReturnsErrInput(t *testing.T) {
 	}
 }
 
+func TestOptimizeGlobalWindowSearchModes(t *testing.T) {
+	// The resampled axis is hour-aligned, so anchor the forecast on the next full hour.
+	now := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	a := New(&fakeProvider{
+		forecastByZone: map[string][]scheduling.ForecastPoint{
+			"DE": {
+				{Timestamp: now, CI: 0.5},
+				{Timestamp: now.Add(time.Hour), CI: 0.1},
+				{Timestamp: now.Add(2 * time.Hour), CI: 0.9},
+				{Timestamp: now.Add(3 * time.Hour), CI: 0.9},
+			},
+			"FR": {
+				{Timestamp: now, CI: 0.6},
+				{Timestamp: now.Add(time.Hour), CI: 0.6},
+				{Timestamp: now.Add(2 * time.Hour), CI: 0.6},
+				{Timestamp: now.Add(3 * time.Hour), CI: 0.6},
+			},
+		},
+	})
+	in := OptimizeGlobalInput{
+		Zones:     []string{"DE", "FR"},
+		Duration:  5400,
+		Lookahead: 5,
+		Model: ModelContext{
+			Runner: "ubuntu",
+			Load:   0.6,
+			PUE:    1.2,
+		},
+		Timeout:      time.Second,
+		WindowSearch: WindowSearchForecastStarts,
+	}
+
+	legacy, err := a.OptimizeGlobal(context.Background(), in)
+	if err != nil {
+		t.Fatalf("OptimizeGlobal() legacy unexpected error: %v", err)
+	}
+	if legacy.BestZone != "DE" || !legacy.BestStart.Equal(now) {
+		t.Fatalf("legacy best = %s at %s, expected DE at %s", legacy.BestZone, legacy.BestStart, now)
+	}
+
+	in.WindowSearch = ""
+	continuous, err := a.OptimizeGlobal(context.Background(), in)
+	if err != nil {
+		t.Fatalf("OptimizeGlobal() continuous unexpected error: %v", err)
+	}
+	if continuous.BestZone != "DE" || !continuous.BestStart.Equal(now.Add(30*time.Minute)) {
+		t.Fatalf("continuous best = %s at %s, expected DE at %s", continuous.BestZone, continuous.BestStart, now.Add(30*time.Minute))
+	}
+	if !(continuous.Emission < legacy.Emission) {
+		t.Fatalf("expected continuous emission < legacy, got %f >= %f", continuous.Emission, legacy.Emission)
+	}
+
+	in.WindowSearch = WindowSearchForecastStarts
+	in.StartGranularity = 5 * time.Minute
+	if _, err := a.OptimizeGlobal(context.Background(), in); !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for granularity with forecast-starts, got %v", err)
+	}
+}
+
+func TestSuggestSplitComparesWithContiguousWindow(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second).Add(time.Minute)
+	a := New(&fakeProvider{
+		forecastByZone: map[string][]scheduling.ForecastPoint{
+			"DE": {
+				{Timestamp: now, CI: 0.1},
+				{Timestamp: now.Add(time.Hour), CI: 0.9},
+				{Timestamp: now.Add(2 * time.Hour), CI: 0.9},
+				{Timestamp: now.Add(3 * time.Hour), CI: 0.1},
+				{Timestamp: now.Add(4 * time.Hour), CI: 0.9},
+			},
+		},
+	})
+	in := SuggestSplitInput{
+		Zone:      "DE",
+		Duration:  7200,
+		Lookahead: 5,
+		Model:     ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+		MaxChunks: 2,
+		Step:      30 * time.Minute,
+	}
+
+	out, err := a.SuggestSplit(context.Background(), in)
+	if err != nil {
+		t.Fatalf("SuggestSplit() unexpected error: %v", err)
+	}
+	if len(out.Chunks) != 2 || !out.Chunks[0].StartUTC.Equal(now) || !out.Chunks[1].StartUTC.Equal(now.Add(3*time.Hour)) {
+		t.Fatalf("chunks = %+v, expected the two clean hours", out.Chunks)
+	}
+	if !(out.SavingKg > 0) || math.Abs(out.ContiguousEmissionKg-out.EmissionKg-out.SavingKg) > 1e-12 {
+		t.Fatalf("saving = %f (contiguous %f, split %f), expected a positive difference", out.SavingKg, out.ContiguousEmissionKg, out.EmissionKg)
+	}
+
+	// A resume overhead longer than the gap between clean hours makes splitting pointless.
+	in.ResumeOverhead = 3 * time.Hour
+	out, err = a.SuggestSplit(context.Background(), in)
+	if err != nil {
+		t.Fatalf("SuggestSplit() unexpected error with overhead: %v", err)
+	}
+	if len(out.Chunks) != 1 || out.SavingKg != 0 || !out.Chunks[0].StartUTC.Equal(out.ContiguousStartUTC) {
+		t.Fatalf("plan = %+v, expected the contiguous window as one chunk", out)
+	}
+
+	in.MaxChunks = -1
+	if _, err := a.SuggestSplit(context.Background(), in); !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for negative max chunks, got %v", err)
+	}
+}
+
+func TestOptimizeFinishByRestrictsWindows(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second).Add(time.Minute)
+	a := New(&fakeProvider{
+		forecastByZone: map[string][]scheduling.ForecastPoint{
+			"DE": {
+				{Timestamp: now, CI: 0.5},
+				{Timestamp: now.Add(time.Hour), CI: 0.4},
+				{Timestamp: now.Add(2 * time.Hour), CI: 0.3},
+				{Timestamp: now.Add(3 * time.Hour), CI: 0.1},
+				{Timestamp: now.Add(4 * time.Hour), CI: 0.1},
+			},
+		},
+	})
+	in := OptimizeInput{
+		Zones:    []string{"DE"},
+		Duration: 3600,
+		Model:    ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+		Timeout:  time.Second,
+		Bounds:   WindowBounds{FinishBy: now.Add(3 * time.Hour)},
+	}
+
+	out, err := a.Optimize(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Optimize() unexpected error: %v", err)
+	}
+	if !out.Best.BestStart.Equal(now.Add(2*time.Hour)) || out.Best.BestEnd.After(in.Bounds.FinishBy) {
+		t.Fatalf("best = %+v, expected the 0.3 hour ending at the deadline", out.Best)
+	}
+
+	in.Bounds = WindowBounds{NotBefore: now.Add(3 * time.Hour)}
+	in.Lookahead = 6
+	out, err = a.Optimize(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Optimize() unexpected error with not-before: %v", err)
+	}
+	if out.Best.BestStart.Before(in.Bounds.NotBefore) {
+		t.Fatalf("best = %+v, expected start at or after not-before", out.Best)
+	}
+
+	in.Bounds = WindowBounds{FinishBy: now.Add(90 * time.Minute)}
+	in.Duration = 7200
+	_, err = a.Optimize(context.Background(), in)
+	if !errors.Is(err, ErrNoValidWindow) || !strings.Contains(err.Error(), "deadline cannot be met") {
+		t.Fatalf("expected ErrNoValidWindow explaining the deadline, got %v", err)
+	}
+
+	in.Bounds = WindowBounds{FinishBy: now.Add(-time.Hour)}
+	if _, err := a.Optimize(context.Background(), in); !errors.Is(err, ErrNoValidWindow) {
+		t.Fatalf("expected ErrNoValidWindow for a past deadline, got %v", err)
+	}
+}
+
 func TestRunAwareMaxWaitExceededReturnsErrMaxWaitExceeded(t *testing.T) {
 	now := time.Now().UTC().Add(2 * time.Hour)
 	a := New(&fakeProvider{
@@ -450,3 +699,982 @@ func TestRunAwareNoRegretGuardRunsNow(t *testing.T) {
 		t.Fatalf("expected no-regret guard message, got %q", out.Message)
 	}
 }
+
+func TestRunUsesExplicitPowerProfile(t *testing.T) {
+	a := New(nil)
+	got, err := a.Run(context.Background(), RunInput{
+		Duration: 3600,
+		Region:   "global",
+		Model: ModelContext{
+			Runner: "ubuntu",
+			Load:   0.5,
+			PUE:    1.0,
+			Power:  &models.PowerProfile{Idle: 10, Peak: 50},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	wantEnergy := 30.0 / 1000.0
+	if math.Abs(got.EnergyTotalKWh-wantEnergy) > 1e-12 {
+		t.Fatalf("EnergyTotalKWh = %.12f, expected %.12f", got.EnergyTotalKWh, wantEnergy)
+	}
+	if math.Abs(got.EmissionsKg-wantEnergy*0.4) > 1e-12 {
+		t.Fatalf("EmissionsKg = %.12f, expected %.12f", got.EmissionsKg, wantEnergy*0.4)
+	}
+}
+
+func TestRunRejectsPartialPowerProfile(t *testing.T) {
+	a := New(nil)
+	partial := []models.PowerProfile{
+		{Idle: 40},
+		{Embodied: models.EmbodiedProfile{TotalKgCO2e: 1000, LifetimeYears: 4, Share: 1}},
+	}
+	for _, power := range partial {
+		_, err := a.Run(context.Background(), RunInput{
+			Duration: 3600,
+			Region:   "global",
+			Model:    ModelContext{Runner: "ubuntu", Load: 0.5, PUE: 1.0, Power: &power},
+		})
+		if !errors.Is(err, ErrInput) {
+			t.Fatalf("Run() with partial profile %#v: expected ErrInput, got %v", power, err)
+		}
+	}
+
+	// An idle of zero is a valid explicit value and must not fall back to the runner profile.
+	got, err := a.Run(context.Background(), RunInput{
+		Duration: 3600,
+		Region:   "global",
+		Model:    ModelContext{Runner: "ubuntu", Load: 0.5, PUE: 1.0, Power: &models.PowerProfile{Peak: 100}},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if want := 0.05; math.Abs(got.EnergyTotalKWh-want) > 1e-12 {
+		t.Fatalf("EnergyTotalKWh = %.12f, expected %.12f", got.EnergyTotalKWh, want)
+	}
+}
+
+func TestRunReportsResourceComponents(t *testing.T) {
+	a := New(nil)
+	got, err := a.Run(context.Background(), RunInput{
+		Duration: 3600,
+		Region:   "global",
+		Model: ModelContext{
+			Runner: "ubuntu",
+			Load:   0.6,
+			PUE:    1.5,
+		},
+		Resources: calculator.ResourceUsage{MemoryGB: 10, StorageWrittenGB: 2, NetworkGB: 3},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	wantMemory := 10 * calculator.DefaultMemoryKWhPerGBHour
+	if math.Abs(got.EnergyMemoryKWh-wantMemory) > 1e-12 {
+		t.Fatalf("EnergyMemoryKWh = %.12f, expected %.12f", got.EnergyMemoryKWh, wantMemory)
+	}
+	sum := got.EnergyCPUKWh + got.EnergyMemoryKWh + got.EnergyStorageKWh + got.EnergyNetworkKWh
+	if math.Abs(got.EnergyITKWh-sum) > 1e-12 {
+		t.Fatalf("EnergyITKWh = %.12f, expected component sum %.12f", got.EnergyITKWh, sum)
+	}
+	if math.Abs(got.EmissionsKg-sum*1.5*0.4) > 1e-12 {
+		t.Fatalf("EmissionsKg = %.12f, expected %.12f", got.EmissionsKg, sum*1.5*0.4)
+	}
+}
+
+func TestRunZeroCoefficientDisablesComponent(t *testing.T) {
+	a := New(nil)
+	coefficients := calculator.DefaultResourceCoefficients()
+	coefficients.NetworkKWhPerGB = 0
+	got, err := a.Run(context.Background(), RunInput{
+		Duration:  3600,
+		Region:    "global",
+		Model:     ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.5, Coefficients: &coefficients},
+		Resources: calculator.ResourceUsage{MemoryGB: 10, NetworkGB: 3},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got.EnergyNetworkKWh != 0 {
+		t.Fatalf("EnergyNetworkKWh = %.12f, expected 0 for a zero coefficient", got.EnergyNetworkKWh)
+	}
+	if want := 10 * calculator.DefaultMemoryKWhPerGBHour; math.Abs(got.EnergyMemoryKWh-want) > 1e-12 {
+		t.Fatalf("EnergyMemoryKWh = %.12f, expected %.12f", got.EnergyMemoryKWh, want)
+	}
+
+	coefficients.MemoryKWhPerGBHour = -1
+	_, err = a.Run(context.Background(), RunInput{
+		Duration: 3600,
+		Region:   "global",
+		Model:    ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.5, Coefficients: &coefficients},
+	})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for a negative coefficient, got %v", err)
+	}
+}
+
+func TestRunMeasuredEnergyReplacesModelledCPUAndMemory(t *testing.T) {
+	a := New(nil)
+	got, err := a.Run(context.Background(), RunInput{
+		Duration:  3600,
+		Region:    "global",
+		Model:     ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.5},
+		Resources: calculator.ResourceUsage{MemoryGB: 10, NetworkGB: 1},
+		Measured:  MeasuredEnergy{Source: "rapl", CPUKWh: 0.2, MemoryKWh: 0.05},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got.EnergySource != "rapl" || got.EnergyCPUKWh != 0.2 || got.EnergyMemoryKWh != 0.05 {
+		t.Fatalf("unexpected measured energy result: %+v", got)
+	}
+	want := (0.2 + 0.05 + got.EnergyNetworkKWh) * 1.5 * 0.4
+	if math.Abs(got.EmissionsKg-want) > 1e-12 {
+		t.Fatalf("EmissionsKg = %.12f, expected %.12f", got.EmissionsKg, want)
+	}
+}
+
+func TestRunMeasuredEnergyUsesMachineShare(t *testing.T) {
+	a := New(nil)
+	got, err := a.Run(context.Background(), RunInput{
+		Duration: 3600,
+		Region:   "global",
+		Model: ModelContext{
+			Runner: "ubuntu",
+			Load:   0.6,
+			PUE:    1.5,
+			Power:  &models.PowerProfile{Idle: 110, Peak: 220, Embodied: models.EmbodiedProfile{Share: 0.25}},
+		},
+		Measured: MeasuredEnergy{Source: "rapl", CPUKWh: 0.2, MemoryKWh: 0.08},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	// Host-wide counters are attributed to the job by its share of the machine.
+	if math.Abs(got.EnergyCPUKWh-0.05) > 1e-12 || math.Abs(got.EnergyMemoryKWh-0.02) > 1e-12 {
+		t.Fatalf("expected shared measured energy (0.05, 0.02), got (%v, %v)", got.EnergyCPUKWh, got.EnergyMemoryKWh)
+	}
+	if want := (0.05 + 0.02) * 1.5 * 0.4; math.Abs(got.EmissionsKg-want) > 1e-12 {
+		t.Fatalf("EmissionsKg = %.12f, expected %.12f", got.EmissionsKg, want)
+	}
+
+	_, err = a.Run(context.Background(), RunInput{
+		Duration: 3600,
+		Region:   "global",
+		Model: ModelContext{
+			Runner: "ubuntu",
+			Load:   0.6,
+			PUE:    1.5,
+			Power:  &models.PowerProfile{Idle: 110, Peak: 220, Embodied: models.EmbodiedProfile{Share: 1.5}},
+		},
+		Measured: MeasuredEnergy{Source: "rapl", CPUKWh: 0.2},
+	})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for machine share > 1, got %v", err)
+	}
+}
+
+func TestRunNegativeResourceUsageReturnsErrInput(t *testing.T) {
+	a := New(nil)
+	_, err := a.Run(context.Background(), RunInput{
+		Duration:  300,
+		Region:    "global",
+		Resources: calculator.ResourceUsage{NetworkGB: -1},
+	})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput, got %v", err)
+	}
+}
+
+func TestRunReportsEmbodiedSeparately(t *testing.T) {
+	a := New(nil)
+	embodied := models.EmbodiedProfile{TotalKgCO2e: 1000, LifetimeYears: 4, Share: 0.5}
+	got, err := a.Run(context.Background(), RunInput{
+		Duration: 3600,
+		Region:   "global",
+		Model: ModelContext{
+			Runner: "ubuntu",
+			Load:   0.6,
+			PUE:    1.2,
+			Power:  &models.PowerProfile{Idle: 110, Peak: 220, Embodied: embodied},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	wantEmbodied := calculator.EstimateEmbodiedKg(3600, embodied)
+	if wantEmbodied <= 0 || math.Abs(got.EmbodiedEmissionsKg-wantEmbodied) > 1e-15 {
+		t.Fatalf("EmbodiedEmissionsKg = %v, expected %v", got.EmbodiedEmissionsKg, wantEmbodied)
+	}
+	if math.Abs(got.TotalEmissionsKg-(got.EmissionsKg+wantEmbodied)) > 1e-15 {
+		t.Fatalf("TotalEmissionsKg = %v, expected operational + embodied", got.TotalEmissionsKg)
+	}
+}
+
+func TestRunDefaultRunnerHasNoEmbodied(t *testing.T) {
+	a := New(nil)
+	got, err := a.Run(context.Background(), RunInput{Duration: 3600, Region: "global"})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got.EmbodiedEmissionsKg != 0 || got.TotalEmissionsKg != got.EmissionsKg {
+		t.Fatalf("expected embodied emissions to be opt-in, got %+v", got)
+	}
+}
+
+func TestRunInvalidEmbodiedShareReturnsErrInput(t *testing.T) {
+	a := New(nil)
+	_, err := a.Run(context.Background(), RunInput{
+		Duration: 300,
+		Region:   "global",
+		Model: ModelContext{
+			Runner: "ubuntu",
+			Load:   0.6,
+			PUE:    1.2,
+			Power:  &models.PowerProfile{Idle: 110, Peak: 220, Embodied: models.EmbodiedProfile{TotalKgCO2e: 1000, LifetimeYears: 4, Share: 2}},
+		},
+	})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput, got %v", err)
+	}
+}
+
+func TestSCIBuildsOnRunResult(t *testing.T) {
+	a := New(nil)
+	embodied := models.EmbodiedProfile{TotalKgCO2e: 1000, LifetimeYears: 4, Share: 0.5}
+	runInput := RunInput{
+		Duration: 3600,
+		Region:   "eu",
+		Model: ModelContext{
+			Runner: "ubuntu",
+			Load:   0.6,
+			PUE:    1.2,
+			Power:  &models.PowerProfile{Idle: 110, Peak: 220, Embodied: embodied},
+		},
+	}
+	run, err := a.Run(context.Background(), runInput)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	got, err := a.SCI(context.Background(), SCIInput{Run: runInput, FunctionalUnits: 250, FunctionalUnit: "test"})
+	if err != nil {
+		t.Fatalf("SCI() unexpected error: %v", err)
+	}
+	if got.EnergyKWh != run.EnergyTotalKWh || got.CarbonIntensity != run.EffectiveCIKgPerKWh {
+		t.Fatalf("E/I = %v/%v, expected %v/%v", got.EnergyKWh, got.CarbonIntensity, run.EnergyTotalKWh, run.EffectiveCIKgPerKWh)
+	}
+	if math.Abs(got.OperationalKg-run.EmissionsKg) > 1e-12 {
+		t.Fatalf("E*I = %v, expected run emissions %v", got.OperationalKg, run.EmissionsKg)
+	}
+	want := (run.EmissionsKg + run.EmbodiedEmissionsKg) / 250
+	if math.Abs(got.SCIKgPerUnit-want) > 1e-15 {
+		t.Fatalf("SCIKgPerUnit = %v, expected %v", got.SCIKgPerUnit, want)
+	}
+	if got.FunctionalUnit != "test" {
+		t.Fatalf("FunctionalUnit = %q, expected test", got.FunctionalUnit)
+	}
+}
+
+func TestSCIInvalidFunctionalUnitsReturnsErrInput(t *testing.T) {
+	a := New(nil)
+	_, err := a.SCI(context.Background(), SCIInput{
+		Run:             RunInput{Duration: 300, Region: "global", Model: ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2}},
+		FunctionalUnits: 0,
+	})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput, got %v", err)
+	}
+}
+
+func TestRunUncertaintyIsDeterministicForSeed(t *testing.T) {
+	a := New(nil)
+	in := RunInput{
+		Duration: 1800,
+		Region:   "global",
+		Model:    ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.25},
+		Uncertainty: UncertaintyInput{
+			Load:       calculator.Range{Min: 0.4, Max: 0.8},
+			PUE:        calculator.Range{Min: 1.1, Max: 1.4},
+			CIRelative: 0.2,
+			Samples:    500,
+			Seed:       7,
+		},
+	}
+	first, err := a.Run(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	second, err := a.Run(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if first.Uncertainty == nil || second.Uncertainty == nil {
+		t.Fatalf("expected uncertainty percentiles")
+	}
+	if *first.Uncertainty != *second.Uncertainty {
+		t.Fatalf("percentiles differ for same seed: %+v vs %+v", *first.Uncertainty, *second.Uncertainty)
+	}
+	p := *first.Uncertainty
+	if !(p.P5 < p.P50 && p.P50 < p.P95) {
+		t.Fatalf("expected p5 < p50 < p95, got %+v", p)
+	}
+	if first.EmissionsKg < p.P5 || first.EmissionsKg > p.P95 {
+		t.Fatalf("point estimate %v outside [p5, p95] %+v", first.EmissionsKg, p)
+	}
+	if first.UncertaintySamples != 500 || first.UncertaintySeed != 7 {
+		t.Fatalf("unexpected sampling metadata: %d/%d", first.UncertaintySamples, first.UncertaintySeed)
+	}
+}
+
+func TestRunUncertaintyKeepsSegmentOverrides(t *testing.T) {
+	a := New(nil)
+	for _, raw := range []string{"3600:0.5:1.0", "3600:0.5:0.0", "1800:0.5:1.0:macos,1800:0.3"} {
+		got, err := a.Run(context.Background(), RunInput{
+			Duration:    3600,
+			Region:      "global",
+			SegmentsRaw: raw,
+			Model:       ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+			Uncertainty: UncertaintyInput{PUE: calculator.Range{Min: 1.2, Max: 1.2000001}, Samples: 200, Seed: 1},
+		})
+		if err != nil {
+			t.Fatalf("Run(%s) unexpected error: %v", raw, err)
+		}
+		if got.Uncertainty == nil {
+			t.Fatalf("Run(%s) expected uncertainty percentiles", raw)
+		}
+		if math.Abs(got.Uncertainty.P50-got.EmissionsKg) > 1e-6 {
+			t.Fatalf("Run(%s) p50 = %v, expected point estimate %v", raw, got.Uncertainty.P50, got.EmissionsKg)
+		}
+	}
+}
+
+func TestRunWithoutRangesSkipsUncertainty(t *testing.T) {
+	a := New(nil)
+	got, err := a.Run(context.Background(), RunInput{Duration: 300, Region: "global"})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got.Uncertainty != nil {
+		t.Fatalf("expected no uncertainty for point inputs, got %+v", *got.Uncertainty)
+	}
+}
+
+func TestRunInvalidUncertaintyRangeReturnsErrInput(t *testing.T) {
+	a := New(nil)
+	_, err := a.Run(context.Background(), RunInput{
+		Duration:    300,
+		Region:      "global",
+		Model:       ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+		Uncertainty: UncertaintyInput{PUE: calculator.Range{Min: 0.9, Max: 1.3}},
+	})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput, got %v", err)
+	}
+}
+
+type historyFakeProvider struct {
+	fakeProvider
+	history []scheduling.ForecastPoint
+}
+
+func (f *historyFakeProvider) GetHistoryCI(_ context.Context, _ string, _ time.Time, _ time.Time) ([]scheduling.ForecastPoint, error) {
+	return f.history, nil
+}
+
+func TestRunSegmentsApplyPerSegmentLoadAndRunner(t *testing.T) {
+	a := New(nil)
+	model := ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2}
+	got, err := a.Run(context.Background(), RunInput{
+		SegmentsRaw: "120:0.4:0.05,1200:0.4:1.0:windows",
+		Model:       model,
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	ubuntu := models.RunnerProfiles["ubuntu"]
+	windows := models.RunnerProfiles["windows"]
+	idleKWh := 120 * (ubuntu.Idle + (ubuntu.Peak-ubuntu.Idle)*0.05) / 1000 / 3600
+	busyKWh := 1200 * windows.Peak / 1000 / 3600
+	want := (idleKWh + busyKWh) * 1.2 * 0.4
+	if math.Abs(got.EmissionsKg-want) > 1e-12 {
+		t.Fatalf("EmissionsKg = %v, expected %v", got.EmissionsKg, want)
+	}
+	if got.DurationSeconds != 1320 {
+		t.Fatalf("DurationSeconds = %d, expected 1320", got.DurationSeconds)
+	}
+}
+
+func TestRunSegmentsRejectInvalidLoadAndRunner(t *testing.T) {
+	a := New(nil)
+	for _, raw := range []string{"60:0.4:1.5", "60:0.4:0.5:solaris", "60:0.4:0.5:ubuntu:extra"} {
+		_, err := a.Run(context.Background(), RunInput{SegmentsRaw: raw})
+		if !errors.Is(err, ErrInput) {
+			t.Fatalf("Run(%q) expected ErrInput, got %v", raw, err)
+		}
+	}
+}
+
+func TestParseSegmentsFileJSONAndCSV(t *testing.T) {
+	jsonSpecs, err := ParseSegmentsFile("build.json", []byte(`{"segments":[
+		{"start":"2026-01-01T10:00:00Z","end":"2026-01-01T10:02:00Z","load":0.05},
+		{"start":"2026-01-01T10:02:00Z","duration_seconds":1200,"ci":0.3,"load":1,"runner":"macos"}
+	]}`))
+	if err != nil {
+		t.Fatalf("ParseSegmentsFile(json) unexpected error: %v", err)
+	}
+	if len(jsonSpecs) != 2 || jsonSpecs[0].Segment.Duration != 120 || !jsonSpecs[0].Segment.HasLoad || jsonSpecs[1].Segment.Runner != "macos" {
+		t.Fatalf("unexpected json specs: %+v", jsonSpecs)
+	}
+
+	csvSpecs, err := ParseSegmentsFile("build.csv", []byte("start,duration_seconds,ci,load\n2026-01-01T10:00:00Z,120,,0.05\n,600,0.3,\n"))
+	if err != nil {
+		t.Fatalf("ParseSegmentsFile(csv) unexpected error: %v", err)
+	}
+	if len(csvSpecs) != 2 || csvSpecs[0].Segment.CI != 0 || csvSpecs[1].Segment.HasLoad {
+		t.Fatalf("unexpected csv specs: %+v", csvSpecs)
+	}
+
+	if _, err := ParseSegmentsFile("build.csv", []byte("duration_seconds,ci\n120,\n")); !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for segment without ci or start, got %v", err)
+	}
+}
+
+func TestParseSegmentsFileOrdersAndRejectsOverlaps(t *testing.T) {
+	sorted, err := ParseSegmentsFile("build.csv", []byte("start,duration_seconds,ci\n2026-01-01T10:10:00Z,300,0.2\n2026-01-01T10:00:00Z,600,0.4\n"))
+	if err != nil {
+		t.Fatalf("ParseSegmentsFile() unexpected error: %v", err)
+	}
+	if sorted[0].Segment.CI != 0.4 || sorted[1].Segment.CI != 0.2 {
+		t.Fatalf("expected segments sorted by start, got %+v", sorted)
+	}
+
+	overlapping := []string{
+		"start,duration_seconds,ci\n2026-01-01T10:00:00Z,600,0.4\n2026-01-01T10:05:00Z,600,0.2\n",
+		"start,duration_seconds,ci\n2026-01-01T10:05:00Z,600,0.2\n2026-01-01T10:00:00Z,600,0.4\n",
+		// The untimestamped row runs 10:10-10:20, so a row starting at 10:15 overlaps it.
+		"start,duration_seconds,ci\n2026-01-01T10:00:00Z,600,0.4\n,600,0.3\n2026-01-01T10:15:00Z,600,0.2\n",
+	}
+	for _, data := range overlapping {
+		if _, err := ParseSegmentsFile("build.csv", []byte(data)); !errors.Is(err, ErrInput) {
+			t.Fatalf("ParseSegmentsFile(%q) expected ErrInput, got %v", data, err)
+		}
+	}
+}
+
+func TestRunFillsSegmentCIFromHistory(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	a := New(&historyFakeProvider{history: []scheduling.ForecastPoint{
+		{Timestamp: start.Add(-time.Hour), CI: 0.1},
+		{Timestamp: start, CI: 0.2},
+		{Timestamp: start.Add(30 * time.Minute), CI: 0.4},
+		{Timestamp: start.Add(time.Hour), CI: 0.5},
+	}})
+	got, err := a.Run(context.Background(), RunInput{
+		Segments: []SegmentSpec{
+			{Start: start.Add(15 * time.Minute), Segment: calculator.Segment{Duration: 1800}},
+		},
+		HistoryZone: "DE",
+		Model:       ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if math.Abs(got.EffectiveCIKgPerKWh-0.3) > 1e-9 {
+		t.Fatalf("EffectiveCIKgPerKWh = %v, expected history mean 0.3", got.EffectiveCIKgPerKWh)
+	}
+}
+
+func TestRunHistoryFillWithoutCapabilityReturnsErrProvider(t *testing.T) {
+	a := New(&fakeProvider{})
+	_, err := a.Run(context.Background(), RunInput{
+		Segments:    []SegmentSpec{{Start: time.Now().UTC(), Segment: calculator.Segment{Duration: 60}}},
+		HistoryZone: "DE",
+	})
+	if !errors.Is(err, ErrProvider) {
+		t.Fatalf("expected ErrProvider, got %v", err)
+	}
+}
+
+func TestRunIntegratesPowerTelemetryAgainstHistory(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	a := New(&historyFakeProvider{history: []scheduling.ForecastPoint{
+		{Timestamp: start, CI: 0.2},
+		{Timestamp: start.Add(15 * time.Minute), CI: 0.4},
+		{Timestamp: start.Add(30 * time.Minute), CI: 0.4},
+		{Timestamp: start.Add(45 * time.Minute), CI: 0.6},
+	}})
+	got, err := a.Run(context.Background(), RunInput{
+		Power: PowerInput{
+			Samples: []PowerSample{
+				{Timestamp: start, Watts: 100},
+				{Timestamp: start.Add(30 * time.Minute), Watts: 300},
+				{Timestamp: start.Add(time.Hour), Watts: 300},
+			},
+			Zone: "DE",
+		},
+		Model: ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	// 100 W for 30 min and 300 W for 30 min against 15-minute CI slots 0.2/0.4/0.4/0.6.
+	wantEnergy := 0.2
+	wantEmissions := (100*0.2 + 100*0.4 + 300*0.4 + 300*0.6) * 900 / 3.6e6 * 1.2
+	if got.DurationSeconds != 3600 || got.EnergySource != "power-file" {
+		t.Fatalf("unexpected power telemetry result: %+v", got)
+	}
+	if math.Abs(got.EnergyCPUKWh-wantEnergy) > 1e-12 {
+		t.Fatalf("EnergyCPUKWh = %.12f, expected %.12f", got.EnergyCPUKWh, wantEnergy)
+	}
+	if math.Abs(got.EmissionsKg-wantEmissions) > 1e-12 {
+		t.Fatalf("EmissionsKg = %.12f, expected %.12f", got.EmissionsKg, wantEmissions)
+	}
+}
+
+func TestRunIntegratesUnalignedPowerTelemetryExactly(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	a := New(&historyFakeProvider{history: []scheduling.ForecastPoint{
+		{Timestamp: start, CI: 0.2},
+		{Timestamp: start.Add(5 * time.Minute), CI: 0.5},
+	}})
+	first := start.Add(80 * time.Second)
+	got, err := a.Run(context.Background(), RunInput{
+		Power: PowerInput{
+			Samples: []PowerSample{
+				{Timestamp: first, Watts: 100},
+				{Timestamp: first.Add(150 * time.Second), Watts: 400},
+				{Timestamp: first.Add(420 * time.Second), Watts: 400},
+			},
+			Zone: "DE",
+		},
+		Model: ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.0},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	// 100 W for 150 s at 0.2, then 400 W for 70 s at 0.2 and 200 s at 0.5; no partial slot is dropped.
+	wantWattSeconds := 100*150.0 + 400*270.0
+	wantCI := (100*150*0.2 + 400*70*0.2 + 400*200*0.5) / wantWattSeconds
+	if got.DurationSeconds != 420 {
+		t.Fatalf("DurationSeconds = %d, expected 420", got.DurationSeconds)
+	}
+	if math.Abs(got.EnergyCPUKWh-wantWattSeconds/3.6e6) > 1e-12 {
+		t.Fatalf("EnergyCPUKWh = %.12f, expected %.12f", got.EnergyCPUKWh, wantWattSeconds/3.6e6)
+	}
+	if math.Abs(got.EffectiveCIKgPerKWh-wantCI) > 1e-9 {
+		t.Fatalf("EffectiveCIKgPerKWh = %v, expected %v", got.EffectiveCIKgPerKWh, wantCI)
+	}
+}
+
+func TestRunPowerTelemetryUncoveredByCIReturnsErrProvider(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	a := New(nil)
+	_, err := a.Run(context.Background(), RunInput{
+		Segments: []SegmentSpec{
+			{Start: start, Segment: calculator.Segment{Duration: 600, CI: 0.3}},
+		},
+		Power: PowerInput{
+			Samples: []PowerSample{
+				{Timestamp: start, Watts: 100},
+				{Timestamp: start.Add(time.Hour), Watts: 100},
+			},
+		},
+		Model: ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+	})
+	if !errors.Is(err, ErrProvider) {
+		t.Fatalf("expected ErrProvider, got %v", err)
+	}
+}
+
+func TestRunPowerTelemetryForecastCI(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	past := []PowerSample{
+		{Timestamp: now.Add(-2 * time.Hour), Watts: 100},
+		{Timestamp: now.Add(-time.Hour), Watts: 100},
+	}
+	provider := &fakeProvider{}
+	a := New(provider)
+	_, err := a.Run(context.Background(), RunInput{
+		Power: PowerInput{Samples: past, CISource: PowerCISourceForecast, Zone: "DE"},
+		Model: ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+	})
+	if !errors.Is(err, ErrInput) || !strings.Contains(err.Error(), "telemetry ending after now") {
+		t.Fatalf("expected ErrInput for past telemetry, got %v", err)
+	}
+
+	start := now.Add(time.Hour)
+	provider.forecastByZone = map[string][]scheduling.ForecastPoint{"DE": {
+		{Timestamp: now, CI: 0.3},
+		{Timestamp: start, CI: 0.3},
+		{Timestamp: start.Add(time.Hour), CI: 0.5},
+		{Timestamp: start.Add(2 * time.Hour), CI: 0.5},
+	}}
+	last := start.Add(2 * time.Hour)
+	before := time.Now().UTC()
+	got, err := a.Run(context.Background(), RunInput{
+		Power: PowerInput{
+			Samples: []PowerSample{
+				{Timestamp: start, Watts: 200},
+				{Timestamp: start.Add(time.Hour), Watts: 200},
+				{Timestamp: last, Watts: 200},
+			},
+			CISource: PowerCISourceForecast,
+			Zone:     "DE",
+		},
+		Model: ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.0},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	// The lookahead is the whole hours from now to the last sample.
+	if want := int(math.Ceil(last.Sub(before).Hours())); provider.forecastHours != want && provider.forecastHours != want-1 {
+		t.Fatalf("forecast hours = %d, expected %d", provider.forecastHours, want)
+	}
+	if math.Abs(got.EffectiveCIKgPerKWh-0.4) > 1e-9 {
+		t.Fatalf("EffectiveCIKgPerKWh = %v, expected forecast-weighted CI", got.EffectiveCIKgPerKWh)
+	}
+}
+
+func TestParsePowerFileRejectsDuplicateTimestamps(t *testing.T) {
+	samples, err := ParsePowerFile([]byte("timestamp,watts\n2026-03-01T10:01:00Z,120\n2026-03-01T10:00:00Z,100\n"))
+	if err != nil {
+		t.Fatalf("ParsePowerFile() unexpected error: %v", err)
+	}
+	if len(samples) != 2 || samples[0].Watts != 100 {
+		t.Fatalf("expected samples sorted by timestamp, got %+v", samples)
+	}
+
+	_, err = ParsePowerFile([]byte("timestamp,watts\n2026-03-01T10:00:00Z,100\n2026-03-01T10:00:00Z,120\n"))
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput, got %v", err)
+	}
+}
+
+func TestRunReportsMarketBasedScope2(t *testing.T) {
+	instruments, err := ParseInstrumentsFile([]byte(`{"instruments":[
+		{"name":"eu-ppa","region":"eu","coverage_pct":75,"residual_mix_kg_per_kwh":0.6,"valid_from":"2026-01-01","valid_to":"2026-12-31"},
+		{"name":"old-rec","region":"eu","coverage_pct":100,"valid_to":"2025-12-31"}
+	]}`))
+	if err != nil {
+		t.Fatalf("ParseInstrumentsFile() unexpected error: %v", err)
+	}
+
+	a := New(nil)
+	got, err := a.Run(context.Background(), RunInput{
+		Region: "eu",
+		Segments: []SegmentSpec{
+			{Start: time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC), Segment: calculator.Segment{Duration: 3600, CI: 0.3}},
+		},
+		Model:       ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+		Instruments: instruments,
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got.MarketBased == nil || len(got.MarketBased.Instruments) != 1 || got.MarketBased.Instruments[0] != "eu-ppa" {
+		t.Fatalf("expected only the valid instrument to apply, got %+v", got.MarketBased)
+	}
+	want := got.EnergyTotalKWh * 0.25 * 0.6
+	if math.Abs(got.MarketBased.EmissionsKg-want) > 1e-12 {
+		t.Fatalf("market-based = %.12f, expected %.12f", got.MarketBased.EmissionsKg, want)
+	}
+	if math.Abs(got.EmissionsKg-got.EnergyTotalKWh*0.3) > 1e-12 {
+		t.Fatalf("EmissionsKg = %.12f, expected location-based value", got.EmissionsKg)
+	}
+}
+
+func TestParseInstrumentsFileRejectsInvalidCoverage(t *testing.T) {
+	_, err := ParseInstrumentsFile([]byte(`{"instruments":[{"zone":"DE","coverage_pct":120}]}`))
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput, got %v", err)
+	}
+}
+
+func TestRunReportsStaticCostAndWater(t *testing.T) {
+	a := New(nil)
+	got, err := a.Run(context.Background(), RunInput{
+		Duration: 3600,
+		Region:   "eu",
+		Model:    ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.5},
+		Price:    PriceInput{Static: map[string]float64{"EU": 0.3, "*": 0.1}, Currency: "EUR"},
+		WUE:      1.8,
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got.Cost == nil || got.Cost.Currency != "EUR" || got.Cost.Source != PriceSourceStatic {
+		t.Fatalf("unexpected cost: %+v", got.Cost)
+	}
+	if math.Abs(got.Cost.Amount-got.EnergyTotalKWh*0.3) > 1e-12 {
+		t.Fatalf("Cost = %.12f, expected %.12f", got.Cost.Amount, got.EnergyTotalKWh*0.3)
+	}
+	if math.Abs(got.WaterLiters-got.EnergyITKWh*1.8) > 1e-12 {
+		t.Fatalf("WaterLiters = %.12f, expected %.12f", got.WaterLiters, got.EnergyITKWh*1.8)
+	}
+}
+
+func TestRunPricesSegmentsAgainstPriceSeries(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	prices, err := ParsePriceFile([]byte("timestamp,price\n2026-03-01T11:00:00Z,0.40\n2026-03-01T10:00:00Z,0.10\n"))
+	if err != nil {
+		t.Fatalf("ParsePriceFile() unexpected error: %v", err)
+	}
+
+	a := New(nil)
+	got, err := a.Run(context.Background(), RunInput{
+		Segments: []SegmentSpec{
+			{Start: start.Add(30 * time.Minute), Segment: calculator.Segment{Duration: 3600, CI: 0.3, Load: 1, HasLoad: true}},
+		},
+		Model: ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.0},
+		Price: PriceInput{Series: prices, Currency: "EUR"},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	// Half of the segment falls in the 0.10 slot and half in the 0.40 slot.
+	want := got.EnergyTotalKWh * 0.25
+	if got.Cost == nil || math.Abs(got.Cost.Amount-want) > 1e-12 || got.Cost.Source != PriceSourceFile {
+		t.Fatalf("Cost = %+v, expected %.12f from file prices", got.Cost, want)
+	}
+}
+
+func TestRunPriceSeriesNotCoveringRunReturnsErrInput(t *testing.T) {
+	a := New(nil)
+	_, err := a.Run(context.Background(), RunInput{
+		Segments: []SegmentSpec{
+			{Start: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Segment: calculator.Segment{Duration: 600, CI: 0.3}},
+		},
+		Price: PriceInput{Series: []PricePoint{{Timestamp: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), PricePerKWh: 0.2}}},
+	})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput, got %v", err)
+	}
+}
+
+func TestRunPricesPowerTelemetryAtPowerWeightedPrice(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	a := New(&historyFakeProvider{history: []scheduling.ForecastPoint{
+		{Timestamp: start, CI: 0.3},
+		{Timestamp: start.Add(30 * time.Minute), CI: 0.3},
+	}})
+	got, err := a.Run(context.Background(), RunInput{
+		Power: PowerInput{
+			Samples: []PowerSample{
+				{Timestamp: start, Watts: 100},
+				{Timestamp: start.Add(30 * time.Minute), Watts: 300},
+				{Timestamp: start.Add(time.Hour), Watts: 300},
+			},
+			Zone: "DE",
+		},
+		Model: ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.0},
+		Price: PriceInput{Series: []PricePoint{
+			{Timestamp: start, PricePerKWh: 0.10},
+			{Timestamp: start.Add(30 * time.Minute), PricePerKWh: 0.40},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	// 100 W at 0.10 then 300 W at 0.40: the power-weighted price is 0.325, not the 0.25 time mean.
+	want := got.EnergyTotalKWh * (100*0.10 + 300*0.40) / 400
+	if got.Cost == nil || math.Abs(got.Cost.Amount-want) > 1e-12 {
+		t.Fatalf("Cost = %+v, expected %.12f", got.Cost, want)
+	}
+}
+
+type priceFakeProvider struct {
+	fakeProvider
+	start time.Time
+	end   time.Time
+}
+
+func (f *priceFakeProvider) GetPriceSeries(_ context.Context, _ string, start time.Time, end time.Time) ([]PricePoint, error) {
+	f.start, f.end = start, end
+	return []PricePoint{{Timestamp: start, PricePerKWh: 0.2, Currency: "EUR"}, {Timestamp: end, PricePerKWh: 0.2, Currency: "EUR"}}, nil
+}
+
+func TestRunPriceZoneAnchorsUntimestampedRunToNow(t *testing.T) {
+	provider := &priceFakeProvider{}
+	a := New(provider)
+	before := time.Now().UTC()
+	got, err := a.Run(context.Background(), RunInput{
+		Duration: 3600,
+		Region:   "global",
+		Model:    ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.0},
+		Price:    PriceInput{Zone: "DE"},
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	after := time.Now().UTC()
+	// A run without timestamps has just finished, so prices are requested for the past hour.
+	if provider.end.Before(before) || provider.end.After(after) {
+		t.Fatalf("price range end = %s, expected now", provider.end)
+	}
+	if span := provider.end.Sub(provider.start); span != 2*time.Hour {
+		t.Fatalf("price range = %s, expected the run plus one leading slot", span)
+	}
+	if got.Cost == nil || math.Abs(got.Cost.Amount-got.EnergyTotalKWh*0.2) > 1e-12 {
+		t.Fatalf("Cost = %+v, expected provider price", got.Cost)
+	}
+}
+
+func TestRunGridMixReplacesRegionCI(t *testing.T) {
+	a := New(nil)
+	mix := GridMixInput{
+		Shares:  map[string]float64{"coal": 25, "hydro": 75},
+		Factors: map[string]float64{"coal": 0.82, "hydro": 0.024},
+	}
+	result, err := a.Run(context.Background(), RunInput{Duration: 3600, Region: "global", GridMix: mix})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if math.Abs(result.EffectiveCIKgPerKWh-(0.25*0.82+0.75*0.024)) > 1e-9 {
+		t.Fatalf("EffectiveCIKgPerKWh = %v, expected grid mix intensity", result.EffectiveCIKgPerKWh)
+	}
+
+	_, err = a.Run(context.Background(), RunInput{Duration: 3600, GridMix: mix, SegmentsRaw: "60:0.4"})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for grid mix with segments, got %v", err)
+	}
+	mix.Shares["fusion"] = 10
+	_, err = a.Run(context.Background(), RunInput{Duration: 3600, GridMix: mix})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for unknown fuel, got %v", err)
+	}
+}
+
+func TestRunResolvesRegionFromDataset(t *testing.T) {
+	a := New(nil)
+	result, err := a.Run(context.Background(), RunInput{Duration: 3600, Region: "aws:eu-central-1"})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	region := result.RegionCI
+	if region == nil || region.Region != "aws:eu-central-1" || region.Zone != "DE" || region.DatasetVersion == "" || region.DatasetYear == 0 {
+		t.Fatalf("RegionCI = %+v, expected DE dataset entry", region)
+	}
+	if math.Abs(result.EffectiveCIKgPerKWh-region.KgPerKWh) > 1e-12 {
+		t.Fatalf("EffectiveCIKgPerKWh = %v, expected %v", result.EffectiveCIKgPerKWh, region.KgPerKWh)
+	}
+
+	_, err = a.Run(context.Background(), RunInput{Duration: 3600, Region: "atlantis"})
+	if !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for unknown region, got %v", err)
+	}
+
+	result, err = a.Run(context.Background(), RunInput{Duration: 3600, Region: "atlantis", SegmentsRaw: "3600:0.3"})
+	if err != nil || result.RegionCI != nil {
+		t.Fatalf("Run(segments) = %+v, %v; region must be ignored when segments set CI", result.RegionCI, err)
+	}
+}
+
+func TestPlanBatchHonoursCapacityAndPriority(t *testing.T) {
+	base := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	a := New(&fakeProvider{
+		forecastByZone: map[string][]scheduling.ForecastPoint{
+			"DE": {
+				{Timestamp: base, CI: 0.5},
+				{Timestamp: base.Add(time.Hour), CI: 0.1},
+				{Timestamp: base.Add(2 * time.Hour), CI: 0.4},
+				{Timestamp: base.Add(3 * time.Hour), CI: 0.3},
+				{Timestamp: base.Add(4 * time.Hour), CI: 0.6},
+			},
+		},
+	})
+	file, err := ParseBatchFile([]byte(`{"jobs": [
+		{"id": "low", "duration": "1h", "zones": ["DE"]},
+		{"id": "high", "duration_seconds": 3600, "zones": ["DE"], "priority": 2},
+		{"id": "late", "duration": "1h", "zones": ["DE"], "deadline": "` + base.Add(30*time.Minute).Format(time.RFC3339) + `"}
+	]}`))
+	if err != nil {
+		t.Fatalf("ParseBatchFile() unexpected error: %v", err)
+	}
+	in := BatchInput{
+		Jobs:      file.Jobs,
+		Slot:      time.Hour,
+		Lookahead: 6,
+		Model:     ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+		Timeout:   time.Second,
+	}
+
+	for _, solver := range []string{BatchSolverHeuristic, BatchSolverExact} {
+		in.Solver = solver
+		out, err := a.PlanBatch(context.Background(), in)
+		if err != nil {
+			t.Fatalf("PlanBatch(%s) unexpected error: %v", solver, err)
+		}
+		if len(out.Assignments) != 2 ||
+			out.Assignments[0].JobID != "high" || !out.Assignments[0].StartUTC.Equal(base.Add(time.Hour)) ||
+			out.Assignments[1].JobID != "low" || !out.Assignments[1].StartUTC.Equal(base.Add(3*time.Hour)) {
+			t.Fatalf("PlanBatch(%s) assignments = %+v, expected high in the 0.1 hour and low in the 0.3 hour", solver, out.Assignments)
+		}
+		if len(out.Unscheduled) != 1 || out.Unscheduled[0].JobID != "late" || out.Unscheduled[0].Reason != "no-window" {
+			t.Fatalf("PlanBatch(%s) unscheduled = %+v, expected late without a window", solver, out.Unscheduled)
+		}
+		if out.Optimal != (solver == BatchSolverExact) {
+			t.Fatalf("PlanBatch(%s) optimal = %t", solver, out.Optimal)
+		}
+	}
+
+	in.Jobs = append(in.Jobs, BatchJobSpec{ID: "low", Duration: 60, Zones: []string{"DE"}})
+	if _, err := a.PlanBatch(context.Background(), in); !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for a duplicate job id, got %v", err)
+	}
+}
+
+func TestOptimizeGlobalLinearResampleMixedCadence(t *testing.T) {
+	base := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	de := make([]scheduling.ForecastPoint, 0, 9)
+	for i := 0; i <= 8; i++ {
+		de = append(de, scheduling.ForecastPoint{Timestamp: base.Add(time.Duration(i) * 15 * time.Minute), CI: 0.9})
+	}
+	a := New(&fakeProvider{
+		forecastByZone: map[string][]scheduling.ForecastPoint{
+			"DE": de,
+			"FR": {
+				{Timestamp: base, CI: 0.9},
+				{Timestamp: base.Add(time.Hour), CI: 0.1},
+				{Timestamp: base.Add(2 * time.Hour), CI: 0.9},
+			},
+		},
+	})
+	in := OptimizeGlobalInput{
+		Zones:            []string{"DE", "FR"},
+		Duration:         900,
+		Lookahead:        4,
+		ResampleFillMode: "linear",
+		Model:            ModelContext{Runner: "ubuntu", Load: 0.6, PUE: 1.2},
+		Timeout:          time.Second,
+	}
+
+	out, err := a.OptimizeGlobal(context.Background(), in)
+	if err != nil {
+		t.Fatalf("OptimizeGlobal() unexpected error: %v", err)
+	}
+	if out.ResampleFillMode != "linear" || out.ResampleMaxGapSeconds != 7200 || out.ResampleMaxFillAgeSeconds != 0 {
+		t.Fatalf("unexpected resample echo: %+v", out)
+	}
+	if out.BestZone != "FR" || !out.BestStart.Equal(base.Add(time.Hour)) {
+		t.Fatalf("best = %s at %s, expected FR at the interpolated minimum", out.BestZone, out.BestStart)
+	}
+
+	in.Zones = []string{"FR", "DE"}
+	reversed, err := a.OptimizeGlobal(context.Background(), in)
+	if err != nil || reversed.BestZone != out.BestZone || !reversed.BestStart.Equal(out.BestStart) || reversed.Emission != out.Emission {
+		t.Fatalf("result depends on zone order: %+v vs %+v (%v)", reversed, out, err)
+	}
+
+	in.ResampleMaxGap = -time.Minute
+	if _, err := a.OptimizeGlobal(context.Background(), in); !errors.Is(err, ErrInput) {
+		t.Fatalf("expected ErrInput for a negative max gap, got %v", err)
+	}
+}
@@ -11,6 +11,7 @@ const (
 	maxLookaheadHours  = 7 * 24
 	maxZonesCount      = 64
 	maxWaitDuration    = 7 * 24 * time.Hour
+	maxBatchJobs       = 1000
 )
 
 func validateDurationSeconds(duration int) error {
@@ -82,13 +83,59 @@ func validateNoRegretConfig(maxDelay time.Duration, minReductionPct float64) err
 	return nil
 }
 
-func validateResampleConfig(fillMode string, maxFillAge time.Duration) error {
+func validateResampleConfig(fillMode string, maxFillAge time.Duration, maxGap time.Duration) error {
 	mode := strings.TrimSpace(strings.ToLower(fillMode))
-	if mode != "" && mode != "forward" && mode != "strict" {
-		return fmt.Errorf("%w: resample-fill must be one of forward|strict", ErrInput)
+	if mode != "" && mode != "forward" && mode != "strict" && mode != "linear" && mode != "nearest" {
+		return fmt.Errorf("%w: resample-fill must be one of forward|strict|linear|nearest", ErrInput)
 	}
 	if maxFillAge < 0 {
 		return fmt.Errorf("%w: resample-max-fill-age must be >= 0", ErrInput)
 	}
+	if maxGap < 0 {
+		return fmt.Errorf("%w: resample-max-gap must be >= 0", ErrInput)
+	}
+	return nil
+}
+
+func validateWindowSearch(mode string, granularity time.Duration) error {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	if mode != "" && mode != WindowSearchContinuous && mode != WindowSearchForecastStarts {
+		return fmt.Errorf("%w: window-search must be one of continuous|forecast-starts", ErrInput)
+	}
+	if granularity < 0 || granularity%time.Second != 0 {
+		return fmt.Errorf("%w: start-granularity must be a non-negative whole number of seconds", ErrInput)
+	}
+	if granularity > 0 && mode == WindowSearchForecastStarts {
+		return fmt.Errorf("%w: start-granularity requires window-search continuous", ErrInput)
+	}
+	return nil
+}
+
+func validateSplit(minChunk time.Duration, maxChunks int, resumeOverhead time.Duration, step time.Duration) error {
+	if step < 0 || step%time.Second != 0 {
+		return fmt.Errorf("%w: split-step must be a non-negative whole number of seconds", ErrInput)
+	}
+	if minChunk < 0 || minChunk%time.Second != 0 {
+		return fmt.Errorf("%w: min-chunk must be a non-negative whole number of seconds", ErrInput)
+	}
+	if maxChunks < 0 {
+		return fmt.Errorf("%w: max-chunks must be >= 0", ErrInput)
+	}
+	if resumeOverhead < 0 || resumeOverhead%time.Second != 0 {
+		return fmt.Errorf("%w: resume-overhead must be a non-negative whole number of seconds", ErrInput)
+	}
+	return nil
+}
+
+func validateRisk(risk RiskModel) error {
+	if risk.Aversion < 0 {
+		return fmt.Errorf("%w: risk-aversion must be >= 0", ErrInput)
+	}
+	if risk.ForecastError < 0 || risk.ForecastError > 1 {
+		return fmt.Errorf("%w: forecast-error must be in [0, 1]", ErrInput)
+	}
+	if risk.ForecastErrorPerHour < 0 || risk.ForecastErrorPerHour > 1 {
+		return fmt.Errorf("%w: forecast-error-per-hour must be in [0, 1]", ErrInput)
+	}
 	return nil
 }
@@ -0,0 +1,86 @@
+package calculator
+
+const (
+	// DefaultMemoryKWhPerGBHour follows the Cloud Carbon Footprint memory coefficient.
+	// DefaultMemoryKWhPerGBHour 采用 Cloud Carbon Footprint 的内存系数。
+	DefaultMemoryKWhPerGBHour = 0.000392
+	// DefaultStorageKWhPerGB is a conservative per-GB-written SSD write energy estimate.
+	// DefaultStorageKWhPerGB 为每写入 1GB 的保守 SSD 能耗估计。
+	DefaultStorageKWhPerGB = 0.0012
+	// DefaultNetworkKWhPerGB follows the Cloud Carbon Footprint network coefficient.
+	// DefaultNetworkKWhPerGB 采用 Cloud Carbon Footprint 的网络系数。
+	DefaultNetworkKWhPerGB = 0.001
+)
+
+// ResourceUsage describes optional non-CPU resource consumption of one job.
+// ResourceUsage 描述单个作业可选的非 CPU 资源消耗。
+type ResourceUsage struct {
+	// MemoryGB is the average resident memory during the run; energy scales with GB x hours.
+	// MemoryGB 为运行期间平均驻留内存；能耗按 GB x 小时计算。
+	MemoryGB         float64
+	StorageWrittenGB float64
+	NetworkGB        float64
+}
+
+// ResourceCoefficients converts resource usage into IT energy (kWh).
+// ResourceCoefficients 将资源使用量换算为 IT 能耗（kWh）。
+type ResourceCoefficients struct {
+	MemoryKWhPerGBHour float64
+	StorageKWhPerGB    float64
+	NetworkKWhPerGB    float64
+}
+
+// ComponentEnergy is the IT energy split by component, before PUE.
+// ComponentEnergy 为按组件拆分的 IT 能耗（未乘 PUE）。
+type ComponentEnergy struct {
+	CPUKWh     float64
+	MemoryKWh  float64
+	StorageKWh float64
+	NetworkKWh float64
+}
+
+// DefaultResourceCoefficients returns the built-in component coefficients.
+// DefaultResourceCoefficients 返回内置组件系数。
+func DefaultResourceCoefficients() ResourceCoefficients {
+	return ResourceCoefficients{
+		MemoryKWhPerGBHour: DefaultMemoryKWhPerGBHour,
+		StorageKWhPerGB:    DefaultStorageKWhPerGB,
+		NetworkKWhPerGB:    DefaultNetworkKWhPerGB,
+	}
+}
+
+// IsZero reports whether no resource usage is recorded.
+// IsZero 判断是否未记录任何资源使用量。
+func (u ResourceUsage) IsZero() bool {
+	return u == ResourceUsage{}
+}
+
+// Total returns the summed IT energy of all components.
+// Total 返回所有组件 IT 能耗之和。
+func (e ComponentEnergy) Total() float64 {
+	return e.CPUKWh + e.MemoryKWh + e.StorageKWh + e.NetworkKWh
+}
+
+// EstimateResourceEnergyKWh returns memory, storage and network IT energy in kWh.
+// EstimateResourceEnergyKWh 返回内存、存储、网络的 IT 能耗（kWh）。
+func EstimateResourceEnergyKWh(durationSeconds int, usage ResourceUsage, coefficients ResourceCoefficients) (float64, float64, float64) {
+	memory := usage.MemoryGB * float64(durationSeconds) / 3600.0 * coefficients.MemoryKWhPerGBHour
+	storage := usage.StorageWrittenGB * coefficients.StorageKWhPerGB
+	network := usage.NetworkGB * coefficients.NetworkKWhPerGB
+	return memory, storage, network
+}
+
+// WeightedMeanCI returns the duration-weighted mean CI of segments.
+// WeightedMeanCI 返回分段按时长加权的平均碳强度。
+func WeightedMeanCI(segments []Segment) float64 {
+	totalDuration := 0
+	weighted := 0.0
+	for _, segment := range segments {
+		totalDuration += segment.Duration
+		weighted += float64(segment.Duration) * segment.CI
+	}
+	if totalDuration <= 0 {
+		return 0
+	}
+	return weighted / float64(totalDuration)
+}
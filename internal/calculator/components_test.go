@@ -0,0 +1,32 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateResourceEnergyKWh(t *testing.T) {
+	usage := ResourceUsage{MemoryGB: 16, StorageWrittenGB: 10, NetworkGB: 4}
+	coefficients := ResourceCoefficients{MemoryKWhPerGBHour: 0.0004, StorageKWhPerGB: 0.001, NetworkKWhPerGB: 0.002}
+
+	memory, storage, network := EstimateResourceEnergyKWh(1800, usage, coefficients)
+	if math.Abs(memory-16*0.5*0.0004) > 1e-12 {
+		t.Fatalf("memory = %v, expected %v", memory, 16*0.5*0.0004)
+	}
+	if math.Abs(storage-0.01) > 1e-12 {
+		t.Fatalf("storage = %v, expected 0.01", storage)
+	}
+	if math.Abs(network-0.008) > 1e-12 {
+		t.Fatalf("network = %v, expected 0.008", network)
+	}
+}
+
+func TestWeightedMeanCI(t *testing.T) {
+	got := WeightedMeanCI([]Segment{{Duration: 100, CI: 0.2}, {Duration: 300, CI: 0.6}})
+	if math.Abs(got-0.5) > 1e-12 {
+		t.Fatalf("WeightedMeanCI() = %v, expected 0.5", got)
+	}
+	if WeightedMeanCI(nil) != 0 {
+		t.Fatalf("expected zero mean for empty segments")
+	}
+}
@@ -0,0 +1,17 @@
+package calculator
+
+import "github.com/chenzhuyu2004/carbon-guard/pkg/models"
+
+const secondsPerYear = 365 * 24 * 3600.0
+
+// EstimateEmbodiedKg amortizes hardware emissions over the job's share of lifetime.
+// EstimateEmbodiedKg 按作业占用的寿命份额摊销硬件制造排放。
+//
+//	M = TE * (duration / lifetime) * share
+func EstimateEmbodiedKg(durationSeconds int, embodied models.EmbodiedProfile) float64 {
+	if durationSeconds <= 0 || embodied.TotalKgCO2e <= 0 || embodied.LifetimeYears <= 0 || embodied.Share <= 0 {
+		return 0
+	}
+	lifetimeSeconds := embodied.LifetimeYears * secondsPerYear
+	return embodied.TotalKgCO2e * float64(durationSeconds) / lifetimeSeconds * embodied.Share
+}
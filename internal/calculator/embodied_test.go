@@ -0,0 +1,23 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/chenzhuyu2004/carbon-guard/pkg/models"
+)
+
+func TestEstimateEmbodiedKg(t *testing.T) {
+	embodied := models.EmbodiedProfile{TotalKgCO2e: 1000, LifetimeYears: 4, Share: 0.25}
+	duration := 3600
+
+	expected := 1000 * 3600.0 / (4 * 365 * 24 * 3600.0) * 0.25
+	got := EstimateEmbodiedKg(duration, embodied)
+	if math.Abs(got-expected) > 1e-15 {
+		t.Fatalf("EstimateEmbodiedKg() = %v, expected %v", got, expected)
+	}
+
+	if EstimateEmbodiedKg(duration, models.EmbodiedProfile{TotalKgCO2e: 1000}) != 0 {
+		t.Fatalf("expected zero embodied without lifetime/share")
+	}
+}
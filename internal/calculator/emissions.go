@@ -8,6 +8,16 @@ import (
 type Segment struct {
 	Duration int
 	CI       float64
+	// Load overrides the run-level load when HasLoad is set (0 is a valid idle load).
+	// HasLoad 为 true 时 Load 覆盖运行级负载（0 表示空闲，是合法值）。
+	Load    float64
+	HasLoad bool
+	// Runner overrides the run-level power profile for this segment when non-empty.
+	// Runner 非空时覆盖该分段的功率画像。
+	Runner string
+	// PowerScale multiplies the resolved power profile when non-zero; zero means 1.
+	// PowerScale 非零时乘以解析后的功率画像；零值表示 1。
+	PowerScale float64
 }
 
 func EstimateEmissionsKg(durationSeconds int) float64 {
@@ -15,15 +25,8 @@ func EstimateEmissionsKg(durationSeconds int) float64 {
 }
 
 func EstimateEmissionsAdvanced(duration int, runner string, region string, load float64, pue float64) float64 {
-	profile, ok := models.RunnerProfiles[runner]
-	if !ok {
-		profile = models.RunnerProfiles["ubuntu"]
-	}
-
-	ci, ok := models.RegionCarbonIntensity[region]
-	if !ok {
-		ci = models.RegionCarbonIntensity["global"]
-	}
+	profile := RunnerProfile(runner)
+	ci := RegionIntensity(region)
 
 	power := profile.Idle + (profile.Peak-profile.Idle)*load
 	energyKWh := float64(duration) * power / 1000.0 / 3600.0
@@ -37,18 +40,71 @@ func EstimateEmissionsWithSegments(
 	load float64,
 	pue float64,
 ) float64 {
-	profile, ok := models.RunnerProfiles[runner]
-	if !ok {
-		profile = models.RunnerProfiles["ubuntu"]
-	}
-
-	power := profile.Idle + (profile.Peak-profile.Idle)*load
+	return EstimateEmissionsWithProfile(segments, RunnerProfile(runner), load, pue)
+}
 
+// EstimateEmissionsWithProfile applies the segment model to an explicit power profile.
+// EstimateEmissionsWithProfile 使用显式功率画像计算分段排放。
+//
+// Segments may override load and runner; the arguments are the run-level defaults.
+// 分段可覆盖 load 与 runner；参数为运行级默认值。
+func EstimateEmissionsWithProfile(
+	segments []Segment,
+	profile models.PowerProfile,
+	load float64,
+	pue float64,
+) float64 {
 	total := 0.0
 	for _, segment := range segments {
-		energyKWh := float64(segment.Duration) * power / 1000.0 / 3600.0
+		energyKWh := segment.EnergyKWh(profile, load)
 		total += energyKWh * pue * segment.CI
 	}
 
 	return total
 }
+
+// SegmentsEnergyKWh returns total IT energy of all segments before PUE.
+// SegmentsEnergyKWh 返回所有分段的 IT 能耗总和（未乘 PUE）。
+func SegmentsEnergyKWh(segments []Segment, profile models.PowerProfile, load float64) float64 {
+	total := 0.0
+	for _, segment := range segments {
+		total += segment.EnergyKWh(profile, load)
+	}
+	return total
+}
+
+// EnergyKWh returns the segment IT energy, applying its load/runner overrides.
+// EnergyKWh 返回分段 IT 能耗，并应用分段的 load/runner 覆盖。
+func (s Segment) EnergyKWh(profile models.PowerProfile, load float64) float64 {
+	if s.Runner != "" {
+		profile = RunnerProfile(s.Runner)
+	}
+	if s.HasLoad {
+		load = s.Load
+	}
+	power := profile.Idle + (profile.Peak-profile.Idle)*load
+	if s.PowerScale != 0 {
+		power *= s.PowerScale
+	}
+	return float64(s.Duration) * power / 1000.0 / 3600.0
+}
+
+// RunnerProfile returns the power profile for a runner, falling back to ubuntu.
+// RunnerProfile 返回 runner 对应的功率画像，未知 runner 回退到 ubuntu。
+func RunnerProfile(runner string) models.PowerProfile {
+	profile, ok := models.RunnerProfiles[runner]
+	if !ok {
+		profile = models.RunnerProfiles["ubuntu"]
+	}
+	return profile
+}
+
+// RegionIntensity returns the static CI for a region, falling back to global.
+// RegionIntensity 返回区域静态碳强度，未知区域回退到 global。
+func RegionIntensity(region string) float64 {
+	ci, ok := models.RegionCarbonIntensity[region]
+	if !ok {
+		ci = models.RegionCarbonIntensity["global"]
+	}
+	return ci
+}
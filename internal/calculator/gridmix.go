@@ -0,0 +1,65 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseGridMix accepts "gas=40,wind=30,nuclear=30"; shares may be percentages, fractions, or MW.
+// ParseGridMix 接受 "gas=40,wind=30,nuclear=30"；份额可为百分比、小数或 MW。
+func ParseGridMix(raw string) (map[string]float64, error) {
+	shares := make(map[string]float64)
+	for _, item := range strings.Split(raw, ",") {
+		fuel, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		fuel = strings.ToLower(strings.TrimSpace(fuel))
+		if !ok || fuel == "" {
+			return nil, fmt.Errorf("invalid grid mix entry %q: expected fuel=share", item)
+		}
+		share, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || math.IsNaN(share) || math.IsInf(share, 0) || share < 0 {
+			return nil, fmt.Errorf("invalid grid mix share for %s: %q", fuel, value)
+		}
+		shares[fuel] += share
+	}
+	return shares, nil
+}
+
+// GridMixIntensity converts generation shares into a carbon intensity in kgCO2eq/kWh.
+// GridMixIntensity 将发电结构份额换算为碳强度，单位 kgCO2eq/kWh。
+//
+//	CI = sum(share_f * EF_f) / sum(share_f)
+//
+// Shares are normalized by their sum, so percentages and absolute generation both work.
+// Fuel names are case-insensitive; a fuel without a factor is an error.
+// 份额按总和归一化，因此百分比与绝对发电量均可使用；燃料名不区分大小写，缺少因子的燃料视为错误。
+func GridMixIntensity(shares map[string]float64, factors map[string]float64) (float64, error) {
+	fuels := make([]string, 0, len(shares))
+	for fuel := range shares {
+		fuels = append(fuels, fuel)
+	}
+	sort.Strings(fuels)
+
+	total, weighted := 0.0, 0.0
+	for _, fuel := range fuels {
+		share := shares[fuel]
+		if math.IsNaN(share) || math.IsInf(share, 0) || share < 0 {
+			return 0, fmt.Errorf("invalid grid mix share for %s", fuel)
+		}
+		if share == 0 {
+			continue
+		}
+		factor, ok := factors[strings.ToLower(strings.TrimSpace(fuel))]
+		if !ok {
+			return 0, fmt.Errorf("no emission factor for fuel %q", fuel)
+		}
+		total += share
+		weighted += share * factor
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("grid mix shares must sum to > 0")
+	}
+	return weighted / total, nil
+}
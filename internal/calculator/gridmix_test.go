@@ -0,0 +1,45 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGridMixIntensityNormalizesShares(t *testing.T) {
+	factors := map[string]float64{"gas": 0.49, "wind": 0.011, "nuclear": 0.012}
+	shares, err := ParseGridMix("Gas=40, wind=30,nuclear=30")
+	if err != nil {
+		t.Fatalf("ParseGridMix() unexpected error: %v", err)
+	}
+
+	got, err := GridMixIntensity(shares, factors)
+	if err != nil {
+		t.Fatalf("GridMixIntensity() unexpected error: %v", err)
+	}
+	expected := 0.4*0.49 + 0.3*0.011 + 0.3*0.012
+	if math.Abs(got-expected) > 1e-12 {
+		t.Fatalf("GridMixIntensity() = %v, expected %v", got, expected)
+	}
+
+	// Absolute generation (MW) yields the same intensity as percentages.
+	got, err = GridMixIntensity(map[string]float64{"gas": 800, "wind": 600, "nuclear": 600}, factors)
+	if err != nil || math.Abs(got-expected) > 1e-12 {
+		t.Fatalf("GridMixIntensity(MW) = %v, %v, expected %v", got, err, expected)
+	}
+}
+
+func TestGridMixIntensityErrors(t *testing.T) {
+	factors := map[string]float64{"gas": 0.49}
+	if _, err := GridMixIntensity(map[string]float64{"gas": 50, "fusion": 50}, factors); err == nil {
+		t.Fatalf("expected error for fuel without factor")
+	}
+	if _, err := GridMixIntensity(map[string]float64{"gas": 0}, factors); err == nil {
+		t.Fatalf("expected error for zero total share")
+	}
+	if _, err := ParseGridMix("gas:40"); err == nil {
+		t.Fatalf("expected error for malformed entry")
+	}
+	if _, err := ParseGridMix("gas=-1"); err == nil {
+		t.Fatalf("expected error for negative share")
+	}
+}
@@ -0,0 +1,108 @@
+package calculator
+
+import (
+	"strings"
+	"time"
+)
+
+// ContractualInstrument is a REC, GO, or PPA covering part of the consumption in a zone or region.
+// ContractualInstrument 为覆盖某区域部分用电的 REC、GO 或 PPA 合同工具。
+//
+// ValidTo is exclusive; zero ValidFrom/ValidTo leave that side of the period open.
+// ValidTo 为开区间；ValidFrom/ValidTo 为零值时对应一侧不设限。
+type ContractualInstrument struct {
+	Name   string
+	Zone   string
+	Region string
+	// CoveragePct is the share of consumption covered by the instrument, in [0, 100].
+	// CoveragePct 为合同工具覆盖的用电比例，取值 [0, 100]。
+	CoveragePct float64
+	// EmissionFactorKgPerKWh applies to covered energy (0 for renewable certificates).
+	// EmissionFactorKgPerKWh 作用于被覆盖的电量（可再生证书为 0）。
+	EmissionFactorKgPerKWh float64
+	// ResidualMixKgPerKWh applies to uncovered energy; 0 falls back to the location-based CI.
+	// ResidualMixKgPerKWh 作用于未覆盖电量；为 0 时回退到基于位置的 CI。
+	ResidualMixKgPerKWh float64
+	ValidFrom           time.Time
+	ValidTo             time.Time
+}
+
+// MarketBasedResult is scope 2 emissions with contractual instruments applied.
+// MarketBasedResult 为应用合同工具后的范围二排放。
+type MarketBasedResult struct {
+	EmissionsKg float64
+	CoveragePct float64
+	Instruments []string
+}
+
+// Matches reports whether the instrument applies to zone or region at time at.
+// Matches 判断合同工具在 at 时刻是否适用于 zone 或 region。
+func (c ContractualInstrument) Matches(zone string, region string, at time.Time) bool {
+	zoneMatch := c.Zone != "" && strings.EqualFold(strings.TrimSpace(c.Zone), strings.TrimSpace(zone))
+	regionMatch := c.Region != "" && strings.EqualFold(strings.TrimSpace(c.Region), strings.TrimSpace(region))
+	if !zoneMatch && !regionMatch {
+		return false
+	}
+	if !c.ValidFrom.IsZero() && at.Before(c.ValidFrom) {
+		return false
+	}
+	if !c.ValidTo.IsZero() && !at.Before(c.ValidTo) {
+		return false
+	}
+	return true
+}
+
+// EstimateMarketBased applies matching instruments to location-based scope 2 emissions.
+// EstimateMarketBased 将匹配的合同工具应用到基于位置的范围二排放。
+//
+//	market = E * (cov * EF_instrument + (1 - cov) * residual)
+//
+// Coverage is summed over matching instruments and capped at 100%. Without a matching
+// instrument the market-based figure equals the location-based one.
+// 覆盖率为各匹配工具之和并以 100% 为上限；无匹配工具时市场法结果等于位置法结果。
+func EstimateMarketBased(
+	energyKWh float64,
+	locationKg float64,
+	instruments []ContractualInstrument,
+	zone string,
+	region string,
+	at time.Time,
+) MarketBasedResult {
+	result := MarketBasedResult{EmissionsKg: locationKg}
+	if energyKWh <= 0 {
+		return result
+	}
+
+	locationCI := locationKg / energyKWh
+	residual := 0.0
+	coveredKg := 0.0
+	coverage := 0.0
+	for _, instrument := range instruments {
+		if !instrument.Matches(zone, region, at) {
+			continue
+		}
+		result.Instruments = append(result.Instruments, instrument.Name)
+		if residual == 0 && instrument.ResidualMixKgPerKWh > 0 {
+			residual = instrument.ResidualMixKgPerKWh
+		}
+		share := instrument.CoveragePct / 100
+		if coverage+share > 1 {
+			share = 1 - coverage
+		}
+		if share <= 0 {
+			continue
+		}
+		coverage += share
+		coveredKg += energyKWh * share * instrument.EmissionFactorKgPerKWh
+	}
+	if len(result.Instruments) == 0 {
+		return result
+	}
+	if residual == 0 {
+		residual = locationCI
+	}
+
+	result.CoveragePct = coverage * 100
+	result.EmissionsKg = coveredKg + energyKWh*(1-coverage)*residual
+	return result
+}
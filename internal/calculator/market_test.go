@@ -0,0 +1,32 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEstimateMarketBasedAppliesCoverageAndResidualMix(t *testing.T) {
+	at := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	instruments := []ContractualInstrument{
+		{Name: "ppa", Zone: "DE", CoveragePct: 60, ResidualMixKgPerKWh: 0.7, ValidFrom: at.AddDate(0, -1, 0), ValidTo: at.AddDate(0, 1, 0)},
+		{Name: "rec", Region: "eu", CoveragePct: 60},
+		{Name: "expired", Zone: "DE", CoveragePct: 100, ValidTo: at},
+	}
+
+	got := EstimateMarketBased(10, 4, instruments, "de", "eu", at)
+	// 60% + 40% (capped) coverage at 0 kg/kWh leaves nothing for the residual mix.
+	if got.EmissionsKg != 0 || got.CoveragePct != 100 || len(got.Instruments) != 2 {
+		t.Fatalf("EstimateMarketBased() = %+v, expected full zero-emission coverage", got)
+	}
+
+	got = EstimateMarketBased(10, 4, instruments[:1], "DE", "", at)
+	if math.Abs(got.EmissionsKg-10*0.4*0.7) > 1e-12 || got.CoveragePct != 60 {
+		t.Fatalf("EstimateMarketBased() = %+v, expected residual mix on 40%%", got)
+	}
+
+	got = EstimateMarketBased(10, 4, instruments, "FR", "us", at)
+	if got.EmissionsKg != 4 || got.Instruments != nil {
+		t.Fatalf("EstimateMarketBased() = %+v, expected location-based fallback", got)
+	}
+}
@@ -0,0 +1,118 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const rangeSeparator = ".."
+
+// Range is a closed interval [Min, Max]; Min == Max represents a point value.
+// Range 表示闭区间 [Min, Max]；Min == Max 时表示单点值。
+type Range struct {
+	Min float64
+	Max float64
+}
+
+// Percentiles summarizes a sampled distribution.
+// Percentiles 汇总采样分布的分位数。
+type Percentiles struct {
+	P5  float64
+	P50 float64
+	P95 float64
+}
+
+// PointRange returns the degenerate range [v, v].
+// PointRange 返回退化区间 [v, v]。
+func PointRange(v float64) Range {
+	return Range{Min: v, Max: v}
+}
+
+// ParseRange accepts "0.6" or "0.4..0.8".
+// ParseRange 接受 "0.6" 或 "0.4..0.8" 两种写法。
+func ParseRange(raw string) (Range, error) {
+	raw = strings.TrimSpace(raw)
+	lowRaw, highRaw, isRange := strings.Cut(raw, rangeSeparator)
+	low, err := strconv.ParseFloat(strings.TrimSpace(lowRaw), 64)
+	if err != nil || math.IsNaN(low) || math.IsInf(low, 0) {
+		return Range{}, fmt.Errorf("invalid value %q", raw)
+	}
+	if !isRange {
+		return PointRange(low), nil
+	}
+	high, err := strconv.ParseFloat(strings.TrimSpace(highRaw), 64)
+	if err != nil || math.IsNaN(high) || math.IsInf(high, 0) {
+		return Range{}, fmt.Errorf("invalid range %q", raw)
+	}
+	if high < low {
+		return Range{}, fmt.Errorf("invalid range %q: max must be >= min", raw)
+	}
+	return Range{Min: low, Max: high}, nil
+}
+
+// Mid returns the interval midpoint, used as the point estimate.
+// Mid 返回区间中点，作为点估计使用。
+func (r Range) Mid() float64 {
+	return (r.Min + r.Max) / 2
+}
+
+// IsPoint reports whether the range carries no spread.
+// IsPoint 判断区间是否没有离散度。
+func (r Range) IsPoint() bool {
+	return r.Min == r.Max
+}
+
+func (r Range) String() string {
+	if r.IsPoint() {
+		return strconv.FormatFloat(r.Min, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(r.Min, 'g', -1, 64) + rangeSeparator + strconv.FormatFloat(r.Max, 'g', -1, 64)
+}
+
+// Sample draws uniformly from the range.
+// Sample 在区间内均匀采样。
+func (r Range) Sample(rng *rand.Rand) float64 {
+	if r.IsPoint() {
+		return r.Min
+	}
+	return r.Min + rng.Float64()*(r.Max-r.Min)
+}
+
+// RelativeRange returns [v*(1-rel), v*(1+rel)] for a symmetric relative error.
+// RelativeRange 返回对称相对误差对应的区间 [v*(1-rel), v*(1+rel)]。
+func RelativeRange(v float64, rel float64) Range {
+	return Range{Min: v * (1 - rel), Max: v * (1 + rel)}
+}
+
+// ComputePercentiles returns p5/p50/p95 using linear interpolation between order statistics.
+// ComputePercentiles 使用顺序统计量线性插值计算 p5/p50/p95。
+func ComputePercentiles(samples []float64) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	return Percentiles{
+		P5:  quantile(sorted, 0.05),
+		P50: quantile(sorted, 0.50),
+		P95: quantile(sorted, 0.95),
+	}
+}
+
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	if lower == upper {
+		return sorted[lower]
+	}
+	frac := pos - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*frac
+}
@@ -0,0 +1,41 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	got, err := ParseRange("0.4..0.8")
+	if err != nil {
+		t.Fatalf("ParseRange() unexpected error: %v", err)
+	}
+	if got.Min != 0.4 || got.Max != 0.8 || math.Abs(got.Mid()-0.6) > 1e-12 {
+		t.Fatalf("ParseRange() = %+v, expected [0.4, 0.8]", got)
+	}
+
+	point, err := ParseRange("1.2")
+	if err != nil || !point.IsPoint() || point.Min != 1.2 {
+		t.Fatalf("ParseRange(point) = %+v, %v", point, err)
+	}
+
+	for _, raw := range []string{"", "abc", "0.8..0.4", "0.1..x"} {
+		if _, err := ParseRange(raw); err == nil {
+			t.Fatalf("ParseRange(%q) expected error", raw)
+		}
+	}
+}
+
+func TestComputePercentilesInterpolates(t *testing.T) {
+	samples := make([]float64, 0, 101)
+	for i := 100; i >= 0; i-- {
+		samples = append(samples, float64(i))
+	}
+	got := ComputePercentiles(samples)
+	if got.P5 != 5 || got.P50 != 50 || got.P95 != 95 {
+		t.Fatalf("ComputePercentiles() = %+v, expected 5/50/95", got)
+	}
+	if samples[0] != 100 {
+		t.Fatalf("ComputePercentiles() must not reorder input")
+	}
+}
@@ -0,0 +1,147 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownCloudRegion = errors.New("unknown cloud region")
+
+// CloudRegion is a cloud provider region mapped to the grid zone of its data centers.
+// CloudRegion 为云厂商 region 及其数据中心所在电网区域的映射。
+type CloudRegion struct {
+	// ID is the canonical "provider:region" identifier, for example "aws:eu-west-1".
+	// ID 为规范化的 "provider:region" 标识，如 "aws:eu-west-1"。
+	ID       string
+	Provider string
+	Region   string
+	Zone     string
+}
+
+// CloudRegionMap maps AWS/GCP/Azure region codes to Electricity Maps zones.
+// CloudRegionMap 将 AWS/GCP/Azure region 代码映射到 Electricity Maps 区域。
+type CloudRegionMap struct {
+	Version string
+	// regions maps provider -> region -> zone.
+	// regions 为 provider -> region -> zone 的映射。
+	regions map[string]map[string]string
+}
+
+type cloudRegionMapFile struct {
+	Version      string                       `json:"version"`
+	CloudRegions map[string]map[string]string `json:"cloud_regions"`
+}
+
+// DefaultCloudRegionMap returns the mapping embedded with the region dataset.
+// DefaultCloudRegionMap 返回随区域数据集内嵌的映射。
+func DefaultCloudRegionMap() (CloudRegionMap, error) {
+	var file cloudRegionMapFile
+	if err := json.Unmarshal(embeddedRegions, &file); err != nil {
+		return CloudRegionMap{}, fmt.Errorf("embedded cloud region map: %w", err)
+	}
+	return buildCloudRegionMap(CloudRegionMap{}, file)
+}
+
+// LoadCloudRegionMap returns the embedded mapping merged with an optional override file.
+// LoadCloudRegionMap 返回内嵌映射，并按需合并覆盖文件中的条目。
+//
+// The override uses the same {"cloud_regions": {"aws": {"eu-west-1": "IE"}}} schema; entries
+// replace embedded regions with the same ID and may add new regions or providers.
+// 覆盖文件使用相同的 {"cloud_regions": {...}} 结构；同 ID 条目替换内嵌条目，也可新增 region 或 provider。
+func LoadCloudRegionMap(overridePath string) (CloudRegionMap, error) {
+	base, err := DefaultCloudRegionMap()
+	if err != nil {
+		return CloudRegionMap{}, err
+	}
+
+	overridePath = strings.TrimSpace(overridePath)
+	if overridePath == "" {
+		return base, nil
+	}
+
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		return CloudRegionMap{}, fmt.Errorf("read cloud region map %q: %w", overridePath, err)
+	}
+	var file cloudRegionMapFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return CloudRegionMap{}, fmt.Errorf("parse cloud region map %q: %w", overridePath, err)
+	}
+	return buildCloudRegionMap(base, file)
+}
+
+// IsCloudRegionID reports whether value looks like a "provider:region" identifier.
+// IsCloudRegionID 判断 value 是否为 "provider:region" 形式的标识。
+func IsCloudRegionID(value string) bool {
+	provider, region, ok := strings.Cut(strings.TrimSpace(value), ":")
+	return ok && strings.TrimSpace(provider) != "" && strings.TrimSpace(region) != ""
+}
+
+// Lookup resolves "provider:region" case-insensitively.
+// Lookup 不区分大小写地解析 "provider:region"。
+func (m CloudRegionMap) Lookup(id string) (CloudRegion, error) {
+	provider, region, ok := strings.Cut(strings.TrimSpace(id), ":")
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	region = strings.ToLower(strings.TrimSpace(region))
+	if !ok || provider == "" || region == "" {
+		return CloudRegion{}, fmt.Errorf("invalid cloud region %q: expected provider:region", id)
+	}
+	zone, found := m.regions[provider][region]
+	if !found {
+		return CloudRegion{}, fmt.Errorf("%w: %s:%s", ErrUnknownCloudRegion, provider, region)
+	}
+	return CloudRegion{ID: provider + ":" + region, Provider: provider, Region: region, Zone: zone}, nil
+}
+
+// Providers returns the sorted providers that define region (without provider prefix).
+// Providers 返回定义了该 region（不含 provider 前缀）的 provider 列表（已排序）。
+func (m CloudRegionMap) Providers(region string) []string {
+	region = strings.ToLower(strings.TrimSpace(region))
+	var providers []string
+	for provider, regions := range m.regions {
+		if _, ok := regions[region]; ok {
+			providers = append(providers, provider)
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+func buildCloudRegionMap(base CloudRegionMap, file cloudRegionMapFile) (CloudRegionMap, error) {
+	out := CloudRegionMap{
+		Version: base.Version,
+		regions: make(map[string]map[string]string, len(base.regions)+len(file.CloudRegions)),
+	}
+	for provider, regions := range base.regions {
+		out.regions[provider] = make(map[string]string, len(regions))
+		for region, zone := range regions {
+			out.regions[provider][region] = zone
+		}
+	}
+	if file.Version != "" {
+		out.Version = file.Version
+	}
+
+	for provider, regions := range file.CloudRegions {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" || strings.Contains(provider, ":") {
+			return CloudRegionMap{}, fmt.Errorf("invalid cloud provider %q", provider)
+		}
+		if out.regions[provider] == nil {
+			out.regions[provider] = make(map[string]string, len(regions))
+		}
+		for region, zone := range regions {
+			region = strings.ToLower(strings.TrimSpace(region))
+			zone = strings.ToUpper(strings.TrimSpace(zone))
+			if region == "" || zone == "" {
+				return CloudRegionMap{}, fmt.Errorf("cloud region %s:%s: region and zone are required", provider, region)
+			}
+			out.regions[provider][region] = zone
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package catalog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultCloudRegionMapLookup(t *testing.T) {
+	regions, err := DefaultCloudRegionMap()
+	if err != nil {
+		t.Fatalf("DefaultCloudRegionMap() unexpected error: %v", err)
+	}
+	if regions.Version == "" {
+		t.Fatalf("expected cloud region map version")
+	}
+
+	cases := map[string]CloudRegion{
+		"aws:eu-west-1":      {ID: "aws:eu-west-1", Provider: "aws", Region: "eu-west-1", Zone: "IE"},
+		" GCP:Europe-West4 ": {ID: "gcp:europe-west4", Provider: "gcp", Region: "europe-west4", Zone: "NL"},
+		"azure:westeurope":   {ID: "azure:westeurope", Provider: "azure", Region: "westeurope", Zone: "NL"},
+	}
+	for id, want := range cases {
+		got, err := regions.Lookup(id)
+		if err != nil {
+			t.Fatalf("Lookup(%q) unexpected error: %v", id, err)
+		}
+		if got != want {
+			t.Fatalf("Lookup(%q) = %+v, expected %+v", id, got, want)
+		}
+	}
+
+	if _, err := regions.Lookup("aws:mars-north-1"); !errors.Is(err, ErrUnknownCloudRegion) {
+		t.Fatalf("expected ErrUnknownCloudRegion, got %v", err)
+	}
+	if _, err := regions.Lookup("eu-west-1"); err == nil || errors.Is(err, ErrUnknownCloudRegion) {
+		t.Fatalf("expected provider:region format error, got %v", err)
+	}
+	if got := regions.Providers("eu-west-1"); !reflect.DeepEqual(got, []string{"aws"}) {
+		t.Fatalf("Providers(eu-west-1) = %v", got)
+	}
+}
+
+func TestLoadCloudRegionMapOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cloud.json")
+	content := `{"version":"custom-1","cloud_regions":{"aws":{"eu-west-1":"gb"},"oci":{"eu-frankfurt-1":"DE"}}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	regions, err := LoadCloudRegionMap(path)
+	if err != nil {
+		t.Fatalf("LoadCloudRegionMap() unexpected error: %v", err)
+	}
+	if regions.Version != "custom-1" {
+		t.Fatalf("Version = %q, expected custom-1", regions.Version)
+	}
+	for id, zone := range map[string]string{"aws:eu-west-1": "GB", "oci:eu-frankfurt-1": "DE", "gcp:europe-west4": "NL"} {
+		got, err := regions.Lookup(id)
+		if err != nil {
+			t.Fatalf("Lookup(%q) unexpected error: %v", id, err)
+		}
+		if got.Zone != zone {
+			t.Fatalf("Lookup(%q).Zone = %q, expected %q", id, got.Zone, zone)
+		}
+	}
+
+	if _, err := LoadCloudRegionMap(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatalf("expected missing override error")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"cloud_regions":{"aws":{"eu-west-1":""}}}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	if _, err := LoadCloudRegionMap(bad); err == nil {
+		t.Fatalf("expected empty zone error")
+	}
+}
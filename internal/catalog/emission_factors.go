@@ -0,0 +1,108 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+//go:embed data/emission_factors.json
+var embeddedEmissionFactors []byte
+
+// EmissionFactors is a lifecycle emission factor table per fuel type, in kgCO2eq/kWh.
+// EmissionFactors 为按燃料类型的全生命周期排放因子表，单位 kgCO2eq/kWh。
+type EmissionFactors struct {
+	Version string
+	factors map[string]float64
+}
+
+// emissionFactorsFile stores factors in gCO2eq/kWh, the unit IPCC publishes them in.
+// emissionFactorsFile 以 IPCC 发布时使用的 gCO2eq/kWh 为单位存储因子。
+type emissionFactorsFile struct {
+	Version string             `json:"version"`
+	Source  string             `json:"source,omitempty"`
+	Factors map[string]float64 `json:"factors"`
+}
+
+// DefaultEmissionFactors returns the embedded IPCC AR5 lifecycle medians.
+// DefaultEmissionFactors 返回内嵌的 IPCC AR5 全生命周期中位数。
+func DefaultEmissionFactors() (EmissionFactors, error) {
+	file, err := decodeEmissionFactors(embeddedEmissionFactors)
+	if err != nil {
+		return EmissionFactors{}, fmt.Errorf("embedded emission factors: %w", err)
+	}
+	return buildEmissionFactors(EmissionFactors{}, file)
+}
+
+// LoadEmissionFactors returns the embedded table merged with an optional override file.
+// LoadEmissionFactors 返回内嵌因子表，并按需合并覆盖文件中的条目。
+//
+// Override entries replace embedded fuels with the same name and may add new ones.
+// 覆盖文件中的同名燃料会替换内嵌条目，也可新增燃料。
+func LoadEmissionFactors(overridePath string) (EmissionFactors, error) {
+	base, err := DefaultEmissionFactors()
+	if err != nil {
+		return EmissionFactors{}, err
+	}
+
+	overridePath = strings.TrimSpace(overridePath)
+	if overridePath == "" {
+		return base, nil
+	}
+
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		return EmissionFactors{}, fmt.Errorf("read emission factors %q: %w", overridePath, err)
+	}
+	file, err := decodeEmissionFactors(data)
+	if err != nil {
+		return EmissionFactors{}, fmt.Errorf("parse emission factors %q: %w", overridePath, err)
+	}
+	return buildEmissionFactors(base, file)
+}
+
+// KgPerKWh returns a copy of the table keyed by lower-case fuel name.
+// KgPerKWh 返回以小写燃料名为键的因子表副本。
+func (f EmissionFactors) KgPerKWh() map[string]float64 {
+	out := make(map[string]float64, len(f.factors))
+	for fuel, factor := range f.factors {
+		out[fuel] = factor
+	}
+	return out
+}
+
+func decodeEmissionFactors(data []byte) (emissionFactorsFile, error) {
+	var file emissionFactorsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return emissionFactorsFile{}, err
+	}
+	return file, nil
+}
+
+func buildEmissionFactors(base EmissionFactors, file emissionFactorsFile) (EmissionFactors, error) {
+	out := EmissionFactors{
+		Version: base.Version,
+		factors: make(map[string]float64, len(base.factors)+len(file.Factors)),
+	}
+	for fuel, factor := range base.factors {
+		out.factors[fuel] = factor
+	}
+	if file.Version != "" {
+		out.Version = file.Version
+	}
+
+	for fuel, grams := range file.Factors {
+		key := strings.ToLower(strings.TrimSpace(fuel))
+		if key == "" {
+			return EmissionFactors{}, fmt.Errorf("emission factor with empty fuel name")
+		}
+		if math.IsNaN(grams) || math.IsInf(grams, 0) || grams < 0 {
+			return EmissionFactors{}, fmt.Errorf("emission factor %q: must be >= 0", fuel)
+		}
+		out.factors[key] = grams / 1000
+	}
+	return out, nil
+}
@@ -0,0 +1,47 @@
+package catalog
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultEmissionFactorsConvertsToKgPerKWh(t *testing.T) {
+	factors, err := DefaultEmissionFactors()
+	if err != nil {
+		t.Fatalf("DefaultEmissionFactors() unexpected error: %v", err)
+	}
+	if factors.Version == "" {
+		t.Fatalf("expected embedded emission factors version")
+	}
+	table := factors.KgPerKWh()
+	if math.Abs(table["coal"]-0.82) > 1e-12 || math.Abs(table["wind"]-0.011) > 1e-12 {
+		t.Fatalf("KgPerKWh() = %v, expected IPCC medians in kg/kWh", table)
+	}
+}
+
+func TestLoadEmissionFactorsOverridesAndExtends(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "factors.json")
+	content := []byte(`{"version": "custom-1", "factors": {"Gas": 400, "hydrogen": 30}}`)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	factors, err := LoadEmissionFactors(path)
+	if err != nil {
+		t.Fatalf("LoadEmissionFactors() unexpected error: %v", err)
+	}
+	table := factors.KgPerKWh()
+	if factors.Version != "custom-1" || table["gas"] != 0.4 || table["hydrogen"] != 0.03 || table["nuclear"] != 0.012 {
+		t.Fatalf("LoadEmissionFactors() = %s %v, expected merged table", factors.Version, table)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"factors": {"coal": -1}}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if _, err := LoadEmissionFactors(path); err == nil {
+		t.Fatalf("expected validation error for negative factor")
+	}
+}
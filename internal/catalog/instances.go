@@ -0,0 +1,202 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chenzhuyu2004/carbon-guard/pkg/models"
+)
+
+//go:embed data/instances.json
+var embeddedInstances []byte
+
+var ErrUnknownInstanceType = errors.New("unknown instance type")
+
+// defaultInstanceLifetimeYears matches the CCF hardware lifespan assumption.
+// defaultInstanceLifetimeYears 与 CCF 的硬件寿命假设一致。
+const defaultInstanceLifetimeYears = 4
+
+// InstanceType describes one cloud instance type and its CCF power coefficients.
+// InstanceType 描述一个云实例类型及其 CCF 功率系数。
+type InstanceType struct {
+	Provider        string  `json:"provider"`
+	Name            string  `json:"name"`
+	VCPUs           int     `json:"vcpus"`
+	Architecture    string  `json:"architecture"`
+	MinWattsPerVCPU float64 `json:"min_watts_per_vcpu"`
+	MaxWattsPerVCPU float64 `json:"max_watts_per_vcpu"`
+	MemoryGB        float64 `json:"memory_gb"`
+	// Embodied fields are optional; HostVCPUs derives the machine share (vcpus / host_vcpus).
+	// Embodied 相关字段可选；HostVCPUs 用于推导整机占用比例（vcpus / host_vcpus）。
+	EmbodiedKgCO2e float64 `json:"embodied_kgco2e,omitempty"`
+	LifetimeYears  float64 `json:"lifetime_years,omitempty"`
+	HostVCPUs      int     `json:"host_vcpus,omitempty"`
+}
+
+// InstanceCatalog is an immutable lookup table keyed by "provider:name".
+// InstanceCatalog 是以 "provider:name" 为键的只读查找表。
+type InstanceCatalog struct {
+	Version   string
+	instances map[string]InstanceType
+}
+
+type instanceCatalogFile struct {
+	Version   string         `json:"version"`
+	Source    string         `json:"source,omitempty"`
+	Instances []InstanceType `json:"instances"`
+}
+
+// ID returns the canonical "provider:name" identifier.
+// ID 返回规范化的 "provider:name" 标识。
+func (t InstanceType) ID() string {
+	return strings.ToLower(strings.TrimSpace(t.Provider)) + ":" + strings.TrimSpace(t.Name)
+}
+
+// PowerProfile converts per-vCPU coefficients into a whole-instance idle/peak profile.
+// PowerProfile 将每 vCPU 系数换算为整机 idle/peak 功率画像。
+func (t InstanceType) PowerProfile() models.PowerProfile {
+	profile := models.PowerProfile{
+		Idle: float64(t.VCPUs) * t.MinWattsPerVCPU,
+		Peak: float64(t.VCPUs) * t.MaxWattsPerVCPU,
+	}
+	if t.EmbodiedKgCO2e > 0 {
+		lifetime := t.LifetimeYears
+		if lifetime <= 0 {
+			lifetime = defaultInstanceLifetimeYears
+		}
+		share := 1.0
+		if t.HostVCPUs > 0 {
+			share = float64(t.VCPUs) / float64(t.HostVCPUs)
+		}
+		profile.Embodied = models.EmbodiedProfile{
+			TotalKgCO2e:   t.EmbodiedKgCO2e,
+			LifetimeYears: lifetime,
+			Share:         share,
+		}
+	}
+	return profile
+}
+
+// DefaultInstanceCatalog returns the embedded catalog; it never touches the network.
+// DefaultInstanceCatalog 返回内嵌目录，完全离线可用。
+func DefaultInstanceCatalog() (InstanceCatalog, error) {
+	file, err := decodeInstanceCatalog(embeddedInstances)
+	if err != nil {
+		return InstanceCatalog{}, fmt.Errorf("embedded instance catalog: %w", err)
+	}
+	return buildInstanceCatalog(InstanceCatalog{}, file)
+}
+
+// LoadInstanceCatalog returns the embedded catalog merged with an optional override file.
+// LoadInstanceCatalog 返回内嵌目录，并按需合并覆盖文件中的条目。
+//
+// Override entries replace embedded entries with the same ID and may add new ones.
+// 覆盖文件中的同 ID 条目会替换内嵌条目，也可新增条目。
+func LoadInstanceCatalog(overridePath string) (InstanceCatalog, error) {
+	base, err := DefaultInstanceCatalog()
+	if err != nil {
+		return InstanceCatalog{}, err
+	}
+
+	overridePath = strings.TrimSpace(overridePath)
+	if overridePath == "" {
+		return base, nil
+	}
+
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		return InstanceCatalog{}, fmt.Errorf("read instance catalog %q: %w", overridePath, err)
+	}
+	file, err := decodeInstanceCatalog(data)
+	if err != nil {
+		return InstanceCatalog{}, fmt.Errorf("parse instance catalog %q: %w", overridePath, err)
+	}
+	return buildInstanceCatalog(base, file)
+}
+
+// Lookup resolves "provider:name" (for example "aws:c6i.4xlarge") case-insensitively.
+// Lookup 按 "provider:name"（如 "aws:c6i.4xlarge"）不区分大小写查找。
+func (c InstanceCatalog) Lookup(id string) (InstanceType, error) {
+	key, err := instanceKey(id)
+	if err != nil {
+		return InstanceType{}, err
+	}
+	instance, ok := c.instances[key]
+	if !ok {
+		return InstanceType{}, fmt.Errorf("%w: %s", ErrUnknownInstanceType, strings.TrimSpace(id))
+	}
+	return instance, nil
+}
+
+func decodeInstanceCatalog(data []byte) (instanceCatalogFile, error) {
+	var file instanceCatalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return instanceCatalogFile{}, err
+	}
+	return file, nil
+}
+
+func buildInstanceCatalog(base InstanceCatalog, file instanceCatalogFile) (InstanceCatalog, error) {
+	out := InstanceCatalog{
+		Version:   base.Version,
+		instances: make(map[string]InstanceType, len(base.instances)+len(file.Instances)),
+	}
+	for key, instance := range base.instances {
+		out.instances[key] = instance
+	}
+	if file.Version != "" {
+		out.Version = file.Version
+	}
+
+	for _, instance := range file.Instances {
+		if err := validateInstanceType(instance); err != nil {
+			return InstanceCatalog{}, err
+		}
+		key, err := instanceKey(instance.ID())
+		if err != nil {
+			return InstanceCatalog{}, err
+		}
+		out.instances[key] = instance
+	}
+	return out, nil
+}
+
+func validateInstanceType(instance InstanceType) error {
+	id := instance.ID()
+	if strings.TrimSpace(instance.Provider) == "" || strings.TrimSpace(instance.Name) == "" {
+		return fmt.Errorf("instance %q: provider and name are required", id)
+	}
+	if instance.VCPUs <= 0 {
+		return fmt.Errorf("instance %q: vcpus must be > 0", id)
+	}
+	if instance.MinWattsPerVCPU < 0 {
+		return fmt.Errorf("instance %q: min_watts_per_vcpu must be >= 0", id)
+	}
+	if instance.MaxWattsPerVCPU < instance.MinWattsPerVCPU {
+		return fmt.Errorf("instance %q: max_watts_per_vcpu must be >= min_watts_per_vcpu", id)
+	}
+	if instance.MemoryGB < 0 {
+		return fmt.Errorf("instance %q: memory_gb must be >= 0", id)
+	}
+	if instance.EmbodiedKgCO2e < 0 || instance.LifetimeYears < 0 {
+		return fmt.Errorf("instance %q: embodied_kgco2e and lifetime_years must be >= 0", id)
+	}
+	if instance.HostVCPUs != 0 && instance.HostVCPUs < instance.VCPUs {
+		return fmt.Errorf("instance %q: host_vcpus must be >= vcpus", id)
+	}
+	return nil
+}
+
+func instanceKey(id string) (string, error) {
+	provider, name, ok := strings.Cut(strings.TrimSpace(id), ":")
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !ok || provider == "" || name == "" {
+		return "", fmt.Errorf("invalid instance type %q: expected provider:name", id)
+	}
+	return provider + ":" + name, nil
+}
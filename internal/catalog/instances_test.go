@@ -0,0 +1,99 @@
+package catalog
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultInstanceCatalogLookupDerivesProfile(t *testing.T) {
+	catalog, err := DefaultInstanceCatalog()
+	if err != nil {
+		t.Fatalf("DefaultInstanceCatalog() unexpected error: %v", err)
+	}
+	if catalog.Version == "" {
+		t.Fatalf("expected embedded catalog version")
+	}
+
+	instance, err := catalog.Lookup("AWS:c6i.4xlarge")
+	if err != nil {
+		t.Fatalf("Lookup() unexpected error: %v", err)
+	}
+	if instance.VCPUs != 16 {
+		t.Fatalf("VCPUs = %d, expected 16", instance.VCPUs)
+	}
+
+	profile := instance.PowerProfile()
+	if math.Abs(profile.Idle-16*instance.MinWattsPerVCPU) > 1e-9 {
+		t.Fatalf("Idle = %v, expected %v", profile.Idle, 16*instance.MinWattsPerVCPU)
+	}
+	if math.Abs(profile.Peak-16*instance.MaxWattsPerVCPU) > 1e-9 {
+		t.Fatalf("Peak = %v, expected %v", profile.Peak, 16*instance.MaxWattsPerVCPU)
+	}
+}
+
+func TestInstanceCatalogLookupErrors(t *testing.T) {
+	catalog, err := DefaultInstanceCatalog()
+	if err != nil {
+		t.Fatalf("DefaultInstanceCatalog() unexpected error: %v", err)
+	}
+
+	if _, err := catalog.Lookup("aws:does-not-exist"); !errors.Is(err, ErrUnknownInstanceType) {
+		t.Fatalf("expected ErrUnknownInstanceType, got %v", err)
+	}
+	if _, err := catalog.Lookup("c6i.4xlarge"); err == nil {
+		t.Fatalf("expected error for identifier without provider")
+	}
+}
+
+func TestLoadInstanceCatalogOverridesAndExtends(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "instances.json")
+	content := []byte(`{
+  "version": "custom-1",
+  "instances": [
+    {"provider": "aws", "name": "c6i.4xlarge", "vcpus": 16, "architecture": "ice-lake", "min_watts_per_vcpu": 1, "max_watts_per_vcpu": 5, "memory_gb": 32},
+    {"provider": "onprem", "name": "build-xl", "vcpus": 64, "architecture": "epyc-4th-gen", "min_watts_per_vcpu": 0.5, "max_watts_per_vcpu": 2, "memory_gb": 256}
+  ]
+}`)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	catalog, err := LoadInstanceCatalog(path)
+	if err != nil {
+		t.Fatalf("LoadInstanceCatalog() unexpected error: %v", err)
+	}
+	if catalog.Version != "custom-1" {
+		t.Fatalf("Version = %q, expected custom-1", catalog.Version)
+	}
+
+	overridden, err := catalog.Lookup("aws:c6i.4xlarge")
+	if err != nil {
+		t.Fatalf("Lookup() unexpected error: %v", err)
+	}
+	if overridden.PowerProfile().Peak != 80 {
+		t.Fatalf("overridden peak = %v, expected 80", overridden.PowerProfile().Peak)
+	}
+	if _, err := catalog.Lookup("onprem:build-xl"); err != nil {
+		t.Fatalf("expected added instance, got %v", err)
+	}
+	if _, err := catalog.Lookup("gcp:n2-standard-8"); err != nil {
+		t.Fatalf("expected embedded instance to remain, got %v", err)
+	}
+}
+
+func TestLoadInstanceCatalogRejectsInvalidEntry(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "instances.json")
+	content := []byte(`{"instances": [{"provider": "aws", "name": "bad", "vcpus": 4, "min_watts_per_vcpu": 3, "max_watts_per_vcpu": 1}]}`)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	if _, err := LoadInstanceCatalog(path); err == nil {
+		t.Fatalf("expected validation error for max < min watts")
+	}
+}
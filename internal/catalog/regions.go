@@ -0,0 +1,148 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//go:embed data/regions.json
+var embeddedRegions []byte
+
+var ErrUnknownRegion = errors.New("unknown region")
+
+// Region kinds reported in RegionIntensity.Kind.
+// RegionIntensity.Kind 中报告的区域类型。
+const (
+	RegionKindAggregate = "aggregate"
+	RegionKindZone      = "zone"
+	RegionKindCloud     = "cloud"
+)
+
+// RegionIntensity is the yearly average carbon intensity of a zone, cloud region, or aggregate.
+// RegionIntensity 为某区域、云 region 或汇总区域的年均碳强度。
+type RegionIntensity struct {
+	// ID is the canonical name: "global", "DE", or "aws:eu-west-1".
+	// ID 为规范名称，如 "global"、"DE" 或 "aws:eu-west-1"。
+	ID   string
+	Kind string
+	// Zone is the Electricity Maps zone; empty for aggregates.
+	// Zone 为 Electricity Maps 区域；汇总区域为空。
+	Zone     string
+	KgPerKWh float64
+}
+
+// RegionDataset is the versioned offline table of yearly average carbon intensity.
+// RegionDataset 为带版本的离线年均碳强度表。
+type RegionDataset struct {
+	Version    string
+	Year       int
+	aggregates map[string]float64
+	zones      map[string]float64
+	cloud      CloudRegionMap
+}
+
+// regionDatasetFile stores intensities in gCO2eq/kWh, the unit Electricity Maps publishes.
+// regionDatasetFile 以 Electricity Maps 发布时使用的 gCO2eq/kWh 为单位存储碳强度。
+type regionDatasetFile struct {
+	Version      string                       `json:"version"`
+	Year         int                          `json:"year"`
+	Source       string                       `json:"source,omitempty"`
+	Aggregates   map[string]float64           `json:"aggregates"`
+	Zones        map[string]float64           `json:"zones"`
+	CloudRegions map[string]map[string]string `json:"cloud_regions"`
+}
+
+// DefaultRegionDataset returns the embedded dataset; it never touches the network.
+// DefaultRegionDataset 返回内嵌数据集，完全离线可用。
+func DefaultRegionDataset() (RegionDataset, error) {
+	var file regionDatasetFile
+	if err := json.Unmarshal(embeddedRegions, &file); err != nil {
+		return RegionDataset{}, fmt.Errorf("embedded region dataset: %w", err)
+	}
+	return buildRegionDataset(file)
+}
+
+// Lookup resolves an aggregate ("global"), an Electricity Maps zone ("DE"), or a cloud region
+// ("aws:eu-west-1" or a bare name such as "eu-west-1") case-insensitively.
+// Lookup 不区分大小写地解析汇总区域（"global"）、Electricity Maps 区域（"DE"）或云 region
+// （"aws:eu-west-1"，或 "eu-west-1" 这类不带 provider 的名称）。
+//
+// A bare cloud region name must be unique across providers.
+// 不带 provider 的云 region 名称必须在各 provider 间唯一。
+func (d RegionDataset) Lookup(name string) (RegionIntensity, error) {
+	raw := strings.TrimSpace(name)
+	if raw == "" {
+		return RegionIntensity{}, fmt.Errorf("%w: empty region", ErrUnknownRegion)
+	}
+
+	if strings.Contains(raw, ":") {
+		return d.lookupCloud(raw)
+	}
+	if ci, ok := d.aggregates[strings.ToLower(raw)]; ok {
+		return RegionIntensity{ID: strings.ToLower(raw), Kind: RegionKindAggregate, KgPerKWh: ci}, nil
+	}
+	if ci, ok := d.zones[strings.ToUpper(raw)]; ok {
+		zone := strings.ToUpper(raw)
+		return RegionIntensity{ID: zone, Kind: RegionKindZone, Zone: zone, KgPerKWh: ci}, nil
+	}
+
+	providers := d.cloud.Providers(raw)
+	switch len(providers) {
+	case 0:
+		return RegionIntensity{}, fmt.Errorf("%w: %s", ErrUnknownRegion, raw)
+	case 1:
+		return d.lookupCloud(providers[0] + ":" + raw)
+	default:
+		return RegionIntensity{}, fmt.Errorf("%w: %s is ambiguous across providers (%s); use provider:region", ErrUnknownRegion, raw, strings.Join(providers, ", "))
+	}
+}
+
+func (d RegionDataset) lookupCloud(id string) (RegionIntensity, error) {
+	region, err := d.cloud.Lookup(id)
+	if err != nil {
+		return RegionIntensity{}, fmt.Errorf("%w: %s", ErrUnknownRegion, strings.TrimSpace(id))
+	}
+	return RegionIntensity{
+		ID:       region.ID,
+		Kind:     RegionKindCloud,
+		Zone:     region.Zone,
+		KgPerKWh: d.zones[region.Zone],
+	}, nil
+}
+
+func buildRegionDataset(file regionDatasetFile) (RegionDataset, error) {
+	out := RegionDataset{
+		Version:    file.Version,
+		Year:       file.Year,
+		aggregates: make(map[string]float64, len(file.Aggregates)),
+		zones:      make(map[string]float64, len(file.Zones)),
+	}
+	for name, grams := range file.Aggregates {
+		if grams <= 0 {
+			return RegionDataset{}, fmt.Errorf("region %q: carbon intensity must be > 0", name)
+		}
+		out.aggregates[strings.ToLower(strings.TrimSpace(name))] = grams / 1000
+	}
+	for zone, grams := range file.Zones {
+		if grams <= 0 {
+			return RegionDataset{}, fmt.Errorf("zone %q: carbon intensity must be > 0", zone)
+		}
+		out.zones[strings.ToUpper(strings.TrimSpace(zone))] = grams / 1000
+	}
+	cloud, err := buildCloudRegionMap(CloudRegionMap{}, cloudRegionMapFile{Version: file.Version, CloudRegions: file.CloudRegions})
+	if err != nil {
+		return RegionDataset{}, err
+	}
+	for provider, regions := range cloud.regions {
+		for region, zone := range regions {
+			if _, ok := out.zones[zone]; !ok {
+				return RegionDataset{}, fmt.Errorf("cloud region %s:%s: unknown zone %q", provider, region, zone)
+			}
+		}
+	}
+	out.cloud = cloud
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultRegionDatasetLookup(t *testing.T) {
+	dataset, err := DefaultRegionDataset()
+	if err != nil {
+		t.Fatalf("DefaultRegionDataset() unexpected error: %v", err)
+	}
+	if dataset.Version == "" || dataset.Year == 0 {
+		t.Fatalf("expected dataset version and year, got %q %d", dataset.Version, dataset.Year)
+	}
+
+	cases := []struct {
+		name string
+		id   string
+		kind string
+		zone string
+	}{
+		{name: "global", id: "global", kind: RegionKindAggregate},
+		{name: "EU", id: "eu", kind: RegionKindAggregate},
+		{name: "de", id: "DE", kind: RegionKindZone, zone: "DE"},
+		{name: "US-CAL-CISO", id: "US-CAL-CISO", kind: RegionKindZone, zone: "US-CAL-CISO"},
+		{name: "AWS:eu-west-1", id: "aws:eu-west-1", kind: RegionKindCloud, zone: "IE"},
+		{name: "europe-west4", id: "gcp:europe-west4", kind: RegionKindCloud, zone: "NL"},
+		{name: "westeurope", id: "azure:westeurope", kind: RegionKindCloud, zone: "NL"},
+	}
+	for _, tc := range cases {
+		got, err := dataset.Lookup(tc.name)
+		if err != nil {
+			t.Fatalf("Lookup(%q) unexpected error: %v", tc.name, err)
+		}
+		if got.ID != tc.id || got.Kind != tc.kind || got.Zone != tc.zone || got.KgPerKWh <= 0 {
+			t.Fatalf("Lookup(%q) = %+v, expected id %s kind %s zone %s", tc.name, got, tc.id, tc.kind, tc.zone)
+		}
+	}
+
+	if global, _ := dataset.Lookup("global"); global.KgPerKWh != 0.4 {
+		t.Fatalf("global = %v, expected legacy 0.4", global.KgPerKWh)
+	}
+	cloud, _ := dataset.Lookup("aws:eu-west-1")
+	zone, _ := dataset.Lookup("IE")
+	if cloud.KgPerKWh != zone.KgPerKWh {
+		t.Fatalf("cloud region CI %v != zone CI %v", cloud.KgPerKWh, zone.KgPerKWh)
+	}
+}
+
+func TestRegionDatasetLookupUnknown(t *testing.T) {
+	dataset, err := DefaultRegionDataset()
+	if err != nil {
+		t.Fatalf("DefaultRegionDataset() unexpected error: %v", err)
+	}
+	for _, name := range []string{"atlantis", "aws:mars-north-1", ""} {
+		if _, err := dataset.Lookup(name); !errors.Is(err, ErrUnknownRegion) {
+			t.Fatalf("Lookup(%q) expected ErrUnknownRegion, got %v", name, err)
+		}
+	}
+}
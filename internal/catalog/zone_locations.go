@@ -0,0 +1,167 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//go:embed data/zone_locations.json
+var embeddedZoneLocations []byte
+
+// Confidence levels of a ZoneGuess, from most to least certain.
+// ZoneGuess 的置信度等级，由高到低。
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// ZoneGuess is the most likely grid zone for a country or timezone.
+// ZoneGuess 为某国家或时区最可能对应的电网区域。
+//
+// Confidence is lowered when the country spans several sub-zones and the location
+// cannot tell them apart (for example "US", or "Asia/Kolkata" for India).
+// 当国家包含多个子区域且该位置无法区分时降低置信度（如 "US"，或印度的 "Asia/Kolkata"）。
+type ZoneGuess struct {
+	// Country is the ISO-3166 alpha-2 code the country or timezone belongs to.
+	// Country 为该国家或时区所属的 ISO-3166 两位国家代码。
+	Country    string
+	Zone       string
+	Confidence string
+}
+
+// ZoneLocations maps ISO-3166 countries and IANA timezones to their most likely grid zone.
+// ZoneLocations 将 ISO-3166 国家与 IANA 时区映射到最可能的电网区域。
+type ZoneLocations struct {
+	Version   string
+	countries map[string]ZoneGuess
+	timezones map[string]ZoneGuess
+}
+
+type zoneGuessEntry struct {
+	Country    string `json:"country,omitempty"`
+	Zone       string `json:"zone"`
+	Confidence string `json:"confidence"`
+}
+
+type zoneLocationsFile struct {
+	Version   string                    `json:"version"`
+	Source    string                    `json:"source,omitempty"`
+	Countries map[string]zoneGuessEntry `json:"countries"`
+	Timezones map[string]zoneGuessEntry `json:"timezones"`
+}
+
+// DefaultZoneLocations returns the embedded country and timezone dataset.
+// DefaultZoneLocations 返回内嵌的国家与时区数据集。
+func DefaultZoneLocations() (ZoneLocations, error) {
+	var file zoneLocationsFile
+	if err := json.Unmarshal(embeddedZoneLocations, &file); err != nil {
+		return ZoneLocations{}, fmt.Errorf("embedded zone locations: %w", err)
+	}
+	return buildZoneLocations(ZoneLocations{}, file)
+}
+
+// LoadZoneLocations returns the embedded dataset merged with an optional override file.
+// LoadZoneLocations 返回内嵌数据集，并按需合并覆盖文件中的条目。
+//
+// Override entries replace embedded countries or timezones with the same key; a timezone
+// override without "country" keeps the embedded country.
+// 覆盖文件中的同名国家或时区会替换内嵌条目；未设置 "country" 的时区覆盖沿用内嵌国家。
+func LoadZoneLocations(overridePath string) (ZoneLocations, error) {
+	base, err := DefaultZoneLocations()
+	if err != nil {
+		return ZoneLocations{}, err
+	}
+
+	overridePath = strings.TrimSpace(overridePath)
+	if overridePath == "" {
+		return base, nil
+	}
+
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		return ZoneLocations{}, fmt.Errorf("read zone locations %q: %w", overridePath, err)
+	}
+	var file zoneLocationsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return ZoneLocations{}, fmt.Errorf("parse zone locations %q: %w", overridePath, err)
+	}
+	return buildZoneLocations(base, file)
+}
+
+// Country looks up an ISO-3166 alpha-2 code case-insensitively.
+// Country 不区分大小写地查询 ISO-3166 两位国家代码。
+func (l ZoneLocations) Country(code string) (ZoneGuess, bool) {
+	guess, ok := l.countries[strings.ToUpper(strings.TrimSpace(code))]
+	return guess, ok
+}
+
+// Timezone looks up an IANA timezone name, including backward-compatible links such as "US/Eastern".
+// Timezone 查询 IANA 时区名，包含 "US/Eastern" 等向后兼容链接。
+func (l ZoneLocations) Timezone(name string) (ZoneGuess, bool) {
+	guess, ok := l.timezones[strings.TrimSpace(name)]
+	return guess, ok
+}
+
+func buildZoneLocations(base ZoneLocations, file zoneLocationsFile) (ZoneLocations, error) {
+	out := ZoneLocations{
+		Version:   base.Version,
+		countries: make(map[string]ZoneGuess, len(base.countries)+len(file.Countries)),
+		timezones: make(map[string]ZoneGuess, len(base.timezones)+len(file.Timezones)),
+	}
+	for code, guess := range base.countries {
+		out.countries[code] = guess
+	}
+	for name, guess := range base.timezones {
+		out.timezones[name] = guess
+	}
+	if file.Version != "" {
+		out.Version = file.Version
+	}
+
+	for code, entry := range file.Countries {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		guess, err := zoneGuessFromEntry(code, code, entry)
+		if err != nil {
+			return ZoneLocations{}, err
+		}
+		out.countries[code] = guess
+	}
+	for name, entry := range file.Timezones {
+		name = strings.TrimSpace(name)
+		if entry.Country == "" {
+			entry.Country = out.timezones[name].Country
+		}
+		guess, err := zoneGuessFromEntry(name, strings.ToUpper(strings.TrimSpace(entry.Country)), entry)
+		if err != nil {
+			return ZoneLocations{}, err
+		}
+		out.timezones[name] = guess
+	}
+	return out, nil
+}
+
+func zoneGuessFromEntry(key string, country string, entry zoneGuessEntry) (ZoneGuess, error) {
+	if key == "" {
+		return ZoneGuess{}, fmt.Errorf("zone location with empty key")
+	}
+	if len(country) != 2 {
+		return ZoneGuess{}, fmt.Errorf("zone location %q: country must be an ISO-3166 alpha-2 code", key)
+	}
+	zone := strings.ToUpper(strings.TrimSpace(entry.Zone))
+	if zone == "" {
+		return ZoneGuess{}, fmt.Errorf("zone location %q: zone is required", key)
+	}
+	confidence := strings.ToLower(strings.TrimSpace(entry.Confidence))
+	switch confidence {
+	case ConfidenceHigh, ConfidenceMedium, ConfidenceLow:
+	case "":
+		confidence = ConfidenceMedium
+	default:
+		return ZoneGuess{}, fmt.Errorf("zone location %q: confidence must be high, medium, or low", key)
+	}
+	return ZoneGuess{Country: country, Zone: zone, Confidence: confidence}, nil
+}
@@ -0,0 +1,86 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultZoneLocationsCoverage(t *testing.T) {
+	locations, err := DefaultZoneLocations()
+	if err != nil {
+		t.Fatalf("DefaultZoneLocations() unexpected error: %v", err)
+	}
+	if len(locations.countries) < 249 {
+		t.Fatalf("expected every ISO-3166 country, got %d", len(locations.countries))
+	}
+	if len(locations.timezones) < 400 {
+		t.Fatalf("expected IANA timezones, got %d", len(locations.timezones))
+	}
+
+	countries := map[string]ZoneGuess{
+		"de": {Country: "DE", Zone: "DE", Confidence: ConfidenceHigh},
+		"US": {Country: "US", Zone: "US-MIDA-PJM", Confidence: ConfidenceLow},
+		"BR": {Country: "BR", Zone: "BR-CS", Confidence: ConfidenceLow},
+	}
+	for code, want := range countries {
+		if got, ok := locations.Country(code); !ok || got != want {
+			t.Fatalf("Country(%q) = %+v, %v; expected %+v", code, got, ok, want)
+		}
+	}
+
+	timezones := map[string]ZoneGuess{
+		"Europe/Berlin":    {Country: "DE", Zone: "DE", Confidence: ConfidenceHigh},
+		"America/Phoenix":  {Country: "US", Zone: "US-SW-AZPS", Confidence: ConfidenceHigh},
+		"Australia/Sydney": {Country: "AU", Zone: "AU-NSW", Confidence: ConfidenceHigh},
+		"Asia/Tokyo":       {Country: "JP", Zone: "JP-TK", Confidence: ConfidenceLow},
+		"Asia/Calcutta":    {Country: "IN", Zone: "IN-WE", Confidence: ConfidenceLow},
+	}
+	for name, want := range timezones {
+		if got, ok := locations.Timezone(name); !ok || got != want {
+			t.Fatalf("Timezone(%q) = %+v, %v; expected %+v", name, got, ok, want)
+		}
+	}
+	if _, ok := locations.Timezone("Etc/UTC"); ok {
+		t.Fatalf("expected Etc/UTC to have no zone")
+	}
+}
+
+func TestLoadZoneLocationsOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locations.json")
+	content := `{"version":"custom","countries":{"us":{"zone":"us-tex-erco","confidence":"medium"}},"timezones":{"America/Chicago":{"zone":"US-TEX-ERCO","confidence":"high"}}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	locations, err := LoadZoneLocations(path)
+	if err != nil {
+		t.Fatalf("LoadZoneLocations() unexpected error: %v", err)
+	}
+	if locations.Version != "custom" {
+		t.Fatalf("Version = %q, expected custom", locations.Version)
+	}
+	if got, _ := locations.Country("US"); got.Zone != "US-TEX-ERCO" || got.Confidence != ConfidenceMedium {
+		t.Fatalf("Country(US) = %+v", got)
+	}
+	if got, _ := locations.Timezone("America/Chicago"); got.Country != "US" || got.Zone != "US-TEX-ERCO" {
+		t.Fatalf("Timezone(America/Chicago) = %+v", got)
+	}
+	if got, _ := locations.Country("FR"); got.Zone != "FR" {
+		t.Fatalf("expected embedded FR to survive merge, got %+v", got)
+	}
+
+	for _, bad := range []string{
+		`{"countries":{"USA":{"zone":"US"}}}`,
+		`{"countries":{"US":{"zone":""}}}`,
+		`{"countries":{"US":{"zone":"US","confidence":"certain"}}}`,
+		`{"timezones":{"Mars/Base":{"zone":"XX"}}}`,
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		if _, err := LoadZoneLocations(path); err == nil {
+			t.Fatalf("LoadZoneLocations(%s) expected error", bad)
+		}
+	}
+}
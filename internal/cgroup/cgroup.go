@@ -0,0 +1,172 @@
+// Package cgroup reads cgroup v2 CPU and memory accounting files.
+// Package cgroup 读取 cgroup v2 的 CPU 与内存统计文件。
+package cgroup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRoot is the cgroup v2 unified hierarchy mount point.
+// DefaultRoot 为 cgroup v2 统一层级的挂载点。
+const DefaultRoot = "/sys/fs/cgroup"
+
+const bytesPerGB = 1e9
+
+var ErrNotCgroupV2 = errors.New("cgroup v2 accounting not available")
+
+// Reader reads accounting files of one cgroup at Root/Path.
+// Reader 读取 Root/Path 下某个 cgroup 的统计文件。
+//
+// Both fields are injectable so tests can point at a fake directory tree. An empty Root
+// uses DefaultRoot; an empty Path is resolved from SelfCgroupFile.
+// 两个字段都可注入，便于测试使用伪造的目录树。Root 为空时使用 DefaultRoot；
+// Path 为空时通过 SelfCgroupFile 解析。
+type Reader struct {
+	Root string
+	Path string
+	// SelfCgroupFile defaults to /proc/self/cgroup.
+	// SelfCgroupFile 默认为 /proc/self/cgroup。
+	SelfCgroupFile string
+}
+
+// Snapshot is one reading of cgroup accounting.
+// Snapshot 为一次 cgroup 统计读数。
+type Snapshot struct {
+	At       time.Time
+	CPUUsage time.Duration
+	// MemoryPeakBytes is the cgroup's peak memory so far; zero when memory.peak is absent.
+	// MemoryPeakBytes 为 cgroup 截至目前的内存峰值；memory.peak 不存在时为零。
+	MemoryPeakBytes uint64
+	HasMemoryPeak   bool
+}
+
+// Usage is the accounting delta between two snapshots.
+// Usage 为两次快照之间的统计增量。
+type Usage struct {
+	Wall         time.Duration
+	CPU          time.Duration
+	MemoryPeakGB float64
+}
+
+// Dir returns the absolute cgroup directory being read.
+// Dir 返回实际读取的 cgroup 目录。
+func (r Reader) Dir() (string, error) {
+	root := r.Root
+	if strings.TrimSpace(root) == "" {
+		root = DefaultRoot
+	}
+	path := r.Path
+	if strings.TrimSpace(path) == "" {
+		self, err := r.selfPath()
+		if err != nil {
+			return "", err
+		}
+		path = self
+	}
+	return filepath.Join(root, filepath.Clean("/"+path)), nil
+}
+
+// Snapshot reads cpu.stat usage_usec and, when present, memory.peak.
+// Snapshot 读取 cpu.stat 中的 usage_usec，以及存在时的 memory.peak。
+func (r Reader) Snapshot(now time.Time) (Snapshot, error) {
+	dir, err := r.Dir()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	usage, err := readCPUUsage(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot := Snapshot{At: now, CPUUsage: usage}
+
+	data, err := os.ReadFile(filepath.Join(dir, "memory.peak"))
+	switch {
+	case err == nil:
+		peak, parseErr := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if parseErr != nil {
+			return Snapshot{}, fmt.Errorf("parse memory.peak: %w", parseErr)
+		}
+		snapshot.MemoryPeakBytes = peak
+		snapshot.HasMemoryPeak = true
+	case !errors.Is(err, os.ErrNotExist):
+		return Snapshot{}, fmt.Errorf("read memory.peak: %w", err)
+	}
+	return snapshot, nil
+}
+
+// Delta returns the usage between start and end readings.
+// Delta 返回起止两次读数之间的使用量。
+//
+// memory.peak is a high-water mark, so the end reading is used as-is.
+// memory.peak 为高水位值，因此直接使用结束时的读数。
+func Delta(start Snapshot, end Snapshot) (Usage, error) {
+	if end.CPUUsage < start.CPUUsage {
+		return Usage{}, fmt.Errorf("cpu usage decreased between snapshots (cgroup reset?)")
+	}
+	if end.At.Before(start.At) {
+		return Usage{}, fmt.Errorf("end snapshot precedes start snapshot")
+	}
+	usage := Usage{
+		Wall: end.At.Sub(start.At),
+		CPU:  end.CPUUsage - start.CPUUsage,
+	}
+	if end.HasMemoryPeak {
+		usage.MemoryPeakGB = float64(end.MemoryPeakBytes) / bytesPerGB
+	}
+	return usage, nil
+}
+
+func readCPUUsage(path string) (time.Duration, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("%w: %s not found", ErrNotCgroupV2, path)
+		}
+		return 0, fmt.Errorf("read cpu.stat: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok || key != "usage_usec" {
+			continue
+		}
+		usec, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse cpu.stat usage_usec: %w", err)
+		}
+		return time.Duration(usec) * time.Microsecond, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("read cpu.stat: %w", err)
+	}
+	return 0, fmt.Errorf("%w: usage_usec missing in %s", ErrNotCgroupV2, path)
+}
+
+// selfPath returns the unified ("0::") cgroup path of the current process.
+// selfPath 返回当前进程在统一层级（"0::"）中的 cgroup 路径。
+func (r Reader) selfPath() (string, error) {
+	file := r.SelfCgroupFile
+	if strings.TrimSpace(file) == "" {
+		file = "/proc/self/cgroup"
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("%w: read %s: %v", ErrNotCgroupV2, file, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(line), "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no unified hierarchy entry in %s", ErrNotCgroupV2, file)
+}
@@ -0,0 +1,71 @@
+package cgroup
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCgroupFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() unexpected error: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+}
+
+func TestSnapshotAndDeltaFromFakeTree(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "kubepods", "job-1")
+	self := filepath.Join(root, "self-cgroup")
+	writeCgroupFile(t, self, "0::/kubepods/job-1\n")
+	writeCgroupFile(t, filepath.Join(dir, "cpu.stat"), "usage_usec 1000000\nuser_usec 800000\nsystem_usec 200000\n")
+
+	reader := Reader{Root: root, SelfCgroupFile: self}
+	begin := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	start, err := reader.Snapshot(begin)
+	if err != nil {
+		t.Fatalf("Snapshot() unexpected error: %v", err)
+	}
+	if start.HasMemoryPeak {
+		t.Fatalf("expected no memory.peak in start snapshot")
+	}
+
+	writeCgroupFile(t, filepath.Join(dir, "cpu.stat"), "usage_usec 61000000\n")
+	writeCgroupFile(t, filepath.Join(dir, "memory.peak"), "2000000000\n")
+	end, err := reader.Snapshot(begin.Add(2 * time.Minute))
+	if err != nil {
+		t.Fatalf("Snapshot() unexpected error: %v", err)
+	}
+
+	usage, err := Delta(start, end)
+	if err != nil {
+		t.Fatalf("Delta() unexpected error: %v", err)
+	}
+	if usage.CPU != time.Minute || usage.Wall != 2*time.Minute {
+		t.Fatalf("usage = %+v, expected 1m cpu over 2m wall", usage)
+	}
+	if math.Abs(usage.MemoryPeakGB-2) > 1e-12 {
+		t.Fatalf("MemoryPeakGB = %v, expected 2", usage.MemoryPeakGB)
+	}
+}
+
+func TestSnapshotWithoutCPUStatIsNotCgroupV2(t *testing.T) {
+	_, err := Reader{Root: t.TempDir(), Path: "/missing"}.Snapshot(time.Now())
+	if !errors.Is(err, ErrNotCgroupV2) {
+		t.Fatalf("expected ErrNotCgroupV2, got %v", err)
+	}
+}
+
+func TestDeltaRejectsCounterReset(t *testing.T) {
+	now := time.Now()
+	_, err := Delta(Snapshot{At: now, CPUUsage: time.Second}, Snapshot{At: now, CPUUsage: 0})
+	if err == nil {
+		t.Fatalf("expected error for decreasing cpu usage")
+	}
+}
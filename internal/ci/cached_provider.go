@@ -47,6 +47,30 @@ func (c *CachedProvider) GetCurrentCI(ctx context.Context, zone string) (float64
 	return c.Inner.GetCurrentCI(ctx, zone)
 }
 
+// GetHistoryCI passes through uncached; past data is requested for one-off run reports.
+// GetHistoryCI 不做缓存直接透传；历史数据仅用于一次性运行报告。
+func (c *CachedProvider) GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) ([]ForecastPoint, error) {
+	if c.Inner == nil {
+		return nil, fmt.Errorf("cached provider inner provider is nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return GetHistoryCI(ctx, c.Inner, zone, start, end)
+}
+
+// GetPriceSeries passes through uncached for the same reason as GetHistoryCI.
+// GetPriceSeries 与 GetHistoryCI 同理，不做缓存直接透传。
+func (c *CachedProvider) GetPriceSeries(ctx context.Context, zone string, start time.Time, end time.Time) ([]PricePoint, error) {
+	if c.Inner == nil {
+		return nil, fmt.Errorf("cached provider inner provider is nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return GetPriceSeries(ctx, c.Inner, zone, start, end)
+}
+
 func (c *CachedProvider) GetForecastCI(ctx context.Context, zone string, hours int) ([]ForecastPoint, error) {
 	if c.Inner == nil {
 		return nil, fmt.Errorf("cached provider inner provider is nil")
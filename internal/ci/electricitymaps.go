@@ -16,6 +16,8 @@ import (
 
 const defaultElectricityMapsLatestURL = "https://api.electricitymaps.com/v3/carbon-intensity/latest"
 const defaultElectricityMapsForecastURL = "https://api.electricitymaps.com/v3/carbon-intensity/forecast"
+const defaultElectricityMapsPastRangeURL = "https://api.electricitymaps.com/v3/carbon-intensity/past-range"
+const defaultElectricityMapsPriceURL = "https://api.electricitymaps.com/v3/price-day-ahead/past-range"
 
 // electricityMapsHTTPClient is intentionally bounded to avoid hanging CI jobs.
 // electricityMapsHTTPClient 设置固定超时，避免 CI 作业因网络问题长期挂起。
@@ -25,6 +27,8 @@ var electricityMapsHTTPClient = &http.Client{
 
 var electricityMapsLatestURL = defaultElectricityMapsLatestURL
 var electricityMapsForecastURL = defaultElectricityMapsForecastURL
+var electricityMapsPastRangeURL = defaultElectricityMapsPastRangeURL
+var electricityMapsPriceURL = defaultElectricityMapsPriceURL
 
 type ElectricityMapsProvider struct {
 	APIKey string
@@ -189,6 +193,181 @@ func (p *ElectricityMapsProvider) GetForecastCI(ctx context.Context, zone string
 	return points, nil
 }
 
+// GetHistoryCI fetches past carbon intensity points for one zone in [start, end].
+// GetHistoryCI 获取单区域 [start, end] 内的历史碳强度点。
+//
+// Upstream unit is gCO2/kWh, converted to kgCO2/kWh; points are sorted ascending.
+// 上游单位为 gCO2/kWh，会转换为 kgCO2/kWh；返回点按时间升序排列。
+func (p *ElectricityMapsProvider) GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) ([]ForecastPoint, error) {
+	const op = "get_history_ci"
+
+	if p.APIKey == "" {
+		return nil, NewProviderError(ErrorKindAuth, op, zone, fmt.Errorf("missing ELECTRICITY_MAPS_API_KEY: set an Electricity Maps API key to use historical carbon data"))
+	}
+	if zone == "" {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("missing electricity maps zone"))
+	}
+	if !end.After(start) {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("history end must be after start"))
+	}
+
+	endpoint, err := url.Parse(electricityMapsPastRangeURL)
+	if err != nil {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("build electricity maps past-range url: %w", err))
+	}
+
+	query := endpoint.Query()
+	query.Set("zone", zone)
+	query.Set("start", start.UTC().Format(time.RFC3339))
+	query.Set("end", end.UTC().Format(time.RFC3339))
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("create electricity maps past-range request: %w", err))
+	}
+	req.Header.Set("auth-token", p.APIKey)
+
+	resp, err := electricityMapsHTTPClient.Do(req)
+	if err != nil {
+		return nil, classifyNetworkError(op, zone, "call electricity maps past-range api", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       readErrorBody(resp.Body),
+		}
+		return nil, NewProviderStatusError(classifyStatusKind(resp.StatusCode), op, zone, resp.StatusCode, statusErr)
+	}
+
+	var body struct {
+		Data []struct {
+			Datetime        string  `json:"datetime"`
+			CarbonIntensity float64 `json:"carbonIntensity"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("decode electricity maps past-range response: %w", err))
+	}
+
+	points := make([]ForecastPoint, 0, len(body.Data))
+	for _, item := range body.Data {
+		if item.CarbonIntensity <= 0 {
+			return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("invalid history carbonIntensity value: %v", item.CarbonIntensity))
+		}
+		timestamp, err := parseForecastTime(item.Datetime)
+		if err != nil {
+			return nil, NewProviderError(ErrorKindInvalidData, op, zone, err)
+		}
+		points = append(points, ForecastPoint{
+			Timestamp: timestamp.UTC(),
+			CI:        item.CarbonIntensity / 1000.0,
+		})
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+
+	return points, nil
+}
+
+// GetPriceSeries fetches day-ahead electricity prices for one zone in [start, end].
+// GetPriceSeries 获取单区域 [start, end] 内的日前电价。
+//
+// Upstream prices are per MWh (for example "EUR/MWh") and converted to per kWh.
+// 上游电价以每 MWh 计（如 "EUR/MWh"），会换算为每 kWh。
+func (p *ElectricityMapsProvider) GetPriceSeries(ctx context.Context, zone string, start time.Time, end time.Time) ([]PricePoint, error) {
+	const op = "get_price_series"
+
+	if p.APIKey == "" {
+		return nil, NewProviderError(ErrorKindAuth, op, zone, fmt.Errorf("missing ELECTRICITY_MAPS_API_KEY: set an Electricity Maps API key to use electricity prices"))
+	}
+	if zone == "" {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("missing electricity maps zone"))
+	}
+	if !end.After(start) {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("price end must be after start"))
+	}
+
+	endpoint, err := url.Parse(electricityMapsPriceURL)
+	if err != nil {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("build electricity maps price url: %w", err))
+	}
+
+	query := endpoint.Query()
+	query.Set("zone", zone)
+	query.Set("start", start.UTC().Format(time.RFC3339))
+	query.Set("end", end.UTC().Format(time.RFC3339))
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("create electricity maps price request: %w", err))
+	}
+	req.Header.Set("auth-token", p.APIKey)
+
+	resp, err := electricityMapsHTTPClient.Do(req)
+	if err != nil {
+		return nil, classifyNetworkError(op, zone, "call electricity maps price api", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       readErrorBody(resp.Body),
+		}
+		return nil, NewProviderStatusError(classifyStatusKind(resp.StatusCode), op, zone, resp.StatusCode, statusErr)
+	}
+
+	var body struct {
+		Data []struct {
+			Datetime string  `json:"datetime"`
+			Value    float64 `json:"value"`
+			Unit     string  `json:"unit"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("decode electricity maps price response: %w", err))
+	}
+
+	points := make([]PricePoint, 0, len(body.Data))
+	for _, item := range body.Data {
+		timestamp, err := parseForecastTime(item.Datetime)
+		if err != nil {
+			return nil, NewProviderError(ErrorKindInvalidData, op, zone, err)
+		}
+		currency, per, ok := strings.Cut(strings.TrimSpace(item.Unit), "/")
+		if !ok || currency == "" {
+			return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("invalid price unit: %q", item.Unit))
+		}
+		price := item.Value
+		switch strings.ToLower(per) {
+		case "mwh":
+			price /= 1000.0
+		case "kwh":
+		default:
+			return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("invalid price unit: %q", item.Unit))
+		}
+		points = append(points, PricePoint{
+			Timestamp:   timestamp.UTC(),
+			PricePerKWh: price,
+			Currency:    currency,
+		})
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+
+	return points, nil
+}
+
 func classifyNetworkError(operation string, zone string, prefix string, err error) error {
 	if err == nil {
 		return nil
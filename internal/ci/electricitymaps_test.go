@@ -16,15 +16,21 @@ func setupElectricityMapsTestServer(t *testing.T, handler http.HandlerFunc) *htt
 	srv := httptest.NewServer(handler)
 	oldLatestURL := electricityMapsLatestURL
 	oldForecastURL := electricityMapsForecastURL
+	oldPastRangeURL := electricityMapsPastRangeURL
+	oldPriceURL := electricityMapsPriceURL
 	oldClient := electricityMapsHTTPClient
 
 	electricityMapsLatestURL = srv.URL + "/latest"
 	electricityMapsForecastURL = srv.URL + "/forecast"
+	electricityMapsPastRangeURL = srv.URL + "/past-range"
+	electricityMapsPriceURL = srv.URL + "/price"
 	electricityMapsHTTPClient = srv.Client()
 
 	t.Cleanup(func() {
 		electricityMapsLatestURL = oldLatestURL
 		electricityMapsForecastURL = oldForecastURL
+		electricityMapsPastRangeURL = oldPastRangeURL
+		electricityMapsPriceURL = oldPriceURL
 		electricityMapsHTTPClient = oldClient
 		srv.Close()
 	})
@@ -160,3 +166,52 @@ func TestGetForecastCIInvalidDataClassified(t *testing.T) {
 		t.Fatalf("expected provider error kind %q, got %v", ErrorKindInvalidData, err)
 	}
 }
+
+func TestGetHistoryCIConvertsAndSorts(t *testing.T) {
+	setupElectricityMapsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/past-range" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("start"); got != "2026-01-01T10:00:00Z" {
+			t.Fatalf("start query = %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"datetime":"2026-01-01T11:00:00.000Z","carbonIntensity":300},
+			{"datetime":"2026-01-01T10:00:00.000Z","carbonIntensity":200}
+		]}`))
+	})
+
+	provider := &ElectricityMapsProvider{APIKey: "test-key"}
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	points, err := GetHistoryCI(context.Background(), provider, "DE", start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("GetHistoryCI() unexpected error: %v", err)
+	}
+	if len(points) != 2 || !points[0].Timestamp.Equal(start) || math.Abs(points[0].CI-0.2) > 1e-9 {
+		t.Fatalf("unexpected history points: %+v", points)
+	}
+}
+
+func TestGetPriceSeriesConvertsMWhToKWh(t *testing.T) {
+	setupElectricityMapsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/price" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"datetime":"2026-01-01T11:00:00.000Z","value":120,"unit":"EUR/MWh"},
+			{"datetime":"2026-01-01T10:00:00.000Z","value":80,"unit":"EUR/MWh"}
+		]}`))
+	})
+
+	provider := &ElectricityMapsProvider{APIKey: "test-key"}
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	points, err := GetPriceSeries(context.Background(), provider, "DE", start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("GetPriceSeries() unexpected error: %v", err)
+	}
+	if len(points) != 2 || !points[0].Timestamp.Equal(start) || math.Abs(points[0].PricePerKWh-0.08) > 1e-12 || points[0].Currency != "EUR" {
+		t.Fatalf("unexpected price points: %+v", points)
+	}
+}
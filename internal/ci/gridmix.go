@@ -0,0 +1,201 @@
+package ci
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenzhuyu2004/carbon-guard/internal/calculator"
+)
+
+// GridMixFileProvider serves carbon intensity offline from hourly generation mix files.
+// GridMixFileProvider 基于逐小时发电结构文件离线提供碳强度。
+//
+// Each zone reads <Dir>/<ZONE>.csv with a "timestamp" column (RFC3339) and one column per fuel
+// type; each row is converted with calculator.GridMixIntensity using Factors (kgCO2eq/kWh).
+// 每个区域读取 <Dir>/<ZONE>.csv，包含 "timestamp" 列（RFC3339）及每种燃料一列；
+// 每行使用 Factors（kgCO2eq/kWh）经 calculator.GridMixIntensity 换算。
+type GridMixFileProvider struct {
+	Dir     string
+	Factors map[string]float64
+	// Now overrides the clock for current/forecast lookups (defaults to time.Now).
+	// Now 覆盖当前值/预测查询所用的时钟（默认 time.Now）。
+	Now func() time.Time
+}
+
+// GetCurrentCI returns the intensity of the latest mix row at or before now.
+// GetCurrentCI 返回不晚于当前时刻的最新一行发电结构对应的碳强度。
+func (p *GridMixFileProvider) GetCurrentCI(ctx context.Context, zone string) (float64, error) {
+	const op = "get_current_ci"
+
+	points, err := p.load(ctx, op, zone)
+	if err != nil {
+		return 0, err
+	}
+	now := p.now()
+	idx := sort.Search(len(points), func(i int) bool {
+		return points[i].Timestamp.After(now)
+	})
+	if idx == 0 {
+		return 0, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("grid mix file has no rows at or before %s", now.Format(time.RFC3339)))
+	}
+	return points[idx-1].CI, nil
+}
+
+// GetForecastCI returns the mix rows from the current hour onwards; lookahead clipping is left
+// to the app layer, as for the live provider.
+// GetForecastCI 返回自当前小时起的发电结构数据；与实时 provider 一致，lookahead 裁剪由 app 层负责。
+func (p *GridMixFileProvider) GetForecastCI(ctx context.Context, zone string, hours int) ([]ForecastPoint, error) {
+	const op = "get_forecast_ci"
+
+	if hours <= 0 {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("hours must be > 0"))
+	}
+	points, err := p.load(ctx, op, zone)
+	if err != nil {
+		return nil, err
+	}
+	from := p.now().Truncate(time.Hour)
+	out := make([]ForecastPoint, 0, len(points))
+	for _, point := range points {
+		if !point.Timestamp.Before(from) {
+			out = append(out, point)
+		}
+	}
+	if len(out) == 0 {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("grid mix file has no rows after %s", from.Format(time.RFC3339)))
+	}
+	return out, nil
+}
+
+// GetHistoryCI returns the mix rows in [start, end].
+// GetHistoryCI 返回 [start, end] 内的发电结构数据。
+func (p *GridMixFileProvider) GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) ([]ForecastPoint, error) {
+	const op = "get_history_ci"
+
+	if !end.After(start) {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("history end must be after start"))
+	}
+	points, err := p.load(ctx, op, zone)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ForecastPoint, 0, len(points))
+	for _, point := range points {
+		if !point.Timestamp.Before(start) && !point.Timestamp.After(end) {
+			out = append(out, point)
+		}
+	}
+	if len(out) == 0 {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("grid mix file has no rows in requested range"))
+	}
+	return out, nil
+}
+
+func (p *GridMixFileProvider) now() time.Time {
+	if p.Now != nil {
+		return p.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func (p *GridMixFileProvider) load(ctx context.Context, op string, zone string) ([]ForecastPoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	zone = strings.TrimSpace(zone)
+	if zone == "" || strings.ContainsAny(zone, `/\`) || zone == "." || zone == ".." {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("invalid grid mix zone %q", zone))
+	}
+
+	path := filepath.Join(p.Dir, zone+".csv")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Zone files are conventionally upper-case (DE.csv); accept lower-case names too.
+		// 区域文件通常为大写（DE.csv），同时兼容小写文件名。
+		for _, alt := range []string{strings.ToUpper(zone), strings.ToLower(zone)} {
+			if data, err = os.ReadFile(filepath.Join(p.Dir, alt+".csv")); err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, NewProviderError(ErrorKindUpstream, op, zone, fmt.Errorf("read grid mix file: %w", err))
+	}
+
+	points, err := parseGridMixCSV(data, p.Factors)
+	if err != nil {
+		return nil, NewProviderError(ErrorKindInvalidData, op, zone, fmt.Errorf("%s: %w", filepath.Base(path), err))
+	}
+	return points, nil
+}
+
+func parseGridMixCSV(data []byte, factors map[string]float64) ([]ForecastPoint, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid grid mix csv header: %v", err)
+	}
+	tsCol := -1
+	fuels := make([]string, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "timestamp" {
+			tsCol = i
+			continue
+		}
+		fuels[i] = name
+	}
+	if tsCol < 0 {
+		return nil, fmt.Errorf("grid mix csv requires a timestamp column")
+	}
+
+	var points []ForecastPoint
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid grid mix csv: %v", err)
+		}
+		ts, err := time.Parse(time.RFC3339, strings.TrimSpace(row[tsCol]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp on line %d: %s", line, row[tsCol])
+		}
+		shares := make(map[string]float64, len(row)-1)
+		for i, raw := range row {
+			raw = strings.TrimSpace(raw)
+			if i == tsCol || fuels[i] == "" || raw == "" {
+				continue
+			}
+			share, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s share on line %d: %s", fuels[i], line, raw)
+			}
+			shares[fuels[i]] += share
+		}
+		intensity, err := calculator.GridMixIntensity(shares, factors)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		points = append(points, ForecastPoint{Timestamp: ts.UTC(), CI: intensity})
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("grid mix csv has no rows")
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points, nil
+}
@@ -0,0 +1,76 @@
+package ci
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGridMixFileProviderServesCurrentForecastAndHistory(t *testing.T) {
+	dir := t.TempDir()
+	content := "timestamp,gas,wind,nuclear\n" +
+		"2026-06-01T02:00:00Z,0,50,50\n" +
+		"2026-06-01T00:00:00Z,40,30,30\n" +
+		"2026-06-01T01:00:00Z,100,,0\n"
+	if err := os.WriteFile(filepath.Join(dir, "DE.csv"), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	provider := &GridMixFileProvider{
+		Dir:     dir,
+		Factors: map[string]float64{"gas": 0.49, "wind": 0.011, "nuclear": 0.012},
+		Now: func() time.Time {
+			return time.Date(2026, 6, 1, 1, 30, 0, 0, time.UTC)
+		},
+	}
+	ctx := context.Background()
+
+	current, err := provider.GetCurrentCI(ctx, "de")
+	if err != nil {
+		t.Fatalf("GetCurrentCI() unexpected error: %v", err)
+	}
+	if math.Abs(current-0.49) > 1e-12 {
+		t.Fatalf("GetCurrentCI() = %v, expected gas-only intensity 0.49", current)
+	}
+
+	forecast, err := provider.GetForecastCI(ctx, "DE", 6)
+	if err != nil {
+		t.Fatalf("GetForecastCI() unexpected error: %v", err)
+	}
+	if len(forecast) != 2 || !forecast[0].Timestamp.Equal(time.Date(2026, 6, 1, 1, 0, 0, 0, time.UTC)) {
+		t.Fatalf("GetForecastCI() = %+v, expected rows from the current hour", forecast)
+	}
+	if math.Abs(forecast[1].CI-0.0115) > 1e-12 {
+		t.Fatalf("forecast[1].CI = %v, expected 0.0115", forecast[1].CI)
+	}
+
+	history, err := provider.GetHistoryCI(ctx, "DE", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 6, 1, 0, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetHistoryCI() unexpected error: %v", err)
+	}
+	expected := 0.4*0.49 + 0.3*0.011 + 0.3*0.012
+	if len(history) != 1 || math.Abs(history[0].CI-expected) > 1e-12 {
+		t.Fatalf("GetHistoryCI() = %+v, expected one point at %v", history, expected)
+	}
+}
+
+func TestGridMixFileProviderErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "FR.csv"), []byte("timestamp,fusion\n2026-06-01T00:00:00Z,100\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	provider := &GridMixFileProvider{Dir: dir, Factors: map[string]float64{"gas": 0.49}}
+	ctx := context.Background()
+
+	if _, err := provider.GetCurrentCI(ctx, "DE"); !IsKind(err, ErrorKindUpstream) {
+		t.Fatalf("expected upstream error for missing zone file, got %v", err)
+	}
+	if _, err := provider.GetCurrentCI(ctx, "FR"); !IsKind(err, ErrorKindInvalidData) {
+		t.Fatalf("expected invalid data error for fuel without factor, got %v", err)
+	}
+	if _, err := provider.GetCurrentCI(ctx, "../FR"); !IsKind(err, ErrorKindInvalidData) {
+		t.Fatalf("expected invalid data error for path-like zone, got %v", err)
+	}
+}
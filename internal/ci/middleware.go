@@ -108,6 +108,18 @@ func (p *timeoutProvider) GetForecastCI(ctx context.Context, zone string, hours
 	return p.next.GetForecastCI(callCtx, zone, hours)
 }
 
+func (p *timeoutProvider) GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) ([]ForecastPoint, error) {
+	callCtx, cancel := withCallTimeout(ctx, p.timeout)
+	defer cancel()
+	return GetHistoryCI(callCtx, p.next, zone, start, end)
+}
+
+func (p *timeoutProvider) GetPriceSeries(ctx context.Context, zone string, start time.Time, end time.Time) ([]PricePoint, error) {
+	callCtx, cancel := withCallTimeout(ctx, p.timeout)
+	defer cancel()
+	return GetPriceSeries(callCtx, p.next, zone, start, end)
+}
+
 func withCallTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
 	if timeout <= 0 {
 		return ctx, func() {}
@@ -162,6 +174,32 @@ func (p *retryProvider) GetForecastCI(ctx context.Context, zone string, hours in
 	return points, err
 }
 
+func (p *retryProvider) GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) ([]ForecastPoint, error) {
+	var points []ForecastPoint
+	err := p.retry(ctx, func(callCtx context.Context) error {
+		v, err := GetHistoryCI(callCtx, p.next, zone, start, end)
+		if err != nil {
+			return err
+		}
+		points = v
+		return nil
+	})
+	return points, err
+}
+
+func (p *retryProvider) GetPriceSeries(ctx context.Context, zone string, start time.Time, end time.Time) ([]PricePoint, error) {
+	var points []PricePoint
+	err := p.retry(ctx, func(callCtx context.Context) error {
+		v, err := GetPriceSeries(callCtx, p.next, zone, start, end)
+		if err != nil {
+			return err
+		}
+		points = v
+		return nil
+	})
+	return points, err
+}
+
 func (p *retryProvider) retry(ctx context.Context, call func(context.Context) error) error {
 	var lastErr error
 	for attempt := 1; attempt <= p.cfg.MaxAttempts; attempt++ {
@@ -290,6 +328,20 @@ func (p *rateLimitProvider) GetForecastCI(ctx context.Context, zone string, hour
 	return p.next.GetForecastCI(ctx, zone, hours)
 }
 
+func (p *rateLimitProvider) GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) ([]ForecastPoint, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return GetHistoryCI(ctx, p.next, zone, start, end)
+}
+
+func (p *rateLimitProvider) GetPriceSeries(ctx context.Context, zone string, start time.Time, end time.Time) ([]PricePoint, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return GetPriceSeries(ctx, p.next, zone, start, end)
+}
+
 type tokenBucket struct {
 	mu    sync.Mutex
 	rate  float64
@@ -388,6 +440,24 @@ func (p *metricsProvider) GetForecastCI(ctx context.Context, zone string, hours
 	return points, err
 }
 
+func (p *metricsProvider) GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) (points []ForecastPoint, err error) {
+	begin := time.Now()
+	defer func() {
+		p.recorder.ObserveCall("GetHistoryCI", zone, time.Since(begin), err)
+	}()
+	points, err = GetHistoryCI(ctx, p.next, zone, start, end)
+	return points, err
+}
+
+func (p *metricsProvider) GetPriceSeries(ctx context.Context, zone string, start time.Time, end time.Time) (points []PricePoint, err error) {
+	begin := time.Now()
+	defer func() {
+		p.recorder.ObserveCall("GetPriceSeries", zone, time.Since(begin), err)
+	}()
+	points, err = GetPriceSeries(ctx, p.next, zone, start, end)
+	return points, err
+}
+
 type lockedRand struct {
 	mu sync.Mutex
 	r  *rand.Rand
@@ -92,3 +92,20 @@ func TestRetryMiddlewareDoesNotRetryInputErrors(t *testing.T) {
 		t.Fatalf("current calls = %d, expected 1", calls)
 	}
 }
+
+func TestPipelineForwardsHistoryCapability(t *testing.T) {
+	stub := &retryStubProvider{}
+	p := NewPipeline(stub, PipelineConfig{
+		Timeout:   time.Second,
+		Retry:     RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		RateLimit: RateLimitConfig{RequestsPerSecond: 100, Burst: 10},
+		CacheDir:  t.TempDir(),
+		Metrics:   NopMetricsRecorder{},
+	})
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := GetHistoryCI(context.Background(), p, "DE", start, start.Add(time.Hour))
+	if !errors.Is(err, ErrHistoryUnsupported) {
+		t.Fatalf("expected ErrHistoryUnsupported through the pipeline, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package ci
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -14,3 +15,47 @@ type Provider interface {
 	GetCurrentCI(ctx context.Context, zone string) (float64, error)
 	GetForecastCI(ctx context.Context, zone string, hours int) ([]ForecastPoint, error)
 }
+
+// HistoryProvider is an optional capability for past carbon intensity in [start, end].
+// HistoryProvider 为可选能力，返回 [start, end] 内的历史碳强度。
+type HistoryProvider interface {
+	GetHistoryCI(ctx context.Context, zone string, start time.Time, end time.Time) ([]ForecastPoint, error)
+}
+
+var ErrHistoryUnsupported = errors.New("provider does not support carbon intensity history")
+
+// GetHistoryCI calls p's history capability, or returns ErrHistoryUnsupported.
+// GetHistoryCI 调用 p 的历史数据能力；不支持时返回 ErrHistoryUnsupported。
+func GetHistoryCI(ctx context.Context, p Provider, zone string, start time.Time, end time.Time) ([]ForecastPoint, error) {
+	history, ok := p.(HistoryProvider)
+	if !ok {
+		return nil, ErrHistoryUnsupported
+	}
+	return history.GetHistoryCI(ctx, zone, start, end)
+}
+
+// PricePoint is a day-ahead electricity price per kWh starting at Timestamp.
+// PricePoint 为自 Timestamp 起的日前电价（每 kWh）。
+type PricePoint struct {
+	Timestamp   time.Time
+	PricePerKWh float64
+	Currency    string
+}
+
+// PriceProvider is an optional capability for electricity prices in [start, end].
+// PriceProvider 为可选能力，返回 [start, end] 内的电价。
+type PriceProvider interface {
+	GetPriceSeries(ctx context.Context, zone string, start time.Time, end time.Time) ([]PricePoint, error)
+}
+
+var ErrPriceUnsupported = errors.New("provider does not support electricity prices")
+
+// GetPriceSeries calls p's price capability, or returns ErrPriceUnsupported.
+// GetPriceSeries 调用 p 的电价能力；不支持时返回 ErrPriceUnsupported。
+func GetPriceSeries(ctx context.Context, p Provider, zone string, start time.Time, end time.Time) ([]PricePoint, error) {
+	prices, ok := p.(PriceProvider)
+	if !ok {
+		return nil, ErrPriceUnsupported
+	}
+	return prices.GetPriceSeries(ctx, zone, start, end)
+}
@@ -0,0 +1,188 @@
+// Package cloudmeta discovers the cloud provider region from instance metadata services.
+// Package cloudmeta 通过实例元数据服务识别云厂商 region。
+package cloudmeta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default metadata endpoints; AWS and Azure share the link-local address.
+// 默认元数据端点；AWS 与 Azure 共用链路本地地址。
+const (
+	DefaultAWSEndpoint   = "http://169.254.169.254"
+	DefaultGCPEndpoint   = "http://metadata.google.internal"
+	DefaultAzureEndpoint = "http://169.254.169.254"
+)
+
+// DefaultTimeout bounds the whole detection so that non-cloud hosts are not slowed down.
+// DefaultTimeout 限制整体探测耗时，避免拖慢非云主机。
+const DefaultTimeout = 300 * time.Millisecond
+
+const (
+	awsTokenTTLSeconds = "60"
+	azureAPIVersion    = "2021-02-01"
+	maxResponseBytes   = 4096
+)
+
+var ErrUnavailable = errors.New("cloud instance metadata unavailable")
+
+// Region is the cloud region an instance runs in.
+// Region 为实例所在的云 region。
+type Region struct {
+	Provider string
+	Region   string
+}
+
+// ID returns the "provider:region" identifier used by catalog.CloudRegionMap.
+// ID 返回 catalog.CloudRegionMap 使用的 "provider:region" 标识。
+func (r Region) ID() string {
+	return r.Provider + ":" + r.Region
+}
+
+// Detector probes AWS IMDSv2, GCP, and Azure IMDS concurrently; empty endpoints use the defaults.
+// Detector 并发探测 AWS IMDSv2、GCP 与 Azure IMDS；端点为空时使用默认值。
+type Detector struct {
+	AWSEndpoint   string
+	GCPEndpoint   string
+	AzureEndpoint string
+	Timeout       time.Duration
+	// Client overrides the HTTP client; the default never uses a proxy.
+	// Client 覆盖 HTTP 客户端；默认客户端不经过代理。
+	Client *http.Client
+}
+
+// Detect returns the region reported by the first provider, in AWS, GCP, Azure order, that answers.
+// Detect 按 AWS、GCP、Azure 顺序返回首个成功应答的 provider 所报告的 region。
+func (d Detector) Detect(ctx context.Context) (Region, error) {
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	probes := []func(context.Context) (Region, error){d.detectAWS, d.detectGCP, d.detectAzure}
+	regions := make([]Region, len(probes))
+	errs := make([]error, len(probes))
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			regions[i], errs[i] = probe(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for i := range probes {
+		if errs[i] == nil {
+			return regions[i], nil
+		}
+	}
+	return Region{}, fmt.Errorf("%w: %v", ErrUnavailable, errors.Join(errs...))
+}
+
+// detectAWS uses IMDSv2: a session token is required before reading the placement region.
+// detectAWS 使用 IMDSv2：读取 placement region 前需先获取会话令牌。
+func (d Detector) detectAWS(ctx context.Context) (Region, error) {
+	base := endpointOrDefault(d.AWSEndpoint, DefaultAWSEndpoint)
+	token, err := d.fetch(ctx, http.MethodPut, base+"/latest/api/token", map[string]string{
+		"X-aws-ec2-metadata-token-ttl-seconds": awsTokenTTLSeconds,
+	})
+	if err != nil {
+		return Region{}, fmt.Errorf("aws: %w", err)
+	}
+	region, err := d.fetch(ctx, http.MethodGet, base+"/latest/meta-data/placement/region", map[string]string{
+		"X-aws-ec2-metadata-token": token,
+	})
+	if err != nil {
+		return Region{}, fmt.Errorf("aws: %w", err)
+	}
+	return Region{Provider: "aws", Region: strings.ToLower(region)}, nil
+}
+
+// detectGCP reads the instance zone ("projects/123/zones/europe-west4-a") and drops the zone suffix.
+// detectGCP 读取实例可用区（"projects/123/zones/europe-west4-a"）并去掉可用区后缀。
+func (d Detector) detectGCP(ctx context.Context) (Region, error) {
+	base := endpointOrDefault(d.GCPEndpoint, DefaultGCPEndpoint)
+	zone, err := d.fetch(ctx, http.MethodGet, base+"/computeMetadata/v1/instance/zone", map[string]string{
+		"Metadata-Flavor": "Google",
+	})
+	if err != nil {
+		return Region{}, fmt.Errorf("gcp: %w", err)
+	}
+	zone = zone[strings.LastIndex(zone, "/")+1:]
+	idx := strings.LastIndex(zone, "-")
+	if idx <= 0 {
+		return Region{}, fmt.Errorf("gcp: unexpected zone %q", zone)
+	}
+	return Region{Provider: "gcp", Region: strings.ToLower(zone[:idx])}, nil
+}
+
+func (d Detector) detectAzure(ctx context.Context) (Region, error) {
+	base := endpointOrDefault(d.AzureEndpoint, DefaultAzureEndpoint)
+	location, err := d.fetch(ctx, http.MethodGet, base+"/metadata/instance/compute/location?api-version="+azureAPIVersion+"&format=text", map[string]string{
+		"Metadata": "true",
+	})
+	if err != nil {
+		return Region{}, fmt.Errorf("azure: %w", err)
+	}
+	return Region{Provider: "azure", Region: strings.ToLower(location)}, nil
+}
+
+func (d Detector) fetch(ctx context.Context, method string, url string, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s %s: status %d", method, req.URL.Path, resp.StatusCode)
+	}
+	value := strings.TrimSpace(string(body))
+	if value == "" {
+		return "", fmt.Errorf("%s %s: empty response", method, req.URL.Path)
+	}
+	return value, nil
+}
+
+func (d Detector) client() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+	return defaultClient
+}
+
+// defaultClient bypasses HTTP proxies: metadata services are only reachable on the local link.
+// defaultClient 绕过 HTTP 代理：元数据服务只能在本地链路上访问。
+var defaultClient = &http.Client{
+	Transport: &http.Transport{Proxy: nil},
+}
+
+func endpointOrDefault(endpoint string, fallback string) string {
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if endpoint == "" {
+		return fallback
+	}
+	return endpoint
+}
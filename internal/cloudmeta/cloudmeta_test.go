@@ -0,0 +1,103 @@
+package cloudmeta
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func notFoundServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDetectAWSUsesIMDSv2Token(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			if r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte("Tok3n"))
+		case r.Method == http.MethodGet && r.URL.Path == "/latest/meta-data/placement/region":
+			if r.Header.Get("X-aws-ec2-metadata-token") != "Tok3n" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte("eu-west-1"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	missing := notFoundServer(t)
+
+	got, err := Detector{AWSEndpoint: server.URL, GCPEndpoint: missing.URL, AzureEndpoint: missing.URL, Timeout: time.Second}.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect() unexpected error: %v", err)
+	}
+	if got != (Region{Provider: "aws", Region: "eu-west-1"}) || got.ID() != "aws:eu-west-1" {
+		t.Fatalf("Detect() = %+v", got)
+	}
+}
+
+func TestDetectGCPAndAzure(t *testing.T) {
+	gcp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" || r.URL.Path != "/computeMetadata/v1/instance/zone" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte("projects/123456/zones/europe-west4-a"))
+	}))
+	defer gcp.Close()
+	azure := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("format") != "text" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("WestEurope\n"))
+	}))
+	defer azure.Close()
+	missing := notFoundServer(t)
+
+	got, err := Detector{AWSEndpoint: missing.URL, GCPEndpoint: gcp.URL, AzureEndpoint: missing.URL}.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect() unexpected error: %v", err)
+	}
+	if got.ID() != "gcp:europe-west4" {
+		t.Fatalf("Detect() = %+v, expected gcp:europe-west4", got)
+	}
+
+	got, err = Detector{AWSEndpoint: missing.URL, GCPEndpoint: missing.URL, AzureEndpoint: azure.URL}.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect() unexpected error: %v", err)
+	}
+	if got.ID() != "azure:westeurope" {
+		t.Fatalf("Detect() = %+v, expected azure:westeurope", got)
+	}
+}
+
+func TestDetectUnavailableHonorsTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	start := time.Now()
+	_, err := Detector{AWSEndpoint: slow.URL, GCPEndpoint: slow.URL, AzureEndpoint: slow.URL, Timeout: 50 * time.Millisecond}.Detect(context.Background())
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Detect() took %s, expected timeout to bound it", elapsed)
+	}
+}
@@ -9,72 +9,110 @@ import (
 )
 
 const (
-	EnvConfigPath   = "CARBON_GUARD_CONFIG"
-	EnvCacheDir     = "CARBON_GUARD_CACHE_DIR"
-	EnvCacheTTL     = "CARBON_GUARD_CACHE_TTL"
-	EnvTimeout      = "CARBON_GUARD_TIMEOUT"
-	EnvOutput       = "CARBON_GUARD_OUTPUT"
-	EnvZone         = "CARBON_GUARD_ZONE"
-	EnvZones        = "CARBON_GUARD_ZONES"
-	EnvZoneMode     = "CARBON_GUARD_ZONE_MODE"
-	EnvZoneHint     = "CARBON_GUARD_ZONE_HINT"
-	EnvCountryHint  = "CARBON_GUARD_COUNTRY_HINT"
-	EnvTimezoneHint = "CARBON_GUARD_TIMEZONE_HINT"
+	EnvConfigPath      = "CARBON_GUARD_CONFIG"
+	EnvCacheDir        = "CARBON_GUARD_CACHE_DIR"
+	EnvCacheTTL        = "CARBON_GUARD_CACHE_TTL"
+	EnvTimeout         = "CARBON_GUARD_TIMEOUT"
+	EnvOutput          = "CARBON_GUARD_OUTPUT"
+	EnvZone            = "CARBON_GUARD_ZONE"
+	EnvZones           = "CARBON_GUARD_ZONES"
+	EnvZoneMode        = "CARBON_GUARD_ZONE_MODE"
+	EnvZoneHint        = "CARBON_GUARD_ZONE_HINT"
+	EnvCountryHint     = "CARBON_GUARD_COUNTRY_HINT"
+	EnvTimezoneHint    = "CARBON_GUARD_TIMEZONE_HINT"
+	EnvGridMixDir      = "CARBON_GUARD_GRID_MIX_DIR"
+	EnvEmissionFactors = "CARBON_GUARD_EMISSION_FACTORS"
+	EnvCloudRegionMap  = "CARBON_GUARD_CLOUD_REGION_MAP"
+	EnvZoneLocations   = "CARBON_GUARD_ZONE_LOCATIONS"
 )
 
 const (
-	DefaultCacheDir     = "~/.carbon-guard"
-	DefaultCacheTTL     = "10m"
-	DefaultTimeout      = "30s"
-	DefaultOutput       = "text"
-	DefaultZone         = ""
-	DefaultZones        = ""
-	DefaultZoneMode     = "fallback"
-	DefaultZoneHint     = ""
-	DefaultCountryHint  = ""
-	DefaultTimezoneHint = ""
+	DefaultCacheDir        = "~/.carbon-guard"
+	DefaultCacheTTL        = "10m"
+	DefaultTimeout         = "30s"
+	DefaultOutput          = "text"
+	DefaultZone            = ""
+	DefaultZones           = ""
+	DefaultZoneMode        = "fallback"
+	DefaultZoneHint        = ""
+	DefaultCountryHint     = ""
+	DefaultTimezoneHint    = ""
+	DefaultGridMixDir      = ""
+	DefaultEmissionFactors = ""
+	DefaultCloudRegionMap  = ""
+	DefaultZoneLocations   = ""
 )
 
 type Shared struct {
-	ConfigPath   string
-	CacheDir     string
-	CacheTTL     string
-	Timeout      string
-	Output       string
-	Zone         string
-	Zones        string
+	ConfigPath string
+	CacheDir   string
+	CacheTTL   string
+	Timeout    string
+	Output     string
+	Zone       string
+	Zones      string
+	// FileZone and FileZones are the config file values before env overrides; they let
+	// `zones resolve --explain` report the config source separately from the env source.
+	// FileZone 与 FileZones 为环境变量覆盖前的配置文件取值，供 `zones resolve --explain`
+	// 将配置来源与环境变量来源分开报告。
+	FileZone     string
+	FileZones    string
 	ZoneMode     string
 	ZoneHint     string
 	CountryHint  string
 	TimezoneHint string
+	// GridMixDir selects the offline grid-mix provider instead of Electricity Maps when set.
+	// GridMixDir 设置时使用离线发电结构 provider 替代 Electricity Maps。
+	GridMixDir      string
+	EmissionFactors string
+	// CloudRegionMap is a JSON file merged over the embedded cloud region -> zone mapping.
+	// CloudRegionMap 为合并到内嵌云 region -> 区域映射之上的 JSON 文件。
+	CloudRegionMap string
+	// ZoneLocations is a JSON file merged over the embedded country/timezone -> zone dataset.
+	// ZoneLocations 为合并到内嵌国家/时区 -> 区域数据集之上的 JSON 文件。
+	ZoneLocations string
+	// ZoneAliases and ZoneGroups name zones and zone lists; they are read from the config file only.
+	// ZoneAliases 与 ZoneGroups 为区域及区域列表命名，仅从配置文件读取。
+	ZoneAliases map[string]string
+	ZoneGroups  map[string][]string
 }
 
 type fileConfig struct {
-	CacheDir     string `json:"cache_dir"`
-	CacheTTL     string `json:"cache_ttl"`
-	Timeout      string `json:"timeout"`
-	Output       string `json:"output"`
-	Zone         string `json:"zone"`
-	Zones        string `json:"zones"`
-	ZoneMode     string `json:"zone_mode"`
-	ZoneHint     string `json:"zone_hint"`
-	CountryHint  string `json:"country_hint"`
-	TimezoneHint string `json:"timezone_hint"`
+	CacheDir        string              `json:"cache_dir"`
+	CacheTTL        string              `json:"cache_ttl"`
+	Timeout         string              `json:"timeout"`
+	Output          string              `json:"output"`
+	Zone            string              `json:"zone"`
+	Zones           string              `json:"zones"`
+	ZoneMode        string              `json:"zone_mode"`
+	ZoneHint        string              `json:"zone_hint"`
+	CountryHint     string              `json:"country_hint"`
+	TimezoneHint    string              `json:"timezone_hint"`
+	GridMixDir      string              `json:"grid_mix_dir"`
+	EmissionFactors string              `json:"emission_factors"`
+	CloudRegionMap  string              `json:"cloud_region_map"`
+	ZoneLocations   string              `json:"zone_locations"`
+	ZoneAliases     map[string]string   `json:"zone_aliases"`
+	ZoneGroups      map[string][]string `json:"zone_groups"`
 }
 
 func Resolve(rawConfigPath string) (Shared, error) {
 	cfg := Shared{
-		ConfigPath:   "",
-		CacheDir:     DefaultCacheDir,
-		CacheTTL:     DefaultCacheTTL,
-		Timeout:      DefaultTimeout,
-		Output:       DefaultOutput,
-		Zone:         DefaultZone,
-		Zones:        DefaultZones,
-		ZoneMode:     DefaultZoneMode,
-		ZoneHint:     DefaultZoneHint,
-		CountryHint:  DefaultCountryHint,
-		TimezoneHint: DefaultTimezoneHint,
+		ConfigPath:      "",
+		CacheDir:        DefaultCacheDir,
+		CacheTTL:        DefaultCacheTTL,
+		Timeout:         DefaultTimeout,
+		Output:          DefaultOutput,
+		Zone:            DefaultZone,
+		Zones:           DefaultZones,
+		ZoneMode:        DefaultZoneMode,
+		ZoneHint:        DefaultZoneHint,
+		CountryHint:     DefaultCountryHint,
+		TimezoneHint:    DefaultTimezoneHint,
+		GridMixDir:      DefaultGridMixDir,
+		EmissionFactors: DefaultEmissionFactors,
+		CloudRegionMap:  DefaultCloudRegionMap,
+		ZoneLocations:   DefaultZoneLocations,
 	}
 
 	configPath := strings.TrimSpace(rawConfigPath)
@@ -110,6 +148,7 @@ func Resolve(rawConfigPath string) (Shared, error) {
 		if fileCfg.Zones != "" {
 			cfg.Zones = fileCfg.Zones
 		}
+		cfg.FileZone, cfg.FileZones = fileCfg.Zone, fileCfg.Zones
 		if fileCfg.ZoneMode != "" {
 			cfg.ZoneMode = fileCfg.ZoneMode
 		}
@@ -122,6 +161,20 @@ func Resolve(rawConfigPath string) (Shared, error) {
 		if fileCfg.TimezoneHint != "" {
 			cfg.TimezoneHint = fileCfg.TimezoneHint
 		}
+		if fileCfg.GridMixDir != "" {
+			cfg.GridMixDir = fileCfg.GridMixDir
+		}
+		if fileCfg.EmissionFactors != "" {
+			cfg.EmissionFactors = fileCfg.EmissionFactors
+		}
+		if fileCfg.CloudRegionMap != "" {
+			cfg.CloudRegionMap = fileCfg.CloudRegionMap
+		}
+		if fileCfg.ZoneLocations != "" {
+			cfg.ZoneLocations = fileCfg.ZoneLocations
+		}
+		cfg.ZoneAliases = fileCfg.ZoneAliases
+		cfg.ZoneGroups = fileCfg.ZoneGroups
 	}
 
 	if v := strings.TrimSpace(os.Getenv(EnvCacheDir)); v != "" {
@@ -154,6 +207,18 @@ func Resolve(rawConfigPath string) (Shared, error) {
 	if v := strings.TrimSpace(os.Getenv(EnvTimezoneHint)); v != "" {
 		cfg.TimezoneHint = v
 	}
+	if v := strings.TrimSpace(os.Getenv(EnvGridMixDir)); v != "" {
+		cfg.GridMixDir = v
+	}
+	if v := strings.TrimSpace(os.Getenv(EnvEmissionFactors)); v != "" {
+		cfg.EmissionFactors = v
+	}
+	if v := strings.TrimSpace(os.Getenv(EnvCloudRegionMap)); v != "" {
+		cfg.CloudRegionMap = v
+	}
+	if v := strings.TrimSpace(os.Getenv(EnvZoneLocations)); v != "" {
+		cfg.ZoneLocations = v
+	}
 
 	return cfg, nil
 }
@@ -18,6 +18,10 @@ func TestResolveDefaults(t *testing.T) {
 	t.Setenv(EnvZoneHint, "")
 	t.Setenv(EnvCountryHint, "")
 	t.Setenv(EnvTimezoneHint, "")
+	t.Setenv(EnvGridMixDir, "")
+	t.Setenv(EnvEmissionFactors, "")
+	t.Setenv(EnvCloudRegionMap, "")
+	t.Setenv(EnvZoneLocations, "")
 
 	got, err := Resolve("")
 	if err != nil {
@@ -69,7 +73,13 @@ func TestResolveConfigAndEnvOverride(t *testing.T) {
   "zone_mode": "auto",
   "zone_hint": "FR",
   "country_hint": "DE",
-  "timezone_hint": "Europe/Berlin"
+  "timezone_hint": "Europe/Berlin",
+  "grid_mix_dir": "/data/mix",
+  "emission_factors": "/data/factors.json",
+  "cloud_region_map": "/data/cloud.json",
+  "zone_locations": "/data/locations.json",
+  "zone_aliases": {"frankfurt-dc": "DE"},
+  "zone_groups": {"eu-primary": ["DE", "FR", "NL"]}
 }`
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		t.Fatalf("WriteFile() error: %v", err)
@@ -84,6 +94,10 @@ func TestResolveConfigAndEnvOverride(t *testing.T) {
 	t.Setenv(EnvZoneHint, "CA-ON")
 	t.Setenv(EnvCountryHint, "US")
 	t.Setenv(EnvTimezoneHint, "America/New_York")
+	t.Setenv(EnvGridMixDir, "/env/mix")
+	t.Setenv(EnvEmissionFactors, "")
+	t.Setenv(EnvCloudRegionMap, "")
+	t.Setenv(EnvZoneLocations, "/env/locations.json")
 
 	got, err := Resolve("")
 	if err != nil {
@@ -111,6 +125,9 @@ func TestResolveConfigAndEnvOverride(t *testing.T) {
 	if got.Zones != "US-NY,CA-ON" {
 		t.Fatalf("Zones = %q, expected %q", got.Zones, "US-NY,CA-ON")
 	}
+	if got.FileZone != "DE" || got.FileZones != "DE,FR" {
+		t.Fatalf("FileZone = %q, FileZones = %q, expected config file values", got.FileZone, got.FileZones)
+	}
 	if got.ZoneMode != "fallback" {
 		t.Fatalf("ZoneMode = %q, expected %q", got.ZoneMode, "fallback")
 	}
@@ -123,6 +140,21 @@ func TestResolveConfigAndEnvOverride(t *testing.T) {
 	if got.TimezoneHint != "America/New_York" {
 		t.Fatalf("TimezoneHint = %q, expected %q", got.TimezoneHint, "America/New_York")
 	}
+	if got.GridMixDir != "/env/mix" {
+		t.Fatalf("GridMixDir = %q, expected %q", got.GridMixDir, "/env/mix")
+	}
+	if got.EmissionFactors != "/data/factors.json" {
+		t.Fatalf("EmissionFactors = %q, expected %q", got.EmissionFactors, "/data/factors.json")
+	}
+	if got.CloudRegionMap != "/data/cloud.json" {
+		t.Fatalf("CloudRegionMap = %q, expected %q", got.CloudRegionMap, "/data/cloud.json")
+	}
+	if got.ZoneLocations != "/env/locations.json" {
+		t.Fatalf("ZoneLocations = %q, expected %q", got.ZoneLocations, "/env/locations.json")
+	}
+	if got.ZoneAliases["frankfurt-dc"] != "DE" || len(got.ZoneGroups["eu-primary"]) != 3 {
+		t.Fatalf("ZoneAliases = %v, ZoneGroups = %v", got.ZoneAliases, got.ZoneGroups)
+	}
 }
 
 func TestResolveExplicitConfigPathBeatsEnvPath(t *testing.T) {
@@ -150,3 +182,20 @@ func TestResolveExplicitConfigPathBeatsEnvPath(t *testing.T) {
 		t.Fatalf("CacheTTL = %q, expected %q", got.CacheTTL, "33m")
 	}
 }
+
+func TestResolveGridMixFromEnvWithoutConfig(t *testing.T) {
+	t.Setenv(EnvConfigPath, "")
+	t.Setenv(EnvGridMixDir, "/env/mix")
+	t.Setenv(EnvEmissionFactors, "/env/factors.json")
+
+	got, err := Resolve("")
+	if err != nil {
+		t.Fatalf("Resolve() unexpected error: %v", err)
+	}
+	if got.ConfigPath != "" {
+		t.Fatalf("ConfigPath = %q, expected none", got.ConfigPath)
+	}
+	if got.GridMixDir != "/env/mix" || got.EmissionFactors != "/env/factors.json" {
+		t.Fatalf("GridMixDir = %q, EmissionFactors = %q, expected env values", got.GridMixDir, got.EmissionFactors)
+	}
+}
@@ -0,0 +1,523 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Limits of the exact batch solver; larger instances must use the heuristic.
+// 精确批量求解器的规模上限；更大的实例需使用启发式算法。
+const (
+	MaxExactBatchJobs  = 16
+	maxExactBatchNodes = 2_000_000
+)
+
+// Reasons reported for jobs left out of a batch plan.
+// 批量计划中未排入作业的原因。
+const (
+	UnscheduledNoWindow = "no-window"
+	UnscheduledCapacity = "capacity"
+)
+
+// ErrBatchTooLarge reports an instance above the exact solver limits.
+// ErrBatchTooLarge 表示实例规模超出精确求解器上限。
+var ErrBatchTooLarge = errors.New("batch too large for exact solver")
+
+// BatchJob is one job of a batch plan.
+// BatchJob 为批量计划中的一个作业。
+type BatchJob struct {
+	ID       string
+	Duration int
+	// EarliestStart and Deadline bound the job's window; zero values use the plan horizon.
+	// EarliestStart 与 Deadline 限制作业窗口；零值时使用计划范围。
+	EarliestStart time.Time
+	Deadline      time.Time
+	// Zones lists the zones the job may run in, in preference order for ties.
+	// Zones 为作业可运行的区域，平局时按此顺序优先。
+	Zones []string
+	// Priority ranks jobs; a higher-priority job is never left out to make room for lower ones.
+	// Priority 为作业优先级；不会为低优先级作业让出高优先级作业的位置。
+	Priority int
+}
+
+// CapacityWindow overrides a zone's capacity for slots starting in [Start, End).
+// CapacityWindow 覆盖起点位于 [Start, End) 内的时间槽的区域容量。
+type CapacityWindow struct {
+	Start    time.Time
+	End      time.Time
+	Capacity int
+}
+
+// ZoneCapacity is the number of jobs a zone runs at once; later Windows take precedence.
+// ZoneCapacity 为区域可同时运行的作业数；靠后的 Windows 优先。
+type ZoneCapacity struct {
+	Default int
+	Windows []CapacityWindow
+}
+
+// At returns the capacity for the slot starting at slot.
+// At 返回起点为 slot 的时间槽容量。
+func (c ZoneCapacity) At(slot time.Time) int {
+	capacity := c.Default
+	for _, window := range c.Windows {
+		if !slot.Before(window.Start) && slot.Before(window.End) {
+			capacity = window.Capacity
+		}
+	}
+	return capacity
+}
+
+// BatchOptions configures PlanBatch.
+// BatchOptions 配置 PlanBatch。
+type BatchOptions struct {
+	// Start anchors the slot grid; jobs start on Start + k*Slot and end by End.
+	// Start 为时间槽网格锚点；作业起点为 Start + k*Slot，且需在 End 之前结束。
+	Start time.Time
+	End   time.Time
+	Slot  time.Duration
+	// Exact runs branch and bound from the heuristic plan and proves optimality.
+	// Exact 以启发式计划为初值执行分支定界并证明最优。
+	Exact  bool
+	Runner string
+	Load   float64
+	PUE    float64
+}
+
+// BatchAssignment places one job in a zone.
+// BatchAssignment 将一个作业安排到某区域。
+type BatchAssignment struct {
+	JobID    string
+	Zone     string
+	Start    time.Time
+	End      time.Time
+	Emission float64
+}
+
+// BatchUnscheduled is a job left out of the plan.
+// BatchUnscheduled 为未排入计划的作业。
+type BatchUnscheduled struct {
+	JobID  string
+	Reason string
+}
+
+// BatchPlan is a capacity-feasible assignment of jobs to zones and start times.
+// BatchPlan 为满足容量约束的作业到区域与起点的分配方案。
+type BatchPlan struct {
+	// Assignments are ordered by start, then zone, then job ID.
+	// Assignments 按起点、区域、作业 ID 排序。
+	Assignments []BatchAssignment
+	// Unscheduled keeps the input job order.
+	// Unscheduled 保持输入作业顺序。
+	Unscheduled []BatchUnscheduled
+	Emission    float64
+	// Optimal is true when the exact solver proved the plan optimal.
+	// Optimal 为 true 表示精确求解器已证明该计划最优。
+	Optimal bool
+}
+
+type batchOption struct {
+	zone     int
+	slot     int
+	span     int
+	emission float64
+}
+
+type batchSolver struct {
+	jobs    []BatchJob
+	options [][]batchOption
+	levels  []int
+	// levelCount is the number of distinct priorities.
+	// levelCount 为不同优先级的数量。
+	levelCount int
+	capacity   [][]int
+	used       [][]int
+	order      []int
+}
+
+// PlanBatch assigns jobs to zones and slot-aligned starts, minimizing total emission.
+// PlanBatch 将作业分配到区域与对齐时间槽的起点，使总排放最小。
+//
+// Jobs occupy one unit of their zone's capacity in every slot their run overlaps. Plans are
+// compared first by the jobs left out, from the highest priority down, then by total emission.
+// The heuristic places jobs by priority, then tightest first, each in its cheapest free option,
+// and then moves single jobs to cheaper free options until nothing improves. It is deterministic.
+// 作业在其运行覆盖的每个时间槽内占用所在区域的一个容量单位。计划先按未排入作业（从最高优先级
+// 开始）比较，再按总排放比较。启发式算法按优先级、再按可选项最少者优先，将作业放入最便宜的
+// 空闲选项，然后逐个将作业移动到更便宜的空闲选项，直到无法改进；结果是确定性的。
+func PlanBatch(
+	jobs []BatchJob,
+	evaluators map[string]EmissionEvaluator,
+	capacity map[string]ZoneCapacity,
+	options BatchOptions,
+) (BatchPlan, error) {
+	if options.Slot < time.Second {
+		return BatchPlan{}, fmt.Errorf("batch slot must be >= 1s")
+	}
+	slots := int(math.Ceil(float64(options.End.Sub(options.Start)) / float64(options.Slot)))
+	if slots <= 0 {
+		return BatchPlan{}, fmt.Errorf("batch horizon must be positive")
+	}
+	if options.Exact && len(jobs) > MaxExactBatchJobs {
+		return BatchPlan{}, fmt.Errorf("%w: %d jobs (limit %d)", ErrBatchTooLarge, len(jobs), MaxExactBatchJobs)
+	}
+
+	zones, zoneIndex := batchZones(jobs)
+	levels, levelCount := priorityLevels(jobs)
+	solver := batchSolver{
+		jobs:       jobs,
+		options:    make([][]batchOption, len(jobs)),
+		levels:     levels,
+		levelCount: levelCount,
+		capacity:   make([][]int, len(zones)),
+		used:       make([][]int, len(zones)),
+	}
+	for z, zone := range zones {
+		zoneCapacity, ok := capacity[zone]
+		if !ok {
+			zoneCapacity = ZoneCapacity{Default: 1}
+		}
+		solver.capacity[z] = make([]int, slots)
+		solver.used[z] = make([]int, slots)
+		for k := range solver.capacity[z] {
+			solver.capacity[z][k] = zoneCapacity.At(options.Start.Add(time.Duration(k) * options.Slot))
+		}
+	}
+	for j, job := range jobs {
+		solver.options[j] = batchJobOptions(job, zoneIndex, evaluators, options, slots)
+	}
+	solver.order = solver.jobOrder(options.End)
+
+	placed := solver.greedy()
+	optimal := false
+	if options.Exact {
+		exact, err := solver.branchAndBound(placed)
+		if err != nil {
+			return BatchPlan{}, err
+		}
+		placed = exact
+		optimal = true
+	}
+	return solver.plan(placed, zones, options, optimal), nil
+}
+
+func batchZones(jobs []BatchJob) ([]string, map[string]int) {
+	var zones []string
+	index := make(map[string]int)
+	for _, job := range jobs {
+		for _, zone := range job.Zones {
+			if _, ok := index[zone]; ok {
+				continue
+			}
+			index[zone] = len(zones)
+			zones = append(zones, zone)
+		}
+	}
+	return zones, index
+}
+
+// priorityLevels maps each job to the rank of its priority, 0 being the highest, and returns
+// the number of ranks.
+// priorityLevels 将每个作业映射为其优先级排名（0 为最高），并返回排名数量。
+func priorityLevels(jobs []BatchJob) ([]int, int) {
+	distinct := make([]int, 0, len(jobs))
+	seen := make(map[int]bool)
+	for _, job := range jobs {
+		if !seen[job.Priority] {
+			seen[job.Priority] = true
+			distinct = append(distinct, job.Priority)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(distinct)))
+	rank := make(map[int]int, len(distinct))
+	for i, priority := range distinct {
+		rank[priority] = i
+	}
+	levels := make([]int, len(jobs))
+	for j, job := range jobs {
+		levels[j] = rank[job.Priority]
+	}
+	return levels, len(distinct)
+}
+
+// batchJobOptions lists a job's feasible (zone, slot) starts, cheapest first.
+// batchJobOptions 列出作业可行的（区域, 时间槽）起点，按排放由低到高排序。
+func batchJobOptions(job BatchJob, zoneIndex map[string]int, evaluators map[string]EmissionEvaluator, options BatchOptions, slots int) []batchOption {
+	deadline := options.End
+	if !job.Deadline.IsZero() && job.Deadline.Before(deadline) {
+		deadline = job.Deadline
+	}
+	length := time.Duration(job.Duration) * time.Second
+	span := int((length + options.Slot - 1) / options.Slot)
+
+	var out []batchOption
+	seen := make(map[int]bool)
+	for _, zone := range job.Zones {
+		z := zoneIndex[zone]
+		evaluator, ok := evaluators[zone]
+		if !ok || seen[z] {
+			continue
+		}
+		seen[z] = true
+		for k := 0; k < slots; k++ {
+			start := options.Start.Add(time.Duration(k) * options.Slot)
+			if start.Before(job.EarliestStart) {
+				continue
+			}
+			if start.Add(length).After(deadline) {
+				break
+			}
+			emission, ok := evaluator.EstimateAt(start, job.Duration, options.Runner, options.Load, options.PUE)
+			if !ok {
+				continue
+			}
+			out = append(out, batchOption{zone: z, slot: k, span: min(span, slots-k), emission: emission})
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		if out[a].emission != out[b].emission {
+			return out[a].emission < out[b].emission
+		}
+		return out[a].slot < out[b].slot
+	})
+	return out
+}
+
+// jobOrder sorts by priority, then fewest options, earliest deadline, longest duration, and ID.
+// jobOrder 依次按优先级、可选项最少、截止时间最早、时长最长与 ID 排序。
+func (s *batchSolver) jobOrder(end time.Time) []int {
+	order := make([]int, len(s.jobs))
+	for j := range order {
+		order[j] = j
+	}
+	deadline := func(j int) time.Time {
+		if s.jobs[j].Deadline.IsZero() || s.jobs[j].Deadline.After(end) {
+			return end
+		}
+		return s.jobs[j].Deadline
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ja, jb := order[a], order[b]
+		switch {
+		case s.levels[ja] != s.levels[jb]:
+			return s.levels[ja] < s.levels[jb]
+		case len(s.options[ja]) != len(s.options[jb]):
+			return len(s.options[ja]) < len(s.options[jb])
+		case !deadline(ja).Equal(deadline(jb)):
+			return deadline(ja).Before(deadline(jb))
+		case s.jobs[ja].Duration != s.jobs[jb].Duration:
+			return s.jobs[ja].Duration > s.jobs[jb].Duration
+		default:
+			return s.jobs[ja].ID < s.jobs[jb].ID
+		}
+	})
+	return order
+}
+
+func (s *batchSolver) fits(option batchOption) bool {
+	for k := option.slot; k < option.slot+option.span; k++ {
+		if s.used[option.zone][k] >= s.capacity[option.zone][k] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *batchSolver) occupy(option batchOption, delta int) {
+	for k := option.slot; k < option.slot+option.span; k++ {
+		s.used[option.zone][k] += delta
+	}
+}
+
+// firstFit returns the index of the job's cheapest option that fits, or -1.
+// firstFit 返回作业可放入的最便宜选项下标，无则返回 -1。
+func (s *batchSolver) firstFit(j int) int {
+	for i, option := range s.options[j] {
+		if s.fits(option) {
+			return i
+		}
+	}
+	return -1
+}
+
+// greedy returns the chosen option index per job (-1 when unscheduled); s.used is left empty.
+// greedy 返回每个作业选中的选项下标（未排入为 -1）；返回时 s.used 已清空。
+func (s *batchSolver) greedy() []int {
+	placed := make([]int, len(s.jobs))
+	for _, j := range s.order {
+		placed[j] = s.firstFit(j)
+		if placed[j] >= 0 {
+			s.occupy(s.options[j][placed[j]], 1)
+		}
+	}
+
+	// Move single jobs to cheaper free options and retry left-out jobs until nothing changes.
+	// 逐个将作业移到更便宜的空闲选项并重试未排入作业，直到没有变化。
+	for pass := 0; pass < len(s.jobs)+1; pass++ {
+		changed := false
+		for _, j := range s.order {
+			if placed[j] < 0 {
+				if i := s.firstFit(j); i >= 0 {
+					placed[j] = i
+					s.occupy(s.options[j][i], 1)
+					changed = true
+				}
+				continue
+			}
+			current := s.options[j][placed[j]]
+			s.occupy(current, -1)
+			i := s.firstFit(j)
+			if s.options[j][i].emission < current.emission {
+				placed[j] = i
+				changed = true
+			}
+			s.occupy(s.options[j][placed[j]], 1)
+		}
+		if !changed {
+			break
+		}
+	}
+
+	for j, i := range placed {
+		if i >= 0 {
+			s.occupy(s.options[j][i], -1)
+		}
+	}
+	return placed
+}
+
+type batchScore struct {
+	unscheduled []int
+	emission    float64
+}
+
+// compareUnscheduled orders left-out counts from the highest priority level down.
+// compareUnscheduled 从最高优先级开始比较未排入数量。
+func compareUnscheduled(a, b []int) int {
+	for level := range a {
+		if a[level] != b[level] {
+			if a[level] < b[level] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func (s *batchSolver) score(placed []int) batchScore {
+	score := batchScore{unscheduled: make([]int, s.levelCount)}
+	for j, i := range placed {
+		if i < 0 {
+			score.unscheduled[s.levels[j]]++
+			continue
+		}
+		score.emission += s.options[j][i].emission
+	}
+	return score
+}
+
+// branchAndBound searches all assignments, starting from the incumbent plan.
+// branchAndBound 以现有计划为初值搜索全部分配方案。
+func (s *batchSolver) branchAndBound(incumbent []int) ([]int, error) {
+	best := append([]int(nil), incumbent...)
+	bestScore := s.score(best)
+
+	// Jobs without options are always left out; the rest are branched in solver order.
+	// 无可选项的作业必然未排入；其余作业按求解顺序分支。
+	current := make([]int, len(s.jobs))
+	unscheduled := make([]int, s.levelCount)
+	var order []int
+	for _, j := range s.order {
+		current[j] = -1
+		if len(s.options[j]) == 0 {
+			unscheduled[s.levels[j]]++
+			continue
+		}
+		order = append(order, j)
+	}
+	suffixMin := make([]float64, len(order)+1)
+	for pos := len(order) - 1; pos >= 0; pos-- {
+		suffixMin[pos] = suffixMin[pos+1] + s.options[order[pos]][0].emission
+	}
+
+	nodes := 0
+	var search func(pos int, emission float64) error
+	search = func(pos int, emission float64) error {
+		nodes++
+		if nodes > maxExactBatchNodes {
+			return fmt.Errorf("%w: more than %d search nodes", ErrBatchTooLarge, maxExactBatchNodes)
+		}
+		cmp := compareUnscheduled(unscheduled, bestScore.unscheduled)
+		if cmp > 0 || (cmp == 0 && emission+suffixMin[pos] >= bestScore.emission-1e-12) {
+			return nil
+		}
+		if pos == len(order) {
+			copy(best, current)
+			bestScore = batchScore{unscheduled: append([]int(nil), unscheduled...), emission: emission}
+			return nil
+		}
+
+		j := order[pos]
+		for i, option := range s.options[j] {
+			if !s.fits(option) {
+				continue
+			}
+			s.occupy(option, 1)
+			current[j] = i
+			err := search(pos+1, emission+option.emission)
+			current[j] = -1
+			s.occupy(option, -1)
+			if err != nil {
+				return err
+			}
+		}
+		unscheduled[s.levels[j]]++
+		err := search(pos+1, emission)
+		unscheduled[s.levels[j]]--
+		return err
+	}
+	if err := search(0, 0); err != nil {
+		return nil, err
+	}
+	return best, nil
+}
+
+func (s *batchSolver) plan(placed []int, zones []string, options BatchOptions, optimal bool) BatchPlan {
+	plan := BatchPlan{Optimal: optimal}
+	for j, i := range placed {
+		job := s.jobs[j]
+		if i < 0 {
+			reason := UnscheduledCapacity
+			if len(s.options[j]) == 0 {
+				reason = UnscheduledNoWindow
+			}
+			plan.Unscheduled = append(plan.Unscheduled, BatchUnscheduled{JobID: job.ID, Reason: reason})
+			continue
+		}
+		option := s.options[j][i]
+		start := options.Start.Add(time.Duration(option.slot) * options.Slot).UTC()
+		plan.Assignments = append(plan.Assignments, BatchAssignment{
+			JobID:    job.ID,
+			Zone:     zones[option.zone],
+			Start:    start,
+			End:      start.Add(time.Duration(job.Duration) * time.Second),
+			Emission: option.emission,
+		})
+		plan.Emission += option.emission
+	}
+	sort.SliceStable(plan.Assignments, func(a, b int) bool {
+		x, y := plan.Assignments[a], plan.Assignments[b]
+		switch {
+		case !x.Start.Equal(y.Start):
+			return x.Start.Before(y.Start)
+		case x.Zone != y.Zone:
+			return x.Zone < y.Zone
+		default:
+			return x.JobID < y.JobID
+		}
+	})
+	return plan
+}
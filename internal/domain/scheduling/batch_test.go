@@ -0,0 +1,100 @@
+package scheduling
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func batchTestEvaluators(t *testing.T, base time.Time, series map[string][]float64) map[string]EmissionEvaluator {
+	t.Helper()
+	evaluators := make(map[string]EmissionEvaluator, len(series))
+	for zone, values := range series {
+		points := make([]ForecastPoint, len(values))
+		for i, ci := range values {
+			points[i] = ForecastPoint{Timestamp: base.Add(time.Duration(i) * time.Hour), CI: ci}
+		}
+		evaluator, ok := BuildEmissionEvaluator(points, base.Add(time.Duration(len(values))*time.Hour))
+		if !ok {
+			t.Fatalf("BuildEmissionEvaluator(%s) expected success", zone)
+		}
+		evaluators[zone] = evaluator
+	}
+	return evaluators
+}
+
+func TestPlanBatchExactBeatsHeuristic(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	evaluators := batchTestEvaluators(t, base, map[string][]float64{
+		"A": {0.1, 0.5},
+		"B": {0.12, 0.5},
+	})
+	jobs := []BatchJob{
+		{ID: "flexible", Duration: 3600, Zones: []string{"A", "B"}, Priority: 1},
+		{ID: "pinned", Duration: 3600, Zones: []string{"A"}},
+	}
+	options := BatchOptions{Start: base, End: base.Add(2 * time.Hour), Slot: time.Hour, Runner: "ubuntu", Load: 0.6, PUE: 1.2}
+
+	heuristic, err := PlanBatch(jobs, evaluators, nil, options)
+	if err != nil {
+		t.Fatalf("PlanBatch() unexpected error: %v", err)
+	}
+	if len(heuristic.Assignments) != 2 || heuristic.Optimal {
+		t.Fatalf("heuristic plan = %+v, expected both jobs placed", heuristic)
+	}
+
+	options.Exact = true
+	exact, err := PlanBatch(jobs, evaluators, nil, options)
+	if err != nil {
+		t.Fatalf("PlanBatch(exact) unexpected error: %v", err)
+	}
+	if !exact.Optimal || !(exact.Emission < heuristic.Emission) {
+		t.Fatalf("exact emission %f, expected optimal and below heuristic %f", exact.Emission, heuristic.Emission)
+	}
+	for _, assignment := range exact.Assignments {
+		if !assignment.Start.Equal(base) {
+			t.Fatalf("exact plan = %+v, expected both jobs in the first hour", exact.Assignments)
+		}
+	}
+}
+
+func TestPlanBatchRespectsCapacityAndPriority(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	evaluators := batchTestEvaluators(t, base, map[string][]float64{
+		"DE": {0.2, 0.1, 0.3},
+	})
+	capacity := map[string]ZoneCapacity{
+		"DE": {Default: 1, Windows: []CapacityWindow{{Start: base.Add(2 * time.Hour), End: base.Add(3 * time.Hour), Capacity: 0}}},
+	}
+	deadline := base.Add(2 * time.Hour)
+	jobs := []BatchJob{
+		{ID: "low", Duration: 3600, Zones: []string{"DE"}, Deadline: deadline},
+		{ID: "high", Duration: 5400, Zones: []string{"DE"}, Deadline: deadline, Priority: 5},
+		{ID: "late", Duration: 3600, Zones: []string{"DE"}, EarliestStart: base.Add(150 * time.Minute)},
+	}
+	options := BatchOptions{Start: base, End: base.Add(3 * time.Hour), Slot: 30 * time.Minute, Runner: "ubuntu", Load: 0.6, PUE: 1.2}
+
+	for _, exact := range []bool{false, true} {
+		options.Exact = exact
+		plan, err := PlanBatch(jobs, evaluators, capacity, options)
+		if err != nil {
+			t.Fatalf("PlanBatch(exact=%t) unexpected error: %v", exact, err)
+		}
+		if len(plan.Assignments) != 1 || plan.Assignments[0].JobID != "high" {
+			t.Fatalf("PlanBatch(exact=%t) assignments = %+v, expected only the high-priority job", exact, plan.Assignments)
+		}
+		want := []BatchUnscheduled{{JobID: "low", Reason: UnscheduledCapacity}, {JobID: "late", Reason: UnscheduledNoWindow}}
+		if len(plan.Unscheduled) != 2 || plan.Unscheduled[0] != want[0] || plan.Unscheduled[1] != want[1] {
+			t.Fatalf("PlanBatch(exact=%t) unscheduled = %+v, expected %+v", exact, plan.Unscheduled, want)
+		}
+	}
+
+	many := make([]BatchJob, MaxExactBatchJobs+1)
+	for i := range many {
+		many[i] = BatchJob{ID: string(rune('a' + i)), Duration: 1800, Zones: []string{"DE"}}
+	}
+	options.Exact = true
+	if _, err := PlanBatch(many, evaluators, capacity, options); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("expected ErrBatchTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package scheduling
+
+import (
+	"math"
+	"time"
+)
+
+// HorizonUncertainty models the relative 1-sigma CI forecast error as Base plus PerHour for
+// every hour of lead time, capped at 1.
+// HorizonUncertainty 将 CI 预测的相对 1-sigma 误差建模为 Base 加上每小时提前量的 PerHour，
+// 上限为 1。
+type HorizonUncertainty struct {
+	Base    float64
+	PerHour float64
+}
+
+// IsZero reports whether the model predicts no error at any lead time.
+// IsZero 判断该模型在任意提前量下是否均无误差。
+func (u HorizonUncertainty) IsZero() bool {
+	return u.Base <= 0 && u.PerHour <= 0
+}
+
+// RelativeError returns the relative CI error at lead; negative leads count as zero.
+// RelativeError 返回提前量 lead 下的相对 CI 误差；负提前量按 0 处理。
+func (u HorizonUncertainty) RelativeError(lead time.Duration) float64 {
+	hours := math.Max(lead.Hours(), 0)
+	return math.Min(math.Max(u.Base+u.PerHour*hours, 0), 1)
+}
+
+// ErrorBand replaces each point's CI with its absolute 1-sigma error at the point's lead from anchor.
+// ErrorBand 将每个点的 CI 替换为其相对 anchor 提前量下的绝对 1-sigma 误差。
+//
+// Emission is linear in CI, so a window's emission over the band is its risk: the 1-sigma
+// emission error when forecast errors within the window are fully correlated. The band keeps the
+// forecast breakpoints, so the risk is piecewise linear in the start like the emission.
+// 排放关于 CI 线性，因此窗口在误差带上的排放即其风险：窗口内预测误差完全相关时的 1-sigma
+// 排放误差。误差带保留 forecast 分段边界，因此风险与排放一样关于起点分段线性。
+func (u HorizonUncertainty) ErrorBand(points []ForecastPoint, anchor time.Time) []ForecastPoint {
+	band := make([]ForecastPoint, len(points))
+	for i, point := range points {
+		band[i] = ForecastPoint{
+			Timestamp: point.Timestamp,
+			CI:        point.CI * u.RelativeError(point.Timestamp.Sub(anchor)),
+		}
+	}
+	return band
+}
@@ -0,0 +1,31 @@
+package scheduling
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHorizonUncertaintyErrorBandGrowsWithLead(t *testing.T) {
+	anchor := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	model := HorizonUncertainty{Base: 0.05, PerHour: 0.01}
+	points := []ForecastPoint{
+		{Timestamp: anchor, CI: 0.4},
+		{Timestamp: anchor.Add(10 * time.Hour), CI: 0.4},
+		{Timestamp: anchor.Add(200 * time.Hour), CI: 0.4},
+	}
+
+	band := model.ErrorBand(points, anchor)
+	want := []float64{0.4 * 0.05, 0.4 * 0.15, 0.4}
+	for i, point := range band {
+		if !point.Timestamp.Equal(points[i].Timestamp) || math.Abs(point.CI-want[i]) > 1e-12 {
+			t.Fatalf("ErrorBand()[%d] = %+v, expected CI %f", i, point, want[i])
+		}
+	}
+	if got := model.RelativeError(-time.Hour); got != 0.05 {
+		t.Fatalf("RelativeError(-1h) = %f, expected the base error", got)
+	}
+	if !(HorizonUncertainty{}).IsZero() || model.IsZero() {
+		t.Fatalf("unexpected IsZero results")
+	}
+}
@@ -0,0 +1,234 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultSplitStep is the start grid of split plans when SplitOptions.Step is unset.
+// DefaultSplitStep 为 SplitOptions.Step 未设置时分段计划的起点网格。
+const DefaultSplitStep = 15 * time.Minute
+
+// maxSplitSearchStates bounds the dynamic program so a long lookahead with a fine step fails fast.
+// maxSplitSearchStates 限制动态规划规模，使长 lookahead 搭配细网格时快速失败。
+const maxSplitSearchStates = 20_000_000
+
+// ErrSplitSearchTooLarge reports a split search above maxSplitSearchStates.
+// ErrSplitSearchTooLarge 表示分段搜索规模超过 maxSplitSearchStates。
+var ErrSplitSearchTooLarge = errors.New("split search space too large")
+
+// SplitOptions constrains a checkpointable job that may run in several chunks.
+// SplitOptions 约束可断点续跑、允许拆分为多个片段执行的作业。
+type SplitOptions struct {
+	// MinChunk is the minimum useful work per chunk in seconds; <=0 means one Step.
+	// MinChunk 为每个片段的最少有效工作秒数；<=0 表示一个 Step。
+	MinChunk int
+	// MaxChunks caps the number of chunks; <=0 means one chunk per Step of work.
+	// MaxChunks 限制片段数量；<=0 表示按每个 Step 的工作量计上限。
+	MaxChunks int
+	// ResumeOverhead is the extra runtime in seconds before every chunk after the first
+	// (restoring a checkpoint); it emits like work but does not count towards the duration.
+	// ResumeOverhead 为第一个片段之后每个片段开始前的额外运行秒数（恢复检查点），
+	// 按工作负载计排放，但不计入作业时长。
+	ResumeOverhead int
+	// Step is the grid chunk starts and work lengths snap to; <=0 uses DefaultSplitStep.
+	// Step 为片段起点与工作时长对齐的网格；<=0 时使用 DefaultSplitStep。
+	Step time.Duration
+}
+
+// ChunkEstimate is one chunk of a split plan; [Start, End) includes the resume overhead.
+// ChunkEstimate 为分段计划中的一个片段；[Start, End) 包含恢复开销。
+type ChunkEstimate struct {
+	Start           time.Time
+	End             time.Time
+	WorkSeconds     int
+	OverheadSeconds int
+	Emission        float64
+}
+
+// SplitPlan is the chunk plan with the lowest total emission.
+// SplitPlan 为总排放最低的片段计划。
+type SplitPlan struct {
+	Chunks   []ChunkEstimate
+	Emission float64
+}
+
+type splitParent struct {
+	pos    int
+	chunks int
+	cells  int
+	// length is the chunk's work in cells; 0 means the cell at pos was skipped.
+	// length 为片段工作量（以网格计）；0 表示跳过 pos 处的网格。
+	length int
+}
+
+// FindBestSplitPlan picks the lowest-carbon set of chunks totalling duration seconds of work.
+// FindBestSplitPlan 选取总工作时长为 duration 秒、碳排放最低的片段组合。
+//
+// Chunks start on a Step grid anchored at the first covered second and do work in whole steps,
+// except the first chunk, which absorbs the remainder when duration is not a multiple of Step.
+// The search is an exact dynamic program over (grid position, chunks used, work cells done); CI
+// is piecewise constant and emission is linear in CI, so each chunk is costed with the
+// evaluator's integral. The boolean is false when no plan fits the coverage or the constraints,
+// and the error wraps ErrSplitSearchTooLarge.
+// 片段起点位于以首个可覆盖秒为锚点的 Step 网格上，工作时长为整数个 Step；duration 不是 Step
+// 整数倍时余数由第一个片段承担。搜索为基于（网格位置、已用片段数、已完成工作格数）的精确动态
+// 规划；CI 为分段常数且排放关于 CI 线性，因此每个片段通过评估器积分计算成本。无可行计划时
+// 布尔值为 false；搜索空间过大时返回包装 ErrSplitSearchTooLarge 的错误。
+func FindBestSplitPlan(
+	evaluator EmissionEvaluator,
+	duration int,
+	runner string,
+	load float64,
+	pue float64,
+	options SplitOptions,
+) (SplitPlan, bool, error) {
+	if duration <= 0 || len(evaluator.starts) == 0 {
+		return SplitPlan{}, false, nil
+	}
+	step := int64(options.Step / time.Second)
+	if step <= 0 {
+		step = int64(DefaultSplitStep / time.Second)
+	}
+	minChunk := int64(options.MinChunk)
+	if minChunk <= 0 {
+		minChunk = step
+	}
+	overhead := int64(options.ResumeOverhead)
+	if overhead < 0 {
+		overhead = 0
+	}
+
+	lo := evaluator.starts[0]
+	positions := int((evaluator.coverageEnd - lo) / step)
+	cells := int((int64(duration) + step - 1) / step)
+	trim := int64(cells)*step - int64(duration)
+	minCells := int((minChunk + step - 1) / step)
+	maxChunks := options.MaxChunks
+	if maxChunks <= 0 || maxChunks > cells {
+		maxChunks = cells
+	}
+	if positions <= 0 {
+		return SplitPlan{}, false, nil
+	}
+
+	states := (positions + 1) * (maxChunks + 1) * (cells + 1)
+	if int64(states)*int64(cells) > maxSplitSearchStates {
+		return SplitPlan{}, false, fmt.Errorf("%w: %d grid positions x %d chunks x %d work steps; use a coarser step, fewer chunks, or a shorter lookahead", ErrSplitSearchTooLarge, positions+1, maxChunks, cells)
+	}
+
+	index := func(pos, chunks, done int) int {
+		return (pos*(maxChunks+1)+chunks)*(cells+1) + done
+	}
+	cost := make([]float64, states)
+	parent := make([]splitParent, states)
+	for i := range cost {
+		cost[i] = math.Inf(1)
+	}
+	cost[index(0, 0, 0)] = 0
+
+	bestIdx := -1
+	for pos := 0; pos <= positions; pos++ {
+		for chunks := 0; chunks <= maxChunks; chunks++ {
+			for done := 0; done <= cells; done++ {
+				current := cost[index(pos, chunks, done)]
+				if math.IsInf(current, 1) {
+					continue
+				}
+				if done == cells {
+					if bestIdx < 0 || current < cost[bestIdx] {
+						bestIdx = index(pos, chunks, done)
+					}
+					continue
+				}
+				if pos < positions {
+					relaxSplit(cost, parent, index(pos+1, chunks, done), current, splitParent{pos: pos, chunks: chunks, cells: done})
+				}
+				if chunks == maxChunks {
+					continue
+				}
+
+				start := lo + int64(pos)*step
+				chunkOverhead := overhead
+				if chunks == 0 {
+					chunkOverhead = 0
+				}
+				for length := minCells; length <= cells-done; length++ {
+					work := int64(length) * step
+					if chunks == 0 {
+						work -= trim
+					}
+					if work < minChunk {
+						continue
+					}
+					end := start + chunkOverhead + work
+					if end > evaluator.coverageEnd {
+						break
+					}
+					next := int((end - lo + step - 1) / step)
+					if next > positions {
+						next = positions
+					}
+					chunkCost := evaluator.integralAt(end) - evaluator.integralAt(start)
+					relaxSplit(cost, parent, index(next, chunks+1, done+length), current+chunkCost, splitParent{pos: pos, chunks: chunks, cells: done, length: length})
+				}
+			}
+		}
+	}
+	if bestIdx < 0 {
+		return SplitPlan{}, false, nil
+	}
+
+	var chunks []ChunkEstimate
+	for idx := bestIdx; idx != index(0, 0, 0); {
+		from := parent[idx]
+		if from.length > 0 {
+			start := lo + int64(from.pos)*step
+			chunkOverhead := overhead
+			work := int64(from.length) * step
+			if from.chunks == 0 {
+				chunkOverhead = 0
+				work -= trim
+			}
+			emission, _ := evaluator.EstimateAtOffset(start, int(chunkOverhead+work), runner, load, pue)
+			chunks = append(chunks, ChunkEstimate{
+				Start:           evaluator.base.Add(time.Duration(start) * time.Second).UTC(),
+				End:             evaluator.base.Add(time.Duration(start+chunkOverhead+work) * time.Second).UTC(),
+				WorkSeconds:     int(work),
+				OverheadSeconds: int(chunkOverhead),
+				Emission:        emission,
+			})
+		}
+		idx = index(from.pos, from.chunks, from.cells)
+	}
+
+	plan := SplitPlan{Chunks: make([]ChunkEstimate, 0, len(chunks))}
+	for i := len(chunks) - 1; i >= 0; i-- {
+		plan.Chunks = append(plan.Chunks, chunks[i])
+		plan.Emission += chunks[i].Emission
+	}
+	return plan, true, nil
+}
+
+// SplitPlanFromWindow wraps a contiguous window as a one-chunk plan.
+// SplitPlanFromWindow 将连续窗口包装为单片段计划。
+func SplitPlanFromWindow(window WindowEstimate) SplitPlan {
+	return SplitPlan{
+		Chunks: []ChunkEstimate{{
+			Start:       window.Start,
+			End:         window.End,
+			WorkSeconds: int(window.End.Sub(window.Start) / time.Second),
+			Emission:    window.Emission,
+		}},
+		Emission: window.Emission,
+	}
+}
+
+func relaxSplit(cost []float64, parent []splitParent, idx int, value float64, from splitParent) {
+	if value < cost[idx] {
+		cost[idx] = value
+		parent[idx] = from
+	}
+}
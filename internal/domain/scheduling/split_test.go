@@ -0,0 +1,91 @@
+package scheduling
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func splitTestEvaluator(t *testing.T) EmissionEvaluator {
+	t.Helper()
+	points := []ForecastPoint{
+		{Timestamp: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC), CI: 0.1},
+		{Timestamp: time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC), CI: 0.9},
+		{Timestamp: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), CI: 0.9},
+		{Timestamp: time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC), CI: 0.1},
+	}
+	evaluator, ok := BuildEmissionEvaluator(points, time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC))
+	if !ok {
+		t.Fatalf("BuildEmissionEvaluator() expected success")
+	}
+	return evaluator
+}
+
+func TestFindBestSplitPlanUsesBothCleanSlices(t *testing.T) {
+	evaluator := splitTestEvaluator(t)
+
+	plan, ok, err := FindBestSplitPlan(evaluator, 7200, "ubuntu", 0.6, 1.2, SplitOptions{MaxChunks: 2, Step: 30 * time.Minute})
+	if err != nil || !ok {
+		t.Fatalf("FindBestSplitPlan() = %v, %v; expected a plan", ok, err)
+	}
+	if len(plan.Chunks) != 2 {
+		t.Fatalf("chunks = %+v, expected 2", plan.Chunks)
+	}
+	if !plan.Chunks[0].Start.Equal(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)) ||
+		!plan.Chunks[1].Start.Equal(time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Fatalf("chunks = %+v, expected 10:00 and 13:00", plan.Chunks)
+	}
+
+	_, contiguous, _ := FindBestWindow(nil, evaluator, 7200, "ubuntu", 0.6, 1.2, WindowSearchOptions{})
+	if !(plan.Emission < contiguous.Emission) {
+		t.Fatalf("split emission %f, expected < contiguous %f", plan.Emission, contiguous.Emission)
+	}
+	sum := plan.Chunks[0].Emission + plan.Chunks[1].Emission
+	if math.Abs(sum-plan.Emission) > 1e-12 {
+		t.Fatalf("plan emission %f, expected sum of chunks %f", plan.Emission, sum)
+	}
+}
+
+func TestFindBestSplitPlanHonoursOverheadAndLimits(t *testing.T) {
+	evaluator := splitTestEvaluator(t)
+
+	plan, ok, err := FindBestSplitPlan(evaluator, 5400, "ubuntu", 0.6, 1.2, SplitOptions{
+		MinChunk:       1800,
+		MaxChunks:      2,
+		ResumeOverhead: 600,
+		Step:           15 * time.Minute,
+	})
+	if err != nil || !ok {
+		t.Fatalf("FindBestSplitPlan() = %v, %v; expected a plan", ok, err)
+	}
+	work := 0
+	for i, chunk := range plan.Chunks {
+		work += chunk.WorkSeconds
+		if chunk.WorkSeconds < 1800 {
+			t.Fatalf("chunk %d = %+v, expected >= min chunk", i, chunk)
+		}
+		wantOverhead := 600
+		if i == 0 {
+			wantOverhead = 0
+		}
+		if chunk.OverheadSeconds != wantOverhead || int(chunk.End.Sub(chunk.Start).Seconds()) != chunk.WorkSeconds+wantOverhead {
+			t.Fatalf("chunk %d = %+v, expected overhead %ds inside [Start, End)", i, chunk, wantOverhead)
+		}
+		if i > 0 && chunk.Start.Before(plan.Chunks[i-1].End) {
+			t.Fatalf("chunks overlap: %+v", plan.Chunks)
+		}
+	}
+	if work != 5400 || len(plan.Chunks) > 2 {
+		t.Fatalf("chunks = %+v, expected 5400s work in at most 2 chunks", plan.Chunks)
+	}
+
+	if _, ok, _ := FindBestSplitPlan(evaluator, 5400, "ubuntu", 0.6, 1.2, SplitOptions{MinChunk: 4 * 3600, Step: 15 * time.Minute}); ok {
+		t.Fatalf("expected no plan when min chunk exceeds the duration")
+	}
+
+	_, _, err = FindBestSplitPlan(evaluator, 4*3600, "ubuntu", 0.6, 1.2, SplitOptions{Step: time.Second})
+	if !errors.Is(err, ErrSplitSearchTooLarge) {
+		t.Fatalf("expected ErrSplitSearchTooLarge, got %v", err)
+	}
+}
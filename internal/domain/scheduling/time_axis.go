@@ -16,8 +16,20 @@ const (
 	// FillModeStrict requires exact timestamp matches (no forward fill).
 	// FillModeStrict 表示必须精确时间戳匹配（不进行前值填充）。
 	FillModeStrict FillMode = "strict"
+	// FillModeLinear interpolates linearly between the surrounding source points.
+	// FillModeLinear 表示在前后相邻源数据点之间线性插值。
+	FillModeLinear FillMode = "linear"
+	// FillModeNearest takes the closer surrounding source point; ties take the earlier one.
+	// FillModeNearest 表示取前后相邻源数据点中较近者；距离相等时取较早者。
+	FillModeNearest FillMode = "nearest"
 )
 
+// DefaultResampleMaxGap is the widest source gap linear and nearest modes bridge by default,
+// so an hourly series with one missing point is still filled.
+// DefaultResampleMaxGap 为 linear 与 nearest 模式默认可跨越的最大源数据间隔，
+// 使缺失一个点的小时序列仍可被填充。
+const DefaultResampleMaxGap = 2 * time.Hour
+
 // ResampleOptions defines alignment behavior for cross-zone resampling.
 // ResampleOptions 定义跨区域重采样时的对齐策略。
 type ResampleOptions struct {
@@ -27,10 +39,16 @@ type ResampleOptions struct {
 	// MaxFillAge is only used by forward mode; <=0 falls back to default (2*step).
 	// MaxFillAge 仅在 forward 模式生效；<=0 时回退到默认值（2*step）。
 	MaxFillAge time.Duration
+	// MaxGap is only used by linear and nearest modes: consecutive source points further apart
+	// are not bridged; <=0 falls back to DefaultResampleMaxGap.
+	// MaxGap 仅在 linear 与 nearest 模式生效：间隔更大的相邻源数据点之间不填充；
+	// <=0 时回退到 DefaultResampleMaxGap。
+	MaxGap time.Duration
 }
 
 // NormalizeForecastUTC converts timestamps to UTC and sorts points by timestamp ascending.
-// NormalizeForecastUTC 将时间戳统一到 UTC，并按升序排序。
+// The sort is stable, so duplicate timestamps keep their provider order.
+// NormalizeForecastUTC 将时间戳统一到 UTC，并按升序排序；排序稳定，重复时间戳保持 provider 顺序。
 func NormalizeForecastUTC(points []ForecastPoint) []ForecastPoint {
 	out := make([]ForecastPoint, len(points))
 	for i, point := range points {
@@ -40,7 +58,7 @@ func NormalizeForecastUTC(points []ForecastPoint) []ForecastPoint {
 		}
 	}
 
-	sort.Slice(out, func(i, j int) bool {
+	sort.SliceStable(out, func(i, j int) bool {
 		return out[i].Timestamp.Before(out[j].Timestamp)
 	})
 
@@ -135,9 +153,12 @@ func BuildResampledIntersection(
 //
 // The function first computes the overlap range, builds a regular axis by step,
 // then samples each zone under the chosen fill policy, and finally keeps only
-// timestamps present in every zone after sampling.
+// timestamps present in every zone after sampling. Each zone is sampled from its own series
+// only, with duplicate timestamps resolved to the first point, so the result does not depend
+// on the order zones were fetched in.
 // 该函数先计算重叠时间范围，再按 step 构建规则时间轴，
 // 然后按填充策略对每个区域采样，最后保留采样后所有区域都存在的时间戳。
+// 每个区域仅依据自身序列采样，重复时间戳取首个点，因此结果与区域拉取顺序无关。
 func BuildResampledIntersectionWithOptions(
 	zones []string,
 	zoneForecasts map[string][]ForecastPoint,
@@ -177,10 +198,10 @@ func BuildResampledIntersectionWithOptions(
 		return nil, nil
 	}
 
-	strict, maxFillAge := normalizeResampleOptions(step, options)
+	policy := normalizeResampleOptions(step, options)
 	zoneSamples := make(map[string]map[int64]float64, len(zones))
 	for _, zone := range zones {
-		zoneSamples[zone] = resampleZoneOnAxis(zoneForecasts[zone], axis, maxFillAge, strict)
+		zoneSamples[zone] = resampleZoneOnAxis(zoneForecasts[zone], axis, policy)
 	}
 
 	counts := make(map[int64]int)
@@ -220,24 +241,32 @@ func BuildResampledIntersectionWithOptions(
 	return common, aligned
 }
 
+// resamplePolicy is the executable form of ResampleOptions.
+// resamplePolicy 为 ResampleOptions 的可执行形式。
+type resamplePolicy struct {
+	mode       FillMode
+	maxFillAge time.Duration
+	maxGap     time.Duration
+}
+
 // normalizeResampleOptions resolves user options into an executable policy.
 // normalizeResampleOptions 将输入选项归一化为可执行策略。
-func normalizeResampleOptions(step time.Duration, options ResampleOptions) (strict bool, maxFillAge time.Duration) {
+func normalizeResampleOptions(step time.Duration, options ResampleOptions) resamplePolicy {
 	switch options.FillMode {
-	case "", FillModeForward:
-		maxFillAge = options.MaxFillAge
-		if maxFillAge <= 0 {
-			maxFillAge = 2 * step
-		}
-		return false, maxFillAge
 	case FillModeStrict:
-		return true, 0
+		return resamplePolicy{mode: FillModeStrict}
+	case FillModeLinear, FillModeNearest:
+		maxGap := options.MaxGap
+		if maxGap <= 0 {
+			maxGap = DefaultResampleMaxGap
+		}
+		return resamplePolicy{mode: options.FillMode, maxGap: maxGap}
 	default:
-		maxFillAge = options.MaxFillAge
+		maxFillAge := options.MaxFillAge
 		if maxFillAge <= 0 {
 			maxFillAge = 2 * step
 		}
-		return false, maxFillAge
+		return resamplePolicy{mode: FillModeForward, maxFillAge: maxFillAge}
 	}
 }
 
@@ -259,39 +288,81 @@ func buildUTCGrid(start time.Time, end time.Time, step time.Duration) []time.Tim
 // resampleZoneOnAxis samples one zone on the target axis.
 // resampleZoneOnAxis 将单个区域序列采样到目标时间轴。
 //
-// strict=true requires exact timestamp equality.
-// strict=false allows bounded forward-fill controlled by maxFillAge.
-// strict=true 表示必须精确匹配时间戳。
-// strict=false 表示允许在 maxFillAge 内进行前值填充。
-func resampleZoneOnAxis(points []ForecastPoint, axis []time.Time, maxFillAge time.Duration, strict bool) map[int64]float64 {
+// Strict requires exact timestamp equality, and forward fill is bounded by maxFillAge.
+// Linear and nearest need source points on both sides at most maxGap apart; an exact match
+// always uses its own value.
+// strict 表示必须精确匹配时间戳，forward 表示在 maxFillAge 内进行前值填充。
+// linear 与 nearest 需要前后两侧均有源数据点且间隔不超过 maxGap；精确匹配时直接使用该点的值。
+func resampleZoneOnAxis(points []ForecastPoint, axis []time.Time, policy resamplePolicy) map[int64]float64 {
 	out := make(map[int64]float64, len(axis))
+	points = dedupeForecastTimestamps(points)
 	if len(points) == 0 {
 		return out
 	}
 
 	idx := 0
 	for _, t := range axis {
-		for idx+1 < len(points) && !points[idx+1].Timestamp.UTC().After(t.UTC()) {
+		t = t.UTC()
+		for idx+1 < len(points) && !points[idx+1].Timestamp.UTC().After(t) {
 			idx++
 		}
 
-		source := points[idx].Timestamp.UTC()
-		if source.After(t.UTC()) {
+		prev := points[idx]
+		source := prev.Timestamp.UTC()
+		if source.After(t) {
 			continue
 		}
-		if strict && !source.Equal(t.UTC()) {
-			continue
-		}
-		if maxFillAge > 0 && t.UTC().Sub(source) > maxFillAge {
-			continue
+		age := t.Sub(source)
+
+		ci := prev.CI
+		switch policy.mode {
+		case FillModeStrict:
+			if age != 0 {
+				continue
+			}
+		case FillModeLinear, FillModeNearest:
+			if age == 0 {
+				break
+			}
+			if idx+1 >= len(points) {
+				continue
+			}
+			next := points[idx+1]
+			gap := next.Timestamp.UTC().Sub(source)
+			if gap > policy.maxGap {
+				continue
+			}
+			if policy.mode == FillModeLinear {
+				ci = prev.CI + (next.CI-prev.CI)*float64(age)/float64(gap)
+			} else if next.Timestamp.UTC().Sub(t) < age {
+				ci = next.CI
+			}
+		default:
+			if policy.maxFillAge > 0 && age > policy.maxFillAge {
+				continue
+			}
 		}
 
-		out[t.UTC().Unix()] = points[idx].CI
+		out[t.Unix()] = ci
 	}
 
 	return out
 }
 
+// dedupeForecastTimestamps keeps the first point of each timestamp in a sorted series,
+// matching BuildForecastIndex.
+// dedupeForecastTimestamps 在已排序序列中保留每个时间戳的首个点，与 BuildForecastIndex 一致。
+func dedupeForecastTimestamps(points []ForecastPoint) []ForecastPoint {
+	out := make([]ForecastPoint, 0, len(points))
+	for _, point := range points {
+		if len(out) > 0 && point.Timestamp.Equal(out[len(out)-1].Timestamp) {
+			continue
+		}
+		out = append(out, point)
+	}
+	return out
+}
+
 // floorToStep rounds time down to the nearest step boundary in UTC.
 // floorToStep 将 UTC 时间向下对齐到最近 step 边界。
 func floorToStep(t time.Time, step time.Duration) time.Time {
@@ -1,6 +1,7 @@
 package scheduling
 
 import (
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -170,3 +171,55 @@ func TestInferResampleStepFromForecastData(t *testing.T) {
 		t.Fatalf("InferResampleStep() = %s, expected %s", got, time.Hour)
 	}
 }
+
+func TestBuildResampledIntersectionWithOptionsLinearAndNearest(t *testing.T) {
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	forecasts := map[string][]ForecastPoint{
+		"DE": {
+			{Timestamp: base, CI: 0.4},
+			{Timestamp: base.Add(15 * time.Minute), CI: 0.4},
+			{Timestamp: base.Add(30 * time.Minute), CI: 0.4},
+			{Timestamp: base.Add(45 * time.Minute), CI: 0.4},
+			{Timestamp: base.Add(time.Hour), CI: 0.4},
+		},
+		"FR": {
+			{Timestamp: base, CI: 0.2},
+			{Timestamp: base.Add(time.Hour), CI: 0.6},
+		},
+	}
+	step := InferResampleStep(forecasts)
+
+	axis, _ := BuildResampledIntersectionWithOptions([]string{"DE", "FR"}, forecasts, step, ResampleOptions{})
+	if len(axis) != 4 {
+		t.Fatalf("forward fill axis = %v, expected the 45-minute point dropped", axis)
+	}
+
+	cases := []struct {
+		mode FillMode
+		want []float64
+	}{
+		{FillModeLinear, []float64{0.2, 0.3, 0.4, 0.5, 0.6}},
+		{FillModeNearest, []float64{0.2, 0.2, 0.2, 0.6, 0.6}},
+	}
+	for _, tc := range cases {
+		axis, aligned := BuildResampledIntersectionWithOptions([]string{"DE", "FR"}, forecasts, step, ResampleOptions{FillMode: tc.mode})
+		if len(axis) != len(tc.want) {
+			t.Fatalf("%s axis = %v, expected %d points", tc.mode, axis, len(tc.want))
+		}
+		for i, point := range aligned["FR"] {
+			if math.Abs(point.CI-tc.want[i]) > 1e-9 {
+				t.Fatalf("%s FR = %v, expected %v", tc.mode, aligned["FR"], tc.want)
+			}
+		}
+
+		reversedAxis, reversed := BuildResampledIntersectionWithOptions([]string{"FR", "DE"}, forecasts, step, ResampleOptions{FillMode: tc.mode})
+		if !reflect.DeepEqual(axis, reversedAxis) || !reflect.DeepEqual(aligned, reversed) {
+			t.Fatalf("%s result depends on zone order", tc.mode)
+		}
+	}
+
+	axis, _ = BuildResampledIntersectionWithOptions([]string{"DE", "FR"}, forecasts, step, ResampleOptions{FillMode: FillModeLinear, MaxGap: 30 * time.Minute})
+	if len(axis) != 2 {
+		t.Fatalf("linear axis with 30m max gap = %v, expected only the source timestamps", axis)
+	}
+}
@@ -188,6 +188,20 @@ func (e EmissionEvaluator) EstimateAtOffset(
 	return emission, true
 }
 
+// MeanCIAt returns the time-weighted mean CI over [start, start+duration).
+// MeanCIAt 返回区间 [start, start+duration) 内的时间加权平均 CI。
+func (e EmissionEvaluator) MeanCIAt(start time.Time, duration int) (float64, bool) {
+	if duration <= 0 || len(e.starts) == 0 {
+		return 0, false
+	}
+	startOffset := int64(start.UTC().Sub(e.base).Seconds())
+	endOffset := startOffset + int64(duration)
+	if startOffset < e.starts[0] || endOffset > e.coverageEnd {
+		return 0, false
+	}
+	return (e.integralAt(endOffset) - e.integralAt(startOffset)) / float64(duration), true
+}
+
 // FindBestWindowAtForecastStarts scans candidate starts at forecast timestamps.
 // FindBestWindowAtForecastStarts 在 forecast 时间戳上扫描候选起点。
 //
@@ -258,6 +272,139 @@ func FindBestWindowAtForecastStarts(
 	return current, best, true
 }
 
+// WindowSearchOptions selects the candidate start times of a window search.
+// WindowSearchOptions 选择窗口搜索的候选起点。
+type WindowSearchOptions struct {
+	// ForecastStartsOnly restores the legacy search that only starts at forecast timestamps.
+	// ForecastStartsOnly 恢复仅在 forecast 时间戳处起跑的旧搜索方式。
+	ForecastStartsOnly bool
+	// Granularity snaps continuous starts to a UTC grid (e.g. 5m); <=0 searches at 1s resolution.
+	// Granularity 将连续起点对齐到 UTC 网格（如 5m）；<=0 表示以 1 秒精度搜索。
+	Granularity time.Duration
+}
+
+// SearchStarts returns the candidate starts for options: forecast timestamps in legacy mode,
+// CandidateStarts otherwise.
+// SearchStarts 返回 options 对应的候选起点：旧模式为 forecast 时间戳，否则为 CandidateStarts。
+func (e EmissionEvaluator) SearchStarts(points []ForecastPoint, duration int, options WindowSearchOptions, anchors ...time.Time) []time.Time {
+	if options.ForecastStartsOnly {
+		starts := make([]time.Time, 0, len(points))
+		for _, point := range points {
+			starts = append(starts, point.Timestamp.UTC())
+		}
+		return starts
+	}
+	return e.CandidateStarts(duration, options.Granularity, anchors...)
+}
+
+// CandidateStarts returns the sorted starts at which a window of duration can reach its minimum.
+// CandidateStarts 返回固定时长窗口可能取得最小值的起点（已排序）。
+//
+// CI is piecewise constant, so the window integral is piecewise linear in the start offset, with
+// kinks only where the start or the end crosses a segment boundary. Any objective that adds a
+// linear term (such as a wait penalty kinked at an anchor) is minimized at one of these kinks or
+// at the ends of the feasible range. With a granularity, each kink is replaced by the grid points
+// around it; the earliest feasible start is always kept as the "run now" baseline.
+// CI 为分段常数，因此窗口积分关于起点偏移为分段线性，仅在起点或终点跨越分段边界处转折。
+// 叠加线性项（如在 anchor 处转折的等待惩罚）的目标函数必在这些转折点或可行区间端点取得最小值。
+// 设置 granularity 时以转折点两侧的网格点代替转折点；最早可行起点始终保留，作为“立即执行”基准。
+func (e EmissionEvaluator) CandidateStarts(duration int, granularity time.Duration, anchors ...time.Time) []time.Time {
+	if duration <= 0 || len(e.starts) == 0 {
+		return nil
+	}
+	lo := e.starts[0]
+	hi := e.coverageEnd - int64(duration)
+	if hi < lo {
+		return nil
+	}
+
+	kinks := make([]int64, 0, 4*len(e.starts)+2+len(anchors))
+	kinks = append(kinks, lo, hi)
+	for i := range e.starts {
+		for _, boundary := range []int64{e.starts[i], e.ends[i]} {
+			kinks = append(kinks, boundary, boundary-int64(duration))
+		}
+	}
+	for _, anchor := range anchors {
+		kinks = append(kinks, int64(anchor.UTC().Sub(e.base).Seconds()))
+	}
+
+	step := int64(granularity / time.Second)
+	offsets := make([]int64, 0, 2*len(kinks))
+	offsets = append(offsets, lo)
+	for _, offset := range kinks {
+		if step <= 0 {
+			offsets = append(offsets, offset)
+			continue
+		}
+		// Snap on absolute Unix time so that a 5m grid means :00, :05, ... regardless of base.
+		// 按绝对 Unix 时间对齐，使 5m 网格始终落在 :00、:05……，与 base 无关。
+		abs := e.base.Unix() + offset
+		floor := abs - ((abs%step)+step)%step
+		offsets = append(offsets, floor-e.base.Unix(), floor+step-e.base.Unix())
+	}
+
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	out := make([]time.Time, 0, len(offsets))
+	last := int64(-1)
+	for _, offset := range offsets {
+		if offset < lo || offset > hi || (len(out) > 0 && offset == last) {
+			continue
+		}
+		last = offset
+		out = append(out, e.base.Add(time.Duration(offset)*time.Second).UTC())
+	}
+	return out
+}
+
+// FindBestWindow is FindBestWindowAtForecastStarts over the starts selected by options.
+// FindBestWindow 为在 options 所选起点上执行的 FindBestWindowAtForecastStarts。
+//
+// current is the earliest feasible window, which in continuous mode starts at the first covered
+// second rather than at the first forecast timestamp after it.
+// current 为最早可行窗口；连续模式下从首个可覆盖的秒开始，而非其后的首个 forecast 时间戳。
+func FindBestWindow(
+	points []ForecastPoint,
+	evaluator EmissionEvaluator,
+	duration int,
+	runner string,
+	load float64,
+	pue float64,
+	options WindowSearchOptions,
+) (WindowEstimate, WindowEstimate, bool) {
+	if options.ForecastStartsOnly {
+		return FindBestWindowAtForecastStarts(points, evaluator, duration, runner, load, pue)
+	}
+
+	found := false
+	var current WindowEstimate
+	var best WindowEstimate
+	for _, start := range evaluator.CandidateStarts(duration, options.Granularity) {
+		emission, ok := evaluator.EstimateAt(start, duration, runner, load, pue)
+		if !ok {
+			continue
+		}
+		candidate := WindowEstimate{
+			Start:    start,
+			End:      start.Add(time.Duration(duration) * time.Second).UTC(),
+			Emission: emission,
+		}
+		if !found {
+			current = candidate
+			best = candidate
+			found = true
+			continue
+		}
+		if candidate.Emission < best.Emission {
+			best = candidate
+		}
+	}
+	if !found {
+		return WindowEstimate{}, WindowEstimate{}, false
+	}
+	return current, best, true
+}
+
 // integralAt returns cumulative (CI * seconds) from base to offset.
 // integralAt 返回从 base 到 offset 的累计积分（CI * 秒）。
 func (e EmissionEvaluator) integralAt(offset int64) float64 {
@@ -2,6 +2,7 @@ package scheduling
 
 import (
 	"math"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -149,3 +150,71 @@ func TestEmissionEvaluatorEstimateAtMatchesEstimateWindowEmissions(t *testing.T)
 		t.Fatalf("EmissionEvaluator.EstimateAt() = %.12f, expected %.12f", got, want)
 	}
 }
+
+func TestFindBestWindowContinuousStartsMidSlice(t *testing.T) {
+	points := []ForecastPoint{
+		{Timestamp: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC), CI: 0.5},
+		{Timestamp: time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC), CI: 0.1},
+		{Timestamp: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), CI: 0.9},
+		{Timestamp: time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC), CI: 0.9},
+	}
+	windowEnd := time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC)
+	evaluator, ok := BuildEmissionEvaluator(points, windowEnd)
+	if !ok {
+		t.Fatalf("BuildEmissionEvaluator() expected success")
+	}
+
+	_, legacy, ok := FindBestWindow(points, evaluator, 5400, "ubuntu", 0.6, 1.2, WindowSearchOptions{ForecastStartsOnly: true})
+	if !ok || !legacy.Start.Equal(points[0].Timestamp) {
+		t.Fatalf("legacy best = %+v, expected start %v", legacy, points[0].Timestamp)
+	}
+
+	current, best, ok := FindBestWindow(points, evaluator, 5400, "ubuntu", 0.6, 1.2, WindowSearchOptions{})
+	if !ok {
+		t.Fatalf("FindBestWindow() expected valid window")
+	}
+	if !current.Start.Equal(points[0].Timestamp) {
+		t.Fatalf("current window start = %v, expected %v", current.Start, points[0].Timestamp)
+	}
+	wantStart := time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC)
+	if !best.Start.Equal(wantStart) || !best.End.Equal(wantStart.Add(90*time.Minute)) {
+		t.Fatalf("best window = %v - %v, expected start %v", best.Start, best.End, wantStart)
+	}
+	if !(best.Emission < legacy.Emission) {
+		t.Fatalf("expected continuous best < legacy best, got %f >= %f", best.Emission, legacy.Emission)
+	}
+
+	// On a 20m grid both neighbours of the 10:30 kink average 25/90; the earlier one wins.
+	_, gridBest, ok := FindBestWindow(points, evaluator, 5400, "ubuntu", 0.6, 1.2, WindowSearchOptions{Granularity: 20 * time.Minute})
+	if !ok || !gridBest.Start.Equal(time.Date(2026, 1, 1, 10, 20, 0, 0, time.UTC)) {
+		t.Fatalf("grid best = %+v, expected start 10:20", gridBest)
+	}
+	if !(gridBest.Emission > best.Emission && gridBest.Emission < legacy.Emission) {
+		t.Fatalf("expected grid best between continuous and legacy, got %f", gridBest.Emission)
+	}
+}
+
+func TestCandidateStartsStayWithinCoverage(t *testing.T) {
+	points := []ForecastPoint{
+		{Timestamp: time.Date(2026, 1, 1, 10, 7, 0, 0, time.UTC), CI: 0.5},
+		{Timestamp: time.Date(2026, 1, 1, 11, 7, 0, 0, time.UTC), CI: 0.1},
+	}
+	windowEnd := time.Date(2026, 1, 1, 12, 7, 0, 0, time.UTC)
+	evaluator, ok := BuildEmissionEvaluator(points, windowEnd)
+	if !ok {
+		t.Fatalf("BuildEmissionEvaluator() expected success")
+	}
+
+	starts := evaluator.CandidateStarts(3600, 15*time.Minute)
+	want := []time.Time{
+		time.Date(2026, 1, 1, 10, 7, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(starts, want) {
+		t.Fatalf("CandidateStarts() = %v, expected %v", starts, want)
+	}
+	if got := evaluator.CandidateStarts(3*3600, 0); len(got) != 0 {
+		t.Fatalf("CandidateStarts() beyond coverage = %v, expected none", got)
+	}
+}
@@ -0,0 +1,156 @@
+// Package procstat reads per-process CPU time from a Linux procfs tree.
+// Package procstat 从 Linux procfs 读取进程 CPU 时间。
+package procstat
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRoot is the procfs mount point.
+// DefaultRoot 为 procfs 挂载点。
+const DefaultRoot = "/proc"
+
+// ClockTicksPerSecond is USER_HZ, fixed at 100 on every mainstream Linux architecture.
+// ClockTicksPerSecond 为 USER_HZ，在主流 Linux 架构上固定为 100。
+const ClockTicksPerSecond = 100
+
+var ErrProcessNotFound = errors.New("process not found")
+
+// Reader reads procfs under Root; an empty Root uses DefaultRoot.
+// Reader 读取 Root 下的 procfs；Root 为空时使用 DefaultRoot。
+type Reader struct {
+	Root string
+}
+
+type procStat struct {
+	PID    int
+	PPID   int
+	UTime  uint64
+	STime  uint64
+	CUTime uint64
+	CSTime uint64
+}
+
+// TreeCPUTime returns CPU time consumed by pid and all live descendants.
+// TreeCPUTime 返回 pid 及其所有存活子孙进程消耗的 CPU 时间。
+//
+// Each process contributes utime+stime plus cutime+cstime, so descendants that already
+// exited and were reaped inside the tree are still counted exactly once.
+// 每个进程贡献 utime+stime 与 cutime+cstime，因此树内已退出且被回收的子孙进程也只计一次。
+func (r Reader) TreeCPUTime(pid int) (time.Duration, int, error) {
+	stats, err := r.readAll()
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, ok := stats[pid]; !ok {
+		return 0, 0, fmt.Errorf("%w: %d", ErrProcessNotFound, pid)
+	}
+
+	children := make(map[int][]int, len(stats))
+	for _, stat := range stats {
+		children[stat.PPID] = append(children[stat.PPID], stat.PID)
+	}
+
+	var ticks uint64
+	count := 0
+	queue := []int{pid}
+	seen := map[int]bool{pid: true}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		stat := stats[current]
+		ticks += stat.UTime + stat.STime + stat.CUTime + stat.CSTime
+		count++
+		for _, child := range children[current] {
+			if !seen[child] {
+				seen[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return ticksToDuration(ticks), count, nil
+}
+
+func (r Reader) root() string {
+	if strings.TrimSpace(r.Root) == "" {
+		return DefaultRoot
+	}
+	return r.Root
+}
+
+func (r Reader) readAll() (map[int]procStat, error) {
+	entries, err := os.ReadDir(r.root())
+	if err != nil {
+		return nil, fmt.Errorf("read procfs %q: %w", r.root(), err)
+	}
+
+	stats := make(map[int]procStat, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid <= 0 {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.root(), entry.Name(), "stat"))
+		if err != nil {
+			// The process exited between listing and reading.
+			// 进程在列举与读取之间已退出。
+			continue
+		}
+		stat, err := parseStat(string(data))
+		if err != nil {
+			continue
+		}
+		stats[stat.PID] = stat
+	}
+	return stats, nil
+}
+
+// parseStat parses /proc/<pid>/stat; comm may contain spaces and parentheses.
+// parseStat 解析 /proc/<pid>/stat；comm 字段可能包含空格与括号。
+func parseStat(line string) (procStat, error) {
+	open := strings.IndexByte(line, '(')
+	closing := strings.LastIndexByte(line, ')')
+	if open <= 0 || closing < open {
+		return procStat{}, fmt.Errorf("invalid stat line")
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(line[:open]))
+	if err != nil {
+		return procStat{}, fmt.Errorf("invalid stat pid: %w", err)
+	}
+
+	// Fields after comm start at field 3 (state); utime is field 14.
+	// comm 之后的字段从第 3 个（state）开始；utime 为第 14 个字段。
+	fields := strings.Fields(line[closing+1:])
+	if len(fields) < 15 {
+		return procStat{}, fmt.Errorf("stat line has %d fields after comm", len(fields))
+	}
+	values := make([]uint64, 4)
+	for i := range values {
+		values[i], err = strconv.ParseUint(fields[11+i], 10, 64)
+		if err != nil {
+			return procStat{}, fmt.Errorf("invalid stat cpu field: %w", err)
+		}
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return procStat{}, fmt.Errorf("invalid stat ppid: %w", err)
+	}
+	return procStat{
+		PID:    pid,
+		PPID:   ppid,
+		UTime:  values[0],
+		STime:  values[1],
+		CUTime: values[2],
+		CSTime: values[3],
+	}, nil
+}
+
+func ticksToDuration(ticks uint64) time.Duration {
+	return time.Duration(ticks) * time.Second / ClockTicksPerSecond
+}
@@ -0,0 +1,47 @@
+package procstat
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeStat(t *testing.T, root string, pid string, line string) {
+	t.Helper()
+	dir := filepath.Join(root, pid)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("MkdirAll() unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(line), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+}
+
+func TestTreeCPUTimeSumsDescendants(t *testing.T) {
+	root := t.TempDir()
+	// utime stime cutime cstime are fields 14-17.
+	writeStat(t, root, "100", "100 (make) S 1 100 100 0 -1 0 0 0 0 0 50 10 40 0 20 0 1 0")
+	writeStat(t, root, "101", "101 (go build (x)) R 100 100 100 0 -1 0 0 0 0 0 200 50 0 0 20 0 1 0")
+	writeStat(t, root, "102", "102 (cc) R 101 100 100 0 -1 0 0 0 0 0 100 0 0 0 20 0 1 0")
+	writeStat(t, root, "200", "200 (other) R 1 200 200 0 -1 0 0 0 0 0 999 0 0 0 20 0 1 0")
+	if err := os.MkdirAll(filepath.Join(root, "self"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() unexpected error: %v", err)
+	}
+
+	got, count, err := Reader{Root: root}.TreeCPUTime(100)
+	if err != nil {
+		t.Fatalf("TreeCPUTime() unexpected error: %v", err)
+	}
+	if want := 4500 * time.Millisecond; got != want || count != 3 {
+		t.Fatalf("TreeCPUTime() = %v over %d processes, expected %v over 3", got, count, want)
+	}
+}
+
+func TestTreeCPUTimeMissingProcess(t *testing.T) {
+	_, _, err := Reader{Root: t.TempDir()}.TreeCPUTime(42)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("expected ErrProcessNotFound, got %v", err)
+	}
+}
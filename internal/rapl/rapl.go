@@ -0,0 +1,168 @@
+// Package rapl reads Intel/AMD RAPL energy counters from the Linux powercap sysfs.
+// Package rapl 从 Linux powercap sysfs 读取 Intel/AMD RAPL 能耗计数器。
+package rapl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultRoot is the powercap sysfs class directory.
+// DefaultRoot 为 powercap sysfs 类目录。
+const DefaultRoot = "/sys/class/powercap"
+
+const microjoulesPerKWh = 3.6e12
+
+var ErrUnavailable = errors.New("rapl energy counters unavailable")
+
+// Reader reads RAPL zones under Root; an empty Root uses DefaultRoot.
+// Reader 读取 Root 下的 RAPL 区域；Root 为空时使用 DefaultRoot。
+type Reader struct {
+	Root string
+}
+
+// Counter is one zone's energy_uj reading and its wraparound range.
+// Counter 为单个区域的 energy_uj 读数及其回绕范围。
+type Counter struct {
+	Zone       string
+	Name       string
+	EnergyUJ   uint64
+	MaxRangeUJ uint64
+}
+
+// Snapshot holds the counters of all measured zones, keyed by zone directory name.
+// Snapshot 保存所有被测区域的计数器，以区域目录名为键。
+type Snapshot map[string]Counter
+
+// Energy is measured energy between two snapshots.
+// Energy 为两次快照之间的实测能耗。
+type Energy struct {
+	PackageKWh float64
+	DRAMKWh    float64
+}
+
+// TotalKWh returns package plus DRAM energy.
+// TotalKWh 返回 package 与 DRAM 能耗之和。
+func (e Energy) TotalKWh() float64 {
+	return e.PackageKWh + e.DRAMKWh
+}
+
+// Snapshot reads package zones and their DRAM subzones.
+// Snapshot 读取 package 区域及其 DRAM 子区域。
+//
+// Core/uncore subzones are already part of the package and psys overlaps the package,
+// so both are skipped to avoid double counting.
+// core/uncore 子区域已包含在 package 内，psys 与 package 重叠，因此均跳过以避免重复计数。
+func (r Reader) Snapshot() (Snapshot, error) {
+	root := r.Root
+	if strings.TrimSpace(root) == "" {
+		root = DefaultRoot
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	snapshot := Snapshot{}
+	for _, entry := range entries {
+		zone := entry.Name()
+		if !strings.Contains(zone, "-rapl:") {
+			continue
+		}
+		dir := filepath.Join(root, zone)
+		name, err := readString(filepath.Join(dir, "name"))
+		if err != nil {
+			continue
+		}
+		isSubzone := strings.Count(zone, ":") > 1
+		switch {
+		case !isSubzone && strings.HasPrefix(name, "package"):
+		case isSubzone && name == "dram":
+		default:
+			continue
+		}
+
+		energy, err := readUint(filepath.Join(dir, "energy_uj"))
+		if err != nil {
+			// energy_uj is root-only on recent kernels; treat unreadable counters as absent.
+			// 新内核中 energy_uj 仅 root 可读；不可读的计数器视为不存在。
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		maxRange, err := readUint(filepath.Join(dir, "max_energy_range_uj"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		snapshot[zone] = Counter{Zone: zone, Name: name, EnergyUJ: energy, MaxRangeUJ: maxRange}
+	}
+
+	if len(snapshot) == 0 {
+		return nil, fmt.Errorf("%w: no package zones under %s", ErrUnavailable, root)
+	}
+	return snapshot, nil
+}
+
+// Zones returns the measured zone names in stable order.
+// Zones 以稳定顺序返回被测区域名称。
+func (s Snapshot) Zones() []string {
+	zones := make([]string, 0, len(s))
+	for zone := range s {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// Delta returns energy consumed between start and end, correcting one counter wraparound.
+// Delta 返回 start 与 end 之间消耗的能量，并修正一次计数器回绕。
+//
+// A job longer than one full counter range (typically hours at full load) cannot be
+// detected from two readings; callers should sample more often for such jobs.
+// 若作业时长超过计数器完整范围（满载时通常为数小时），仅凭两次读数无法检测，
+// 此类作业需更频繁地采样。
+func Delta(start Snapshot, end Snapshot) (Energy, error) {
+	var energy Energy
+	for zone, before := range start {
+		after, ok := end[zone]
+		if !ok {
+			return Energy{}, fmt.Errorf("rapl zone %s missing from end snapshot", zone)
+		}
+		var consumed uint64
+		if after.EnergyUJ >= before.EnergyUJ {
+			consumed = after.EnergyUJ - before.EnergyUJ
+		} else {
+			if before.MaxRangeUJ == 0 || before.EnergyUJ > before.MaxRangeUJ {
+				return Energy{}, fmt.Errorf("rapl zone %s wrapped without a valid max_energy_range_uj", zone)
+			}
+			consumed = before.MaxRangeUJ - before.EnergyUJ + after.EnergyUJ
+		}
+
+		kWh := float64(consumed) / microjoulesPerKWh
+		if before.Name == "dram" {
+			energy.DRAMKWh += kWh
+		} else {
+			energy.PackageKWh += kWh
+		}
+	}
+	return energy, nil
+}
+
+func readString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readUint(path string) (uint64, error) {
+	text, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(text, 10, 64)
+}
@@ -0,0 +1,67 @@
+package rapl
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeZone(t *testing.T, root string, zone string, name string, energy string, maxRange string) {
+	t.Helper()
+	dir := filepath.Join(root, zone)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("MkdirAll() unexpected error: %v", err)
+	}
+	files := map[string]string{"name": name, "energy_uj": energy, "max_energy_range_uj": maxRange}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content+"\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() unexpected error: %v", err)
+		}
+	}
+}
+
+func TestDeltaSumsPackageAndDRAMWithWraparound(t *testing.T) {
+	root := t.TempDir()
+	writeZone(t, root, "intel-rapl:0", "package-0", "262143000000", "262143328850")
+	writeZone(t, root, "intel-rapl:0:0", "core", "1000", "262143328850")
+	writeZone(t, root, "intel-rapl:0:1", "dram", "1000000", "65712999613")
+	writeZone(t, root, "intel-rapl:1", "psys", "5", "262143328850")
+
+	reader := Reader{Root: root}
+	start, err := reader.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() unexpected error: %v", err)
+	}
+	if got := start.Zones(); len(got) != 2 || got[0] != "intel-rapl:0" || got[1] != "intel-rapl:0:1" {
+		t.Fatalf("Zones() = %v, expected package and dram only", got)
+	}
+
+	// Package wraps: 328850 uj to the range end plus 3600000000 - 328850 after the wrap.
+	writeZone(t, root, "intel-rapl:0", "package-0", "3599671150", "262143328850")
+	writeZone(t, root, "intel-rapl:0:1", "dram", "361000000", "65712999613")
+	end, err := reader.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() unexpected error: %v", err)
+	}
+
+	energy, err := Delta(start, end)
+	if err != nil {
+		t.Fatalf("Delta() unexpected error: %v", err)
+	}
+	if math.Abs(energy.PackageKWh-0.001) > 1e-12 || math.Abs(energy.DRAMKWh-0.0001) > 1e-12 {
+		t.Fatalf("Delta() = %+v, expected package 0.001 kWh and dram 0.0001 kWh", energy)
+	}
+}
+
+func TestSnapshotWithoutZonesIsUnavailable(t *testing.T) {
+	_, err := Reader{Root: t.TempDir()}.Snapshot()
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	_, err = Reader{Root: filepath.Join(t.TempDir(), "missing")}.Snapshot()
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable for missing root, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"strings"
 
 	"github.com/chenzhuyu2004/carbon-guard/pkg"
 )
@@ -15,6 +16,97 @@ type BuildOptions struct {
 	BaselineKg          float64
 	EnergyTotalKWh      float64
 	EffectiveCIKgPerKWh float64
+	EnergyITKWh         float64
+	// EmbodiedKg is amortized hardware emissions reported next to operational emissions.
+	// EmbodiedKg 为摊销的硬件排放，与运行期排放并列输出。
+	EmbodiedKg float64
+	// EnergyComponents lists IT energy per component in display order (before PUE).
+	// EnergyComponents 按展示顺序列出各组件 IT 能耗（未乘 PUE）。
+	EnergyComponents []EnergyComponent
+	// Uncertainty adds p5/p50/p95 operational emissions; nil keeps the single-value report.
+	// Uncertainty 输出运行期排放 p5/p50/p95；为 nil 时保持单值报告。
+	Uncertainty *EmissionUncertainty
+	// Scope2 adds market-based scope 2 next to the location-based emissions; nil omits it.
+	// Scope2 在位置法排放旁输出市场法范围二排放；为 nil 时不输出。
+	Scope2 *Scope2
+	// Cost is the electricity cost of the run; nil omits it. WaterLiters > 0 adds water usage.
+	// Cost 为运行电费，为 nil 时不输出；WaterLiters > 0 时输出用水量。
+	Cost        *Cost
+	WaterLiters float64
+	// CIDataset records the offline dataset entry behind a static region CI; nil omits it.
+	// CIDataset 记录静态 region CI 所用的离线数据集条目；为 nil 时不输出。
+	CIDataset *CIDataset
+	// EnergySource names measured energy (for example "power-file"); empty means modelled.
+	// EnergySource 标识实测能耗来源（如 "power-file"）；为空表示建模能耗。
+	EnergySource string
+	// Measurement describes a wrapped command whose duration and load were measured.
+	// Measurement 描述被包装命令的实测时长与负载信息。
+	Measurement *Measurement
+}
+
+// CIDataset identifies a yearly average CI entry so static figures are auditable.
+// CIDataset 标识一条年均 CI 数据，使静态结果可审计。
+type CIDataset struct {
+	Region  string
+	Zone    string
+	Version string
+	Year    int
+}
+
+// Cost is an electricity cost in Currency; Source is static, file, or provider.
+// Cost 为以 Currency 计价的电费；Source 为 static、file 或 provider。
+type Cost struct {
+	Amount   float64
+	Currency string
+	Source   string
+}
+
+// Scope2 is the GHG Protocol scope 2 dual report (location-based and market-based).
+// Scope2 为 GHG Protocol 范围二双重报告（位置法与市场法）。
+type Scope2 struct {
+	LocationBasedKg float64
+	MarketBasedKg   float64
+	CoveragePct     float64
+	Instruments     []string
+}
+
+// Measurement is the observed execution of a wrapped command.
+// Measurement 为被包装命令的实际执行观测值。
+type Measurement struct {
+	Command         string
+	ExitCode        int
+	WallSeconds     float64
+	CPUSeconds      float64
+	AverageLoad     float64
+	Samples         int
+	SamplingEnabled bool
+	// CPUSource is "proc" (process tree) or "cgroup" (cgroup v2 cpu.stat).
+	// CPUSource 为 "proc"（进程树）或 "cgroup"（cgroup v2 cpu.stat）。
+	CPUSource    string
+	MemoryPeakGB float64
+	// EnergySource is "model" or "rapl"; RAPL fields hold the measured counter deltas.
+	// EnergySource 为 "model" 或 "rapl"；RAPL 字段为计数器实测增量。
+	EnergySource   string
+	RAPLPackageKWh float64
+	RAPLDRAMKWh    float64
+}
+
+// EmissionUncertainty is a sampled emissions interval and the basis used for budget gating.
+// EmissionUncertainty 为采样得到的排放区间及预算判定所用基准。
+type EmissionUncertainty struct {
+	P5Kg        float64
+	P50Kg       float64
+	P95Kg       float64
+	Samples     int
+	Seed        int64
+	BudgetBasis string
+}
+
+// EnergyComponent is one named slice of IT energy.
+// EnergyComponent 表示一个具名的 IT 能耗分量。
+type EnergyComponent struct {
+	Name string
+	KWh  float64
 }
 
 type emissionUnit struct {
@@ -41,7 +133,8 @@ func BuildFromEmissions(durationSeconds int, asJSON bool, emissions float64, opt
 	emissions = round4(emissions)
 	budgetKg := round4(opts.BudgetKg)
 	baselineKg := round4(opts.BaselineKg)
-	budgetExceeded := budgetKg > 0 && emissions > budgetKg
+	budgetExceeded := budgetKg > 0 && budgetBasisEmissions(emissions, opts.Uncertainty) > budgetKg
+	hasEmbodied := opts.EmbodiedKg > 0
 
 	if asJSON {
 		payload := map[string]any{
@@ -57,6 +150,98 @@ func BuildFromEmissions(durationSeconds int, asJSON bool, emissions float64, opt
 			payload["baseline_kg"] = baselineKg
 			payload["delta_vs_baseline_pct"] = round2(deltaVsBaselinePct(emissions, baselineKg))
 		}
+		if hasEmbodied {
+			payload["operational_emissions_kg"] = emissions
+			payload["embodied_emissions_kg"] = round6(opts.EmbodiedKg)
+			payload["total_emissions_kg"] = round4(emissions + opts.EmbodiedKg)
+		}
+		if u := opts.Uncertainty; u != nil {
+			interval := map[string]any{
+				"method":  "monte-carlo",
+				"samples": u.Samples,
+				"seed":    u.Seed,
+				"p5_kg":   round6(u.P5Kg),
+				"p50_kg":  round6(u.P50Kg),
+				"p95_kg":  round6(u.P95Kg),
+			}
+			if budgetKg > 0 {
+				interval["budget_exceeded_p5"] = round6(u.P5Kg) > budgetKg
+				interval["budget_exceeded_p50"] = round6(u.P50Kg) > budgetKg
+				interval["budget_exceeded_p95"] = round6(u.P95Kg) > budgetKg
+				payload["budget_basis"] = u.BudgetBasis
+			}
+			payload["emissions_uncertainty"] = interval
+		}
+		if m := opts.Measurement; m != nil {
+			measurement := map[string]any{
+				"command":          m.Command,
+				"exit_code":        m.ExitCode,
+				"wall_seconds":     round4(m.WallSeconds),
+				"cpu_seconds":      round4(m.CPUSeconds),
+				"average_load":     round4(m.AverageLoad),
+				"samples":          m.Samples,
+				"sampling_enabled": m.SamplingEnabled,
+				"cpu_source":       m.CPUSource,
+			}
+			if m.MemoryPeakGB > 0 {
+				measurement["memory_peak_gb"] = round4(m.MemoryPeakGB)
+			}
+			if m.EnergySource != "" {
+				measurement["energy_source"] = m.EnergySource
+			}
+			if m.RAPLPackageKWh > 0 {
+				measurement["rapl_package_kwh"] = round6(m.RAPLPackageKWh)
+				measurement["rapl_dram_kwh"] = round6(m.RAPLDRAMKWh)
+			}
+			payload["measurement"] = measurement
+		}
+		if opts.EnergyTotalKWh > 0 {
+			payload["energy_total_kwh"] = round6(opts.EnergyTotalKWh)
+		}
+		if opts.EnergyITKWh > 0 {
+			payload["energy_it_kwh"] = round6(opts.EnergyITKWh)
+		}
+		if opts.EnergySource != "" {
+			payload["energy_source"] = opts.EnergySource
+		}
+		if dataset := opts.CIDataset; dataset != nil {
+			entry := map[string]any{
+				"region":  dataset.Region,
+				"version": dataset.Version,
+				"year":    dataset.Year,
+			}
+			if dataset.Zone != "" {
+				entry["zone"] = dataset.Zone
+			}
+			payload["ci_dataset"] = entry
+		}
+		if cost := opts.Cost; cost != nil {
+			payload["cost"] = round6(cost.Amount)
+			payload["currency"] = cost.Currency
+			payload["price_source"] = cost.Source
+		}
+		if opts.WaterLiters > 0 {
+			payload["water_liters"] = round6(opts.WaterLiters)
+		}
+		if scope2 := opts.Scope2; scope2 != nil {
+			instruments := scope2.Instruments
+			if instruments == nil {
+				instruments = []string{}
+			}
+			payload["scope2"] = map[string]any{
+				"location_based_kg":       round6(scope2.LocationBasedKg),
+				"market_based_kg":         round6(scope2.MarketBasedKg),
+				"instrument_coverage_pct": round4(scope2.CoveragePct),
+				"instruments":             instruments,
+			}
+		}
+		if len(opts.EnergyComponents) > 0 {
+			components := make(map[string]float64, len(opts.EnergyComponents))
+			for _, component := range opts.EnergyComponents {
+				components[component.Name] = round6(component.KWh)
+			}
+			payload["energy_components_kwh"] = components
+		}
 
 		data, err := json.MarshalIndent(payload, "", "  ")
 		if err != nil {
@@ -68,6 +253,13 @@ func BuildFromEmissions(durationSeconds int, asJSON bool, emissions float64, opt
 	smartphoneCharges, evKilometers := buildComparisons(emissions, opts)
 	score, emoji := carbonScore(emissions, durationSeconds, opts)
 	emissionsLine := formatEmissionDisplay(emissions)
+	if hasEmbodied {
+		emissionsLine += fmt.Sprintf(
+			"\nEmbodied Emissions: %s\nTotal Emissions (operational + embodied): %s",
+			formatEmissionDisplay(opts.EmbodiedKg),
+			formatEmissionDisplay(emissions+opts.EmbodiedKg),
+		)
+	}
 	report := fmt.Sprintf(
 		"%s\nCarbon Report\n%s\nDuration: %ds\nEstimated Emissions: %s\nCarbon Score: %s %s\nFun Facts:\n- Equivalent to charging %.2f smartphones\n- Equivalent to driving %.2f km in an EV\n",
 		divider,
@@ -87,17 +279,137 @@ func BuildFromEmissions(durationSeconds int, asJSON bool, emissions float64, opt
 		}
 		report += fmt.Sprintf("Budget: %s (%s)\n", formatEmissionDisplay(budgetKg), status)
 	}
+	if u := opts.Uncertainty; u != nil {
+		report += fmt.Sprintf(
+			"Emissions Range (p5 / p50 / p95, %d samples, seed %d): %s / %s / %s\n",
+			u.Samples,
+			u.Seed,
+			formatEmissionDisplay(u.P5Kg),
+			formatEmissionDisplay(u.P50Kg),
+			formatEmissionDisplay(u.P95Kg),
+		)
+		if budgetKg > 0 {
+			report += fmt.Sprintf(
+				"Budget at p5 / p50 / p95: %s / %s / %s (gate: %s)\n",
+				budgetStatus(u.P5Kg, budgetKg),
+				budgetStatus(u.P50Kg, budgetKg),
+				budgetStatus(u.P95Kg, budgetKg),
+				u.BudgetBasis,
+			)
+		}
+	}
 	if baselineKg > 0 {
 		report += fmt.Sprintf("Baseline: %s (delta: %.2f%%)\n", formatEmissionDisplay(baselineKg), deltaVsBaselinePct(emissions, baselineKg))
 	}
+	if m := opts.Measurement; m != nil {
+		report += fmt.Sprintf(
+			"Measured: %s (exit %d), wall %.2fs, cpu %.2fs [%s], average load %.2f (%d samples)\n",
+			m.Command,
+			m.ExitCode,
+			m.WallSeconds,
+			m.CPUSeconds,
+			m.CPUSource,
+			m.AverageLoad,
+			m.Samples,
+		)
+		if m.MemoryPeakGB > 0 {
+			report += fmt.Sprintf("Memory Peak (cgroup): %.3f GB\n", m.MemoryPeakGB)
+		}
+		if m.RAPLPackageKWh > 0 {
+			report += fmt.Sprintf(
+				"Energy Source: %s (package %.6f kWh, dram %.6f kWh)\n",
+				m.EnergySource,
+				m.RAPLPackageKWh,
+				m.RAPLDRAMKWh,
+			)
+		} else if m.EnergySource != "" {
+			report += fmt.Sprintf("Energy Source: %s\n", m.EnergySource)
+		}
+	}
+	if cost := opts.Cost; cost != nil {
+		report += fmt.Sprintf("Energy Cost: %.4f %s (%s price)\n", cost.Amount, cost.Currency, cost.Source)
+	}
+	if opts.WaterLiters > 0 {
+		report += fmt.Sprintf("Water Usage: %.3f L\n", opts.WaterLiters)
+	}
+	if scope2 := opts.Scope2; scope2 != nil {
+		report += fmt.Sprintf("Scope 2 (location-based): %s\n", formatEmissionDisplay(scope2.LocationBasedKg))
+		report += fmt.Sprintf(
+			"Scope 2 (market-based): %s (coverage %.1f%%, %d instruments)\n",
+			formatEmissionDisplay(scope2.MarketBasedKg),
+			scope2.CoveragePct,
+			len(scope2.Instruments),
+		)
+	}
+	if dataset := opts.CIDataset; dataset != nil {
+		region := dataset.Region
+		if dataset.Zone != "" && dataset.Zone != dataset.Region {
+			region += " -> " + dataset.Zone
+		}
+		report += fmt.Sprintf("CI Dataset: %s (%s, %d annual average)\n", region, dataset.Version, dataset.Year)
+	}
+	if opts.EnergySource != "" && opts.Measurement == nil {
+		report += fmt.Sprintf("Energy Source: %s (measured)\n", opts.EnergySource)
+	}
+	if line, ok := formatEnergyBreakdown(opts.EnergyComponents); ok {
+		report += line
+	}
 
 	return report + divider + "\n"
 }
 
+// budgetBasisEmissions returns the value the budget gate compares: the percentile named by
+// BudgetBasis when an interval is reported, otherwise the point estimate.
+// budgetBasisEmissions 返回预算判定所比较的值：报告区间时为 BudgetBasis 指定的分位数，否则为点估计。
+func budgetBasisEmissions(emissions float64, u *EmissionUncertainty) float64 {
+	if u == nil {
+		return emissions
+	}
+	switch u.BudgetBasis {
+	case "p50":
+		return round6(u.P50Kg)
+	case "p95":
+		return round6(u.P95Kg)
+	default:
+		return emissions
+	}
+}
+
+func budgetStatus(emissionsKg float64, budgetKg float64) string {
+	if round6(emissionsKg) > budgetKg {
+		return "exceeded"
+	}
+	return "within"
+}
+
+// formatEnergyBreakdown renders components only when something beyond CPU is present.
+// formatEnergyBreakdown 仅在存在 CPU 以外的组件时输出能耗拆分。
+func formatEnergyBreakdown(components []EnergyComponent) (string, bool) {
+	parts := make([]string, 0, len(components))
+	extra := false
+	for _, component := range components {
+		if component.KWh <= 0 {
+			continue
+		}
+		if component.Name != "cpu" {
+			extra = true
+		}
+		parts = append(parts, fmt.Sprintf("%s %.6f kWh", component.Name, component.KWh))
+	}
+	if !extra {
+		return "", false
+	}
+	return fmt.Sprintf("Energy Breakdown (IT): %s\n", strings.Join(parts, ", ")), true
+}
+
 func round4(v float64) float64 {
 	return math.Round(v*10000) / 10000
 }
 
+func round6(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
 func round2(v float64) float64 {
 	return math.Round(v*100) / 100
 }
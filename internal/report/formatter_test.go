@@ -86,3 +86,96 @@ func TestAutoScaledEmissionKeepsValueInTargetRange(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildFromEmissionsReportsEnergyComponents(t *testing.T) {
+	opts := BuildOptions{
+		EnergyTotalKWh: 0.06,
+		EnergyITKWh:    0.05,
+		EnergyComponents: []EnergyComponent{
+			{Name: "cpu", KWh: 0.04},
+			{Name: "memory", KWh: 0.01},
+		},
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(BuildFromEmissions(300, true, 0.02, opts)), &payload); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	components, ok := payload["energy_components_kwh"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected energy_components_kwh object, got %#v", payload["energy_components_kwh"])
+	}
+	if components["memory"].(float64) != 0.01 {
+		t.Fatalf("memory component = %#v, expected 0.01", components["memory"])
+	}
+
+	text := BuildFromEmissions(300, false, 0.02, opts)
+	if !strings.Contains(text, "Energy Breakdown (IT): cpu 0.040000 kWh, memory 0.010000 kWh") {
+		t.Fatalf("expected energy breakdown line, got: %s", text)
+	}
+	if strings.Contains(BuildFromEmissions(300, false, 0.02, BuildOptions{EnergyComponents: opts.EnergyComponents[:1]}), "Energy Breakdown") {
+		t.Fatalf("expected no breakdown line for cpu-only components")
+	}
+}
+
+func TestBuildFromEmissionsReportsEmbodiedSeparately(t *testing.T) {
+	opts := BuildOptions{EmbodiedKg: 0.002}
+
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(BuildFromEmissions(3600, true, 0.08, opts)), &payload); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	if payload["operational_emissions_kg"].(float64) != 0.08 {
+		t.Fatalf("operational_emissions_kg = %#v, expected 0.08", payload["operational_emissions_kg"])
+	}
+	if payload["embodied_emissions_kg"].(float64) != 0.002 {
+		t.Fatalf("embodied_emissions_kg = %#v, expected 0.002", payload["embodied_emissions_kg"])
+	}
+	if payload["total_emissions_kg"].(float64) != 0.082 {
+		t.Fatalf("total_emissions_kg = %#v, expected 0.082", payload["total_emissions_kg"])
+	}
+
+	text := BuildFromEmissions(3600, false, 0.08, opts)
+	for _, c := range []string{"Embodied Emissions:", "Total Emissions (operational + embodied):"} {
+		if !strings.Contains(text, c) {
+			t.Fatalf("expected output to contain %q, got: %s", c, text)
+		}
+	}
+}
+
+func TestBuildFromEmissionsReportsUncertainty(t *testing.T) {
+	opts := BuildOptions{
+		BudgetKg: 0.1,
+		Uncertainty: &EmissionUncertainty{
+			P5Kg: 0.06, P50Kg: 0.08, P95Kg: 0.12, Samples: 1000, Seed: 42, BudgetBasis: "p95",
+		},
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(BuildFromEmissions(3600, true, 0.08, opts)), &payload); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	interval, ok := payload["emissions_uncertainty"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected emissions_uncertainty object, got %#v", payload["emissions_uncertainty"])
+	}
+	if interval["p5_kg"].(float64) != 0.06 || interval["p95_kg"].(float64) != 0.12 {
+		t.Fatalf("unexpected percentiles: %#v", interval)
+	}
+	if interval["budget_exceeded_p50"].(bool) || !interval["budget_exceeded_p95"].(bool) {
+		t.Fatalf("unexpected percentile budget status: %#v", interval)
+	}
+	if payload["budget_basis"] != "p95" {
+		t.Fatalf("budget_basis = %#v, expected p95", payload["budget_basis"])
+	}
+	if payload["budget_exceeded"] != true {
+		t.Fatalf("budget_exceeded = %#v, expected true from the p95 gate basis", payload["budget_exceeded"])
+	}
+
+	text := BuildFromEmissions(3600, false, 0.08, opts)
+	for _, c := range []string{"Emissions Range (p5 / p50 / p95", "Budget at p5 / p50 / p95: within / within / exceeded (gate: p95)"} {
+		if !strings.Contains(text, c) {
+			t.Fatalf("expected output to contain %q, got: %s", c, text)
+		}
+	}
+}
@@ -3,8 +3,25 @@ package models
 type PowerProfile struct {
 	Idle float64
 	Peak float64
+	// Embodied holds hardware manufacturing emissions used for scope 3 amortization.
+	// Embodied 描述用于范围三摊销的硬件制造排放参数。
+	Embodied EmbodiedProfile
 }
 
+// EmbodiedProfile follows the SCI amortization M = TE * (duration / lifetime) * share.
+// EmbodiedProfile 对应 SCI 摊销公式 M = TE * (时长 / 寿命) * 占用比例。
+type EmbodiedProfile struct {
+	TotalKgCO2e   float64
+	LifetimeYears float64
+	// Share is the fraction of the machine reserved by one job, in (0, 1].
+	// Share 为单个作业占用整机的比例，取值 (0, 1]。
+	Share float64
+}
+
+// RunnerProfiles carry no embodied emissions: there is no sourced figure for a generic hosted
+// runner, so embodied emissions are opt-in through flags or the instance catalog.
+// RunnerProfiles 不含 embodied 排放：通用托管 runner 没有可引用来源的数据，
+// 因此 embodied 排放需通过参数或实例目录显式启用。
 var RunnerProfiles = map[string]PowerProfile{
 	"ubuntu":  {Idle: 110, Peak: 220},
 	"windows": {Idle: 150, Peak: 300},